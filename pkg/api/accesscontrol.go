@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// GET /api/access-control/user/permissions
+func (hs *HTTPServer) GetSignedInUserPermissions(c *models.ReqContext) response.Response {
+	permissions, err := hs.AccessControl.GetUserPermissions(c.SignedInUser)
+	if err != nil {
+		return response.Error(500, "Failed to get permissions", err)
+	}
+
+	return response.JSON(200, permissions)
+}
+
+// GET /api/access-control/builtin-roles
+func (hs *HTTPServer) GetBuiltinRoles(c *models.ReqContext) response.Response {
+	return response.JSON(200, hs.AccessControl.GetBuiltinRoles())
+}
+
+// GET /api/access-control/roles
+func GetRoles(c *models.ReqContext) response.Response {
+	query := models.GetRolesQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get roles", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// GET /api/access-control/roles/:roleUid
+func GetRoleByUID(c *models.ReqContext) response.Response {
+	query := models.GetRoleByUidQuery{OrgId: c.OrgId, Uid: c.Params(":roleUid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/access-control/roles
+func (hs *HTTPServer) CreateRole(c *models.ReqContext, cmd models.CreateRoleCommand) response.Response {
+	cmd.OrgId = c.OrgId
+
+	if resp := hs.canGrantPermissions(c, cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to create role")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// PUT /api/access-control/roles/:roleUid
+func (hs *HTTPServer) UpdateRole(c *models.ReqContext, cmd models.UpdateRoleCommand) response.Response {
+	cmd.OrgId = c.OrgId
+	cmd.Uid = c.Params(":roleUid")
+
+	if resp := hs.canGrantPermissions(c, cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to update role")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// DELETE /api/access-control/roles/:roleUid
+func DeleteRole(c *models.ReqContext) response.Response {
+	cmd := models.DeleteRoleCommand{OrgId: c.OrgId, Uid: c.Params(":roleUid")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to delete role")
+	}
+
+	return response.Success("Role deleted")
+}
+
+// GET /api/access-control/roles/:roleUid/assignments
+func GetRoleAssignments(c *models.ReqContext) response.Response {
+	query := models.GetRoleAssignmentsQuery{OrgId: c.OrgId, RoleUid: c.Params(":roleUid")}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role assignments")
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/access-control/roles/:roleUid/assignments
+func (hs *HTTPServer) AddRoleAssignment(c *models.ReqContext, cmd models.AddRoleAssignmentCommand) response.Response {
+	cmd.OrgId = c.OrgId
+	cmd.RoleUid = c.Params(":roleUid")
+
+	query := models.GetRoleByUidQuery{OrgId: c.OrgId, Uid: cmd.RoleUid}
+	if err := bus.Dispatch(&query); err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+
+	if resp := hs.canGrantPermissions(c, query.Result.Permissions); resp != nil {
+		return resp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to assign role")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// DELETE /api/access-control/roles/:roleUid/assignments/:assignmentId
+func RemoveRoleAssignment(c *models.ReqContext) response.Response {
+	cmd := models.RemoveRoleAssignmentCommand{
+		OrgId:   c.OrgId,
+		RoleUid: c.Params(":roleUid"),
+		Id:      c.ParamsInt64(":assignmentId"),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return roleErrorResponse(err, "Failed to remove role assignment")
+	}
+
+	return response.Success("Role assignment removed")
+}
+
+// canGrantPermissions returns an error response unless the signed in user has all the permissions,
+// so that users can't grant more permissions than they have.
+func (hs *HTTPServer) canGrantPermissions(c *models.ReqContext, permissions []models.Permission) response.Response {
+	for _, p := range permissions {
+		scope := p.Scope
+		if scope == "" {
+			scope = ac.ScopeAll
+		}
+
+		allowed, err := hs.AccessControl.Evaluate(c.SignedInUser, p.Action, scope)
+		if err != nil {
+			return response.Error(500, "Failed to evaluate permissions", err)
+		}
+
+		if !allowed {
+			return response.Error(403, "Cannot grant a permission you don't have: "+p.Action+" on "+scope, nil)
+		}
+	}
+
+	return nil
+}
+
+func roleErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, models.ErrRoleNotFound), errors.Is(err, models.ErrRoleAssignmentNotFound):
+		return response.Error(404, err.Error(), err)
+	case errors.Is(err, models.ErrRoleNameTaken), errors.Is(err, models.ErrRoleUidTaken), errors.Is(err, models.ErrRoleAssignmentExists):
+		return response.Error(409, err.Error(), err)
+	case errors.Is(err, models.ErrRoleInvalidUid), errors.Is(err, models.ErrInvalidRoleAssignment),
+		errors.Is(err, models.ErrInvalidBuiltinRole), errors.Is(err, models.ErrRoleAssigneeNotFound),
+		errors.Is(err, models.ErrInvalidPermissionAction):
+		return response.Error(400, err.Error(), err)
+	}
+
+	return response.Error(500, message, err)
+}
@@ -20,7 +20,7 @@ func AdminGetSettings(c *models.ReqContext) response.Response {
 		for _, key := range section.Keys() {
 			keyName := key.Name()
 			value := key.Value()
-			if strings.Contains(keyName, "secret") || strings.Contains(keyName, "password") || (strings.Contains(keyName, "provider_config")) {
+			if strings.Contains(keyName, "secret") || strings.Contains(keyName, "password") || (strings.Contains(keyName, "provider_config")) || keyName == "token" {
 				value = "************"
 			}
 			if strings.Contains(keyName, "url") {
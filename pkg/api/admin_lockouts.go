@@ -0,0 +1,47 @@
+package api
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// AdminGetLoginLockouts returns the usernames and IP addresses whose logins are blocked after failed attempts.
+func (hs *HTTPServer) AdminGetLoginLockouts(c *models.ReqContext) response.Response {
+	if hs.Cfg.DisableBruteForceLoginProtection {
+		return response.JSON(200, []*models.LoginLockout{})
+	}
+
+	query := models.GetLoginLockoutsQuery{
+		Since:           time.Now().Add(-hs.Cfg.LoginLockout.Window),
+		MaxUserAttempts: hs.Cfg.LoginLockout.MaxUserAttempts,
+		MaxIPAttempts:   hs.Cfg.LoginLockout.MaxIPAttempts,
+	}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get login lockouts", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// AdminDeleteUserLoginLockout clears the failed login attempts with a username.
+func AdminDeleteUserLoginLockout(c *models.ReqContext) response.Response {
+	cmd := models.DeleteLoginAttemptsCommand{Username: c.Params(":username")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to clear login lockout", err)
+	}
+
+	return response.Success("Login lockout cleared")
+}
+
+// AdminDeleteIPLoginLockout clears the failed login attempts from an IP address.
+func AdminDeleteIPLoginLockout(c *models.ReqContext) response.Response {
+	cmd := models.DeleteLoginAttemptsCommand{IpAddress: c.Params(":ip")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to clear login lockout", err)
+	}
+
+	return response.Success("Login lockout cleared")
+}
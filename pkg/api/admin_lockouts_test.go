@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminGetLoginLockouts(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	cfg := setting.NewCfg()
+	cfg.LoginLockout = setting.LoginLockoutSettings{MaxUserAttempts: 5, MaxIPAttempts: 20, Window: 5 * time.Minute}
+	hs := &HTTPServer{Cfg: cfg}
+
+	lastAttempt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	var sentQuery *models.GetLoginLockoutsQuery
+	bus.AddHandler("test", func(query *models.GetLoginLockoutsQuery) error {
+		sentQuery = query
+		query.Result = []*models.LoginLockout{
+			{Username: "user", Attempts: 5, FirstAttempt: lastAttempt.Add(-time.Minute), LastAttempt: lastAttempt},
+			{IpAddress: "192.168.1.1", Attempts: 20, FirstAttempt: lastAttempt.Add(-time.Minute), LastAttempt: lastAttempt},
+		}
+		return nil
+	})
+
+	sc := setupScenarioContext(t, "/api/admin/lockouts")
+	sc.m.Get("/api/admin/lockouts", routing.Wrap(hs.AdminGetLoginLockouts))
+	sc.fakeReqNoAssertions("GET", "/api/admin/lockouts").exec()
+	require.Equal(t, 200, sc.resp.Code)
+
+	require.NotNil(t, sentQuery)
+	assert.Equal(t, int64(5), sentQuery.MaxUserAttempts)
+	assert.Equal(t, int64(20), sentQuery.MaxIPAttempts)
+	assert.WithinDuration(t, time.Now().Add(-5*time.Minute), sentQuery.Since, time.Minute)
+
+	var lockouts []map[string]interface{}
+	require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &lockouts))
+	require.Len(t, lockouts, 2)
+	assert.Equal(t, "user", lockouts[0]["username"])
+	assert.NotContains(t, lockouts[0], "ipAddress")
+	assert.Equal(t, "192.168.1.1", lockouts[1]["ipAddress"])
+	assert.Equal(t, float64(20), lockouts[1]["attempts"])
+	assert.Equal(t, "2021-05-01T10:00:00Z", lockouts[1]["lastAttempt"])
+}
+
+func TestAdminDeleteLoginLockouts(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	var sentCmd *models.DeleteLoginAttemptsCommand
+	bus.AddHandler("test", func(cmd *models.DeleteLoginAttemptsCommand) error {
+		sentCmd = cmd
+		return nil
+	})
+
+	sc := setupScenarioContext(t, "/api/admin/lockouts")
+	sc.m.Delete("/api/admin/lockouts/users/:username", routing.Wrap(AdminDeleteUserLoginLockout))
+	sc.m.Delete("/api/admin/lockouts/ips/:ip", routing.Wrap(AdminDeleteIPLoginLockout))
+
+	t.Run("Clears the lockout of a username", func(t *testing.T) {
+		sc.fakeReqNoAssertions("DELETE", "/api/admin/lockouts/users/user@example.com").exec()
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, &models.DeleteLoginAttemptsCommand{Username: "user@example.com"}, sentCmd)
+	})
+
+	t.Run("Clears the lockout of an IP address", func(t *testing.T) {
+		sc.fakeReqNoAssertions("DELETE", "/api/admin/lockouts/ips/2001:db8::1").exec()
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, &models.DeleteLoginAttemptsCommand{IpAddress: "2001:db8::1"}, sentCmd)
+	})
+}
@@ -39,3 +39,27 @@ func (hs *HTTPServer) AdminProvisioningReloadNotifications(c *models.ReqContext)
 	}
 	return response.Success("Notifications config reloaded")
 }
+
+func (hs *HTTPServer) AdminProvisioningReloadAlerting(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionAlerting()
+	if err != nil {
+		return response.Error(500, "Failed to reload alerting config", err)
+	}
+	return response.Success("Alerting config reloaded")
+}
+
+func (hs *HTTPServer) AdminProvisioningReloadAccess(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionAccess()
+	if err != nil {
+		return response.Error(500, "Failed to reload access config", err)
+	}
+	return response.Success("Access config reloaded")
+}
+
+func (hs *HTTPServer) AdminProvisioningReloadLibraryPanels(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionLibraryPanels()
+	if err != nil {
+		return response.Error(500, "Failed to reload library panels config", err)
+	}
+	return response.Success("Library panels config reloaded")
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 )
 
-func AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) response.Response {
+func (hs *HTTPServer) AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) response.Response {
 	cmd := models.CreateUserCommand{
 		Login:    form.Login,
 		Email:    form.Email,
@@ -31,6 +31,9 @@ func AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) respon
 	if len(cmd.Password) < 4 {
 		return response.Error(400, "Password is missing or too short", nil)
 	}
+	if resp := hs.validatePassword(cmd.Password, nil); resp != nil {
+		return resp
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		if errors.Is(err, models.ErrOrgNotFound) {
@@ -56,7 +59,7 @@ func AdminCreateUser(c *models.ReqContext, form dtos.AdminCreateUserForm) respon
 	return response.JSON(200, result)
 }
 
-func AdminUpdateUserPassword(c *models.ReqContext, form dtos.AdminUpdateUserPasswordForm) response.Response {
+func (hs *HTTPServer) AdminUpdateUserPassword(c *models.ReqContext, form dtos.AdminUpdateUserPasswordForm) response.Response {
 	userID := c.ParamsInt64(":id")
 
 	if len(form.Password) < 4 {
@@ -69,6 +72,10 @@ func AdminUpdateUserPassword(c *models.ReqContext, form dtos.AdminUpdateUserPass
 		return response.Error(500, "Could not read user from database", err)
 	}
 
+	if resp := hs.validatePassword(form.Password, userQuery.Result); resp != nil {
+		return resp
+	}
+
 	passwordHashed, err := util.EncodePassword(form.Password, userQuery.Result.Salt)
 	if err != nil {
 		return response.Error(500, "Could not encode password", err)
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+const alertHistoryDefaultLimit = 1000
+
+// The summaries page through the state history, up to alertHistorySummaryMaxItems state changes.
+// The dashboards the user can view are searched by pages of alertHistorySearchPageSize.
+var (
+	alertHistorySummaryPageSize int64 = 1000
+	alertHistorySummaryMaxItems int64 = 100000
+	alertHistorySearchPageSize  int64 = 1000
+)
+
+// GET /api/alerts/history
+func GetAlertHistory(c *models.ReqContext) response.Response {
+	limit := c.QueryInt64("limit")
+	if limit == 0 {
+		limit = alertHistoryDefaultLimit
+	}
+
+	items, _, err := findAlertHistory(c, limit, limit)
+	if err != nil {
+		return response.Error(500, "Failed to get alert history", err)
+	}
+
+	if c.Query("format") == "csv" {
+		return alertHistoryCSV(items)
+	}
+
+	return response.JSON(200, items)
+}
+
+// GET /api/alerts/history/summary
+func GetAlertHistorySummary(c *models.ReqContext) response.Response {
+	items, truncated, err := findAlertHistory(c, alertHistorySummaryPageSize, alertHistorySummaryMaxItems)
+	if err != nil {
+		return response.Error(500, "Failed to get alert history", err)
+	}
+
+	to := c.QueryInt64("to")
+	if to == 0 {
+		to = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	summaries := alerting.SummarizeStateHistory(items, c.QueryInt64("from"), to)
+
+	if c.Query("format") == "csv" {
+		resp := alertHistorySummaryCSV(summaries)
+		if truncated {
+			resp.Header("X-Grafana-Truncated", "true")
+		}
+		return resp
+	}
+
+	return response.JSON(200, map[string]interface{}{
+		"summaries": summaries,
+		"truncated": truncated,
+	})
+}
+
+// findAlertHistory returns the alert state annotations matching the request filters,
+// oldest first. It fetches pages of pageSize annotations up to maxItems, and reports
+// whether there were more.
+func findAlertHistory(c *models.ReqContext, pageSize, maxItems int64) ([]*annotations.ItemDTO, bool, error) {
+	dashboardIDs, err := alertHistoryDashboardIDs(c)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(dashboardIDs) == 0 {
+		return []*annotations.ItemDTO{}, false, nil
+	}
+
+	query := annotations.ItemQuery{
+		OrgId:        c.OrgId,
+		From:         c.QueryInt64("from"),
+		To:           c.QueryInt64("to"),
+		AlertId:      c.QueryInt64("alertId"),
+		PanelId:      c.QueryInt64("panelId"),
+		DashboardIds: dashboardIDs,
+		PrevStates:   c.QueryStrings("prevState"),
+		NewStates:    c.QueryStrings("newState"),
+		Type:         "alert",
+	}
+
+	items := make([]*annotations.ItemDTO, 0)
+	for offset := int64(0); offset < maxItems; offset += pageSize {
+		pageQuery := query
+		pageQuery.Offset = offset
+		pageQuery.Limit = pageSize
+		if offset+pageSize > maxItems {
+			pageQuery.Limit = maxItems - offset
+		}
+
+		page, err := annotations.GetRepository().Find(&pageQuery)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items = append(items, page...)
+		if int64(len(page)) < pageQuery.Limit {
+			alerting.SortStateHistory(items)
+			return items, false, nil
+		}
+	}
+
+	// the history is truncated if there is a state change after the max
+	nextQuery := query
+	nextQuery.Offset = maxItems
+	nextQuery.Limit = 1
+	next, err := annotations.GetRepository().Find(&nextQuery)
+	if err != nil {
+		return nil, false, err
+	}
+
+	alerting.SortStateHistory(items)
+	return items, len(next) > 0, nil
+}
+
+// alertHistoryDashboardIDs resolves the dashboard filters of the request to the dashboards
+// the user can view, which are all the viewable dashboards without filters.
+func alertHistoryDashboardIDs(c *models.ReqContext) ([]int64, error) {
+	dashboardIDs := make([]int64, 0)
+	for _, id := range c.QueryStrings("dashboardId") {
+		dashboardID, err := strconv.ParseInt(id, 10, 64)
+		if err == nil {
+			dashboardIDs = append(dashboardIDs, dashboardID)
+		}
+	}
+
+	folderIDs := make([]int64, 0)
+	for _, id := range c.QueryStrings("folderId") {
+		folderID, err := strconv.ParseInt(id, 10, 64)
+		if err == nil {
+			folderIDs = append(folderIDs, folderID)
+		}
+	}
+
+	result := make([]int64, 0)
+	for page := int64(1); ; page++ {
+		searchQuery := search.Query{
+			Title:        c.Query("dashboardQuery"),
+			Tags:         c.QueryStrings("dashboardTag"),
+			SignedInUser: c.SignedInUser,
+			Limit:        alertHistorySearchPageSize,
+			Page:         page,
+			OrgId:        c.OrgId,
+			DashboardIds: dashboardIDs,
+			Type:         string(search.DashHitDB),
+			FolderIds:    folderIDs,
+			Permission:   models.PERMISSION_VIEW,
+		}
+
+		if err := bus.Dispatch(&searchQuery); err != nil {
+			return nil, err
+		}
+
+		for _, d := range searchQuery.Result {
+			if d.Type == search.DashHitDB && d.ID > 0 {
+				result = append(result, d.ID)
+			}
+		}
+
+		if int64(len(searchQuery.Result)) < alertHistorySearchPageSize {
+			return result, nil
+		}
+	}
+}
+
+func alertHistoryCSV(items []*annotations.ItemDTO) response.Response {
+	rows := [][]string{{"time", "alertId", "alertName", "dashboardId", "panelId", "prevState", "newState", "text"}}
+	for _, item := range items {
+		rows = append(rows, []string{
+			time.Unix(0, item.Time*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+			strconv.FormatInt(item.AlertId, 10),
+			item.AlertName,
+			strconv.FormatInt(item.DashboardId, 10),
+			strconv.FormatInt(item.PanelId, 10),
+			item.PrevState,
+			item.NewState,
+			item.Text,
+		})
+	}
+
+	return csvResponse("alert-history.csv", rows)
+}
+
+func alertHistorySummaryCSV(summaries []*alerting.RuleHistorySummary) *response.NormalResponse {
+	rows := [][]string{{"alertId", "alertName", "dashboardId", "panelId", "transitions", "alertingCount", "resolvedCount", "timeInAlertingMs", "mttrMs"}}
+	for _, s := range summaries {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.AlertID, 10),
+			s.AlertName,
+			strconv.FormatInt(s.DashboardID, 10),
+			strconv.FormatInt(s.PanelID, 10),
+			strconv.Itoa(s.Transitions),
+			strconv.Itoa(s.AlertingCount),
+			strconv.Itoa(s.ResolvedCount),
+			strconv.FormatInt(s.TimeInAlertingMs, 10),
+			strconv.FormatInt(s.MTTRMs, 10),
+		})
+	}
+
+	return csvResponse("alert-history-summary.csv", rows)
+}
+
+func csvResponse(filename string, rows [][]string) *response.NormalResponse {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return response.Error(500, "Failed to write CSV", err)
+	}
+
+	return response.Respond(200, buf.Bytes()).
+		Header("Content-Type", "text/csv; charset=utf-8").
+		Header("Content-Disposition", "attachment; filename="+filename)
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+type fakeAlertHistoryRepo struct {
+	fakeAnnotationsRepo
+	query *annotations.ItemQuery
+	items []*annotations.ItemDTO
+}
+
+func (repo *fakeAlertHistoryRepo) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	repo.query = query
+
+	items := make([]*annotations.ItemDTO, 0)
+	for _, item := range repo.items {
+		if !containsInt64(query.DashboardIds, item.DashboardId) ||
+			(len(query.PrevStates) > 0 && !containsString(query.PrevStates, item.PrevState)) ||
+			(len(query.NewStates) > 0 && !containsString(query.NewStates, item.NewState)) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if query.Offset >= int64(len(items)) {
+		return []*annotations.ItemDTO{}, nil
+	}
+	end := query.Offset + query.Limit
+	if end > int64(len(items)) {
+		end = int64(len(items))
+	}
+	return items[query.Offset:end], nil
+}
+
+func containsInt64(values []int64, value int64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// withViewableDashboards makes the dashboard search return the dashboards the user can view.
+func withViewableDashboards(ids ...int64) {
+	bus.AddHandler("test", func(query *search.Query) error {
+		query.Result = search.HitList{}
+		for _, id := range ids {
+			query.Result = append(query.Result, &search.Hit{ID: id, Type: search.DashHitDB})
+		}
+		return nil
+	})
+}
+
+func TestAlertHistoryAPIEndpoint(t *testing.T) {
+	repo := &fakeAlertHistoryRepo{
+		items: []*annotations.ItemDTO{
+			{Id: 3, AlertId: 1, AlertName: "cpu", DashboardId: 2, Time: 3000, PrevState: "alerting", NewState: "ok"},
+			{Id: 1, AlertId: 1, AlertName: "cpu", DashboardId: 2, Time: 1000, PrevState: "ok", NewState: "alerting"},
+			{Id: 2, AlertId: 5, AlertName: "disk", DashboardId: 7, Time: 2000, PrevState: "ok", NewState: "alerting"},
+		},
+	}
+
+	loggedInUserScenario(t, "When calling GET on", "/api/alerts/history", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(2, 7)
+
+		sc.handlerFunc = GetAlertHistory
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"newState": "alerting"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, "alert", repo.query.Type)
+		assert.Equal(t, []string{"alerting"}, repo.query.NewStates)
+
+		var items []*annotations.ItemDTO
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &items))
+		require.Len(t, items, 2)
+		assert.Equal(t, int64(1), items[0].Id)
+		assert.Equal(t, int64(2), items[1].Id)
+	})
+
+	loggedInUserScenario(t, "When calling GET with a folder filter on", "/api/alerts/history", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+
+		bus.AddHandler("test", func(query *search.Query) error {
+			assert.Equal(t, []int64{4}, query.FolderIds)
+			query.Result = search.HitList{{ID: 7, Type: search.DashHitDB}}
+			return nil
+		})
+
+		sc.handlerFunc = GetAlertHistory
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"folderId": "4"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, []int64{7}, repo.query.DashboardIds)
+
+		var items []*annotations.ItemDTO
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &items))
+		require.Len(t, items, 1)
+		assert.Equal(t, "disk", items[0].AlertName)
+	})
+
+	loggedInUserScenario(t, "When calling GET as CSV on", "/api/alerts/history/summary", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(2, 7)
+
+		sc.handlerFunc = GetAlertHistorySummary
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"format": "csv", "from": "0", "to": "4000"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", sc.resp.Header().Get("Content-Type"))
+		assert.Equal(t, "alertId,alertName,dashboardId,panelId,transitions,alertingCount,resolvedCount,timeInAlertingMs,mttrMs\n"+
+			"1,cpu,2,0,2,1,1,2000,2000\n"+
+			"5,disk,7,0,1,1,0,2000,0\n", sc.resp.Body.String())
+		assert.Empty(t, sc.resp.Header().Get("X-Grafana-Truncated"))
+	})
+
+	loggedInUserScenario(t, "When calling GET with more state changes than a page on", "/api/alerts/history/summary", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(2, 7)
+		alertHistorySummaryPageSize = 2
+		t.Cleanup(func() { alertHistorySummaryPageSize = 1000 })
+
+		sc.handlerFunc = GetAlertHistorySummary
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"from": "0", "to": "4000"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, int64(2), repo.query.Offset)
+
+		var result struct {
+			Summaries []*alerting.RuleHistorySummary `json:"summaries"`
+			Truncated bool                           `json:"truncated"`
+		}
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		assert.False(t, result.Truncated)
+		require.Len(t, result.Summaries, 2)
+		assert.Equal(t, 3, result.Summaries[0].Transitions+result.Summaries[1].Transitions)
+	})
+
+	loggedInUserScenario(t, "When calling GET with more state changes than the max on", "/api/alerts/history/summary", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(2, 7)
+		alertHistorySummaryPageSize = 1
+		alertHistorySummaryMaxItems = 2
+		t.Cleanup(func() {
+			alertHistorySummaryPageSize = 1000
+			alertHistorySummaryMaxItems = 100000
+		})
+
+		sc.handlerFunc = GetAlertHistorySummary
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"from": "0", "to": "4000"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result struct {
+			Summaries []*alerting.RuleHistorySummary `json:"summaries"`
+			Truncated bool                           `json:"truncated"`
+		}
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		assert.True(t, result.Truncated)
+	})
+
+	loggedInUserScenario(t, "When calling GET without dashboard filter on", "/api/alerts/history", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(7)
+
+		sc.handlerFunc = GetAlertHistory
+		sc.fakeReq("GET", sc.url).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+
+		var items []*annotations.ItemDTO
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &items))
+		require.Len(t, items, 1, "the state changes of the dashboards the user can't view aren't returned")
+		assert.Equal(t, "disk", items[0].AlertName)
+	})
+
+	loggedInUserScenario(t, "When calling GET with as many state changes as the max on", "/api/alerts/history/summary", func(sc *scenarioContext) {
+		annotations.SetRepository(repo)
+		withViewableDashboards(2, 7)
+		alertHistorySummaryPageSize = 2
+		alertHistorySummaryMaxItems = 3
+		t.Cleanup(func() {
+			alertHistorySummaryPageSize = 1000
+			alertHistorySummaryMaxItems = 100000
+		})
+
+		sc.handlerFunc = GetAlertHistorySummary
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{"from": "0", "to": "4000"}).exec()
+
+		require.Equal(t, 200, sc.resp.Code)
+
+		var result struct {
+			Summaries []*alerting.RuleHistorySummary `json:"summaries"`
+			Truncated bool                           `json:"truncated"`
+		}
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &result))
+		assert.False(t, result.Truncated)
+		assert.Equal(t, 3, result.Summaries[0].Transitions+result.Summaries[1].Transitions)
+	})
+}
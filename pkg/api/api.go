@@ -12,6 +12,8 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var plog = log.New("api")
@@ -21,14 +23,13 @@ func (hs *HTTPServer) registerRoutes() {
 	reqSignedIn := middleware.ReqSignedIn
 	reqSignedInNoAnonymous := middleware.ReqSignedInNoAnonymous
 	reqGrafanaAdmin := middleware.ReqGrafanaAdmin
-	reqEditorRole := middleware.ReqEditorRole
-	reqOrgAdmin := middleware.ReqOrgAdmin
-	reqCanAccessTeams := middleware.AdminOrFeatureEnabled(hs.Cfg.EditorsCanAdmin)
+	authorize := middleware.Authorize
 	reqSnapshotPublicModeOrSignedIn := middleware.SnapshotPublicModeOrSignedIn(hs.Cfg)
 	redirectFromLegacyDashboardURL := middleware.RedirectFromLegacyDashboardURL()
 	redirectFromLegacyDashboardSoloURL := middleware.RedirectFromLegacyDashboardSoloURL(hs.Cfg)
 	redirectFromLegacyPanelEditURL := middleware.RedirectFromLegacyPanelEditURL(hs.Cfg)
 	quota := middleware.Quota(hs.QuotaService)
+	rateLimit := middleware.RateLimitRoute(hs.RateLimitService)
 	bind := binding.Bind
 
 	r := hs.RouteRegister
@@ -36,6 +37,9 @@ func (hs *HTTPServer) registerRoutes() {
 	// not logged in views
 	r.Get("/logout", hs.Logout)
 	r.Post("/login", quota("session"), bind(dtos.LoginCommand{}), routing.Wrap(hs.LoginPost))
+	r.Post("/login/totp", quota("session"), bind(dtos.LoginTotpCommand{}), routing.Wrap(hs.LoginTotp))
+	r.Post("/login/password", quota("session"), bind(dtos.LoginPasswordChangeCommand{}), routing.Wrap(hs.LoginPasswordChange))
+	r.Post("/login/totp/enroll", bind(dtos.LoginTotpEnrollCommand{}), routing.Wrap(hs.LoginTotpEnroll))
 	r.Get("/login/:name", quota("session"), hs.OAuthLogin)
 	r.Get("/login", hs.LoginView)
 	r.Get("/invite/:code", hs.Index)
@@ -46,17 +50,17 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/profile/password", reqSignedInNoAnonymous, hs.Index)
 	r.Get("/.well-known/change-password", redirectToChangePassword)
 	r.Get("/profile/switch-org/:id", reqSignedInNoAnonymous, hs.ChangeActiveOrgAndRedirectToHome)
-	r.Get("/org/", reqOrgAdmin, hs.Index)
+	r.Get("/org/", authorize(ac.ActionOrgsWrite, ""), hs.Index)
 	r.Get("/org/new", reqGrafanaAdmin, hs.Index)
-	r.Get("/datasources/", reqOrgAdmin, hs.Index)
-	r.Get("/datasources/new", reqOrgAdmin, hs.Index)
-	r.Get("/datasources/edit/*", reqOrgAdmin, hs.Index)
-	r.Get("/org/users", reqOrgAdmin, hs.Index)
-	r.Get("/org/users/new", reqOrgAdmin, hs.Index)
-	r.Get("/org/users/invite", reqOrgAdmin, hs.Index)
-	r.Get("/org/teams", reqCanAccessTeams, hs.Index)
-	r.Get("/org/teams/*", reqCanAccessTeams, hs.Index)
-	r.Get("/org/apikeys/", reqOrgAdmin, hs.Index)
+	r.Get("/datasources/", authorize(ac.ActionDatasourcesRead, ac.ScopeDatasourcesAll), hs.Index)
+	r.Get("/datasources/new", authorize(ac.ActionDatasourcesCreate, ""), hs.Index)
+	r.Get("/datasources/edit/*", authorize(ac.ActionDatasourcesRead, ""), hs.Index)
+	r.Get("/org/users", authorize(ac.ActionOrgUsersRead, ac.ScopeUsersAll), hs.Index)
+	r.Get("/org/users/new", authorize(ac.ActionOrgUsersAdd, ac.ScopeUsersAll), hs.Index)
+	r.Get("/org/users/invite", authorize(ac.ActionOrgUsersAdd, ac.ScopeUsersAll), hs.Index)
+	r.Get("/org/teams", authorize(ac.ActionTeamsRead, ""), hs.Index)
+	r.Get("/org/teams/*", authorize(ac.ActionTeamsRead, ""), hs.Index)
+	r.Get("/org/apikeys/", authorize(ac.ActionAPIKeysRead, ""), hs.Index)
 	r.Get("/dashboard/import/", reqSignedIn, hs.Index)
 	r.Get("/configuration", reqGrafanaAdmin, hs.Index)
 	r.Get("/admin", reqGrafanaAdmin, hs.Index)
@@ -96,8 +100,8 @@ func (hs *HTTPServer) registerRoutes() {
 
 	r.Get("/playlists/", reqSignedIn, hs.Index)
 	r.Get("/playlists/*", reqSignedIn, hs.Index)
-	r.Get("/alerting/", reqEditorRole, hs.Index)
-	r.Get("/alerting/*", reqEditorRole, hs.Index)
+	r.Get("/alerting/", authorize(ac.ActionAlertingManage, ""), hs.Index)
+	r.Get("/alerting/*", authorize(ac.ActionAlertingManage, ""), hs.Index)
 
 	// sign up
 	r.Get("/verify", hs.Index)
@@ -115,7 +119,7 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/user/password/reset", hs.Index)
 
 	r.Post("/api/user/password/send-reset-email", bind(dtos.SendResetPasswordEmailForm{}), routing.Wrap(SendResetPasswordEmail))
-	r.Post("/api/user/password/reset", bind(dtos.ResetUserPasswordForm{}), routing.Wrap(ResetPassword))
+	r.Post("/api/user/password/reset", bind(dtos.ResetUserPasswordForm{}), routing.Wrap(hs.ResetPassword))
 
 	// dashboard snapshots
 	r.Get("/dashboard/snapshot/*", hs.Index)
@@ -137,7 +141,7 @@ func (hs *HTTPServer) registerRoutes() {
 			userRoute.Post("/stars/dashboard/:id", routing.Wrap(StarDashboard))
 			userRoute.Delete("/stars/dashboard/:id", routing.Wrap(UnstarDashboard))
 
-			userRoute.Put("/password", bind(models.ChangeUserPasswordCommand{}), routing.Wrap(ChangeUserPassword))
+			userRoute.Put("/password", bind(models.ChangeUserPasswordCommand{}), routing.Wrap(hs.ChangeUserPassword))
 			userRoute.Get("/quotas", routing.Wrap(GetUserQuotas))
 			userRoute.Put("/helpflags/:id", routing.Wrap(SetHelpFlag))
 			// For dev purpose
@@ -148,33 +152,42 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/auth-tokens", routing.Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), routing.Wrap(hs.RevokeUserAuthToken))
+
+			userRoute.Get("/totp", routing.Wrap(hs.GetUserTotpStatus))
+			userRoute.Post("/totp/enroll", routing.Wrap(hs.EnrollUserTotp))
+			userRoute.Post("/totp/enable", bind(dtos.TotpCodeForm{}), routing.Wrap(hs.EnableUserTotp))
+			userRoute.Post("/totp/recovery-codes", bind(dtos.TotpCodeForm{}), routing.Wrap(hs.RegenerateUserTotpRecoveryCodes))
+			userRoute.Post("/totp/disable", bind(dtos.TotpCodeForm{}), routing.Wrap(hs.DisableUserTotp))
 		}, reqSignedInNoAnonymous)
 
-		// users (admin permission required)
+		// users (users permissions required)
 		apiRoute.Group("/users", func(usersRoute routing.RouteRegister) {
-			usersRoute.Get("/", routing.Wrap(SearchUsers))
-			usersRoute.Get("/search", routing.Wrap(SearchUsersWithPaging))
-			usersRoute.Get("/:id", routing.Wrap(GetUserByID))
-			usersRoute.Get("/:id/teams", routing.Wrap(GetUserTeams))
-			usersRoute.Get("/:id/orgs", routing.Wrap(GetUserOrgList))
+			usersRoute.Get("/", authorize(ac.ActionUsersRead, ac.ScopeUsersAll), routing.Wrap(SearchUsers))
+			usersRoute.Get("/search", authorize(ac.ActionUsersRead, ac.ScopeUsersAll), routing.Wrap(SearchUsersWithPaging))
+			usersRoute.Get("/:id", authorize(ac.ActionUsersRead, ac.ScopeUsersID), routing.Wrap(GetUserByID))
+			usersRoute.Get("/:id/teams", authorize(ac.ActionUsersRead, ac.ScopeUsersID), routing.Wrap(GetUserTeams))
+			usersRoute.Get("/:id/orgs", authorize(ac.ActionUsersRead, ac.ScopeUsersID), routing.Wrap(GetUserOrgList))
 			// query parameters /users/lookup?loginOrEmail=admin@example.com
-			usersRoute.Get("/lookup", routing.Wrap(GetUserByLoginOrEmail))
-			usersRoute.Put("/:id", bind(models.UpdateUserCommand{}), routing.Wrap(UpdateUser))
-			usersRoute.Post("/:id/using/:orgId", routing.Wrap(UpdateUserActiveOrg))
-		}, reqGrafanaAdmin)
+			usersRoute.Get("/lookup", authorize(ac.ActionUsersRead, ac.ScopeUsersAll), routing.Wrap(GetUserByLoginOrEmail))
+			usersRoute.Put("/:id", authorize(ac.ActionUsersWrite, ac.ScopeUsersID), bind(models.UpdateUserCommand{}), routing.Wrap(UpdateUser))
+			usersRoute.Post("/:id/using/:orgId", authorize(ac.ActionUsersWrite, ac.ScopeUsersID), routing.Wrap(UpdateUserActiveOrg))
+		})
 
-		// team (admin permission required)
+		// team (teams permissions required)
 		apiRoute.Group("/teams", func(teamsRoute routing.RouteRegister) {
-			teamsRoute.Post("/", bind(models.CreateTeamCommand{}), routing.Wrap(hs.CreateTeam))
-			teamsRoute.Put("/:teamId", bind(models.UpdateTeamCommand{}), routing.Wrap(hs.UpdateTeam))
-			teamsRoute.Delete("/:teamId", routing.Wrap(hs.DeleteTeamByID))
-			teamsRoute.Get("/:teamId/members", routing.Wrap(hs.GetTeamMembers))
-			teamsRoute.Post("/:teamId/members", bind(models.AddTeamMemberCommand{}), routing.Wrap(hs.AddTeamMember))
-			teamsRoute.Put("/:teamId/members/:userId", bind(models.UpdateTeamMemberCommand{}), routing.Wrap(hs.UpdateTeamMember))
-			teamsRoute.Delete("/:teamId/members/:userId", routing.Wrap(hs.RemoveTeamMember))
-			teamsRoute.Get("/:teamId/preferences", routing.Wrap(hs.GetTeamPreferences))
-			teamsRoute.Put("/:teamId/preferences", bind(dtos.UpdatePrefsCmd{}), routing.Wrap(hs.UpdateTeamPreferences))
-		}, reqCanAccessTeams)
+			teamsRoute.Post("/", authorize(ac.ActionTeamsCreate, ""), bind(models.CreateTeamCommand{}), routing.Wrap(hs.CreateTeam))
+			teamsRoute.Put("/:teamId", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), bind(models.UpdateTeamCommand{}), routing.Wrap(hs.UpdateTeam))
+			teamsRoute.Delete("/:teamId", authorize(ac.ActionTeamsDelete, ac.ScopeTeamsID), routing.Wrap(hs.DeleteTeamByID))
+			teamsRoute.Get("/:teamId/members", authorize(ac.ActionTeamsRead, ac.ScopeTeamsID), routing.Wrap(hs.GetTeamMembers))
+			teamsRoute.Post("/:teamId/members", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), bind(models.AddTeamMemberCommand{}), routing.Wrap(hs.AddTeamMember))
+			teamsRoute.Put("/:teamId/members/:userId", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), bind(models.UpdateTeamMemberCommand{}), routing.Wrap(hs.UpdateTeamMember))
+			teamsRoute.Delete("/:teamId/members/:userId", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), routing.Wrap(hs.RemoveTeamMember))
+			teamsRoute.Get("/:teamId/groups", authorize(ac.ActionTeamsRead, ac.ScopeTeamsID), routing.Wrap(hs.GetTeamGroups))
+			teamsRoute.Post("/:teamId/groups", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), bind(models.AddTeamGroupCommand{}), routing.Wrap(hs.AddTeamGroup))
+			teamsRoute.Delete("/:teamId/groups/:groupId", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), routing.Wrap(hs.RemoveTeamGroup))
+			teamsRoute.Get("/:teamId/preferences", authorize(ac.ActionTeamsRead, ac.ScopeTeamsID), routing.Wrap(hs.GetTeamPreferences))
+			teamsRoute.Put("/:teamId/preferences", authorize(ac.ActionTeamsWrite, ac.ScopeTeamsID), bind(dtos.UpdatePrefsCmd{}), routing.Wrap(hs.UpdateTeamPreferences))
+		})
 
 		// team without requirement of user to be org admin
 		apiRoute.Group("/teams", func(teamsRoute routing.RouteRegister) {
@@ -190,22 +203,23 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// current org
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
-			orgRoute.Put("/", bind(dtos.UpdateOrgForm{}), routing.Wrap(UpdateOrgCurrent))
-			orgRoute.Put("/address", bind(dtos.UpdateOrgAddressForm{}), routing.Wrap(UpdateOrgAddressCurrent))
-			orgRoute.Get("/users", routing.Wrap(hs.GetOrgUsersForCurrentOrg))
-			orgRoute.Post("/users", quota("user"), bind(models.AddOrgUserCommand{}), routing.Wrap(AddOrgUserToCurrentOrg))
-			orgRoute.Patch("/users/:userId", bind(models.UpdateOrgUserCommand{}), routing.Wrap(UpdateOrgUserForCurrentOrg))
-			orgRoute.Delete("/users/:userId", routing.Wrap(RemoveOrgUserForCurrentOrg))
+			orgRoute.Put("/", authorize(ac.ActionOrgsWrite, ""), bind(dtos.UpdateOrgForm{}), routing.Wrap(UpdateOrgCurrent))
+			orgRoute.Put("/address", authorize(ac.ActionOrgsWrite, ""), bind(dtos.UpdateOrgAddressForm{}), routing.Wrap(UpdateOrgAddressCurrent))
+			orgRoute.Put("/totp", authorize(ac.ActionOrgsWrite, ""), bind(dtos.UpdateOrgRequireTotpForm{}), routing.Wrap(hs.UpdateOrgRequireTotp))
+			orgRoute.Get("/users", authorize(ac.ActionOrgUsersRead, ac.ScopeUsersAll), routing.Wrap(hs.GetOrgUsersForCurrentOrg))
+			orgRoute.Post("/users", authorize(ac.ActionOrgUsersAdd, ac.ScopeUsersAll), quota("user"), bind(models.AddOrgUserCommand{}), routing.Wrap(AddOrgUserToCurrentOrg))
+			orgRoute.Patch("/users/:userId", authorize(ac.ActionOrgUsersRoleUpdate, ac.ScopeOrgUsersID), bind(models.UpdateOrgUserCommand{}), routing.Wrap(UpdateOrgUserForCurrentOrg))
+			orgRoute.Delete("/users/:userId", authorize(ac.ActionOrgUsersRemove, ac.ScopeOrgUsersID), routing.Wrap(RemoveOrgUserForCurrentOrg))
 
 			// invites
-			orgRoute.Get("/invites", routing.Wrap(GetPendingOrgInvites))
-			orgRoute.Post("/invites", quota("user"), bind(dtos.AddInviteForm{}), routing.Wrap(AddOrgInvite))
-			orgRoute.Patch("/invites/:code/revoke", routing.Wrap(RevokeInvite))
+			orgRoute.Get("/invites", authorize(ac.ActionOrgUsersRead, ac.ScopeUsersAll), routing.Wrap(GetPendingOrgInvites))
+			orgRoute.Post("/invites", authorize(ac.ActionOrgUsersAdd, ac.ScopeUsersAll), quota("user"), bind(dtos.AddInviteForm{}), routing.Wrap(AddOrgInvite))
+			orgRoute.Patch("/invites/:code/revoke", authorize(ac.ActionOrgUsersAdd, ac.ScopeUsersAll), routing.Wrap(RevokeInvite))
 
 			// prefs
-			orgRoute.Get("/preferences", routing.Wrap(GetOrgPreferences))
-			orgRoute.Put("/preferences", bind(dtos.UpdatePrefsCmd{}), routing.Wrap(UpdateOrgPreferences))
-		}, reqOrgAdmin)
+			orgRoute.Get("/preferences", authorize(ac.ActionOrgsPreferencesRead, ""), routing.Wrap(GetOrgPreferences))
+			orgRoute.Put("/preferences", authorize(ac.ActionOrgsPreferencesWrite, ""), bind(dtos.UpdatePrefsCmd{}), routing.Wrap(UpdateOrgPreferences))
+		})
 
 		// current org without requirement of user to be org admin
 		apiRoute.Group("/org", func(orgRoute routing.RouteRegister) {
@@ -239,10 +253,24 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// auth api keys
 		apiRoute.Group("/auth/keys", func(keysRoute routing.RouteRegister) {
-			keysRoute.Get("/", routing.Wrap(GetAPIKeys))
-			keysRoute.Post("/", quota("api_key"), bind(models.AddApiKeyCommand{}), routing.Wrap(hs.AddAPIKey))
-			keysRoute.Delete("/:id", routing.Wrap(DeleteAPIKey))
-		}, reqOrgAdmin)
+			keysRoute.Get("/", authorize(ac.ActionAPIKeysRead, ""), routing.Wrap(GetAPIKeys))
+			keysRoute.Post("/", authorize(ac.ActionAPIKeysCreate, ""), quota("api_key"), bind(models.AddApiKeyCommand{}), routing.Wrap(hs.AddAPIKey))
+			keysRoute.Delete("/:id", authorize(ac.ActionAPIKeysDelete, ""), routing.Wrap(DeleteAPIKey))
+		})
+
+		// Service accounts
+		apiRoute.Group("/serviceaccounts", func(saRoute routing.RouteRegister) {
+			saRoute.Get("/", authorize(ac.ActionServiceAccountsRead, ac.ScopeServiceAccountsAll), routing.Wrap(GetServiceAccounts))
+			saRoute.Post("/", authorize(ac.ActionServiceAccountsCreate, ""), bind(models.CreateServiceAccountCommand{}), routing.Wrap(CreateServiceAccount))
+			saRoute.Post("/migrate", authorize(ac.ActionServiceAccountsCreate, ""), routing.Wrap(ConvertAPIKeysToServiceAccounts))
+			saRoute.Post("/migrate/:keyId", authorize(ac.ActionServiceAccountsCreate, ""), routing.Wrap(ConvertAPIKeysToServiceAccounts))
+			saRoute.Get("/:serviceAccountId", authorize(ac.ActionServiceAccountsRead, ac.ScopeServiceAccountsID), routing.Wrap(GetServiceAccountByID))
+			saRoute.Patch("/:serviceAccountId", authorize(ac.ActionServiceAccountsWrite, ac.ScopeServiceAccountsID), bind(models.UpdateServiceAccountCommand{}), routing.Wrap(UpdateServiceAccount))
+			saRoute.Delete("/:serviceAccountId", authorize(ac.ActionServiceAccountsDelete, ac.ScopeServiceAccountsID), routing.Wrap(DeleteServiceAccount))
+			saRoute.Get("/:serviceAccountId/tokens", authorize(ac.ActionServiceAccountsRead, ac.ScopeServiceAccountsID), routing.Wrap(GetServiceAccountTokens))
+			saRoute.Post("/:serviceAccountId/tokens", authorize(ac.ActionServiceAccountsWrite, ac.ScopeServiceAccountsID), quota("api_key"), bind(models.AddServiceAccountTokenCommand{}), routing.Wrap(hs.CreateServiceAccountToken))
+			saRoute.Delete("/:serviceAccountId/tokens/:tokenId", authorize(ac.ActionServiceAccountsWrite, ac.ScopeServiceAccountsID), routing.Wrap(DeleteServiceAccountToken))
+		})
 
 		// Preferences
 		apiRoute.Group("/preferences", func(prefRoute routing.RouteRegister) {
@@ -251,16 +279,16 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// Data sources
 		apiRoute.Group("/datasources", func(datasourceRoute routing.RouteRegister) {
-			datasourceRoute.Get("/", routing.Wrap(hs.GetDataSources))
-			datasourceRoute.Post("/", quota("data_source"), bind(models.AddDataSourceCommand{}), routing.Wrap(AddDataSource))
-			datasourceRoute.Put("/:id", bind(models.UpdateDataSourceCommand{}), routing.Wrap(UpdateDataSource))
-			datasourceRoute.Delete("/:id", routing.Wrap(DeleteDataSourceById))
-			datasourceRoute.Delete("/uid/:uid", routing.Wrap(DeleteDataSourceByUID))
-			datasourceRoute.Delete("/name/:name", routing.Wrap(DeleteDataSourceByName))
-			datasourceRoute.Get("/:id", routing.Wrap(GetDataSourceById))
-			datasourceRoute.Get("/uid/:uid", routing.Wrap(GetDataSourceByUID))
-			datasourceRoute.Get("/name/:name", routing.Wrap(GetDataSourceByName))
-		}, reqOrgAdmin)
+			datasourceRoute.Get("/", authorize(ac.ActionDatasourcesRead, ac.ScopeDatasourcesAll), routing.Wrap(hs.GetDataSources))
+			datasourceRoute.Post("/", authorize(ac.ActionDatasourcesCreate, ""), quota("data_source"), bind(models.AddDataSourceCommand{}), routing.Wrap(AddDataSource))
+			datasourceRoute.Put("/:id", authorize(ac.ActionDatasourcesWrite, ac.ScopeDatasourcesID), bind(models.UpdateDataSourceCommand{}), routing.Wrap(UpdateDataSource))
+			datasourceRoute.Delete("/:id", authorize(ac.ActionDatasourcesDelete, ac.ScopeDatasourcesID), routing.Wrap(DeleteDataSourceById))
+			datasourceRoute.Delete("/uid/:uid", authorize(ac.ActionDatasourcesDelete, ac.ScopeDatasourcesUID), routing.Wrap(DeleteDataSourceByUID))
+			datasourceRoute.Delete("/name/:name", authorize(ac.ActionDatasourcesDelete, ac.ScopeDatasourcesName), routing.Wrap(DeleteDataSourceByName))
+			datasourceRoute.Get("/:id", authorize(ac.ActionDatasourcesRead, ac.ScopeDatasourcesID), routing.Wrap(GetDataSourceById))
+			datasourceRoute.Get("/uid/:uid", authorize(ac.ActionDatasourcesRead, ac.ScopeDatasourcesUID), routing.Wrap(GetDataSourceByUID))
+			datasourceRoute.Get("/name/:name", authorize(ac.ActionDatasourcesRead, ac.ScopeDatasourcesName), routing.Wrap(GetDataSourceByName))
+		})
 
 		apiRoute.Get("/datasources/id/:name", routing.Wrap(GetDataSourceIdByName), reqSignedIn)
 
@@ -276,11 +304,11 @@ func (hs *HTTPServer) registerRoutes() {
 			pluginRoute.Get("/:pluginId/dashboards/", routing.Wrap(hs.GetPluginDashboards))
 			pluginRoute.Post("/:pluginId/settings", bind(models.UpdatePluginSettingCmd{}), routing.Wrap(UpdatePluginSetting))
 			pluginRoute.Get("/:pluginId/metrics", routing.Wrap(hs.CollectPluginMetrics))
-		}, reqOrgAdmin)
+		}, authorize(ac.ActionPluginsManage, ""))
 
 		apiRoute.Get("/frontend/settings/", hs.GetFrontendSettings)
-		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, hs.ProxyDataSourceRequest)
-		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id/*", reqSignedIn, rateLimit(setting.RateLimitGroupQuery), hs.ProxyDataSourceRequest)
+		apiRoute.Any("/datasources/proxy/:id", reqSignedIn, rateLimit(setting.RateLimitGroupQuery), hs.ProxyDataSourceRequest)
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", routing.Wrap(hs.CheckDatasourceHealth))
@@ -341,32 +369,34 @@ func (hs *HTTPServer) registerRoutes() {
 			playlistRoute.Get("/:id", ValidateOrgPlaylist, routing.Wrap(GetPlaylist))
 			playlistRoute.Get("/:id/items", ValidateOrgPlaylist, routing.Wrap(GetPlaylistItems))
 			playlistRoute.Get("/:id/dashboards", ValidateOrgPlaylist, routing.Wrap(GetPlaylistDashboards))
-			playlistRoute.Delete("/:id", reqEditorRole, ValidateOrgPlaylist, routing.Wrap(DeletePlaylist))
-			playlistRoute.Put("/:id", reqEditorRole, bind(models.UpdatePlaylistCommand{}), ValidateOrgPlaylist, routing.Wrap(UpdatePlaylist))
-			playlistRoute.Post("/", reqEditorRole, bind(models.CreatePlaylistCommand{}), routing.Wrap(CreatePlaylist))
+			playlistRoute.Delete("/:id", authorize(ac.ActionPlaylistsWrite, ""), ValidateOrgPlaylist, routing.Wrap(DeletePlaylist))
+			playlistRoute.Put("/:id", authorize(ac.ActionPlaylistsWrite, ""), bind(models.UpdatePlaylistCommand{}), ValidateOrgPlaylist, routing.Wrap(UpdatePlaylist))
+			playlistRoute.Post("/", authorize(ac.ActionPlaylistsWrite, ""), bind(models.CreatePlaylistCommand{}), routing.Wrap(CreatePlaylist))
 		})
 
 		// Search
 		apiRoute.Get("/search/sorting", routing.Wrap(hs.ListSortOptions))
-		apiRoute.Get("/search/", routing.Wrap(Search))
+		apiRoute.Get("/search/", rateLimit(setting.RateLimitGroupSearch), routing.Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", rateLimit(setting.RateLimitGroupQuery), bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, routing.Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", routing.Wrap(hs.GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", rateLimit(setting.RateLimitGroupQuery), bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), routing.Wrap(hs.AlertTest))
-			alertsRoute.Post("/:alertId/pause", reqEditorRole, bind(dtos.PauseAlertCommand{}), routing.Wrap(PauseAlert))
+			alertsRoute.Post("/:alertId/pause", authorize(ac.ActionAlertingManage, ""), bind(dtos.PauseAlertCommand{}), routing.Wrap(PauseAlert))
 			alertsRoute.Get("/:alertId", ValidateOrgAlert, routing.Wrap(GetAlert))
 			alertsRoute.Get("/", routing.Wrap(GetAlerts))
 			alertsRoute.Get("/states-for-dashboard", routing.Wrap(GetAlertStatesForDashboard))
+			alertsRoute.Get("/history", routing.Wrap(GetAlertHistory))
+			alertsRoute.Get("/history/summary", routing.Wrap(GetAlertHistorySummary))
 		})
 
-		apiRoute.Get("/alert-notifiers", reqEditorRole, routing.Wrap(GetAlertNotifiers))
+		apiRoute.Get("/alert-notifiers", authorize(ac.ActionAlertingManage, ""), routing.Wrap(GetAlertNotifiers))
 
 		apiRoute.Group("/alert-notifications", func(alertNotifications routing.RouteRegister) {
 			alertNotifications.Get("/", routing.Wrap(GetAlertNotifications))
@@ -378,7 +408,7 @@ func (hs *HTTPServer) registerRoutes() {
 			alertNotifications.Get("/uid/:uid", routing.Wrap(GetAlertNotificationByUID))
 			alertNotifications.Put("/uid/:uid", bind(models.UpdateAlertNotificationWithUidCommand{}), routing.Wrap(UpdateAlertNotificationByUID))
 			alertNotifications.Delete("/uid/:uid", routing.Wrap(DeleteAlertNotificationByUID))
-		}, reqEditorRole)
+		}, authorize(ac.ActionAlertingManage, ""))
 
 		// alert notifications without requirement of user to be org editor
 		apiRoute.Group("/alert-notifications", func(orgRoute routing.RouteRegister) {
@@ -386,47 +416,94 @@ func (hs *HTTPServer) registerRoutes() {
 		})
 
 		apiRoute.Get("/annotations", routing.Wrap(GetAnnotations))
-		apiRoute.Post("/annotations/mass-delete", reqOrgAdmin, bind(dtos.DeleteAnnotationsCmd{}), routing.Wrap(DeleteAnnotations))
+		apiRoute.Post("/annotations/mass-delete", authorize(ac.ActionAnnotationsBulkDelete, ""), bind(dtos.DeleteAnnotationsCmd{}), routing.Wrap(DeleteAnnotations))
 
 		apiRoute.Group("/annotations", func(annotationsRoute routing.RouteRegister) {
 			annotationsRoute.Post("/", bind(dtos.PostAnnotationsCmd{}), routing.Wrap(PostAnnotation))
 			annotationsRoute.Delete("/:annotationId", routing.Wrap(DeleteAnnotationByID))
 			annotationsRoute.Put("/:annotationId", bind(dtos.UpdateAnnotationsCmd{}), routing.Wrap(UpdateAnnotation))
 			annotationsRoute.Patch("/:annotationId", bind(dtos.PatchAnnotationsCmd{}), routing.Wrap(PatchAnnotation))
-			annotationsRoute.Post("/graphite", reqEditorRole, bind(dtos.PostGraphiteAnnotationsCmd{}), routing.Wrap(PostGraphiteAnnotation))
+			annotationsRoute.Post("/graphite", authorize(ac.ActionAnnotationsGraphiteCreate, ""), bind(dtos.PostGraphiteAnnotationsCmd{}), routing.Wrap(PostGraphiteAnnotation))
 		})
 
 		// short urls
 		apiRoute.Post("/short-urls", bind(dtos.CreateShortURLCmd{}), routing.Wrap(hs.createShortURL))
+
+		// access control
+		apiRoute.Group("/access-control", func(acRoute routing.RouteRegister) {
+			acRoute.Get("/user/permissions", routing.Wrap(hs.GetSignedInUserPermissions))
+			acRoute.Get("/builtin-roles", authorize(ac.ActionRolesRead, ac.ScopeRolesAll), routing.Wrap(hs.GetBuiltinRoles))
+			acRoute.Get("/roles", authorize(ac.ActionRolesRead, ac.ScopeRolesAll), routing.Wrap(GetRoles))
+			acRoute.Post("/roles", authorize(ac.ActionRolesWrite, ""), bind(models.CreateRoleCommand{}), routing.Wrap(hs.CreateRole))
+			acRoute.Get("/roles/:roleUid", authorize(ac.ActionRolesRead, ac.ScopeRolesUID), routing.Wrap(GetRoleByUID))
+			acRoute.Put("/roles/:roleUid", authorize(ac.ActionRolesWrite, ac.ScopeRolesUID), bind(models.UpdateRoleCommand{}), routing.Wrap(hs.UpdateRole))
+			acRoute.Delete("/roles/:roleUid", authorize(ac.ActionRolesWrite, ac.ScopeRolesUID), routing.Wrap(DeleteRole))
+			acRoute.Get("/roles/:roleUid/assignments", authorize(ac.ActionRolesRead, ac.ScopeRolesUID), routing.Wrap(GetRoleAssignments))
+			acRoute.Post("/roles/:roleUid/assignments", authorize(ac.ActionRolesWrite, ac.ScopeRolesUID), bind(models.AddRoleAssignmentCommand{}), routing.Wrap(hs.AddRoleAssignment))
+			acRoute.Delete("/roles/:roleUid/assignments/:assignmentId", authorize(ac.ActionRolesWrite, ac.ScopeRolesUID), routing.Wrap(RemoveRoleAssignment))
+		})
 	}, reqSignedIn)
 
+	// admin api for users (users permissions required)
+	r.Group("/api/admin/users", func(adminUserRoute routing.RouteRegister) {
+		adminUserRoute.Post("/", authorize(ac.ActionUsersCreate, ""), bind(dtos.AdminCreateUserForm{}), routing.Wrap(hs.AdminCreateUser))
+		adminUserRoute.Put("/:id/password", authorize(ac.ActionUsersPasswordUpdate, ac.ScopeUsersID), bind(dtos.AdminUpdateUserPasswordForm{}), routing.Wrap(hs.AdminUpdateUserPassword))
+		adminUserRoute.Put("/:id/permissions", authorize(ac.ActionUsersPermissionsWrite, ac.ScopeUsersID), bind(dtos.AdminUpdateUserPermissionsForm{}), routing.Wrap(AdminUpdateUserPermissions))
+		adminUserRoute.Delete("/:id", authorize(ac.ActionUsersDelete, ac.ScopeUsersID), routing.Wrap(AdminDeleteUser))
+		adminUserRoute.Post("/:id/disable", authorize(ac.ActionUsersDisable, ac.ScopeUsersID), routing.Wrap(hs.AdminDisableUser))
+		adminUserRoute.Post("/:id/enable", authorize(ac.ActionUsersEnable, ac.ScopeUsersID), routing.Wrap(AdminEnableUser))
+		adminUserRoute.Get("/:id/quotas", authorize(ac.ActionUsersQuotasRead, ac.ScopeUsersID), routing.Wrap(GetUserQuotas))
+		adminUserRoute.Put("/:id/quotas/:target", authorize(ac.ActionUsersQuotasWrite, ac.ScopeUsersID), bind(models.UpdateUserQuotaCmd{}), routing.Wrap(UpdateUserQuota))
+
+		adminUserRoute.Post("/:id/logout", authorize(ac.ActionUsersAuthTokensWrite, ac.ScopeUsersID), routing.Wrap(hs.AdminLogoutUser))
+		adminUserRoute.Get("/:id/auth-tokens", authorize(ac.ActionUsersAuthTokensRead, ac.ScopeUsersID), routing.Wrap(hs.AdminGetUserAuthTokens))
+		adminUserRoute.Post("/:id/revoke-auth-token", authorize(ac.ActionUsersAuthTokensWrite, ac.ScopeUsersID), bind(models.RevokeAuthTokenCmd{}), routing.Wrap(hs.AdminRevokeUserAuthToken))
+		adminUserRoute.Delete("/:id/totp", authorize(ac.ActionUsersTotpDelete, ac.ScopeUsersID), routing.Wrap(hs.AdminDeleteUserTotp))
+	}, reqSignedIn, rateLimit(setting.RateLimitGroupAdmin))
+
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
 		adminRoute.Get("/settings", routing.Wrap(AdminGetSettings))
-		adminRoute.Post("/users", bind(dtos.AdminCreateUserForm{}), routing.Wrap(AdminCreateUser))
-		adminRoute.Put("/users/:id/password", bind(dtos.AdminUpdateUserPasswordForm{}), routing.Wrap(AdminUpdateUserPassword))
-		adminRoute.Put("/users/:id/permissions", bind(dtos.AdminUpdateUserPermissionsForm{}), routing.Wrap(AdminUpdateUserPermissions))
-		adminRoute.Delete("/users/:id", routing.Wrap(AdminDeleteUser))
-		adminRoute.Post("/users/:id/disable", routing.Wrap(hs.AdminDisableUser))
-		adminRoute.Post("/users/:id/enable", routing.Wrap(AdminEnableUser))
-		adminRoute.Get("/users/:id/quotas", routing.Wrap(GetUserQuotas))
-		adminRoute.Put("/users/:id/quotas/:target", bind(models.UpdateUserQuotaCmd{}), routing.Wrap(UpdateUserQuota))
 		adminRoute.Get("/stats", routing.Wrap(AdminGetStats))
 		adminRoute.Post("/pause-all-alerts", bind(dtos.PauseAllAlertsCommand{}), routing.Wrap(PauseAllAlerts))
 
-		adminRoute.Post("/users/:id/logout", routing.Wrap(hs.AdminLogoutUser))
-		adminRoute.Get("/users/:id/auth-tokens", routing.Wrap(hs.AdminGetUserAuthTokens))
-		adminRoute.Post("/users/:id/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), routing.Wrap(hs.AdminRevokeUserAuthToken))
-
 		adminRoute.Post("/provisioning/dashboards/reload", routing.Wrap(hs.AdminProvisioningReloadDashboards))
 		adminRoute.Post("/provisioning/plugins/reload", routing.Wrap(hs.AdminProvisioningReloadPlugins))
 		adminRoute.Post("/provisioning/datasources/reload", routing.Wrap(hs.AdminProvisioningReloadDatasources))
 		adminRoute.Post("/provisioning/notifications/reload", routing.Wrap(hs.AdminProvisioningReloadNotifications))
+		adminRoute.Post("/provisioning/alerting/reload", routing.Wrap(hs.AdminProvisioningReloadAlerting))
+		adminRoute.Post("/provisioning/access/reload", routing.Wrap(hs.AdminProvisioningReloadAccess))
+		adminRoute.Post("/provisioning/librarypanels/reload", routing.Wrap(hs.AdminProvisioningReloadLibraryPanels))
 		adminRoute.Post("/ldap/reload", routing.Wrap(hs.ReloadLDAPCfg))
 		adminRoute.Post("/ldap/sync/:id", routing.Wrap(hs.PostSyncUserWithLDAP))
 		adminRoute.Get("/ldap/:username", routing.Wrap(hs.GetUserFromLDAP))
 		adminRoute.Get("/ldap/status", routing.Wrap(hs.GetLDAPStatus))
-	}, reqGrafanaAdmin)
+		adminRoute.Get("/ldap-sync-status", routing.Wrap(hs.GetLDAPSyncStatus))
+		adminRoute.Get("/audit-logs", routing.Wrap(SearchAuditLog))
+
+		adminRoute.Get("/lockouts", routing.Wrap(hs.AdminGetLoginLockouts))
+		adminRoute.Delete("/lockouts/users/:username", routing.Wrap(AdminDeleteUserLoginLockout))
+		adminRoute.Delete("/lockouts/ips/:ip", routing.Wrap(AdminDeleteIPLoginLockout))
+	}, reqGrafanaAdmin, rateLimit(setting.RateLimitGroupAdmin))
+
+	// SCIM provisioning of the users and teams, authenticated with the SCIM token
+	r.Group("/api/scim/v2", func(scimRoute routing.RouteRegister) {
+		scimRoute.Get("/ServiceProviderConfig", routing.Wrap(hs.SCIMGetServiceProviderConfig))
+
+		scimRoute.Get("/Users", routing.Wrap(hs.SCIMListUsers))
+		scimRoute.Post("/Users", routing.Wrap(hs.SCIMCreateUser))
+		scimRoute.Get("/Users/:id", routing.Wrap(hs.SCIMGetUser))
+		scimRoute.Put("/Users/:id", routing.Wrap(hs.SCIMReplaceUser))
+		scimRoute.Patch("/Users/:id", routing.Wrap(hs.SCIMPatchUser))
+		scimRoute.Delete("/Users/:id", routing.Wrap(hs.SCIMDeleteUser))
+
+		scimRoute.Get("/Groups", routing.Wrap(hs.SCIMListGroups))
+		scimRoute.Post("/Groups", routing.Wrap(hs.SCIMCreateGroup))
+		scimRoute.Get("/Groups/:id", routing.Wrap(hs.SCIMGetGroup))
+		scimRoute.Put("/Groups/:id", routing.Wrap(hs.SCIMReplaceGroup))
+		scimRoute.Patch("/Groups/:id", routing.Wrap(hs.SCIMPatchGroup))
+		scimRoute.Delete("/Groups/:id", routing.Wrap(hs.SCIMDeleteGroup))
+	}, hs.scimAuth)
 
 	// rendering
 	r.Get("/render/*", reqSignedIn, hs.RenderToPng)
@@ -443,7 +520,7 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, GetSharingOptions)
 	r.Get("/api/snapshots/:key", routing.Wrap(GetDashboardSnapshot))
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, routing.Wrap(DeleteDashboardSnapshotByDeleteKey))
-	r.Delete("/api/snapshots/:key", reqEditorRole, routing.Wrap(DeleteDashboardSnapshot))
+	r.Delete("/api/snapshots/:key", authorize(ac.ActionSnapshotsDelete, ""), routing.Wrap(DeleteDashboardSnapshot))
 
 	// Frontend logs
 	sourceMapStore := frontendlogging.NewSourceMapStore(hs.Cfg, frontendlogging.ReadSourceMapFromFS)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -9,6 +10,8 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func GetAPIKeys(c *models.ReqContext) response.Response {
@@ -39,6 +42,12 @@ func GetAPIKeys(c *models.ReqContext) response.Response {
 func DeleteAPIKey(c *models.ReqContext) response.Response {
 	id := c.ParamsInt64(":id")
 
+	auditlog.SetResource(c, "apikeys", strconv.FormatInt(id, 10))
+	keyQuery := models.GetApiKeyByIdQuery{ApiKeyId: id}
+	if err := bus.Dispatch(&keyQuery); err == nil && keyQuery.Result.OrgId == c.OrgId {
+		auditlog.SetBefore(c, util.DynMap{"name": keyQuery.Result.Name, "role": keyQuery.Result.Role})
+	}
+
 	cmd := &models.DeleteApiKeyCommand{Id: id, OrgId: c.OrgId}
 
 	err := bus.Dispatch(cmd)
@@ -54,6 +63,10 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext, cmd models.AddApiKeyComman
 		return response.Error(400, "Invalid role specified", nil)
 	}
 
+	if !c.OrgRole.Includes(cmd.Role) {
+		return response.Error(403, "Cannot create an API key with a role higher than your own", nil)
+	}
+
 	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
 		if cmd.SecondsToLive == 0 {
 			return response.Error(400, "Number of seconds before expiration should be set", nil)
@@ -81,6 +94,8 @@ func (hs *HTTPServer) AddAPIKey(c *models.ReqContext, cmd models.AddApiKeyComman
 		return response.Error(500, "Failed to add API Key", err)
 	}
 
+	auditlog.SetResource(c, "apikeys", strconv.FormatInt(cmd.Result.Id, 10))
+
 	result := &dtos.NewApiKeyResult{
 		ID:   cmd.Result.Id,
 		Name: cmd.Result.Name,
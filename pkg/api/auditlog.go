@@ -0,0 +1,55 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/admin/audit-logs
+func SearchAuditLog(c *models.ReqContext) response.Response {
+	from, err := parseAuditLogTime(c.Query("from"))
+	if err != nil {
+		return response.Error(400, "Invalid from time", err)
+	}
+
+	to, err := parseAuditLogTime(c.Query("to"))
+	if err != nil {
+		return response.Error(400, "Invalid to time", err)
+	}
+
+	query := models.SearchAuditLogQuery{
+		OrgId:        c.QueryInt64("orgId"),
+		UserId:       c.QueryInt64("userId"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resourceType"),
+		ResourceUid:  c.Query("resourceUid"),
+		Outcome:      c.Query("outcome"),
+		From:         from,
+		To:           to,
+		Page:         c.QueryInt("page"),
+		PerPage:      c.QueryInt("perpage"),
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to search audit log", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// parseAuditLogTime parses times in RFC 3339 format or in milliseconds since the epoch.
+func parseAuditLogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -100,6 +101,9 @@ func DeleteDataSourceById(c *models.ReqContext) response.Response {
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	auditlog.SetResource(c, "datasources", ds.Uid)
+	auditlog.SetBefore(c, dataSourceAuditSummary(ds))
+
 	cmd := &models.DeleteDataSourceCommand{ID: id, OrgID: c.OrgId}
 
 	err = bus.Dispatch(cmd)
@@ -145,6 +149,8 @@ func DeleteDataSourceByUID(c *models.ReqContext) response.Response {
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	auditlog.SetBefore(c, dataSourceAuditSummary(ds))
+
 	cmd := &models.DeleteDataSourceCommand{UID: uid, OrgID: c.OrgId}
 
 	err = bus.Dispatch(cmd)
@@ -174,6 +180,9 @@ func DeleteDataSourceByName(c *models.ReqContext) response.Response {
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	auditlog.SetResource(c, "datasources", getCmd.Result.Uid)
+	auditlog.SetBefore(c, dataSourceAuditSummary(getCmd.Result))
+
 	cmd := &models.DeleteDataSourceCommand{Name: name, OrgID: c.OrgId}
 	err := bus.Dispatch(cmd)
 	if err != nil {
@@ -213,6 +222,8 @@ func AddDataSource(c *models.ReqContext, cmd models.AddDataSourceCommand) respon
 		return response.Error(500, "Failed to add datasource", err)
 	}
 
+	auditlog.SetResource(c, "datasources", cmd.Result.Uid)
+
 	ds := convertModelToDtos(cmd.Result)
 	return response.JSON(200, util.DynMap{
 		"message":    "Datasource added",
@@ -230,6 +241,11 @@ func UpdateDataSource(c *models.ReqContext, cmd models.UpdateDataSourceCommand)
 		return resp
 	}
 
+	if before, err := getRawDataSourceById(cmd.Id, c.OrgId); err == nil {
+		auditlog.SetResource(c, "datasources", before.Uid)
+		auditlog.SetBefore(c, dataSourceAuditSummary(before))
+	}
+
 	err := fillWithSecureJSONData(&cmd)
 	if err != nil {
 		return response.Error(500, "Failed to update datasource", err)
@@ -265,6 +281,18 @@ func UpdateDataSource(c *models.ReqContext, cmd models.UpdateDataSourceCommand)
 	})
 }
 
+// dataSourceAuditSummary returns the fields of a data source recorded in the audit log.
+func dataSourceAuditSummary(ds *models.DataSource) util.DynMap {
+	return util.DynMap{
+		"name":      ds.Name,
+		"type":      ds.Type,
+		"url":       ds.Url,
+		"access":    ds.Access,
+		"isDefault": ds.IsDefault,
+		"jsonData":  ds.JsonData,
+	}
+}
+
 func fillWithSecureJSONData(cmd *models.UpdateDataSourceCommand) error {
 	if len(cmd.SecureJsonData) == 0 {
 		return nil
@@ -24,6 +24,32 @@ type LoginCommand struct {
 	Remember bool   `json:"remember"`
 }
 
+// LoginTotpCommand completes the logins of the users with two-factor authentication,
+// with a code of their authenticator or one of their recovery codes.
+type LoginTotpCommand struct {
+	Token        string `json:"token" binding:"Required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// LoginPasswordChangeCommand completes the logins of the users whose password expired,
+// with their new password.
+type LoginPasswordChangeCommand struct {
+	Token           string `json:"token" binding:"Required"`
+	NewPassword     string `json:"newPassword" binding:"Required"`
+	ConfirmPassword string `json:"confirmPassword" binding:"Required"`
+}
+
+// LoginTotpEnrollCommand starts the enrollment of an authenticator during the logins
+// of the users required to use two-factor authentication.
+type LoginTotpEnrollCommand struct {
+	Token string `json:"token" binding:"Required"`
+}
+
+type TotpCodeForm struct {
+	Code string `json:"code" binding:"Required"`
+}
+
 type CurrentUser struct {
 	IsSignedIn                 bool              `json:"isSignedIn"`
 	Id                         int64             `json:"id"`
@@ -12,3 +12,7 @@ type UpdateOrgAddressForm struct {
 	State    string `json:"state"`
 	Country  string `json:"country"`
 }
+
+type UpdateOrgRequireTotpForm struct {
+	Required bool `json:"required"`
+}
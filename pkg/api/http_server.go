@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/search"
@@ -37,10 +38,13 @@ import (
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/ldapsync"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/ratelimit"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -64,31 +68,35 @@ type HTTPServer struct {
 	httpSrv     *http.Server
 	middlewares []macaron.Handler
 
-	RouteRegister          routing.RouteRegister              `inject:""`
-	Bus                    bus.Bus                            `inject:""`
-	RenderService          rendering.Service                  `inject:""`
-	Cfg                    *setting.Cfg                       `inject:""`
-	HooksService           *hooks.HooksService                `inject:""`
-	CacheService           *localcache.CacheService           `inject:""`
-	DatasourceCache        datasources.CacheService           `inject:""`
-	AuthTokenService       models.UserTokenService            `inject:""`
-	QuotaService           *quota.QuotaService                `inject:""`
-	RemoteCacheService     *remotecache.RemoteCache           `inject:""`
-	ProvisioningService    provisioning.ProvisioningService   `inject:""`
-	Login                  *login.LoginService                `inject:""`
-	License                models.Licensing                   `inject:""`
-	BackendPluginManager   backendplugin.Manager              `inject:""`
-	PluginRequestValidator models.PluginRequestValidator      `inject:""`
-	PluginManager          *manager.PluginManager             `inject:""`
-	SearchService          *search.SearchService              `inject:""`
-	ShortURLService        *shorturls.ShortURLService         `inject:""`
-	Live                   *live.GrafanaLive                  `inject:""`
-	ContextHandler         *contexthandler.ContextHandler     `inject:""`
-	SQLStore               *sqlstore.SQLStore                 `inject:""`
-	LibraryPanelService    *librarypanels.LibraryPanelService `inject:""`
-	DataService            *tsdb.Service                      `inject:""`
-	PluginDashboardService *plugindashboards.Service          `inject:""`
-	AlertEngine            *alerting.AlertEngine              `inject:""`
+	RouteRegister          routing.RouteRegister                 `inject:""`
+	Bus                    bus.Bus                               `inject:""`
+	RenderService          rendering.Service                     `inject:""`
+	Cfg                    *setting.Cfg                          `inject:""`
+	HooksService           *hooks.HooksService                   `inject:""`
+	CacheService           *localcache.CacheService              `inject:""`
+	DatasourceCache        datasources.CacheService              `inject:""`
+	AuthTokenService       models.UserTokenService               `inject:""`
+	QuotaService           *quota.QuotaService                   `inject:""`
+	RateLimitService       *ratelimit.RateLimitService           `inject:""`
+	PasswordPolicyService  *passwordpolicy.PasswordPolicyService `inject:""`
+	RemoteCacheService     *remotecache.RemoteCache              `inject:""`
+	ProvisioningService    provisioning.ProvisioningService      `inject:""`
+	Login                  *login.LoginService                   `inject:""`
+	License                models.Licensing                      `inject:""`
+	BackendPluginManager   backendplugin.Manager                 `inject:""`
+	PluginRequestValidator models.PluginRequestValidator         `inject:""`
+	PluginManager          *manager.PluginManager                `inject:""`
+	SearchService          *search.SearchService                 `inject:""`
+	ShortURLService        *shorturls.ShortURLService            `inject:""`
+	Live                   *live.GrafanaLive                     `inject:""`
+	ContextHandler         *contexthandler.ContextHandler        `inject:""`
+	SQLStore               *sqlstore.SQLStore                    `inject:""`
+	LibraryPanelService    *librarypanels.LibraryPanelService    `inject:""`
+	DataService            *tsdb.Service                         `inject:""`
+	PluginDashboardService *plugindashboards.Service             `inject:""`
+	AlertEngine            *alerting.AlertEngine                 `inject:""`
+	AccessControl          *accesscontrol.AccessControl          `inject:""`
+	LDAPSyncService        *ldapsync.LDAPSyncService             `inject:""`
 	Listener               net.Listener
 }
 
@@ -150,6 +150,15 @@ func (hs *HTTPServer) GetLDAPStatus(c *models.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, serverDTOs)
 }
 
+// GetLDAPSyncStatus returns the schedule of the background synchronization of the LDAP users and the summary of the last one run by this server.
+func (hs *HTTPServer) GetLDAPSyncStatus(c *models.ReqContext) response.Response {
+	if !ldap.IsEnabled() {
+		return response.Error(http.StatusBadRequest, "LDAP is not enabled", nil)
+	}
+
+	return response.JSON(http.StatusOK, hs.LDAPSyncService.Status())
+}
+
 // PostSyncUserWithLDAP enables a single Grafana user to be synchronized against LDAP
 func (hs *HTTPServer) PostSyncUserWithLDAP(c *models.ReqContext) response.Response {
 	if !ldap.IsEnabled() {
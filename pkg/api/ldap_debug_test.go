@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/ldapsync"
 	"github.com/grafana/grafana/pkg/services/multildap"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -373,6 +374,35 @@ func TestGetLDAPStatusAPIEndpoint(t *testing.T) {
 	assert.JSONEq(t, expected, sc.resp.Body.String())
 }
 
+func TestGetLDAPSyncStatusAPIEndpoint(t *testing.T) {
+	requestURL := "/api/admin/ldap-sync-status"
+	sc := setupScenarioContext(t, requestURL)
+
+	ldapEnabled, activeSyncEnabled, syncCron := setting.LDAPEnabled, setting.LDAPActiveSyncEnabled, setting.LDAPSyncCron
+	t.Cleanup(func() {
+		setting.LDAPEnabled, setting.LDAPActiveSyncEnabled, setting.LDAPSyncCron = ldapEnabled, activeSyncEnabled, syncCron
+	})
+	setting.LDAPEnabled = true
+	setting.LDAPActiveSyncEnabled = false
+	setting.LDAPSyncCron = "0 0 1 * * *"
+
+	hs := &HTTPServer{Cfg: setting.NewCfg(), LDAPSyncService: &ldapsync.LDAPSyncService{}}
+
+	sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+		sc.context = c
+		return hs.GetLDAPSyncStatus(c)
+	})
+	sc.m.Get(requestURL, sc.defaultHandler)
+
+	sc.resp = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, requestURL, nil)
+	sc.req = req
+	sc.exec()
+
+	require.Equal(t, http.StatusOK, sc.resp.Code)
+	assert.JSONEq(t, `{"enabled": false, "schedule": "0 0 1 * * *"}`, sc.resp.Body.String())
+}
+
 // ***
 // PostSyncUserWithLDAP tests
 // ***
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -197,7 +198,7 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 		ReqContext: c,
 		Username:   cmd.User,
 		Password:   cmd.Password,
-		IpAddress:  c.Req.RemoteAddr,
+		IpAddress:  c.ClientIPAddress(hs.Cfg.LoginLockout.TrustedProxies),
 		Cfg:        hs.Cfg,
 	}
 
@@ -223,21 +224,49 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 
 	user = authQuery.User
 
-	err = hs.loginUserWithUser(user, c)
-	if err != nil {
-		var createTokenErr *models.CreateTokenErr
-		if errors.As(err, &createTokenErr) {
-			resp = response.Error(createTokenErr.StatusCode, createTokenErr.ExternalErr, createTokenErr.InternalErr)
-		} else {
-			resp = response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+	if hs.Cfg.Totp.Enabled && authModule == "grafana" {
+		totpResp, err := hs.totpLoginResponse(user)
+		if err != nil {
+			resp = response.Error(http.StatusInternalServerError, "Error while checking two-factor authentication", err)
+			return resp
+		}
+		if totpResp != nil {
+			resp = totpResp
+			return resp
 		}
-		return resp
 	}
 
 	result := map[string]interface{}{
 		"message": "Logged in",
 	}
 
+	if hs.Cfg.PasswordPolicy.Enabled && authModule == "grafana" {
+		passwordResp, err := hs.passwordExpiredLoginResponse(user, result)
+		if err != nil {
+			resp = response.Error(http.StatusInternalServerError, "Error while checking password expiration", err)
+			return resp
+		}
+		if passwordResp != nil {
+			resp = passwordResp
+			return resp
+		}
+	}
+
+	resp = hs.loginResponse(c, user, result)
+	return resp
+}
+
+// loginResponse creates the session of the user and returns the result of the login,
+// with the URL to redirect the user to.
+func (hs *HTTPServer) loginResponse(c *models.ReqContext, user *models.User, result map[string]interface{}) *response.NormalResponse {
+	if err := hs.loginUserWithUser(user, c); err != nil {
+		var createTokenErr *models.CreateTokenErr
+		if errors.As(err, &createTokenErr) {
+			return response.Error(createTokenErr.StatusCode, createTokenErr.ExternalErr, createTokenErr.InternalErr)
+		}
+		return response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+	}
+
 	if redirectTo := c.GetCookie("redirect_to"); len(redirectTo) > 0 {
 		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
 			result["redirectUrl"] = redirectTo
@@ -248,8 +277,7 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext, cmd dtos.LoginCommand) res
 	}
 
 	metrics.MApiLoginPost.Inc()
-	resp = response.JSON(http.StatusOK, result)
-	return resp
+	return response.JSON(http.StatusOK, result)
 }
 
 func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext) error {
@@ -257,8 +285,8 @@ func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext)
 		return errors.New("could not login user")
 	}
 
-	addr := c.RemoteAddr()
-	ip, err := network.GetIPFromAddress(addr)
+	addr := c.Req.RemoteAddr
+	ip, err := network.ClientIP(addr, c.Req.Header, hs.Cfg.LoginLockout.TrustedProxies)
 	if err != nil {
 		hs.log.Debug("Failed to get IP from client address", "addr", addr)
 		ip = nil
@@ -266,6 +294,9 @@ func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext)
 
 	hs.log.Debug("Got IP address from client address", "addr", addr, "ip", ip)
 	ctx := context.WithValue(c.Req.Context(), models.RequestURIKey{}, c.Req.RequestURI)
+	if hs.Cfg.LoginLockout.NotifyNewLogin {
+		hs.notifyNewLogin(ctx, user, ip, c.Req.UserAgent())
+	}
 	userToken, err := hs.AuthTokenService.CreateToken(ctx, user, ip, c.Req.UserAgent())
 	if err != nil {
 		return errutil.Wrap("failed to create auth token", err)
@@ -277,6 +308,34 @@ func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext)
 	return nil
 }
 
+// notifyNewLogin emails the user when none of their sessions comes from the IP address and user agent of the login.
+// Users without sessions, as at their first login, aren't notified.
+func (hs *HTTPServer) notifyNewLogin(ctx context.Context, user *models.User, ip net.IP, userAgent string) {
+	tokens, err := hs.AuthTokenService.GetUserTokens(ctx, user.Id)
+	if err != nil {
+		hs.log.Error("Failed to get the sessions of the user", "userId", user.Id, "error", err)
+		return
+	}
+	if len(tokens) == 0 {
+		return
+	}
+
+	ipAddress := ""
+	if len(ip) > 0 {
+		ipAddress = ip.String()
+	}
+	for _, token := range tokens {
+		if token.ClientIp == ipAddress && token.UserAgent == userAgent {
+			return
+		}
+	}
+
+	cmd := models.SendNewLoginEmailCommand{User: user, IpAddress: ipAddress, UserAgent: userAgent}
+	if err := bus.Dispatch(&cmd); err != nil {
+		hs.log.Error("Failed to notify the user of a new login", "userId", user.Id, "error", err)
+	}
+}
+
 func (hs *HTTPServer) Logout(c *models.ReqContext) {
 	if hs.Cfg.SAMLEnabled && hs.Cfg.SAMLSingleLogoutEnabled && hs.License.HasValidLicense() {
 		c.Redirect(hs.Cfg.AppSubURL + "/logout/saml")
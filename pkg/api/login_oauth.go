@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"golang.org/x/oauth2"
 
@@ -173,7 +174,16 @@ func (hs *HTTPServer) OAuthLogin(ctx *models.ReqContext) {
 		return
 	}
 
-	loginInfo.ExternalUser = *buildExternalUserInfo(token, userInfo, name)
+	extUser, err := buildExternalUserInfo(token, userInfo, name)
+	if err != nil {
+		hs.handleOAuthLoginError(ctx, loginInfo, LoginError{
+			HttpStatus:    http.StatusInternalServerError,
+			PublicMessage: "Failed to map the organization roles of the user",
+			Err:           err,
+		})
+		return
+	}
+	loginInfo.ExternalUser = *extUser
 	loginInfo.User, err = syncUser(ctx, &loginInfo.ExternalUser, connect)
 	if err != nil {
 		hs.handleOAuthLoginErrorWithRedirect(ctx, loginInfo, err)
@@ -203,7 +213,7 @@ func (hs *HTTPServer) OAuthLogin(ctx *models.ReqContext) {
 }
 
 // buildExternalUserInfo returns a ExternalUserInfo struct from OAuth user profile
-func buildExternalUserInfo(token *oauth2.Token, userInfo *social.BasicUserInfo, name string) *models.ExternalUserInfo {
+func buildExternalUserInfo(token *oauth2.Token, userInfo *social.BasicUserInfo, name string) (*models.ExternalUserInfo, error) {
 	oauthLogger.Debug("Building external user info from OAuth user info")
 
 	extUser := &models.ExternalUserInfo{
@@ -215,6 +225,8 @@ func buildExternalUserInfo(token *oauth2.Token, userInfo *social.BasicUserInfo,
 		Email:      userInfo.Email,
 		OrgRoles:   map[int64]models.RoleType{},
 		Groups:     userInfo.Groups,
+
+		IsGrafanaAdmin: userInfo.IsGrafanaAdmin,
 	}
 
 	if userInfo.Role != "" {
@@ -235,7 +247,24 @@ func buildExternalUserInfo(token *oauth2.Token, userInfo *social.BasicUserInfo,
 		}
 	}
 
-	return extUser
+	// The roles of the organizations mapped by the provider take precedence
+	for org, role := range userInfo.OrgRoles {
+		orgID, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			query := &models.GetOrgByNameQuery{Name: org}
+			if err := bus.Dispatch(query); err != nil {
+				if errors.Is(err, models.ErrOrgNotFound) {
+					oauthLogger.Warn("Ignoring role of unknown organization", "org", org)
+					continue
+				}
+				return nil, err
+			}
+			orgID = query.Result.Id
+		}
+		extUser.OrgRoles[orgID] = models.RoleType(role)
+	}
+
+	return extUser, nil
 }
 
 // syncUser syncs a Grafana user profile with the corresponding OAuth profile.
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestBuildExternalUserInfo(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.GetOrgByNameQuery) error {
+		if query.Name != "Ops" {
+			return models.ErrOrgNotFound
+		}
+		query.Result = &models.Org{Id: 2, Name: query.Name}
+		return nil
+	})
+
+	isGrafanaAdmin := true
+	userInfo := &social.BasicUserInfo{
+		Id:             "42",
+		Login:          "john",
+		Email:          "john.doe@example.com",
+		Role:           "Viewer",
+		OrgRoles:       map[string]string{"1": "Admin", "Ops": "Editor", "Unknown": "Viewer", "3": "Viewer"},
+		IsGrafanaAdmin: &isGrafanaAdmin,
+	}
+
+	extUser, err := buildExternalUserInfo(&oauth2.Token{}, userInfo, "generic_oauth")
+	require.NoError(t, err)
+
+	assert.Equal(t, "oauth_generic_oauth", extUser.AuthModule)
+	assert.Equal(t, map[int64]models.RoleType{
+		1: models.ROLE_ADMIN,
+		2: models.ROLE_EDITOR,
+		3: models.ROLE_VIEWER,
+	}, extUser.OrgRoles)
+	require.NotNil(t, extUser.IsGrafanaAdmin)
+	assert.True(t, *extUser.IsGrafanaAdmin)
+}
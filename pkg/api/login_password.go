@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	passwordChangeLoginKeyPrefix = "password-change-login:%x"
+	// passwordChangeLoginTTL is how long users have to send a new password after their expired one
+	passwordChangeLoginTTL = 10 * time.Minute
+)
+
+func init() {
+	remotecache.Register(&passwordChangeLoginChallenge{})
+}
+
+// passwordChangeLoginChallenge is stored in the remote cache between the expired password and the new
+// password of a login.
+type passwordChangeLoginChallenge struct {
+	UserId    int64
+	ExpiresAt time.Time
+}
+
+func passwordChangeLoginKey(token string) string {
+	return fmt.Sprintf(passwordChangeLoginKeyPrefix, sha256.Sum256([]byte(token)))
+}
+
+// passwordExpiredLoginResponse returns the response asking for a new password when the password of the
+// user expired, nil when the user can be logged in with it. It is only asked once the user passed two-factor
+// authentication, since the token lets the user log in with the new password. The response includes the result
+// of the login so far.
+func (hs *HTTPServer) passwordExpiredLoginResponse(user *models.User, result map[string]interface{}) (*response.NormalResponse, error) {
+	expired, err := hs.PasswordPolicyService.IsExpired(user)
+	if err != nil || !expired {
+		return nil, err
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &passwordChangeLoginChallenge{UserId: user.Id, ExpiresAt: time.Now().Add(passwordChangeLoginTTL)}
+	if err := hs.RemoteCacheService.Set(passwordChangeLoginKey(token), challenge, passwordChangeLoginTTL); err != nil {
+		return nil, err
+	}
+
+	result["message"] = "Password expired"
+	result["passwordExpired"] = true
+	result["passwordChangeToken"] = token
+	return response.JSON(http.StatusOK, result), nil
+}
+
+func (hs *HTTPServer) getPasswordChangeLoginChallenge(token string) (*passwordChangeLoginChallenge, error) {
+	value, err := hs.RemoteCacheService.Get(passwordChangeLoginKey(token))
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, ok := value.(*passwordChangeLoginChallenge)
+	if !ok || time.Now().After(challenge.ExpiresAt) {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+
+	return challenge, nil
+}
+
+// POST /login/password
+func (hs *HTTPServer) LoginPasswordChange(c *models.ReqContext, cmd dtos.LoginPasswordChangeCommand) response.Response {
+	var user *models.User
+	var resp *response.NormalResponse
+
+	defer func() {
+		err := resp.Err()
+		if err == nil && resp.ErrMessage() != "" {
+			err = errors.New(resp.ErrMessage())
+		}
+		hs.HooksService.RunLoginHook(&models.LoginInfo{
+			AuthModule: "grafana",
+			User:       user,
+			HTTPStatus: resp.Status(),
+			Error:      err,
+		}, c)
+	}()
+
+	if !hs.Cfg.PasswordPolicy.Enabled {
+		resp = response.Error(http.StatusNotFound, "Password policy is disabled", nil)
+		return resp
+	}
+
+	challenge, err := hs.getPasswordChangeLoginChallenge(cmd.Token)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			resp = response.Error(http.StatusUnauthorized, "Password change expired, log in again", nil)
+		} else {
+			resp = response.Error(http.StatusInternalServerError, "Failed to get password change", err)
+		}
+		return resp
+	}
+
+	user, err = getUserWithSalt(challenge.UserId)
+	if err != nil {
+		resp = response.Error(http.StatusInternalServerError, "Failed to get user", err)
+		return resp
+	}
+	if user.IsDisabled {
+		resp = response.Error(http.StatusUnauthorized, "Invalid username or password", nil)
+		return resp
+	}
+
+	if cmd.NewPassword != cmd.ConfirmPassword {
+		resp = response.Error(http.StatusBadRequest, "Passwords do not match", nil)
+		return resp
+	}
+	if validationResp := hs.validatePassword(cmd.NewPassword, user); validationResp != nil {
+		resp = validationResp
+		return resp
+	}
+
+	passwordHashed, err := util.EncodePassword(cmd.NewPassword, user.Salt)
+	if err != nil {
+		resp = response.Error(http.StatusInternalServerError, "Failed to encode password", err)
+		return resp
+	}
+	if err := bus.Dispatch(&models.ChangeUserPasswordCommand{UserId: user.Id, NewPassword: passwordHashed}); err != nil {
+		resp = response.Error(http.StatusInternalServerError, "Failed to change user password", err)
+		return resp
+	}
+
+	if err := hs.RemoteCacheService.Delete(passwordChangeLoginKey(cmd.Token)); err != nil {
+		hs.log.Error("Failed to delete password change login challenge", "error", err)
+	}
+
+	resp = hs.loginResponse(c, user, map[string]interface{}{
+		"message": "Password changed, logged in",
+	})
+	return resp
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/totp"
+	"github.com/grafana/grafana/pkg/infra/encryption"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginPasswordChange(t *testing.T) {
+	user := &models.User{Id: 42, Login: "admin", Salt: "salt", Created: time.Now().Add(-48 * time.Hour)}
+	encoded, err := util.EncodePassword("expired-password", user.Salt)
+	require.NoError(t, err)
+	user.Password = encoded
+
+	cfg := setting.NewCfg()
+	cfg.LoginCookieName = "grafana_session"
+	cfg.PasswordPolicy = setting.PasswordPolicySettings{Enabled: true, MinLength: 12, HistoryCount: 1, MaxAge: 24 * time.Hour}
+	policy := &passwordpolicy.PasswordPolicyService{Cfg: cfg}
+	hs := &HTTPServer{
+		log:                   log.New("test"),
+		Cfg:                   cfg,
+		License:               &licensing.OSSLicensingService{},
+		AuthTokenService:      auth.NewFakeUserAuthTokenService(),
+		HooksService:          &hooks.HooksService{},
+		RemoteCacheService:    remotecache.NewFakeStore(t),
+		PasswordPolicyService: policy,
+	}
+
+	loginSc := setupScenarioContext(t, "/login")
+	loginSc.m.Post(loginSc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginPost(c, dtos.LoginCommand{User: "admin", Password: "expired-password"})
+	}))
+
+	var cmd dtos.LoginPasswordChangeCommand
+	changeSc := setupScenarioContext(t, "/login/password")
+	changeSc.m.Post(changeSc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginPasswordChange(c, cmd)
+	}))
+	changePassword := func(c dtos.LoginPasswordChangeCommand) int {
+		cmd = c
+		changeSc.fakeReqNoAssertions("POST", changeSc.url).exec()
+		return changeSc.resp.Code
+	}
+
+	totpSc := setupScenarioContext(t, "/login/totp")
+	var totpCmd dtos.LoginTotpCommand
+	totpSc.m.Post(totpSc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginTotp(c, totpCmd)
+	}))
+
+	// the data keys are created after the test database is reset
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	encryptedSecret, err := encryption.Encrypt([]byte(secret))
+	require.NoError(t, err)
+
+	// the scenarios and the remote cache register the SQL handlers, which are replaced by fakes
+	var userTotp *models.UserTotp
+	history := []*models.UserPasswordHistory{{UserId: user.Id, Password: user.Password, Salt: user.Salt, Created: user.Created}}
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.LoginUserQuery) error {
+		query.User = user
+		query.AuthModule = "grafana"
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+		query.Result = user
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserPasswordHistoryQuery) error {
+		query.Result = history
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.ChangeUserPasswordCommand) error {
+		history = []*models.UserPasswordHistory{{UserId: cmd.UserId, Password: cmd.NewPassword, Salt: user.Salt, Created: time.Now()}}
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.SeedUserPasswordHistoryCommand) error {
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserTotpQuery) error {
+		if userTotp == nil {
+			return models.ErrUserTotpNotFound
+		}
+		query.Result = userTotp
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.IsTotpRequiredForUserQuery) error {
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UseUserTotpStepCommand) error {
+		return nil
+	})
+	require.NoError(t, policy.Init())
+
+	var token string
+	t.Run("Users with an expired password must change it", func(t *testing.T) {
+		loginSc.fakeReqNoAssertions("POST", loginSc.url).exec()
+		require.Equal(t, http.StatusOK, loginSc.resp.Code)
+		assert.Empty(t, loginSc.resp.Header().Get("Set-Cookie"), "no session before the change")
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(loginSc.resp.Body.Bytes(), &result))
+		assert.Equal(t, true, result["passwordExpired"])
+		require.NotEmpty(t, result["passwordChangeToken"])
+		token = result["passwordChangeToken"].(string)
+	})
+
+	t.Run("New passwords must comply with the policy", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: token, NewPassword: "short", ConfirmPassword: "short",
+		}))
+		assert.Equal(t, http.StatusBadRequest, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: token, NewPassword: "expired-password", ConfirmPassword: "expired-password",
+		}))
+		assert.Equal(t, http.StatusBadRequest, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: token, NewPassword: "new-long-password", ConfirmPassword: "other-long-password",
+		}))
+	})
+
+	t.Run("A valid new password logs the user in once", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: token, NewPassword: "new-long-password", ConfirmPassword: "new-long-password",
+		}))
+		assert.NotEmpty(t, changeSc.resp.Header().Get("Set-Cookie"))
+		require.Len(t, history, 1)
+
+		assert.Equal(t, http.StatusUnauthorized, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: token, NewPassword: "other-long-password", ConfirmPassword: "other-long-password",
+		}))
+	})
+
+	t.Run("Users whose password was changed recently are logged in", func(t *testing.T) {
+		loginSc.fakeReqNoAssertions("POST", loginSc.url).exec()
+		require.Equal(t, http.StatusOK, loginSc.resp.Code)
+		assert.NotEmpty(t, loginSc.resp.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("Users with two-factor authentication change their expired password after the code", func(t *testing.T) {
+		history[0].Created = time.Now().Add(-48 * time.Hour)
+		cfg.Totp.Enabled = true
+		cfg.DisableBruteForceLoginProtection = true
+		userTotp = &models.UserTotp{UserId: user.Id, Secret: encryptedSecret, Enabled: true}
+		t.Cleanup(func() {
+			cfg.Totp.Enabled = false
+			cfg.DisableBruteForceLoginProtection = false
+			userTotp = nil
+		})
+
+		loginSc.fakeReqNoAssertions("POST", loginSc.url).exec()
+		require.Equal(t, http.StatusOK, loginSc.resp.Code)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(loginSc.resp.Body.Bytes(), &result))
+		assert.Nil(t, result["passwordChangeToken"], "no password change before the code")
+		require.NotEmpty(t, result["totpToken"])
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		totpCmd = dtos.LoginTotpCommand{Token: result["totpToken"].(string), Code: code}
+		totpSc.fakeReqNoAssertions("POST", totpSc.url).exec()
+		require.Equal(t, http.StatusOK, totpSc.resp.Code)
+		assert.Empty(t, totpSc.resp.Header().Get("Set-Cookie"), "no session before the change")
+		result = nil
+		require.NoError(t, json.Unmarshal(totpSc.resp.Body.Bytes(), &result))
+		assert.Equal(t, true, result["passwordExpired"])
+		require.NotEmpty(t, result["passwordChangeToken"])
+
+		require.Equal(t, http.StatusOK, changePassword(dtos.LoginPasswordChangeCommand{
+			Token: result["passwordChangeToken"].(string), NewPassword: "newer-long-password", ConfirmPassword: "newer-long-password",
+		}))
+		assert.NotEmpty(t, changeSc.resp.Header().Get("Set-Cookie"))
+	})
+}
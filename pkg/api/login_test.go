@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/auth"
@@ -707,3 +709,89 @@ func TestLoginPostRunLokingHook(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginPostNotifiesOfNewLogin(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	sc := setupScenarioContext(t, "/login")
+	tokenService := auth.NewFakeUserAuthTokenService()
+	hs := &HTTPServer{
+		log:              log.New("test"),
+		Cfg:              setting.NewCfg(),
+		License:          &licensing.OSSLicensingService{},
+		AuthTokenService: tokenService,
+		HooksService:     &hooks.HooksService{},
+	}
+	hs.Cfg.LoginLockout.NotifyNewLogin = true
+	trustedProxies, err := network.ParseCIDRs([]string{"10.1.0.0/16"})
+	require.NoError(t, err)
+	hs.Cfg.LoginLockout.TrustedProxies = trustedProxies
+
+	sc.defaultHandler = routing.Wrap(func(w http.ResponseWriter, c *models.ReqContext) response.Response {
+		return hs.LoginPost(c, dtos.LoginCommand{User: "admin", Password: "admin"})
+	})
+	sc.m.Post(sc.url, sc.defaultHandler)
+
+	testUser := &models.User{Id: 42, Email: "admin@example.com"}
+	clientIP := "192.168.1.1"
+	bus.AddHandler("grafana-auth", func(query *models.LoginUserQuery) error {
+		assert.Equal(t, clientIP, query.IpAddress)
+		query.User = testUser
+		return nil
+	})
+
+	var sentCmd *models.SendNewLoginEmailCommand
+	bus.AddHandler("test", func(cmd *models.SendNewLoginEmailCommand) error {
+		sentCmd = cmd
+		return nil
+	})
+
+	proxyAddr := "10.1.2.3:51234"
+	postLogin := func(t *testing.T, tokens ...*models.UserToken) {
+		t.Helper()
+
+		sentCmd = nil
+		tokenService.GetUserTokensProvider = func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
+			require.Equal(t, testUser.Id, userId)
+			return tokens, nil
+		}
+
+		sc.fakeReqNoAssertions("POST", sc.url)
+		sc.req.RemoteAddr = proxyAddr
+		sc.req.Header.Set("X-Real-IP", "192.168.1.1")
+		sc.req.Header.Set("User-Agent", "Mozilla/5.0")
+		sc.exec()
+		require.Equal(t, 200, sc.resp.Code)
+	}
+
+	t.Run("Users aren't notified of their first login", func(t *testing.T) {
+		postLogin(t)
+		assert.Nil(t, sentCmd)
+	})
+
+	t.Run("Users aren't notified of logins from the IP address and user agent of a session", func(t *testing.T) {
+		postLogin(t,
+			&models.UserToken{ClientIp: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+			&models.UserToken{ClientIp: "192.168.1.1", UserAgent: "Mozilla/5.0"},
+		)
+		assert.Nil(t, sentCmd)
+	})
+
+	t.Run("Users are notified of logins from a new IP address or user agent", func(t *testing.T) {
+		postLogin(t,
+			&models.UserToken{ClientIp: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+			&models.UserToken{ClientIp: "192.168.1.1", UserAgent: "curl/7.64.1"},
+		)
+		assert.Equal(t, &models.SendNewLoginEmailCommand{
+			User:      testUser,
+			IpAddress: "192.168.1.1",
+			UserAgent: "Mozilla/5.0",
+		}, sentCmd)
+	})
+	t.Run("The forwarding headers of untrusted proxies are ignored", func(t *testing.T) {
+		proxyAddr, clientIP = "203.0.113.7:51234", "203.0.113.7"
+		postLogin(t, &models.UserToken{ClientIp: "192.168.1.1", UserAgent: "Mozilla/5.0"})
+		require.NotNil(t, sentCmd)
+		assert.Equal(t, "203.0.113.7", sentCmd.IpAddress)
+	})
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	totpLoginKeyPrefix = "totp-login:%x"
+	// totpLoginTTL is how long users have to send a code after their password
+	totpLoginTTL = 5 * time.Minute
+	// totpLoginMaxAttempts is the number of invalid codes after which users must send their password again
+	totpLoginMaxAttempts = 5
+)
+
+func init() {
+	remotecache.Register(&totpLoginChallenge{})
+}
+
+// totpLoginChallenge is stored in the remote cache between the password and the code of a login.
+type totpLoginChallenge struct {
+	UserId    int64
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+func totpLoginKey(token string) string {
+	return fmt.Sprintf(totpLoginKeyPrefix, sha256.Sum256([]byte(token)))
+}
+
+// totpLoginResponse returns the response asking for a code when the user must use two-factor
+// authentication, nil when the user can be logged in with the password only.
+func (hs *HTTPServer) totpLoginResponse(user *models.User) (*response.NormalResponse, error) {
+	enabled := false
+	query := models.GetUserTotpQuery{UserId: user.Id}
+	if err := bus.Dispatch(&query); err == nil {
+		enabled = query.Result.Enabled
+	} else if !errors.Is(err, models.ErrUserTotpNotFound) {
+		return nil, err
+	}
+
+	requiredQuery := models.IsTotpRequiredForUserQuery{UserId: user.Id}
+	if err := bus.Dispatch(&requiredQuery); err != nil {
+		return nil, err
+	}
+
+	if !enabled && !requiredQuery.Result {
+		return nil, nil
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &totpLoginChallenge{UserId: user.Id, ExpiresAt: time.Now().Add(totpLoginTTL)}
+	if err := hs.RemoteCacheService.Set(totpLoginKey(token), challenge, totpLoginTTL); err != nil {
+		return nil, err
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message":                "Two-factor authentication required",
+		"totpRequired":           enabled,
+		"totpEnrollmentRequired": !enabled,
+		"totpToken":              token,
+	}), nil
+}
+
+func (hs *HTTPServer) getTotpLoginChallenge(token string) (*totpLoginChallenge, error) {
+	if token == "" {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+
+	value, err := hs.RemoteCacheService.Get(totpLoginKey(token))
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, ok := value.(*totpLoginChallenge)
+	if !ok || time.Now().After(challenge.ExpiresAt) {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+
+	return challenge, nil
+}
+
+// failTotpLoginChallenge counts an invalid code, and forgets the challenge after too many of them.
+func (hs *HTTPServer) failTotpLoginChallenge(token string, challenge *totpLoginChallenge) *response.NormalResponse {
+	challenge.Attempts++
+	if challenge.Attempts >= totpLoginMaxAttempts {
+		if err := hs.RemoteCacheService.Delete(totpLoginKey(token)); err != nil {
+			hs.log.Error("Failed to delete two-factor login challenge", "error", err)
+		}
+		return response.Error(http.StatusUnauthorized, "Too many invalid codes, log in again", nil)
+	}
+
+	if err := hs.RemoteCacheService.Set(totpLoginKey(token), challenge, time.Until(challenge.ExpiresAt)); err != nil {
+		hs.log.Error("Failed to update two-factor login challenge", "error", err)
+	}
+	return response.Error(http.StatusUnauthorized, models.ErrUserTotpCodeInvalid.Error(), nil)
+}
+
+func (hs *HTTPServer) releaseLoginAttempt(id int64) {
+	if err := login.ReleaseLoginAttempt(id); err != nil {
+		hs.log.Error("Failed to delete login attempt", "error", err)
+	}
+}
+
+// POST /login/totp
+func (hs *HTTPServer) LoginTotp(c *models.ReqContext, cmd dtos.LoginTotpCommand) response.Response {
+	var user *models.User
+	var resp *response.NormalResponse
+
+	defer func() {
+		err := resp.Err()
+		if err == nil && resp.ErrMessage() != "" {
+			err = errors.New(resp.ErrMessage())
+		}
+		hs.HooksService.RunLoginHook(&models.LoginInfo{
+			AuthModule: "grafana",
+			User:       user,
+			HTTPStatus: resp.Status(),
+			Error:      err,
+		}, c)
+	}()
+
+	if !hs.Cfg.Totp.Enabled {
+		resp = response.Error(http.StatusNotFound, "Two-factor authentication is disabled", nil)
+		return resp
+	}
+
+	challenge, err := hs.getTotpLoginChallenge(cmd.Token)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			resp = response.Error(http.StatusUnauthorized, "Two-factor authentication expired, log in again", nil)
+		} else {
+			resp = response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication", err)
+		}
+		return resp
+	}
+
+	user, err = getUserWithSalt(challenge.UserId)
+	if err != nil {
+		resp = response.Error(http.StatusInternalServerError, "Failed to get user", err)
+		return resp
+	}
+	if user.IsDisabled {
+		resp = response.Error(http.StatusUnauthorized, "Invalid username or password", nil)
+		return resp
+	}
+
+	query := models.GetUserTotpQuery{UserId: user.Id}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrUserTotpNotFound) {
+			resp = response.Error(http.StatusBadRequest, "Two-factor authentication enrollment not started", nil)
+		} else {
+			resp = response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication", err)
+		}
+		return resp
+	}
+
+	// the invalid codes are counted as failed logins of the user, so that the lockout applies to them too
+	attemptQuery := &models.LoginUserQuery{
+		ReqContext: c,
+		Username:   user.Login,
+		IpAddress:  c.ClientIPAddress(hs.Cfg.LoginLockout.TrustedProxies),
+		Cfg:        hs.Cfg,
+	}
+	attemptID, err := login.ReserveLoginAttempt(attemptQuery)
+	if err != nil {
+		if errors.Is(err, login.ErrTooManyLoginAttempts) {
+			resp = response.Error(http.StatusUnauthorized, "Too many invalid codes, try again later", err)
+		} else {
+			resp = response.Error(http.StatusInternalServerError, "Failed to check login attempts", err)
+		}
+		return resp
+	}
+
+	result := map[string]interface{}{
+		"message": "Logged in",
+	}
+
+	switch {
+	case !query.Result.Enabled:
+		var codes []string
+		codes, err = enableTotp(query.Result, user.Id, cmd.Code)
+		result["recoveryCodes"] = codes
+	case cmd.RecoveryCode != "":
+		err = useTotpRecoveryCode(query.Result, user, cmd.RecoveryCode)
+	default:
+		err = validateTotpCode(query.Result, cmd.Code)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrUserTotpCodeInvalid) || errors.Is(err, models.ErrUserTotpCodeUsed) {
+			if err := login.FailLoginAttempt(attemptQuery); err != nil {
+				hs.log.Error("Failed to save invalid two-factor authentication code", "error", err)
+			}
+			resp = hs.failTotpLoginChallenge(cmd.Token, challenge)
+		} else {
+			hs.releaseLoginAttempt(attemptID)
+			resp = response.Error(http.StatusInternalServerError, "Failed to validate two-factor authentication code", err)
+		}
+		return resp
+	}
+	hs.releaseLoginAttempt(attemptID)
+
+	if err := hs.RemoteCacheService.Delete(totpLoginKey(cmd.Token)); err != nil {
+		hs.log.Error("Failed to delete two-factor login challenge", "error", err)
+	}
+
+	if hs.Cfg.PasswordPolicy.Enabled {
+		passwordResp, err := hs.passwordExpiredLoginResponse(user, result)
+		if err != nil {
+			resp = response.Error(http.StatusInternalServerError, "Error while checking password expiration", err)
+			return resp
+		}
+		if passwordResp != nil {
+			resp = passwordResp
+			return resp
+		}
+	}
+
+	resp = hs.loginResponse(c, user, result)
+	return resp
+}
+
+// POST /login/totp/enroll
+func (hs *HTTPServer) LoginTotpEnroll(c *models.ReqContext, cmd dtos.LoginTotpEnrollCommand) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	challenge, err := hs.getTotpLoginChallenge(cmd.Token)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return response.Error(http.StatusUnauthorized, "Two-factor authentication expired, log in again", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication", err)
+	}
+
+	query := models.GetUserTotpQuery{UserId: challenge.UserId}
+	if err := bus.Dispatch(&query); err == nil && query.Result.Enabled {
+		return response.Error(http.StatusConflict, "Two-factor authentication already enabled", nil)
+	} else if err != nil && !errors.Is(err, models.ErrUserTotpNotFound) {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor authentication", err)
+	}
+
+	user, err := getUserWithSalt(challenge.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	return hs.enrollTotp(user.Id, user.Login)
+}
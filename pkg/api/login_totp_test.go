@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/totp"
+	"github.com/grafana/grafana/pkg/infra/encryption"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginTotp(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	user := &models.User{Id: 42, Login: "admin", Salt: "salt"}
+	recoveryHash, err := util.EncodePassword("recoverycode", user.Salt)
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.Totp.Enabled = true
+	cfg.LoginLockout.MaxUserAttempts = 5
+	cfg.LoginLockout.Window = 5 * time.Minute
+	cfg.LoginCookieName = "grafana_session"
+	hs := &HTTPServer{
+		log:                log.New("test"),
+		Cfg:                cfg,
+		License:            &licensing.OSSLicensingService{},
+		AuthTokenService:   auth.NewFakeUserAuthTokenService(),
+		HooksService:       &hooks.HooksService{},
+		RemoteCacheService: remotecache.NewFakeStore(t),
+	}
+
+	loginSc := setupScenarioContext(t, "/login")
+	loginSc.m.Post(loginSc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginPost(c, dtos.LoginCommand{User: "admin", Password: "admin"})
+	}))
+
+	// login returns the token to send with the code
+	login := func(t *testing.T) string {
+		loginSc.fakeReqNoAssertions("POST", loginSc.url).exec()
+		require.Equal(t, http.StatusOK, loginSc.resp.Code)
+		assert.Empty(t, loginSc.resp.Header().Get("Set-Cookie"), "no session before the code")
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(loginSc.resp.Body.Bytes(), &result))
+		assert.Equal(t, true, result["totpRequired"])
+		require.NotEmpty(t, result["totpToken"])
+		return result["totpToken"].(string)
+	}
+
+	var cmd dtos.LoginTotpCommand
+	totpSc := setupScenarioContext(t, "/login/totp")
+	totpSc.m.Post(totpSc.url, routing.Wrap(func(c *models.ReqContext) response.Response {
+		return hs.LoginTotp(c, cmd)
+	}))
+	sendCode := func(c dtos.LoginTotpCommand) int {
+		cmd = c
+		totpSc.fakeReqNoAssertions("POST", totpSc.url).exec()
+		return totpSc.resp.Code
+	}
+
+	// the data keys are created after the test database is reset
+	encrypted, err := encryption.Encrypt([]byte(secret))
+	require.NoError(t, err)
+
+	// the scenarios and the remote cache register the SQL handlers, which are replaced by fakes
+	var userTotp *models.UserTotp
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.LoginUserQuery) error {
+		query.User = user
+		query.AuthModule = "grafana"
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserByIdQuery) error {
+		query.Result = user
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserTotpQuery) error {
+		query.Result = userTotp
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.IsTotpRequiredForUserQuery) error {
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UseUserTotpStepCommand) error {
+		if cmd.Step <= userTotp.LastUsedStep {
+			return models.ErrUserTotpCodeUsed
+		}
+		userTotp.LastUsedStep = cmd.Step
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UpdateUserTotpRecoveryCodesCommand) error {
+		if cmd.PreviousRecoveryCodes != userTotp.RecoveryCodes {
+			return models.ErrUserTotpCodeUsed
+		}
+		userTotp.RecoveryCodes = cmd.RecoveryCodes
+		return nil
+	})
+
+	var loginAttempts map[int64]string
+	var lastLoginAttemptID int64
+	bus.AddHandler("test", func(cmd *models.CreateLoginAttemptCommand) error {
+		lastLoginAttemptID++
+		loginAttempts[lastLoginAttemptID] = cmd.Username
+		cmd.Result = models.LoginAttempt{Id: lastLoginAttemptID, Username: cmd.Username}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetUserLoginAttemptCountQuery) error {
+		query.Result = 0
+		for _, username := range loginAttempts {
+			if username == query.Username {
+				query.Result++
+			}
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.DeleteLoginAttemptsCommand) error {
+		delete(loginAttempts, cmd.Id)
+		return nil
+	})
+
+	reset := func() {
+		userTotp = &models.UserTotp{UserId: user.Id, Secret: encrypted, Enabled: true, RecoveryCodes: `["` + recoveryHash + `"]`}
+		loginAttempts = map[int64]string{}
+	}
+
+	t.Run("Users without two-factor authentication are logged in with their password", func(t *testing.T) {
+		reset()
+		userTotp = &models.UserTotp{UserId: user.Id, Secret: encrypted}
+		loginSc.fakeReqNoAssertions("POST", loginSc.url).exec()
+		require.Equal(t, http.StatusOK, loginSc.resp.Code)
+		assert.NotEmpty(t, loginSc.resp.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("A valid code logs the user in once", func(t *testing.T) {
+		reset()
+		token := login(t)
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, sendCode(dtos.LoginTotpCommand{Token: token, Code: code}))
+		assert.NotEmpty(t, totpSc.resp.Header().Get("Set-Cookie"))
+		assert.Empty(t, loginAttempts, "valid codes aren't failed logins")
+
+		assert.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: token, Code: code}))
+	})
+
+	t.Run("Codes can't be replayed", func(t *testing.T) {
+		reset()
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, sendCode(dtos.LoginTotpCommand{Token: login(t), Code: code}))
+		assert.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: login(t), Code: code}))
+	})
+
+	t.Run("Recovery codes can be used once", func(t *testing.T) {
+		reset()
+		require.Equal(t, http.StatusOK, sendCode(dtos.LoginTotpCommand{Token: login(t), RecoveryCode: "recoverycode"}))
+		assert.Equal(t, "[]", userTotp.RecoveryCodes)
+
+		assert.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: login(t), RecoveryCode: "recoverycode"}))
+	})
+
+	t.Run("Too many invalid codes cancel the login", func(t *testing.T) {
+		reset()
+		token := login(t)
+		for i := 0; i < totpLoginMaxAttempts; i++ {
+			require.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: token, Code: "000000"}))
+		}
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: token, Code: code}))
+	})
+
+	t.Run("Invalid codes lock the user out across logins", func(t *testing.T) {
+		reset()
+		for i := int64(0); i < cfg.LoginLockout.MaxUserAttempts; i++ {
+			require.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: login(t), Code: "000000"}))
+		}
+		assert.Len(t, loginAttempts, int(cfg.LoginLockout.MaxUserAttempts))
+
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, sendCode(dtos.LoginTotpCommand{Token: login(t), Code: code}))
+		assert.Len(t, loginAttempts, int(cfg.LoginLockout.MaxUserAttempts), "blocked attempts aren't saved")
+	})
+}
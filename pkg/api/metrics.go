@@ -77,6 +77,7 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDTO dtos.MetricReq
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Metric request error", err)
 	}
+	setQueryCacheHeaders(c, resp)
 
 	statusCode := http.StatusOK
 	for _, res := range resp.Results {
@@ -202,6 +203,7 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Metric request error", err)
 	}
+	setQueryCacheHeaders(c, resp)
 
 	statusCode := http.StatusOK
 	for _, res := range resp.Results {
@@ -215,6 +217,18 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 	return response.JSON(statusCode, &resp)
 }
 
+// setQueryCacheHeaders tells whether the results come from the query cache and, if so, when they were cached.
+func setQueryCacheHeaders(c *models.ReqContext, resp plugins.DataResponse) {
+	if resp.CacheStatus == "" {
+		return
+	}
+
+	c.Resp.Header().Set("X-Cache", resp.CacheStatus)
+	if resp.CacheStatus == plugins.DataCacheHit {
+		c.Resp.Header().Set("X-Cache-Cached-At", resp.CachedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
 // GET /api/tsdb/testdata/gensql
 func GenerateSQLTestData(c *models.ReqContext) response.Response {
 	if err := bus.Dispatch(&models.InsertSQLTestDataCommand{}); err != nil {
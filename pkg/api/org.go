@@ -43,6 +43,7 @@ func (hs *HTTPServer) GetOrgByName(c *models.ReqContext) response.Response {
 			State:    org.State,
 			Country:  org.Country,
 		},
+		RequireTotp: org.RequireTotp,
 	}
 
 	return response.JSON(200, &result)
@@ -71,6 +72,7 @@ func getOrgHelper(orgID int64) response.Response {
 			State:    org.State,
 			Country:  org.Country,
 		},
+		RequireTotp: org.RequireTotp,
 	}
 
 	return response.JSON(200, &result)
@@ -33,6 +33,10 @@ func AddOrgInvite(c *models.ReqContext, inviteDto dtos.AddInviteForm) response.R
 		return response.Error(400, "Invalid role specified", nil)
 	}
 
+	if !c.OrgRole.Includes(inviteDto.Role) {
+		return response.Error(403, "Cannot invite a user with a role higher than your own", nil)
+	}
+
 	// first try get existing user
 	userQuery := models.GetUserByLoginQuery{LoginOrEmail: inviteDto.LoginOrEmail}
 	if err := bus.Dispatch(&userQuery); err != nil {
@@ -178,6 +182,10 @@ func (hs *HTTPServer) CompleteInvite(c *models.ReqContext, completeInvite dtos.C
 		return response.Error(412, fmt.Sprintf("Invite cannot be used in status %s", invite.Status), nil)
 	}
 
+	if resp := hs.validatePassword(completeInvite.Password, nil); resp != nil {
+		return resp
+	}
+
 	cmd := models.CreateUserCommand{
 		Email:        completeInvite.Email,
 		Name:         completeInvite.Name,
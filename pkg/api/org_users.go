@@ -13,6 +13,9 @@ import (
 // POST /api/org/users
 func AddOrgUserToCurrentOrg(c *models.ReqContext, cmd models.AddOrgUserCommand) response.Response {
 	cmd.OrgId = c.OrgId
+	if !c.OrgRole.Includes(cmd.Role) {
+		return response.Error(403, "Cannot add a user with a role higher than your own", nil)
+	}
 	return addOrgUserHelper(cmd)
 }
 
@@ -161,6 +164,9 @@ func (hs *HTTPServer) getOrgUsersHelper(query *models.GetOrgUsersQuery, signedIn
 func UpdateOrgUserForCurrentOrg(c *models.ReqContext, cmd models.UpdateOrgUserCommand) response.Response {
 	cmd.OrgId = c.OrgId
 	cmd.UserId = c.ParamsInt64(":userId")
+	if !c.OrgRole.Includes(cmd.Role) {
+		return response.Error(403, "Cannot assign a role higher than your own", nil)
+	}
 	return updateOrgUserHelper(cmd)
 }
 
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -34,7 +35,7 @@ func SendResetPasswordEmail(c *models.ReqContext, form dtos.SendResetPasswordEma
 	return response.Success("Email sent")
 }
 
-func ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) response.Response {
+func (hs *HTTPServer) ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) response.Response {
 	query := models.ValidateResetPasswordCodeQuery{Code: form.Code}
 
 	if err := bus.Dispatch(&query); err != nil {
@@ -47,6 +48,9 @@ func ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) respon
 	if form.NewPassword != form.ConfirmPassword {
 		return response.Error(400, "Passwords do not match", nil)
 	}
+	if resp := hs.validatePassword(form.NewPassword, query.Result); resp != nil {
+		return resp
+	}
 
 	cmd := models.ChangeUserPasswordCommand{}
 	cmd.UserId = query.Result.Id
@@ -62,3 +66,22 @@ func ResetPassword(c *models.ReqContext, form dtos.ResetUserPasswordForm) respon
 
 	return response.Success("User password changed")
 }
+
+// validatePassword checks a new password against the password policy, and returns the response
+// refusing the password, or nil. The user is nil for the users being created.
+func (hs *HTTPServer) validatePassword(password string, user *models.User) *response.NormalResponse {
+	if !hs.Cfg.PasswordPolicy.Enabled {
+		return nil
+	}
+
+	err := hs.PasswordPolicyService.Validate(password, user)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr passwordpolicy.ValidationError
+	if errors.As(err, &validationErr) {
+		return response.Error(400, validationErr.Message, nil)
+	}
+	return response.Error(500, "Failed to validate password", err)
+}
@@ -20,7 +20,7 @@ func ApplyRoute(ctx context.Context, req *http.Request, proxyPath string, route
 
 	data := templateData{
 		JsonData:       ds.JsonData.Interface().(map[string]interface{}),
-		SecureJsonData: ds.SecureJsonData.Decrypt(),
+		SecureJsonData: ds.DecryptedValues(),
 	}
 
 	if len(route.URL) > 0 {
@@ -31,7 +31,7 @@ func NewApiPluginProxy(ctx *models.ReqContext, proxyPath string, route *plugins.
 
 		data := templateData{
 			JsonData:       query.Result.JsonData,
-			SecureJsonData: query.Result.SecureJsonData.Decrypt(),
+			SecureJsonData: query.Result.DecryptedValues(),
 		}
 
 		interpolatedURL, err := interpolateString(route.URL, data)
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// scimAuth authenticates the requests of the identity providers with the SCIM token.
+func (hs *HTTPServer) scimAuth(c *models.ReqContext) {
+	if !hs.Cfg.SCIM.Enabled {
+		writeSCIMError(c, scim.NewError(http.StatusNotFound, "", "SCIM is disabled"))
+		return
+	}
+
+	header := c.Req.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(hs.Cfg.SCIM.Token)) != 1 {
+		writeSCIMError(c, scim.NewError(http.StatusUnauthorized, "", "invalid SCIM token"))
+		return
+	}
+}
+
+func writeSCIMError(c *models.ReqContext, scimErr *scim.Error) {
+	body, err := json.Marshal(scimErr)
+	if err != nil {
+		c.Logger.Error("Failed to marshal SCIM error", "error", err)
+		c.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", scim.ContentType)
+	c.Resp.WriteHeader(scimErr.StatusCode())
+	if _, err := c.Resp.Write(body); err != nil {
+		c.Logger.Error("Failed to write SCIM error", "error", err)
+	}
+}
+
+func scimResponse(status int, body interface{}) response.Response {
+	return response.Respond(status, body).Header("Content-Type", scim.ContentType)
+}
+
+// scimErrorResponse returns the SCIM errors as they are, and the other errors as internal errors.
+func (hs *HTTPServer) scimErrorResponse(err error) response.Response {
+	var scimErr *scim.Error
+	if errors.As(err, &scimErr) {
+		return scimResponse(scimErr.StatusCode(), scimErr)
+	}
+
+	hs.log.Error("SCIM request failed", "error", err)
+	return scimResponse(http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", "internal server error"))
+}
+
+func decodeSCIMBody(c *models.ReqContext, v interface{}) error {
+	if err := json.NewDecoder(c.Req.Request.Body).Decode(v); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidSyntax, "invalid request body: %s", err)
+	}
+	return nil
+}
+
+// scimListParams returns the parsed filter, the start index and the count of a list request.
+func scimListParams(c *models.ReqContext) (scim.Filter, int, int, error) {
+	var filter scim.Filter
+	if f := c.Query("filter"); f != "" {
+		var err error
+		if filter, err = scim.ParseFilter(f); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	startIndex, count := 1, scim.MaxResults
+	if s := c.Query("startIndex"); s != "" {
+		var err error
+		if startIndex, err = strconv.Atoi(s); err != nil {
+			return nil, 0, 0, scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "invalid startIndex %q", s)
+		}
+	}
+	if s := c.Query("count"); s != "" {
+		var err error
+		if count, err = strconv.Atoi(s); err != nil {
+			return nil, 0, 0, scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "invalid count %q", s)
+		}
+	}
+	return filter, startIndex, count, nil
+}
+
+// scimListResponse filters the resources, and returns the requested page of the matching ones.
+func scimListResponse(resources []interface{}, filter scim.Filter, startIndex, count int) (*scim.ListResponse, error) {
+	matching := resources
+	if filter != nil {
+		matching = make([]interface{}, 0, len(resources))
+		for _, resource := range resources {
+			ok, err := scim.Matches(filter, resource)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matching = append(matching, resource)
+			}
+		}
+	}
+
+	from, to := scim.Page(len(matching), startIndex, count)
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	return &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(matching),
+		StartIndex:   startIndex,
+		ItemsPerPage: to - from,
+		Resources:    matching[from:to],
+	}, nil
+}
+
+// scimResourceID parses the ID of the resource of the request, the resources of unknown IDs are not found.
+func scimResourceID(c *models.ReqContext) (int64, error) {
+	id, err := strconv.ParseInt(c.Params(":id"), 10, 64)
+	if err != nil {
+		return 0, scim.NewError(http.StatusNotFound, "", "resource %s not found", c.Params(":id"))
+	}
+	return id, nil
+}
+
+func (hs *HTTPServer) scimLocation(resourceType string, id int64) string {
+	return strings.TrimSuffix(hs.Cfg.AppURL, "/") + "/api/scim/v2/" + resourceType + "/" + strconv.FormatInt(id, 10)
+}
+
+// decodeSCIMBool decodes a boolean value of a PATCH operation, some identity providers send them as strings.
+func decodeSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "invalid boolean %s", value)
+}
+
+func decodeSCIMValue(value json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "invalid value %s", value)
+	}
+	return nil
+}
+
+// scimPatchOperations checks the operations of a PATCH request, and calls apply with the lowercased operation,
+// the parsed path, and the value of every operation. The operations without path are split into operations of
+// the attributes of their value.
+func scimPatchOperations(req dtos.SCIMPatchRequest, apply func(op string, path *scim.Path, value json.RawMessage) error) error {
+	for _, operation := range req.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidSyntax, "invalid operation %q", operation.Op)
+		}
+
+		if operation.Path != "" {
+			path, err := scim.ParsePath(operation.Path)
+			if err != nil {
+				return err
+			}
+			if err := apply(op, path, operation.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if op == "remove" {
+			return scim.NewError(http.StatusBadRequest, scim.ScimTypeNoTarget, "remove operations require a path")
+		}
+		var attributes map[string]json.RawMessage
+		if err := decodeSCIMValue(operation.Value, &attributes); err != nil {
+			return err
+		}
+		for name, value := range attributes {
+			path, err := scim.ParsePath(name)
+			if err != nil {
+				return err
+			}
+			if err := apply(op, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GET /api/scim/v2/ServiceProviderConfig
+func (hs *HTTPServer) SCIMGetServiceProviderConfig(c *models.ReqContext) response.Response {
+	return scimResponse(http.StatusOK, util.DynMap{
+		"schemas":          []string{scim.SchemaServiceProviderConfig},
+		"documentationUri": "https://grafana.com/docs/grafana/latest/auth/scim/",
+		"patch":            util.DynMap{"supported": true},
+		"bulk":             util.DynMap{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           util.DynMap{"supported": true, "maxResults": scim.MaxResults},
+		"changePassword":   util.DynMap{"supported": false},
+		"sort":             util.DynMap{"supported": false},
+		"etag":             util.DynMap{"supported": false},
+		"authenticationSchemes": []util.DynMap{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the SCIM token of the Grafana configuration",
+			"primary":     true,
+		}},
+		"meta": dtos.SCIMMeta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     strings.TrimSuffix(hs.Cfg.AppURL, "/") + "/api/scim/v2/ServiceProviderConfig",
+		},
+	})
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+// scimGroup returns the SCIM resource of a team.
+func (hs *HTTPServer) scimGroup(team *models.TeamDTO, members []*models.TeamMemberDTO) *dtos.SCIMGroup {
+	resource := &dtos.SCIMGroup{
+		Schemas:     []string{scim.SchemaGroup},
+		Id:          strconv.FormatInt(team.Id, 10),
+		DisplayName: team.Name,
+		Members:     make([]dtos.SCIMMember, 0, len(members)),
+		Meta: &dtos.SCIMMeta{
+			ResourceType: "Group",
+			Location:     hs.scimLocation("Groups", team.Id),
+		},
+	}
+	for _, member := range members {
+		resource.Members = append(resource.Members, dtos.SCIMMember{
+			Value:   strconv.FormatInt(member.UserId, 10),
+			Display: member.Login,
+			Ref:     hs.scimLocation("Users", member.UserId),
+		})
+	}
+	return resource
+}
+
+// getSCIMTeam returns a team of the organization provisioned with SCIM, with its members.
+func (hs *HTTPServer) getSCIMTeam(teamID int64) (*models.TeamDTO, []*models.TeamMemberDTO, error) {
+	teamQuery := models.GetTeamByIdQuery{OrgId: hs.Cfg.SCIM.OrgID, Id: teamID}
+	if err := bus.Dispatch(&teamQuery); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return nil, nil, scim.NewError(http.StatusNotFound, "", "group %d not found", teamID)
+		}
+		return nil, nil, err
+	}
+
+	membersQuery := models.GetTeamMembersQuery{OrgId: hs.Cfg.SCIM.OrgID, TeamId: teamID}
+	if err := bus.Dispatch(&membersQuery); err != nil {
+		return nil, nil, err
+	}
+	return teamQuery.Result, membersQuery.Result, nil
+}
+
+func (hs *HTTPServer) scimGroupResponse(status int, teamID int64) response.Response {
+	team, members, err := hs.getSCIMTeam(teamID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return scimResponse(status, hs.scimGroup(team, members))
+}
+
+// updateSCIMGroup replaces the name and the members of a team with the ones of the resource. The members are
+// added as external members, like the members synchronized with the groups of the other identity providers.
+func (hs *HTTPServer) updateSCIMGroup(team *models.TeamDTO, resource *dtos.SCIMGroup) error {
+	if resource.DisplayName == "" {
+		return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "displayName is required")
+	}
+	if resource.DisplayName != team.Name {
+		cmd := models.UpdateTeamCommand{Id: team.Id, OrgId: hs.Cfg.SCIM.OrgID, Name: resource.DisplayName, Email: team.Email}
+		if err := bus.Dispatch(&cmd); err != nil {
+			if errors.Is(err, models.ErrTeamNameTaken) {
+				return scim.NewError(http.StatusConflict, scim.ScimTypeUniqueness, "group %s already exists", resource.DisplayName)
+			}
+			return err
+		}
+	}
+
+	orgUsersQuery := models.GetOrgUsersQuery{OrgId: hs.Cfg.SCIM.OrgID}
+	if err := bus.Dispatch(&orgUsersQuery); err != nil {
+		return err
+	}
+	orgUsers := make(map[int64]bool, len(orgUsersQuery.Result))
+	for _, orgUser := range orgUsersQuery.Result {
+		orgUsers[orgUser.UserId] = true
+	}
+
+	desired := make(map[int64]bool, len(resource.Members))
+	for _, member := range resource.Members {
+		userID, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil || !orgUsers[userID] {
+			return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "user %s not found", member.Value)
+		}
+		desired[userID] = true
+	}
+
+	membersQuery := models.GetTeamMembersQuery{OrgId: hs.Cfg.SCIM.OrgID, TeamId: team.Id}
+	if err := bus.Dispatch(&membersQuery); err != nil {
+		return err
+	}
+	for _, member := range membersQuery.Result {
+		if desired[member.UserId] {
+			delete(desired, member.UserId)
+			continue
+		}
+		cmd := models.RemoveTeamMemberCommand{OrgId: hs.Cfg.SCIM.OrgID, TeamId: team.Id, UserId: member.UserId}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+	}
+	for userID := range desired {
+		cmd := models.AddTeamMemberCommand{OrgId: hs.Cfg.SCIM.OrgID, TeamId: team.Id, UserId: userID, External: true}
+		if err := bus.Dispatch(&cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GET /api/scim/v2/Groups
+func (hs *HTTPServer) SCIMListGroups(c *models.ReqContext) response.Response {
+	filter, startIndex, count, err := scimListParams(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	teamsQuery := models.SearchTeamsQuery{OrgId: hs.Cfg.SCIM.OrgID}
+	if err := bus.Dispatch(&teamsQuery); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	// the identity providers looking for groups by name don't need their members
+	members := map[int64][]*models.TeamMemberDTO{}
+	if !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members") {
+		membersQuery := models.GetTeamMembersQuery{OrgId: hs.Cfg.SCIM.OrgID}
+		if err := bus.Dispatch(&membersQuery); err != nil {
+			return hs.scimErrorResponse(err)
+		}
+		for _, member := range membersQuery.Result {
+			members[member.TeamId] = append(members[member.TeamId], member)
+		}
+	}
+
+	resources := make([]interface{}, 0, len(teamsQuery.Result.Teams))
+	for _, team := range teamsQuery.Result.Teams {
+		resources = append(resources, hs.scimGroup(team, members[team.Id]))
+	}
+
+	list, err := scimListResponse(resources, filter, startIndex, count)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return scimResponse(http.StatusOK, list)
+}
+
+// GET /api/scim/v2/Groups/:id
+func (hs *HTTPServer) SCIMGetGroup(c *models.ReqContext) response.Response {
+	teamID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return hs.scimGroupResponse(http.StatusOK, teamID)
+}
+
+// POST /api/scim/v2/Groups
+func (hs *HTTPServer) SCIMCreateGroup(c *models.ReqContext) response.Response {
+	var resource dtos.SCIMGroup
+	if err := decodeSCIMBody(c, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if resource.DisplayName == "" {
+		return hs.scimErrorResponse(scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "displayName is required"))
+	}
+
+	cmd := models.CreateTeamCommand{OrgId: hs.Cfg.SCIM.OrgID, Name: resource.DisplayName}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrTeamNameTaken) {
+			return hs.scimErrorResponse(scim.NewError(http.StatusConflict, scim.ScimTypeUniqueness, "group %s already exists", resource.DisplayName))
+		}
+		return hs.scimErrorResponse(err)
+	}
+
+	team := &models.TeamDTO{Id: cmd.Result.Id, OrgId: cmd.Result.OrgId, Name: cmd.Result.Name}
+	if err := hs.updateSCIMGroup(team, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimGroupResponse(http.StatusCreated, team.Id)
+}
+
+// PUT /api/scim/v2/Groups/:id
+func (hs *HTTPServer) SCIMReplaceGroup(c *models.ReqContext) response.Response {
+	teamID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	team, _, err := hs.getSCIMTeam(teamID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	var resource dtos.SCIMGroup
+	if err := decodeSCIMBody(c, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if err := hs.updateSCIMGroup(team, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimGroupResponse(http.StatusOK, teamID)
+}
+
+// PATCH /api/scim/v2/Groups/:id
+func (hs *HTTPServer) SCIMPatchGroup(c *models.ReqContext) response.Response {
+	teamID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	team, members, err := hs.getSCIMTeam(teamID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	var req dtos.SCIMPatchRequest
+	if err := decodeSCIMBody(c, &req); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	resource := hs.scimGroup(team, members)
+	if err := scimPatchOperations(req, func(op string, path *scim.Path, value json.RawMessage) error {
+		return applySCIMGroupPatch(resource, op, path, value)
+	}); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if err := hs.updateSCIMGroup(team, resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimGroupResponse(http.StatusOK, teamID)
+}
+
+// applySCIMGroupPatch applies an operation of a PATCH request to the resource of a team. The attributes which
+// Grafana doesn't store are ignored.
+func applySCIMGroupPatch(resource *dtos.SCIMGroup, op string, path *scim.Path, value json.RawMessage) error {
+	switch path.Attr {
+	case "displayname":
+		if op == "remove" {
+			return scim.NewError(http.StatusBadRequest, scim.ScimTypeMutability, "displayName can't be removed")
+		}
+		return decodeSCIMValue(value, &resource.DisplayName)
+	case "members":
+		var members []dtos.SCIMMember
+		if len(value) > 0 && string(value) != "null" {
+			if err := decodeSCIMValue(value, &members); err != nil {
+				return err
+			}
+		}
+
+		if path.Filter != nil || op == "remove" {
+			kept, err := removeSCIMMembers(resource.Members, path.Filter, members)
+			if err != nil {
+				return err
+			}
+			resource.Members = kept
+			if op == "remove" {
+				return nil
+			}
+		} else if op == "replace" {
+			resource.Members = nil
+		}
+
+		present := map[string]bool{}
+		for _, member := range resource.Members {
+			present[member.Value] = true
+		}
+		for _, member := range members {
+			if !present[member.Value] {
+				resource.Members = append(resource.Members, member)
+				present[member.Value] = true
+			}
+		}
+	}
+	return nil
+}
+
+// removeSCIMMembers returns the members which neither match the filter nor are listed. Without filter nor
+// list, all the members are removed.
+func removeSCIMMembers(members []dtos.SCIMMember, filter scim.Filter, listed []dtos.SCIMMember) ([]dtos.SCIMMember, error) {
+	if filter == nil && len(listed) == 0 {
+		return nil, nil
+	}
+
+	removed := map[string]bool{}
+	for _, member := range listed {
+		removed[member.Value] = true
+	}
+
+	kept := make([]dtos.SCIMMember, 0, len(members))
+	for _, member := range members {
+		if removed[member.Value] {
+			continue
+		}
+		if filter != nil {
+			matches, err := scim.Matches(filter, member)
+			if err != nil {
+				return nil, err
+			}
+			if matches {
+				continue
+			}
+		}
+		kept = append(kept, member)
+	}
+	return kept, nil
+}
+
+// DELETE /api/scim/v2/Groups/:id
+func (hs *HTTPServer) SCIMDeleteGroup(c *models.ReqContext) response.Response {
+	teamID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	if err := bus.Dispatch(&models.DeleteTeamCommand{OrgId: hs.Cfg.SCIM.OrgID, Id: teamID}); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return hs.scimErrorResponse(scim.NewError(http.StatusNotFound, "", "group %d not found", teamID))
+		}
+		return hs.scimErrorResponse(err)
+	}
+
+	return response.Respond(http.StatusNoContent, []byte(nil))
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSCIMToken = "scim-token"
+
+func setupSCIMScenario(t *testing.T) *scenarioContext {
+	t.Helper()
+
+	sc := setupScenarioContext(t, "/api/scim/v2")
+	// other tests of the package clear the SQL handlers, which the SCIM API needs
+	bus.AddHandlerCtx("test", sqlstore.CreateUser)
+	for _, handler := range []bus.HandlerFunc{
+		sqlstore.GetUserById, sqlstore.GetUserByLogin, sqlstore.GetUserOrgList, sqlstore.UpdateUser, sqlstore.DisableUser,
+		sqlstore.AddOrgUser, sqlstore.RemoveOrgUser, sqlstore.GetOrgUsers, sqlstore.SearchSCIMUsers,
+		sqlstore.CreateTeam, sqlstore.UpdateTeam, sqlstore.DeleteTeam, sqlstore.SearchTeams, sqlstore.GetTeamById,
+		sqlstore.GetTeamsByUser, sqlstore.AddTeamMember, sqlstore.RemoveTeamMember, sqlstore.GetTeamMembers,
+	} {
+		bus.AddHandler("test", handler)
+	}
+	t.Cleanup(bus.ClearBusHandlers)
+
+	admin := models.CreateUserCommand{Login: "admin", Email: "admin@example.com"}
+	require.NoError(t, bus.DispatchCtx(context.Background(), &admin))
+
+	sc.cfg.AppURL = "http://localhost:3000/"
+	sc.cfg.SCIM.Enabled = true
+	sc.cfg.SCIM.Token = testSCIMToken
+	sc.cfg.SCIM.OrgID = admin.Result.OrgId
+	sc.cfg.SCIM.OrgRole = "Viewer"
+	hs := &HTTPServer{
+		log:              log.New("test"),
+		Cfg:              sc.cfg,
+		AuthTokenService: auth.NewFakeUserAuthTokenService(),
+	}
+
+	sc.m.Get("/api/scim/v2/ServiceProviderConfig", hs.scimAuth, routing.Wrap(hs.SCIMGetServiceProviderConfig))
+	sc.m.Get("/api/scim/v2/Users", hs.scimAuth, routing.Wrap(hs.SCIMListUsers))
+	sc.m.Post("/api/scim/v2/Users", hs.scimAuth, routing.Wrap(hs.SCIMCreateUser))
+	sc.m.Get("/api/scim/v2/Users/:id", hs.scimAuth, routing.Wrap(hs.SCIMGetUser))
+	sc.m.Put("/api/scim/v2/Users/:id", hs.scimAuth, routing.Wrap(hs.SCIMReplaceUser))
+	sc.m.Patch("/api/scim/v2/Users/:id", hs.scimAuth, routing.Wrap(hs.SCIMPatchUser))
+	sc.m.Delete("/api/scim/v2/Users/:id", hs.scimAuth, routing.Wrap(hs.SCIMDeleteUser))
+	sc.m.Get("/api/scim/v2/Groups", hs.scimAuth, routing.Wrap(hs.SCIMListGroups))
+	sc.m.Post("/api/scim/v2/Groups", hs.scimAuth, routing.Wrap(hs.SCIMCreateGroup))
+	sc.m.Get("/api/scim/v2/Groups/:id", hs.scimAuth, routing.Wrap(hs.SCIMGetGroup))
+	sc.m.Patch("/api/scim/v2/Groups/:id", hs.scimAuth, routing.Wrap(hs.SCIMPatchGroup))
+	sc.m.Delete("/api/scim/v2/Groups/:id", hs.scimAuth, routing.Wrap(hs.SCIMDeleteGroup))
+	return sc
+}
+
+// scimRequest sends a request with the SCIM token, and decodes the response into result unless it is nil.
+func scimRequest(t *testing.T, sc *scenarioContext, method, url, body string, result interface{}) int {
+	t.Helper()
+
+	sc.resp = httptest.NewRecorder()
+	sc.req = httptest.NewRequest(method, url, strings.NewReader(body))
+	sc.req.Header.Set("Authorization", "Bearer "+testSCIMToken)
+	sc.req.Header.Set("Content-Type", scim.ContentType)
+	sc.exec()
+
+	if result != nil {
+		require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), result), sc.resp.Body.String())
+	}
+	return sc.resp.Code
+}
+
+func TestSCIM(t *testing.T) {
+	sc := setupSCIMScenario(t)
+
+	t.Run("Requests without the SCIM token are refused", func(t *testing.T) {
+		for _, header := range []string{"", "Bearer wrong-token", "Basic " + testSCIMToken} {
+			sc.resp = httptest.NewRecorder()
+			sc.req = httptest.NewRequest("GET", "/api/scim/v2/Users", nil)
+			sc.req.Header.Set("Authorization", header)
+			sc.exec()
+			assert.Equal(t, http.StatusUnauthorized, sc.resp.Code, header)
+			assert.Equal(t, scim.ContentType, sc.resp.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("The service provider config lists the supported features", func(t *testing.T) {
+		var config map[string]interface{}
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", "/api/scim/v2/ServiceProviderConfig", "", &config))
+		assert.Equal(t, map[string]interface{}{"supported": true}, config["patch"])
+	})
+
+	var user dtos.SCIMUser
+	t.Run("Users are created in the organization", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, scimRequest(t, sc, "POST", "/api/scim/v2/Users", `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": "bjensen",
+			"name": {"givenName": "Barbara", "familyName": "Jensen"},
+			"emails": [{"value": "bjensen@example.com", "type": "work", "primary": true}],
+			"active": true
+		}`, &user))
+		assert.Equal(t, "bjensen", user.UserName)
+		assert.Equal(t, "Barbara Jensen", user.DisplayName)
+		assert.Equal(t, "http://localhost:3000/api/scim/v2/Users/"+user.Id, user.Meta.Location)
+
+		query := models.GetOrgUsersQuery{OrgId: sc.cfg.SCIM.OrgID, Query: "bjensen"}
+		require.NoError(t, bus.Dispatch(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "Viewer", query.Result[0].Role)
+	})
+
+	t.Run("Users are unique", func(t *testing.T) {
+		var scimErr scim.Error
+		require.Equal(t, http.StatusConflict, scimRequest(t, sc, "POST", "/api/scim/v2/Users", `{"userName": "bjensen"}`, &scimErr))
+		assert.Equal(t, scim.ScimTypeUniqueness, scimErr.ScimType)
+	})
+
+	t.Run("Existing users are added to the organization when enabled", func(t *testing.T) {
+		existing := models.CreateUserCommand{Login: "existing", Email: "existing@example.com", SkipOrgSetup: true}
+		require.NoError(t, bus.DispatchCtx(context.Background(), &existing))
+
+		require.Equal(t, http.StatusConflict, scimRequest(t, sc, "POST", "/api/scim/v2/Users", `{"userName": "existing"}`, nil))
+
+		sc.cfg.SCIM.AdoptExistingUsers = true
+		var created dtos.SCIMUser
+		require.Equal(t, http.StatusCreated, scimRequest(t, sc, "POST", "/api/scim/v2/Users", `{
+			"userName": "existing", "displayName": "Existing User"
+		}`, &created))
+		assert.Equal(t, existing.Result.Id, mustParseID(t, created.Id))
+		assert.Equal(t, "Existing User", created.DisplayName)
+	})
+
+	t.Run("Grafana Admins are not adopted", func(t *testing.T) {
+		serverAdmin := models.CreateUserCommand{Login: "server-admin", Email: "server-admin@example.com", IsAdmin: true, SkipOrgSetup: true}
+		require.NoError(t, bus.DispatchCtx(context.Background(), &serverAdmin))
+		require.NoError(t, bus.Dispatch(&models.AddOrgUserCommand{
+			OrgId: sc.cfg.SCIM.OrgID, UserId: serverAdmin.Result.Id, Role: models.ROLE_VIEWER,
+		}))
+
+		var scimErr scim.Error
+		require.Equal(t, http.StatusConflict, scimRequest(t, sc, "POST", "/api/scim/v2/Users", `{
+			"userName": "server-admin", "displayName": "Taken Over"
+		}`, &scimErr))
+		assert.Equal(t, scim.ScimTypeUniqueness, scimErr.ScimType)
+		require.Equal(t, http.StatusNotFound, scimRequest(t, sc, "GET", fmt.Sprintf("/api/scim/v2/Users/%d", serverAdmin.Result.Id), "", nil))
+
+		query := models.GetUserByIdQuery{Id: serverAdmin.Result.Id}
+		require.NoError(t, bus.Dispatch(&query))
+		assert.Equal(t, "", query.Result.Name)
+	})
+
+	t.Run("Users are listed with filters", func(t *testing.T) {
+		var list scim.ListResponse
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", `/api/scim/v2/Users?filter=userName+eq+%22BJENSEN%22`, "", &list))
+		assert.Equal(t, 1, list.TotalResults)
+		require.Len(t, list.Resources, 1)
+
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", "/api/scim/v2/Users?startIndex=2&count=1", "", &list))
+		assert.Equal(t, 3, list.TotalResults, "the Grafana Admins aren't listed")
+		assert.Equal(t, 2, list.StartIndex)
+		assert.Equal(t, 1, list.ItemsPerPage)
+		require.Len(t, list.Resources, 1)
+		assert.Equal(t, "bjensen", list.Resources[0].(map[string]interface{})["userName"])
+
+		for filter, expected := range map[string]int{
+			`emails co "@EXAMPLE"`:                              2,
+			`emails co "@EXAMPLE" and not (userName sw "adm")`:  1,
+			`displayName eq "existing user" or active eq false`: 1,
+			`userName ew "%"`:                                   0,
+			`emails[type eq "work" and value sw "bjensen"]`:     1,
+		} {
+			require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", "/api/scim/v2/Users?filter="+url.QueryEscape(filter), "", &list), filter)
+			assert.Equal(t, expected, list.TotalResults, filter)
+			assert.Len(t, list.Resources, expected, filter)
+		}
+
+		var scimErr scim.Error
+		require.Equal(t, http.StatusBadRequest, scimRequest(t, sc, "GET", `/api/scim/v2/Users?filter=userName+like+%22b%22`, "", &scimErr))
+		assert.Equal(t, scim.ScimTypeInvalidFilter, scimErr.ScimType)
+	})
+
+	t.Run("Users are patched", func(t *testing.T) {
+		var patched dtos.SCIMUser
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "PATCH", "/api/scim/v2/Users/"+user.Id, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "barbara@example.com"},
+				{"op": "Replace", "path": "active", "value": "False"},
+				{"op": "replace", "value": {"displayName": "Babs Jensen", "title": "Tour Guide"}}
+			]
+		}`, &patched))
+		assert.Equal(t, "barbara@example.com", patched.Emails[0].Value)
+		assert.Equal(t, "Babs Jensen", patched.DisplayName)
+		require.NotNil(t, patched.Active)
+		assert.False(t, *patched.Active)
+
+		var scimErr scim.Error
+		require.Equal(t, http.StatusBadRequest, scimRequest(t, sc, "PATCH", "/api/scim/v2/Users/"+user.Id, `{
+			"Operations": [{"op": "remove", "path": "userName"}]
+		}`, &scimErr))
+		assert.Equal(t, scim.ScimTypeMutability, scimErr.ScimType)
+	})
+
+	t.Run("Users are replaced", func(t *testing.T) {
+		var replaced dtos.SCIMUser
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "PUT", "/api/scim/v2/Users/"+user.Id, `{
+			"userName": "barbara", "displayName": "Barbara Jensen", "active": true,
+			"emails": [{"value": "barbara@example.com", "primary": true}]
+		}`, &replaced))
+		assert.Equal(t, "barbara", replaced.UserName)
+		require.NotNil(t, replaced.Active)
+		assert.True(t, *replaced.Active)
+
+		var scimErr scim.Error
+		require.Equal(t, http.StatusConflict, scimRequest(t, sc, "PUT", "/api/scim/v2/Users/"+user.Id, `{
+			"userName": "existing"
+		}`, &scimErr))
+	})
+
+	var group dtos.SCIMGroup
+	t.Run("Groups are created with their members", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, scimRequest(t, sc, "POST", "/api/scim/v2/Groups", `{
+			"displayName": "Tour Guides", "members": [{"value": "`+user.Id+`"}]
+		}`, &group))
+		assert.Equal(t, "Tour Guides", group.DisplayName)
+		require.Len(t, group.Members, 1)
+		assert.Equal(t, user.Id, group.Members[0].Value)
+
+		var fetched dtos.SCIMUser
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", "/api/scim/v2/Users/"+user.Id, "", &fetched))
+		require.Len(t, fetched.Groups, 1)
+		assert.Equal(t, group.Id, fetched.Groups[0].Value)
+
+		var members scim.ListResponse
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", "/api/scim/v2/Users?filter="+url.QueryEscape(`groups eq "`+group.Id+`"`), "", &members))
+		require.Equal(t, 1, members.TotalResults)
+		assert.Equal(t, user.Id, members.Resources[0].(map[string]interface{})["id"])
+
+		var scimErr scim.Error
+		require.Equal(t, http.StatusBadRequest, scimRequest(t, sc, "POST", "/api/scim/v2/Groups", `{
+			"displayName": "Others", "members": [{"value": "9999"}]
+		}`, &scimErr))
+	})
+
+	t.Run("Groups are listed with filters", func(t *testing.T) {
+		var list scim.ListResponse
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", `/api/scim/v2/Groups?filter=displayName+eq+%22tour+guides%22&excludedAttributes=members`, "", &list))
+		assert.Equal(t, 1, list.TotalResults)
+	})
+
+	t.Run("Group members are patched", func(t *testing.T) {
+		var users scim.ListResponse
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "GET", `/api/scim/v2/Users?filter=userName+eq+%22existing%22`, "", &users))
+		existingID := users.Resources[0].(map[string]interface{})["id"].(string)
+
+		var patched dtos.SCIMGroup
+		require.Equal(t, http.StatusOK, scimRequest(t, sc, "PATCH", "/api/scim/v2/Groups/"+group.Id, `{
+			"Operations": [
+				{"op": "add", "path": "members", "value": [{"value": "`+existingID+`"}]},
+				{"op": "remove", "path": "members[value eq \"`+user.Id+`\"]"},
+				{"op": "replace", "path": "displayName", "value": "Guides"}
+			]
+		}`, &patched))
+		assert.Equal(t, "Guides", patched.DisplayName)
+		require.Len(t, patched.Members, 1)
+		assert.Equal(t, existingID, patched.Members[0].Value)
+
+		query := models.GetTeamMembersQuery{OrgId: sc.cfg.SCIM.OrgID, TeamId: mustParseID(t, group.Id), External: true}
+		require.NoError(t, bus.Dispatch(&query))
+		assert.Len(t, query.Result, 1)
+	})
+
+	t.Run("Groups are deleted", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, scimRequest(t, sc, "DELETE", "/api/scim/v2/Groups/"+group.Id, "", nil))
+		require.Equal(t, http.StatusNotFound, scimRequest(t, sc, "GET", "/api/scim/v2/Groups/"+group.Id, "", nil))
+	})
+
+	t.Run("Users are deleted with their last organization", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, scimRequest(t, sc, "DELETE", "/api/scim/v2/Users/"+user.Id, "", nil))
+		require.Equal(t, http.StatusNotFound, scimRequest(t, sc, "GET", "/api/scim/v2/Users/"+user.Id, "", nil))
+
+		err := bus.Dispatch(&models.GetUserByIdQuery{Id: mustParseID(t, user.Id)})
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	})
+}
+
+func mustParseID(t *testing.T, id string) int64 {
+	t.Helper()
+
+	var parsed int64
+	require.NoError(t, json.Unmarshal([]byte(id), &parsed))
+	return parsed
+}
@@ -0,0 +1,554 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+// scimUser returns the SCIM resource of a user, member of the groups.
+func (hs *HTTPServer) scimUser(user *models.User, groups []dtos.SCIMMember) *dtos.SCIMUser {
+	active := !user.IsDisabled
+	resource := &dtos.SCIMUser{
+		Schemas:     []string{scim.SchemaUser},
+		Id:          strconv.FormatInt(user.Id, 10),
+		UserName:    user.Login,
+		DisplayName: user.Name,
+		Active:      &active,
+		Groups:      groups,
+		Meta: &dtos.SCIMMeta{
+			ResourceType: "User",
+			Created:      user.Created.UTC().Format(time.RFC3339),
+			LastModified: user.Updated.UTC().Format(time.RFC3339),
+			Location:     hs.scimLocation("Users", user.Id),
+		},
+	}
+	if user.Name != "" {
+		resource.Name = &dtos.SCIMName{Formatted: user.Name}
+	}
+	if user.Email != "" {
+		resource.Emails = []dtos.SCIMEmail{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	return resource
+}
+
+// scimUserGroups returns the teams of a user in the organization provisioned with SCIM.
+func (hs *HTTPServer) scimUserGroups(userID int64) ([]dtos.SCIMMember, error) {
+	query := models.GetTeamsByUserQuery{OrgId: hs.Cfg.SCIM.OrgID, UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	groups := make([]dtos.SCIMMember, 0, len(query.Result))
+	for _, team := range query.Result {
+		groups = append(groups, dtos.SCIMMember{
+			Value:   strconv.FormatInt(team.Id, 10),
+			Display: team.Name,
+			Ref:     hs.scimLocation("Groups", team.Id),
+		})
+	}
+	return groups, nil
+}
+
+// scimUsersGroups returns the teams of all the users of the organization provisioned with SCIM.
+func (hs *HTTPServer) scimUsersGroups() (map[int64][]dtos.SCIMMember, error) {
+	teamsQuery := models.SearchTeamsQuery{OrgId: hs.Cfg.SCIM.OrgID}
+	if err := bus.Dispatch(&teamsQuery); err != nil {
+		return nil, err
+	}
+	teamNames := make(map[int64]string, len(teamsQuery.Result.Teams))
+	for _, team := range teamsQuery.Result.Teams {
+		teamNames[team.Id] = team.Name
+	}
+
+	membersQuery := models.GetTeamMembersQuery{OrgId: hs.Cfg.SCIM.OrgID}
+	if err := bus.Dispatch(&membersQuery); err != nil {
+		return nil, err
+	}
+	groups := map[int64][]dtos.SCIMMember{}
+	for _, member := range membersQuery.Result {
+		groups[member.UserId] = append(groups[member.UserId], dtos.SCIMMember{
+			Value:   strconv.FormatInt(member.TeamId, 10),
+			Display: teamNames[member.TeamId],
+			Ref:     hs.scimLocation("Groups", member.TeamId),
+		})
+	}
+	return groups, nil
+}
+
+// getSCIMUser returns a user of the organization provisioned with SCIM, the other users and the Grafana Admins
+// are not found.
+func (hs *HTTPServer) getSCIMUser(userID int64) (*models.User, error) {
+	notFound := scim.NewError(http.StatusNotFound, "", "user %d not found", userID)
+
+	userQuery := models.GetUserByIdQuery{Id: userID}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, notFound
+		}
+		return nil, err
+	}
+	if userQuery.Result.IsAdmin {
+		return nil, notFound
+	}
+
+	isMember, err := hs.isSCIMOrgMember(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, notFound
+	}
+	return userQuery.Result, nil
+}
+
+func (hs *HTTPServer) isSCIMOrgMember(userID int64) (bool, error) {
+	orgsQuery := models.GetUserOrgListQuery{UserId: userID}
+	if err := bus.Dispatch(&orgsQuery); err != nil {
+		return false, err
+	}
+	for _, org := range orgsQuery.Result {
+		if org.OrgId == hs.Cfg.SCIM.OrgID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scimUserName returns the name of a user: the display name, or else the formatted name, or else the
+// given and family names.
+func scimUserName(resource *dtos.SCIMUser) string {
+	if resource.DisplayName != "" {
+		return resource.DisplayName
+	}
+	if resource.Name == nil {
+		return ""
+	}
+	if resource.Name.Formatted != "" {
+		return resource.Name.Formatted
+	}
+	return strings.TrimSpace(resource.Name.GivenName + " " + resource.Name.FamilyName)
+}
+
+// scimUserEmail returns the primary email of a user, or else the first one.
+func scimUserEmail(resource *dtos.SCIMUser) string {
+	for _, email := range resource.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(resource.Emails) > 0 {
+		return resource.Emails[0].Value
+	}
+	return ""
+}
+
+// checkSCIMUserUnique refuses the login or the email of another user.
+func checkSCIMUserUnique(userID int64, loginOrEmail string) error {
+	query := models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if query.Result.Id != userID {
+		return scim.NewError(http.StatusConflict, scim.ScimTypeUniqueness, "user %s already exists", loginOrEmail)
+	}
+	return nil
+}
+
+// updateSCIMUser replaces the attributes of a user with the ones of the resource, and logs the user out
+// when the resource is deactivated.
+func (hs *HTTPServer) updateSCIMUser(c *models.ReqContext, user *models.User, resource *dtos.SCIMUser) error {
+	if resource.UserName == "" {
+		return scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "userName is required")
+	}
+	email := scimUserEmail(resource)
+	if email == "" {
+		email = resource.UserName
+	}
+
+	if resource.UserName != user.Login {
+		if err := checkSCIMUserUnique(user.Id, resource.UserName); err != nil {
+			return err
+		}
+	}
+	if email != user.Email {
+		if err := checkSCIMUserUnique(user.Id, email); err != nil {
+			return err
+		}
+	}
+
+	cmd := models.UpdateUserCommand{
+		UserId: user.Id,
+		Login:  resource.UserName,
+		Email:  email,
+		Name:   scimUserName(resource),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return err
+	}
+
+	if resource.Active == nil || *resource.Active != user.IsDisabled {
+		return nil
+	}
+	if err := bus.Dispatch(&models.DisableUserCommand{UserId: user.Id, IsDisabled: !*resource.Active}); err != nil {
+		return err
+	}
+	if !*resource.Active {
+		return hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), user.Id)
+	}
+	return nil
+}
+
+// scimUserResponse returns the resource of a user, with its current attributes.
+func (hs *HTTPServer) scimUserResponse(status int, userID int64) response.Response {
+	userQuery := models.GetUserByIdQuery{Id: userID}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	groups, err := hs.scimUserGroups(userID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return scimResponse(status, hs.scimUser(userQuery.Result, groups))
+}
+
+// GET /api/scim/v2/Users
+func (hs *HTTPServer) SCIMListUsers(c *models.ReqContext) response.Response {
+	filter, startIndex, count, err := scimListParams(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	groups, err := hs.scimUsersGroups()
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	from, to := scim.Page(math.MaxInt32, startIndex, count)
+	query := scim.SearchUsersQuery{OrgId: hs.Cfg.SCIM.OrgID, Filter: filter, Offset: from, Limit: to - from}
+	err = bus.Dispatch(&query)
+	if errors.Is(err, scim.ErrFilterNotSupported) {
+		// the filters which can't be translated to SQL are matched on the resources of all the users
+		return hs.scimListMatchingUsers(filter, startIndex, count, groups)
+	}
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	resources := make([]interface{}, 0, len(query.Result))
+	for _, user := range query.Result {
+		resources = append(resources, hs.scimUser(user, groups[user.Id]))
+	}
+	return scimResponse(http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: int(query.TotalCount),
+		StartIndex:   from + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// scimListMatchingUsers lists the users matching a filter which FilterSQL can't translate.
+func (hs *HTTPServer) scimListMatchingUsers(filter scim.Filter, startIndex, count int, groups map[int64][]dtos.SCIMMember) response.Response {
+	query := scim.SearchUsersQuery{OrgId: hs.Cfg.SCIM.OrgID, Limit: -1}
+	if err := bus.Dispatch(&query); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	resources := make([]interface{}, 0, len(query.Result))
+	for _, user := range query.Result {
+		resources = append(resources, hs.scimUser(user, groups[user.Id]))
+	}
+	list, err := scimListResponse(resources, filter, startIndex, count)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return scimResponse(http.StatusOK, list)
+}
+
+// GET /api/scim/v2/Users/:id
+func (hs *HTTPServer) SCIMGetUser(c *models.ReqContext) response.Response {
+	userID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if _, err := hs.getSCIMUser(userID); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	return hs.scimUserResponse(http.StatusOK, userID)
+}
+
+// POST /api/scim/v2/Users
+//
+// With adopt_existing_users, users who exist already but aren't members of the organization provisioned with
+// SCIM, like the users who logged in before their provisioning, are added to the organization.
+func (hs *HTTPServer) SCIMCreateUser(c *models.ReqContext) response.Response {
+	var resource dtos.SCIMUser
+	if err := decodeSCIMBody(c, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if resource.UserName == "" {
+		return hs.scimErrorResponse(scim.NewError(http.StatusBadRequest, scim.ScimTypeInvalidValue, "userName is required"))
+	}
+
+	cmd := models.CreateUserCommand{
+		Login:        resource.UserName,
+		Email:        scimUserEmail(&resource),
+		Name:         scimUserName(&resource),
+		IsDisabled:   resource.Active != nil && !*resource.Active,
+		SkipOrgSetup: true,
+	}
+	var user *models.User
+	err := bus.Dispatch(&cmd)
+	switch {
+	case err == nil:
+		user = &cmd.Result
+	case errors.Is(err, models.ErrUserAlreadyExists):
+		if user, err = hs.adoptSCIMUser(c, &resource); err != nil {
+			return hs.scimErrorResponse(err)
+		}
+	default:
+		return hs.scimErrorResponse(err)
+	}
+
+	addCmd := models.AddOrgUserCommand{
+		OrgId:        hs.Cfg.SCIM.OrgID,
+		UserId:       user.Id,
+		LoginOrEmail: user.Login,
+		Role:         models.RoleType(hs.Cfg.SCIM.OrgRole),
+	}
+	if err := bus.Dispatch(&addCmd); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimUserResponse(http.StatusCreated, user.Id)
+}
+
+// adoptSCIMUser returns the existing user of a new resource, with the attributes of the resource, when
+// adopt_existing_users is enabled and the user isn't a member of the organization provisioned with SCIM
+// already. The Grafana Admins are never taken over by the identity provider.
+func (hs *HTTPServer) adoptSCIMUser(c *models.ReqContext, resource *dtos.SCIMUser) (*models.User, error) {
+	conflict := scim.NewError(http.StatusConflict, scim.ScimTypeUniqueness, "user %s already exists", resource.UserName)
+	if !hs.Cfg.SCIM.AdoptExistingUsers {
+		return nil, conflict
+	}
+
+	query := models.GetUserByLoginQuery{LoginOrEmail: resource.UserName}
+	err := bus.Dispatch(&query)
+	if errors.Is(err, models.ErrUserNotFound) && scimUserEmail(resource) != "" {
+		query = models.GetUserByLoginQuery{LoginOrEmail: scimUserEmail(resource)}
+		err = bus.Dispatch(&query)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, conflict
+		}
+		return nil, err
+	}
+
+	if query.Result.IsAdmin {
+		return nil, conflict
+	}
+	isMember, err := hs.isSCIMOrgMember(query.Result.Id)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, conflict
+	}
+
+	if err := hs.updateSCIMUser(c, query.Result, resource); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+// PUT /api/scim/v2/Users/:id
+func (hs *HTTPServer) SCIMReplaceUser(c *models.ReqContext) response.Response {
+	userID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	user, err := hs.getSCIMUser(userID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	var resource dtos.SCIMUser
+	if err := decodeSCIMBody(c, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if err := hs.updateSCIMUser(c, user, &resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimUserResponse(http.StatusOK, userID)
+}
+
+// PATCH /api/scim/v2/Users/:id
+func (hs *HTTPServer) SCIMPatchUser(c *models.ReqContext) response.Response {
+	userID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	user, err := hs.getSCIMUser(userID)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	var req dtos.SCIMPatchRequest
+	if err := decodeSCIMBody(c, &req); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	resource := hs.scimUser(user, nil)
+	patch := &scimUserPatch{resource: resource}
+	if err := scimPatchOperations(req, patch.apply); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if err := hs.updateSCIMUser(c, user, resource); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	return hs.scimUserResponse(http.StatusOK, userID)
+}
+
+// scimUserPatch applies the operations of a PATCH request to the resource of a user. The attributes which
+// Grafana doesn't store are ignored.
+type scimUserPatch struct {
+	resource *dtos.SCIMUser
+	// displayNameSet is whether the display name was patched, which then takes precedence over the name
+	displayNameSet bool
+}
+
+func (p *scimUserPatch) apply(op string, path *scim.Path, value json.RawMessage) error {
+	required := func(attr string) error {
+		if op == "remove" {
+			return scim.NewError(http.StatusBadRequest, scim.ScimTypeMutability, "%s can't be removed", attr)
+		}
+		return nil
+	}
+
+	switch path.Attr {
+	case "username":
+		if err := required("userName"); err != nil {
+			return err
+		}
+		return decodeSCIMValue(value, &p.resource.UserName)
+	case "displayname":
+		p.displayNameSet = true
+		p.resource.DisplayName = ""
+		if op == "remove" {
+			return nil
+		}
+		return decodeSCIMValue(value, &p.resource.DisplayName)
+	case "name", "name.formatted", "name.givenname", "name.familyname":
+		return p.applyName(op, path.Attr, value)
+	case "emails", "emails.value":
+		if err := required("emails"); err != nil {
+			return err
+		}
+		if path.Attr == "emails.value" || path.SubAttr == "value" {
+			var email string
+			if err := decodeSCIMValue(value, &email); err != nil {
+				return err
+			}
+			p.resource.Emails = []dtos.SCIMEmail{{Value: email, Primary: true}}
+			return nil
+		}
+		var emails []dtos.SCIMEmail
+		if err := decodeSCIMValue(value, &emails); err != nil {
+			var email dtos.SCIMEmail
+			if err := decodeSCIMValue(value, &email); err != nil {
+				return err
+			}
+			emails = []dtos.SCIMEmail{email}
+		}
+		p.resource.Emails = emails
+		return nil
+	case "active":
+		if err := required("active"); err != nil {
+			return err
+		}
+		active, err := decodeSCIMBool(value)
+		if err != nil {
+			return err
+		}
+		p.resource.Active = &active
+		return nil
+	case "groups":
+		return scim.NewError(http.StatusBadRequest, scim.ScimTypeMutability, "groups are read-only, update the members of the groups")
+	}
+	return nil
+}
+
+func (p *scimUserPatch) applyName(op string, attr string, value json.RawMessage) error {
+	if !p.displayNameSet {
+		// the name replaces the display name, which holds the current name of the user
+		p.resource.DisplayName = ""
+	}
+	if p.resource.Name == nil {
+		p.resource.Name = &dtos.SCIMName{}
+	}
+
+	if attr == "name" {
+		p.resource.Name = &dtos.SCIMName{}
+		if op == "remove" {
+			return nil
+		}
+		return decodeSCIMValue(value, p.resource.Name)
+	}
+
+	var s string
+	if op != "remove" {
+		if err := decodeSCIMValue(value, &s); err != nil {
+			return err
+		}
+	}
+	switch attr {
+	case "name.formatted":
+		p.resource.Name.Formatted = s
+	case "name.givenname":
+		p.resource.Name.GivenName = s
+	case "name.familyname":
+		p.resource.Name.FamilyName = s
+	}
+	return nil
+}
+
+// DELETE /api/scim/v2/Users/:id
+//
+// The users are removed from the organization provisioned with SCIM, and deleted when they aren't members of
+// other organizations.
+func (hs *HTTPServer) SCIMDeleteUser(c *models.ReqContext) response.Response {
+	userID, err := scimResourceID(c)
+	if err != nil {
+		return hs.scimErrorResponse(err)
+	}
+	if _, err := hs.getSCIMUser(userID); err != nil {
+		return hs.scimErrorResponse(err)
+	}
+
+	cmd := models.RemoveOrgUserCommand{OrgId: hs.Cfg.SCIM.OrgID, UserId: userID, ShouldDeleteOrphanedUser: true}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrLastOrgAdmin) {
+			return hs.scimErrorResponse(scim.NewError(http.StatusBadRequest, scim.ScimTypeMutability, "%s", err))
+		}
+		return hs.scimErrorResponse(err)
+	}
+
+	return response.Respond(http.StatusNoContent, []byte(nil))
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// GET /api/serviceaccounts
+func GetServiceAccounts(c *models.ReqContext) response.Response {
+	query := models.GetServiceAccountsQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to list service accounts", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// GET /api/serviceaccounts/:serviceAccountId
+func GetServiceAccountByID(c *models.ReqContext) response.Response {
+	query := models.GetServiceAccountByIdQuery{OrgId: c.OrgId, Id: c.ParamsInt64(":serviceAccountId")}
+	if err := bus.Dispatch(&query); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to get service account")
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/serviceaccounts
+func CreateServiceAccount(c *models.ReqContext, cmd models.CreateServiceAccountCommand) response.Response {
+	if !cmd.Role.IsValid() {
+		return response.Error(400, "Invalid role specified", nil)
+	}
+
+	if !c.OrgRole.Includes(cmd.Role) {
+		return response.Error(403, "Cannot create a service account with a role higher than your own", nil)
+	}
+
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to create service account")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// PATCH /api/serviceaccounts/:serviceAccountId
+func UpdateServiceAccount(c *models.ReqContext, cmd models.UpdateServiceAccountCommand) response.Response {
+	if cmd.Role != nil {
+		if !cmd.Role.IsValid() {
+			return response.Error(400, "Invalid role specified", nil)
+		}
+
+		if !c.OrgRole.Includes(*cmd.Role) {
+			return response.Error(403, "Cannot give a service account a role higher than your own", nil)
+		}
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.Id = c.ParamsInt64(":serviceAccountId")
+	if err := bus.Dispatch(&cmd); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to update service account")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId
+func DeleteServiceAccount(c *models.ReqContext) response.Response {
+	cmd := models.DeleteServiceAccountCommand{OrgId: c.OrgId, Id: c.ParamsInt64(":serviceAccountId")}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to delete service account")
+	}
+
+	return response.Success("Service account deleted")
+}
+
+// GET /api/serviceaccounts/:serviceAccountId/tokens
+func GetServiceAccountTokens(c *models.ReqContext) response.Response {
+	serviceAccountID := c.ParamsInt64(":serviceAccountId")
+	if resp := checkServiceAccount(c, serviceAccountID); resp != nil {
+		return resp
+	}
+
+	query := models.GetServiceAccountTokensQuery{OrgId: c.OrgId, ServiceAccountId: serviceAccountID}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to list service account tokens", err)
+	}
+
+	result := make([]*models.ServiceAccountTokenDTO, len(query.Result))
+	for i, t := range query.Result {
+		var expiration *time.Time
+		if t.Expires != nil {
+			v := time.Unix(*t.Expires, 0)
+			expiration = &v
+		}
+		result[i] = &models.ServiceAccountTokenDTO{
+			Id:         t.Id,
+			Name:       t.Name,
+			Created:    t.Created,
+			Expiration: expiration,
+			LastUsedAt: t.LastUsedAt,
+		}
+	}
+
+	return response.JSON(200, result)
+}
+
+// POST /api/serviceaccounts/:serviceAccountId/tokens
+func (hs *HTTPServer) CreateServiceAccountToken(c *models.ReqContext, form models.AddServiceAccountTokenCommand) response.Response {
+	serviceAccountID := c.ParamsInt64(":serviceAccountId")
+	query := models.GetServiceAccountByIdQuery{OrgId: c.OrgId, Id: serviceAccountID}
+	if err := bus.Dispatch(&query); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to get service account")
+	}
+
+	if !c.OrgRole.Includes(query.Result.Role) {
+		return response.Error(403, "Cannot create a token for a service account with a role higher than your own", nil)
+	}
+
+	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
+		if form.SecondsToLive == 0 {
+			return response.Error(400, "Number of seconds before expiration should be set", nil)
+		}
+		if form.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
+			return response.Error(400, "Number of seconds before expiration is greater than the global limit", nil)
+		}
+	}
+
+	cmd := models.AddApiKeyCommand{
+		Name:             form.Name,
+		OrgId:            c.OrgId,
+		SecondsToLive:    form.SecondsToLive,
+		ServiceAccountId: &serviceAccountID,
+		// the tokens get the role of the service account when they are used
+		Role: query.Result.Role,
+	}
+
+	newKeyInfo, err := apikeygen.New(cmd.OrgId, cmd.Name)
+	if err != nil {
+		return response.Error(500, "Generating service account token failed", err)
+	}
+
+	cmd.Key = newKeyInfo.HashedKey
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKeyExpiration) {
+			return response.Error(400, err.Error(), nil)
+		}
+		if errors.Is(err, models.ErrDuplicateApiKey) {
+			return response.Error(409, err.Error(), nil)
+		}
+		return response.Error(500, "Failed to add service account token", err)
+	}
+
+	return response.JSON(200, &dtos.NewApiKeyResult{
+		ID:   cmd.Result.Id,
+		Name: cmd.Result.Name,
+		Key:  newKeyInfo.ClientSecret,
+	})
+}
+
+// DELETE /api/serviceaccounts/:serviceAccountId/tokens/:tokenId
+func DeleteServiceAccountToken(c *models.ReqContext) response.Response {
+	cmd := models.DeleteServiceAccountTokenCommand{
+		OrgId:            c.OrgId,
+		ServiceAccountId: c.ParamsInt64(":serviceAccountId"),
+		Id:               c.ParamsInt64(":tokenId"),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to delete service account token")
+	}
+
+	return response.Success("Service account token deleted")
+}
+
+// POST /api/serviceaccounts/migrate
+// POST /api/serviceaccounts/migrate/:keyId
+func ConvertAPIKeysToServiceAccounts(c *models.ReqContext) response.Response {
+	cmd := models.ConvertApiKeysToServiceAccountsCommand{
+		OrgId:    c.OrgId,
+		ApiKeyId: c.ParamsInt64(":keyId"),
+		MaxRole:  c.OrgRole,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrInvalidApiKey) {
+			return response.Error(404, "API key not found", err)
+		}
+		if errors.Is(err, models.ErrServiceAccountRoleTooHigh) {
+			return response.Error(403, err.Error(), nil)
+		}
+		return serviceAccountErrorResponse(err, "Failed to convert API keys to service accounts")
+	}
+
+	return response.JSON(200, cmd.Result)
+}
+
+func checkServiceAccount(c *models.ReqContext, id int64) response.Response {
+	query := models.GetServiceAccountByIdQuery{OrgId: c.OrgId, Id: id}
+	if err := bus.Dispatch(&query); err != nil {
+		return serviceAccountErrorResponse(err, "Failed to get service account")
+	}
+
+	return nil
+}
+
+func serviceAccountErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, models.ErrServiceAccountNotFound), errors.Is(err, models.ErrServiceAccountTokenNotFound):
+		return response.Error(404, err.Error(), err)
+	case errors.Is(err, models.ErrServiceAccountAlreadyExists):
+		return response.Error(409, err.Error(), err)
+	}
+
+	return response.Error(500, message, err)
+}
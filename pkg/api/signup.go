@@ -65,6 +65,10 @@ func (hs *HTTPServer) SignUpStep2(c *models.ReqContext, form dtos.SignUpStep2For
 		return response.Error(401, "User signup is disabled", nil)
 	}
 
+	if resp := hs.validatePassword(form.Password, nil); resp != nil {
+		return resp
+	}
+
 	createUserCmd := models.CreateUserCommand{
 		Email:    form.Email,
 		Login:    form.Username,
@@ -15,10 +15,6 @@ import (
 func (hs *HTTPServer) CreateTeam(c *models.ReqContext, cmd models.CreateTeamCommand) response.Response {
 	cmd.OrgId = c.OrgId
 
-	if c.OrgRole == models.ROLE_VIEWER {
-		return response.Error(403, "Not allowed to create team.", nil)
-	}
-
 	if err := hs.Bus.Dispatch(&cmd); err != nil {
 		if errors.Is(err, models.ErrTeamNameTaken) {
 			return response.Error(409, "Team name taken", err)
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/teamguardian"
+)
+
+// GET /api/teams/:teamId/groups
+func (hs *HTTPServer) GetTeamGroups(c *models.ReqContext) response.Response {
+	query := models.GetTeamGroupsQuery{OrgId: c.OrgId, TeamId: c.ParamsInt64(":teamId")}
+
+	if err := hs.Bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get team groups", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/teams/:teamId/groups
+func (hs *HTTPServer) AddTeamGroup(c *models.ReqContext, cmd models.AddTeamGroupCommand) response.Response {
+	cmd.OrgId = c.OrgId
+	cmd.TeamId = c.ParamsInt64(":teamId")
+
+	if err := teamguardian.CanAdmin(hs.Bus, cmd.OrgId, cmd.TeamId, c.SignedInUser); err != nil {
+		return response.Error(403, "Not allowed to add team group", err)
+	}
+
+	if err := hs.Bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrTeamNotFound) {
+			return response.Error(404, "Team not found", nil)
+		}
+
+		if errors.Is(err, models.ErrTeamGroupAlreadyAdded) {
+			return response.Error(400, "Group is already added to this team", nil)
+		}
+
+		return response.Error(500, "Failed to add group to team", err)
+	}
+
+	return response.Success("Group added to team")
+}
+
+// DELETE /api/teams/:teamId/groups/:groupId
+func (hs *HTTPServer) RemoveTeamGroup(c *models.ReqContext) response.Response {
+	cmd := models.RemoveTeamGroupCommand{
+		OrgId:   c.OrgId,
+		TeamId:  c.ParamsInt64(":teamId"),
+		GroupId: c.Params(":groupId"),
+	}
+
+	if err := teamguardian.CanAdmin(hs.Bus, cmd.OrgId, cmd.TeamId, c.SignedInUser); err != nil {
+		return response.Error(403, "Not allowed to remove team group", err)
+	}
+
+	if err := hs.Bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrTeamGroupNotFound) {
+			return response.Error(404, "Team group not found", nil)
+		}
+
+		return response.Error(500, "Failed to remove group from team", err)
+	}
+
+	return response.Success("Group removed from team")
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamGroupsAPIEndpoint(t *testing.T) {
+	hs := &HTTPServer{
+		Cfg: setting.NewCfg(),
+	}
+
+	loggedInUserScenarioWithRole(t, "When calling GET on", "GET", "/api/teams/1/groups",
+		"/api/teams/:teamId/groups", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetTeamGroupsQuery) error {
+				assert.Equal(t, int64(1), query.TeamId)
+				query.Result = []*models.TeamGroupDTO{{OrgId: testOrgID, TeamId: 1, GroupId: "admins"}}
+				return nil
+			})
+
+			hs.Bus = bus.GetBus()
+			sc.handlerFunc = hs.GetTeamGroups
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+
+			var resp []models.TeamGroupDTO
+			require.NoError(t, json.Unmarshal(sc.resp.Body.Bytes(), &resp))
+			require.Len(t, resp, 1)
+			assert.Equal(t, "admins", resp[0].GroupId)
+		})
+
+	loggedInUserScenarioWithRole(t, "When calling DELETE on", "DELETE", "/api/teams/1/groups/cn%3Dadmins%2Cdc%3Dgrafana%2Cdc%3Dorg",
+		"/api/teams/:teamId/groups/:groupId", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			var removed *models.RemoveTeamGroupCommand
+			bus.AddHandler("test", func(cmd *models.RemoveTeamGroupCommand) error {
+				removed = cmd
+				return nil
+			})
+
+			hs.Bus = bus.GetBus()
+			sc.handlerFunc = hs.RemoveTeamGroup
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+			require.NotNil(t, removed)
+			assert.Equal(t, "cn=admins,dc=grafana,dc=org", removed.GroupId)
+			assert.Equal(t, testOrgID, removed.OrgId)
+		})
+
+	loggedInUserScenarioWithRole(t, "When calling DELETE on an unknown group", "DELETE", "/api/teams/1/groups/unknown",
+		"/api/teams/:teamId/groups/:groupId", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			bus.AddHandler("test", func(cmd *models.RemoveTeamGroupCommand) error {
+				return models.ErrTeamGroupNotFound
+			})
+
+			hs.Bus = bus.GetBus()
+			sc.handlerFunc = hs.RemoveTeamGroup
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+
+			assert.Equal(t, http.StatusNotFound, sc.resp.Code)
+		})
+}
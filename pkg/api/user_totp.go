@@ -0,0 +1,369 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/totp"
+	"github.com/grafana/grafana/pkg/infra/encryption"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// totpRecoveryCodes is the number of recovery codes generated when two-factor authentication is enabled
+const totpRecoveryCodes = 10
+
+func (hs *HTTPServer) totpDisabledResponse() response.Response {
+	return response.Error(404, "Two-factor authentication is disabled", nil)
+}
+
+// GET /api/user/totp
+func (hs *HTTPServer) GetUserTotpStatus(c *models.ReqContext) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	status := models.UserTotpStatusDTO{}
+
+	query := models.GetUserTotpQuery{UserId: c.UserId}
+	if err := bus.Dispatch(&query); err == nil {
+		status.Enabled = query.Result.Enabled
+		status.EnrollmentPending = !query.Result.Enabled
+		if codes, err := totpRecoveryCodeHashes(query.Result); err == nil {
+			status.RecoveryCodesLeft = len(codes)
+		}
+	} else if !errors.Is(err, models.ErrUserTotpNotFound) {
+		return response.Error(500, "Failed to get two-factor authentication", err)
+	}
+
+	requiredQuery := models.IsTotpRequiredForUserQuery{UserId: c.UserId}
+	if err := bus.Dispatch(&requiredQuery); err != nil {
+		return response.Error(500, "Failed to get two-factor authentication", err)
+	}
+	status.Required = requiredQuery.Result
+
+	return response.JSON(200, status)
+}
+
+// POST /api/user/totp/enroll
+func (hs *HTTPServer) EnrollUserTotp(c *models.ReqContext) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	query := models.GetUserTotpQuery{UserId: c.UserId}
+	if err := bus.Dispatch(&query); err == nil && query.Result.Enabled {
+		return response.Error(409, "Two-factor authentication already enabled", nil)
+	} else if err != nil && !errors.Is(err, models.ErrUserTotpNotFound) {
+		return response.Error(500, "Failed to get two-factor authentication", err)
+	}
+
+	return hs.enrollTotp(c.UserId, c.Login)
+}
+
+// enrollTotp saves a new secret for the user, which is enabled once the user sends a code of it.
+func (hs *HTTPServer) enrollTotp(userID int64, login string) response.Response {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return response.Error(500, "Failed to generate two-factor authentication secret", err)
+	}
+
+	encrypted, err := encryption.Encrypt([]byte(secret))
+	if err != nil {
+		return response.Error(500, "Failed to encrypt two-factor authentication secret", err)
+	}
+
+	if err := bus.Dispatch(&models.SaveUserTotpSecretCommand{UserId: userID, Secret: encrypted}); err != nil {
+		return response.Error(500, "Failed to save two-factor authentication secret", err)
+	}
+
+	return response.JSON(200, util.DynMap{
+		"secret": secret,
+		"uri":    totp.ProvisioningURI(secret, hs.Cfg.Totp.Issuer, login),
+	})
+}
+
+// POST /api/user/totp/enable
+func (hs *HTTPServer) EnableUserTotp(c *models.ReqContext, form dtos.TotpCodeForm) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	query := models.GetUserTotpQuery{UserId: c.UserId}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrUserTotpNotFound) {
+			return response.Error(400, "Two-factor authentication enrollment not started", nil)
+		}
+		return response.Error(500, "Failed to get two-factor authentication", err)
+	}
+	if query.Result.Enabled {
+		return response.Error(409, "Two-factor authentication already enabled", nil)
+	}
+
+	codes, err := enableTotp(query.Result, c.UserId, form.Code)
+	if err != nil {
+		return totpErrorResponse(err)
+	}
+
+	return response.JSON(200, util.DynMap{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// POST /api/user/totp/recovery-codes
+func (hs *HTTPServer) RegenerateUserTotpRecoveryCodes(c *models.ReqContext, form dtos.TotpCodeForm) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	userTotp, resp := getEnabledUserTotp(c.UserId)
+	if resp != nil {
+		return resp
+	}
+
+	if err := validateTotpCode(userTotp, form.Code); err != nil {
+		return totpErrorResponse(err)
+	}
+
+	user, err := getUserWithSalt(c.UserId)
+	if err != nil {
+		return response.Error(500, "Failed to get user", err)
+	}
+
+	codes, hashes, err := generateTotpRecoveryCodes(user.Salt)
+	if err != nil {
+		return response.Error(500, "Failed to generate recovery codes", err)
+	}
+
+	cmd := models.UpdateUserTotpRecoveryCodesCommand{
+		UserId:                c.UserId,
+		RecoveryCodes:         hashes,
+		PreviousRecoveryCodes: userTotp.RecoveryCodes,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrUserTotpCodeUsed) {
+			return response.Error(409, "Recovery codes changed, try again", nil)
+		}
+		return response.Error(500, "Failed to save recovery codes", err)
+	}
+
+	return response.JSON(200, util.DynMap{"recoveryCodes": codes})
+}
+
+// POST /api/user/totp/disable
+func (hs *HTTPServer) DisableUserTotp(c *models.ReqContext, form dtos.TotpCodeForm) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	requiredQuery := models.IsTotpRequiredForUserQuery{UserId: c.UserId}
+	if err := bus.Dispatch(&requiredQuery); err != nil {
+		return response.Error(500, "Failed to get two-factor authentication", err)
+	}
+	if requiredQuery.Result {
+		return response.Error(403, "Two-factor authentication is required by your organization", nil)
+	}
+
+	userTotp, resp := getEnabledUserTotp(c.UserId)
+	if resp != nil {
+		return resp
+	}
+
+	if err := validateTotpCode(userTotp, form.Code); err != nil {
+		return totpErrorResponse(err)
+	}
+
+	if err := bus.Dispatch(&models.DeleteUserTotpCommand{UserId: c.UserId}); err != nil {
+		return response.Error(500, "Failed to disable two-factor authentication", err)
+	}
+
+	return response.Success("Two-factor authentication disabled")
+}
+
+// DELETE /api/admin/users/:id/totp
+func (hs *HTTPServer) AdminDeleteUserTotp(c *models.ReqContext) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	userID := c.ParamsInt64(":id")
+	if _, err := getUserWithSalt(userID); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return response.Error(404, models.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(500, "Failed to get user", err)
+	}
+
+	if err := bus.Dispatch(&models.DeleteUserTotpCommand{UserId: userID}); err != nil {
+		return response.Error(500, "Failed to reset two-factor authentication", err)
+	}
+
+	return response.Success("Two-factor authentication reset")
+}
+
+// PUT /api/org/totp
+func (hs *HTTPServer) UpdateOrgRequireTotp(c *models.ReqContext, form dtos.UpdateOrgRequireTotpForm) response.Response {
+	if !hs.Cfg.Totp.Enabled {
+		return hs.totpDisabledResponse()
+	}
+
+	cmd := models.UpdateOrgRequireTotpCommand{OrgId: c.OrgId, Required: form.Required}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return response.Error(500, "Failed to update organization", err)
+	}
+
+	return response.Success("Organization updated")
+}
+
+func getEnabledUserTotp(userID int64) (*models.UserTotp, response.Response) {
+	query := models.GetUserTotpQuery{UserId: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		if errors.Is(err, models.ErrUserTotpNotFound) {
+			return nil, response.Error(400, "Two-factor authentication not enabled", nil)
+		}
+		return nil, response.Error(500, "Failed to get two-factor authentication", err)
+	}
+	if !query.Result.Enabled {
+		return nil, response.Error(400, "Two-factor authentication not enabled", nil)
+	}
+
+	return query.Result, nil
+}
+
+func getUserWithSalt(userID int64) (*models.User, error) {
+	query := models.GetUserByIdQuery{Id: userID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+func totpErrorResponse(err error) response.Response {
+	if errors.Is(err, models.ErrUserTotpCodeInvalid) || errors.Is(err, models.ErrUserTotpCodeUsed) {
+		return response.Error(401, err.Error(), nil)
+	}
+	return response.Error(500, "Failed to validate two-factor authentication code", err)
+}
+
+// validateTotpCode checks a code of the enabled authenticator of the user and records its time step.
+func validateTotpCode(userTotp *models.UserTotp, code string) error {
+	step, err := checkTotpCode(userTotp, code)
+	if err != nil {
+		return err
+	}
+
+	if err := bus.Dispatch(&models.UseUserTotpStepCommand{UserId: userTotp.UserId, Step: step}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkTotpCode(userTotp *models.UserTotp, code string) (int64, error) {
+	secret, err := encryption.Decrypt(userTotp.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok {
+		return 0, models.ErrUserTotpCodeInvalid
+	}
+
+	return step, nil
+}
+
+// enableTotp checks a code of the authenticator being enrolled and enables it, returning new recovery codes.
+func enableTotp(userTotp *models.UserTotp, userID int64, code string) ([]string, error) {
+	step, err := checkTotpCode(userTotp, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := getUserWithSalt(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateTotpRecoveryCodes(user.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bus.Dispatch(&models.EnableUserTotpCommand{UserId: userID, RecoveryCodes: hashes, Step: step}); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateTotpRecoveryCodes returns new recovery codes and the JSON array of their hashes.
+func generateTotpRecoveryCodes(salt string) ([]string, string, error) {
+	codes := make([]string, 0, totpRecoveryCodes)
+	hashes := make([]string, 0, totpRecoveryCodes)
+	for i := 0; i < totpRecoveryCodes; i++ {
+		code, err := util.GetRandomString(10, []byte("abcdefghijkmnpqrstuvwxyz23456789")...)
+		if err != nil {
+			return nil, "", err
+		}
+		hash, err := util.EncodePassword(code, salt)
+		if err != nil {
+			return nil, "", err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	encoded, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return codes, string(encoded), nil
+}
+
+func totpRecoveryCodeHashes(userTotp *models.UserTotp) ([]string, error) {
+	var hashes []string
+	if userTotp.RecoveryCodes == "" {
+		return hashes, nil
+	}
+	err := json.Unmarshal([]byte(userTotp.RecoveryCodes), &hashes)
+	return hashes, err
+}
+
+// useTotpRecoveryCode checks a recovery code of the user and removes it from the unused ones.
+func useTotpRecoveryCode(userTotp *models.UserTotp, user *models.User, code string) error {
+	hashes, err := totpRecoveryCodeHashes(userTotp)
+	if err != nil {
+		return err
+	}
+
+	hash, err := util.EncodePassword(strings.ToLower(strings.TrimSpace(code)), user.Salt)
+	if err != nil {
+		return err
+	}
+
+	for i, h := range hashes {
+		if h != hash {
+			continue
+		}
+
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		encoded, err := json.Marshal(remaining)
+		if err != nil {
+			return err
+		}
+		return bus.Dispatch(&models.UpdateUserTotpRecoveryCodesCommand{
+			UserId:                user.Id,
+			RecoveryCodes:         string(encoded),
+			PreviousRecoveryCodes: userTotp.RecoveryCodes,
+		})
+	}
+
+	return models.ErrUserTotpCodeInvalid
+}
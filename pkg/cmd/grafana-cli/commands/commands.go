@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/datamigrations"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/secretsmigrations"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
@@ -15,6 +16,16 @@ import (
 )
 
 func runDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore.SQLStore) error) func(context *cli.Context) error {
+	return newDbCommand(command, false)
+}
+
+// runReadOnlyDbCommand is like runDbCommand but doesn't run database migrations nor create the main
+// organization and admin user, for commands which must not change the database.
+func runReadOnlyDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore.SQLStore) error) func(context *cli.Context) error {
+	return newDbCommand(command, true)
+}
+
+func newDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore.SQLStore) error, readOnly bool) func(context *cli.Context) error {
 	return func(context *cli.Context) error {
 		cmd := &utils.ContextCommandLine{Context: context}
 		debug := cmd.Bool("debug")
@@ -34,9 +45,16 @@ func runDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore
 			cfg.LogConfigSources()
 		}
 
+		if readOnly {
+			cfg.Raw.Section("database").Key("skip_migrations").SetValue("true")
+		}
+
 		engine := &sqlstore.SQLStore{}
 		engine.Cfg = cfg
 		engine.Bus = bus.GetBus()
+		if readOnly {
+			engine.SkipEnsureDefaultOrgAndUser()
+		}
 		if err := engine.Init(); err != nil {
 			return errutil.Wrap("failed to initialize SQL engine", err)
 		}
@@ -130,6 +148,35 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:  "secrets",
+		Usage: "Secrets commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "rotate-key",
+				Usage:  "rotate-key <new secret_key (optional)>. Re-encrypts all secrets in the database with a new data key in a transaction. If a new secret_key is given, the data keys are re-encrypted with it.",
+				Action: runDbCommand(secretsmigrations.RotateSecretsKey),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "secret-key-from-stdin",
+						Usage: "Read the new secret_key from stdin",
+						Value: false,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:  "provisioning",
+		Usage: "Provisioning commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "validate",
+				Usage:  "validate <provisioning directory (optional)>. Validates the provisioning files and prints the changes provisioning them would make, without changing the database.",
+				Action: runReadOnlyDbCommand(validateProvisioningCommand),
+			},
+		},
+	},
 }
 
 var Commands = []*cli.Command{
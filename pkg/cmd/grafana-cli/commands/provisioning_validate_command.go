@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	librarypanelservice "github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/provisioning/access"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/librarypanels"
+	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
+	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	provisioningutils "github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+
+	// register the alert notifiers, provisioned notifiers are validated against them
+	_ "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+type provisioningPlanner struct {
+	name string
+	plan func(path string) ([]*provisioningutils.PlanItem, error)
+}
+
+// validateProvisioningCommand validates the provisioning files in a directory and prints
+// the changes provisioning them would make to the database.
+func validateProvisioningCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	dir := c.Args().First()
+	if dir == "" {
+		dir = sqlStore.Cfg.ProvisioningPath
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+
+	logger.Infof("Validating provisioning files in %s\n\n", dir)
+
+	installedPlugins := findInstalledPlugins(sqlStore.Cfg)
+	isPluginInstalled := func(pluginType, pluginID string) bool {
+		return installedPlugins[pluginType][pluginID]
+	}
+
+	planners := []provisioningPlanner{
+		{name: "access", plan: access.Plan},
+		{
+			name: "datasources",
+			plan: func(path string) ([]*provisioningutils.PlanItem, error) {
+				return datasources.Plan(path, isPluginInstalled)
+			},
+		},
+		{
+			name: "plugins",
+			plan: func(path string) ([]*provisioningutils.PlanItem, error) {
+				return plugins.Plan(path, isPluginInstalled)
+			},
+		},
+		{name: "notifiers", plan: notifiers.Plan},
+		{name: "dashboards", plan: dashboards.Plan},
+	}
+	if sqlStore.Cfg.IsNgAlertEnabled() {
+		planners = append(planners, provisioningPlanner{
+			name: "alerting",
+			plan: func(path string) ([]*provisioningutils.PlanItem, error) {
+				return alerting.Plan(path, store.DBstore{SQLStore: sqlStore})
+			},
+		})
+	}
+
+	if sqlStore.Cfg.IsPanelLibraryEnabled() {
+		planners = append(planners, provisioningPlanner{
+			name: "librarypanels",
+			plan: func(path string) ([]*provisioningutils.PlanItem, error) {
+				return librarypanels.Plan(path, &librarypanelservice.LibraryPanelService{Cfg: sqlStore.Cfg, SQLStore: sqlStore})
+			},
+		})
+	}
+
+	counts := map[provisioningutils.PlanAction]int{}
+	failed := 0
+	for _, planner := range planners {
+		items, err := planner.plan(filepath.Join(dir, planner.name))
+		if err != nil {
+			failed++
+			logger.Errorf("%s %s\n%s\n\n", color.RedString("✗"), planner.name, err)
+			continue
+		}
+
+		logger.Infof("%s %s\n", color.GreenString("✔"), planner.name)
+		for _, item := range items {
+			counts[item.Action]++
+			if item.Action == provisioningutils.PlanUnchanged {
+				logger.Debugf("  %s\n", item)
+				continue
+			}
+			logger.Infof("  %s\n", planItemColor(item.Action)(item.String()))
+		}
+		logger.Info("\n")
+	}
+
+	logger.Infof("Plan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		counts[provisioningutils.PlanCreate], counts[provisioningutils.PlanUpdate],
+		counts[provisioningutils.PlanDelete], counts[provisioningutils.PlanUnchanged])
+
+	if failed > 0 {
+		return fmt.Errorf("provisioning files of %d types are invalid", failed)
+	}
+	return nil
+}
+
+func planItemColor(action provisioningutils.PlanAction) func(format string, a ...interface{}) string {
+	switch action {
+	case provisioningutils.PlanCreate:
+		return color.GreenString
+	case provisioningutils.PlanDelete:
+		return color.RedString
+	default:
+		return color.YellowString
+	}
+}
+
+// findInstalledPlugins returns the ids of the core, bundled and installed plugins by type,
+// read from their plugin.json files.
+func findInstalledPlugins(cfg *setting.Cfg) map[string]map[string]bool {
+	installed := map[string]map[string]bool{}
+	dirs := []string{
+		filepath.Join(setting.StaticRootPath, "app", "plugins"),
+		cfg.BundledPluginsPath,
+		cfg.PluginsPath,
+	}
+
+	for _, dir := range dirs {
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || info.Name() != "plugin.json" {
+				return nil
+			}
+
+			// nolint:gosec
+			// We can ignore the gosec G304 warning on this one because `path` is within the plugin directories.
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+
+			var plugin struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(data, &plugin); err != nil || plugin.ID == "" {
+				return nil
+			}
+
+			if installed[plugin.Type] == nil {
+				installed[plugin.Type] = map[string]bool{}
+			}
+			installed[plugin.Type][plugin.ID] = true
+			return nil
+		})
+	}
+
+	return installed
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/passwordpolicy"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -46,6 +47,14 @@ func resetPasswordCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) erro
 		return fmt.Errorf("could not read user from database. Error: %v", err)
 	}
 
+	policy := &passwordpolicy.PasswordPolicyService{Cfg: sqlStore.Cfg}
+	if err := policy.Init(); err != nil {
+		return errutil.Wrapf(err, "failed to load password policy")
+	}
+	if err := policy.Validate(newPassword, userQuery.Result); err != nil {
+		return errutil.Wrapf(err, "new password is not valid")
+	}
+
 	passwordHashed, err := util.EncodePassword(newPassword, userQuery.Result.Salt)
 	if err != nil {
 		return err
@@ -0,0 +1,229 @@
+package secretsmigrations
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/encryption"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// secretColumn is a column with secrets
+type secretColumn struct {
+	table  string
+	column string
+	// description of the secrets in the column, for the summary
+	description string
+	reencrypt   func(r *keyRotator, value []byte) (interface{}, error)
+}
+
+var secretColumns = []secretColumn{
+	{table: "data_source", column: "secure_json_data", description: "data sources", reencrypt: reencryptJSONMap},
+	{table: "alert_notification", column: "secure_settings", description: "alert notification channels", reencrypt: reencryptJSONMap},
+	{table: "plugin_setting", column: "secure_json_data", description: "plugin settings", reencrypt: reencryptJSONMap},
+	{table: "user_auth", column: "o_auth_access_token", description: "OAuth access tokens", reencrypt: reencryptBase64},
+	{table: "user_auth", column: "o_auth_refresh_token", description: "OAuth refresh tokens", reencrypt: reencryptBase64},
+	{table: "user_auth", column: "o_auth_token_type", description: "OAuth token types", reencrypt: reencryptBase64},
+	{table: "dashboard_snapshot", column: "dashboard_encrypted", description: "dashboard snapshots", reencrypt: reencryptBytes},
+	{table: "user_totp", column: "secret", description: "two-factor authentication secrets", reencrypt: reencryptBytes},
+}
+
+// keyRotator decrypts secrets with the existing data keys, or the secret_key,
+// and encrypts them with a new data key
+type keyRotator struct {
+	dataKeys map[string][]byte
+	name     string
+	key      []byte
+}
+
+// RotateSecretsKey creates a new data key and re-encrypts all the secrets stored in the
+// database with it, in a transaction. If a new secret_key is given, all data keys are
+// re-encrypted with it, so it can replace the secret_key of the configuration.
+func RotateSecretsKey(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	newSecretKey := c.Args().First()
+	if c.Bool("secret-key-from-stdin") {
+		logger.Infof("New secret_key: ")
+
+		scanner := bufio.NewScanner(os.Stdin)
+		if ok := scanner.Scan(); !ok {
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("can't read secret_key from stdin: %w", err)
+			}
+			return fmt.Errorf("can't read secret_key from stdin")
+		}
+		newSecretKey = scanner.Text()
+	}
+
+	secretKey := setting.SecretKey
+	if newSecretKey != "" {
+		secretKey = newSecretKey
+	}
+
+	rotated := make([]int, len(secretColumns))
+	var dataKeysCount int
+	err := sqlStore.WithTransactionalDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		var dataKeys []*encryption.DataKey
+		if err := session.Find(&dataKeys); err != nil {
+			return errutil.Wrap("failed to read data keys", err)
+		}
+		dataKeysCount = len(dataKeys)
+
+		r := &keyRotator{dataKeys: make(map[string][]byte, len(dataKeys))}
+		for _, dataKey := range dataKeys {
+			key, err := encryption.DecryptDataKey(dataKey, setting.SecretKey)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to decrypt data key %q", dataKey.Name)
+			}
+			r.dataKeys[dataKey.Name] = key
+
+			if newSecretKey == "" {
+				continue
+			}
+
+			dataKey.EncryptedKey, err = util.Encrypt(key, newSecretKey)
+			if err != nil {
+				return err
+			}
+			dataKey.Updated = time.Now()
+			if _, err := session.ID(dataKey.Id).Cols("encrypted_key", "updated").Update(dataKey); err != nil {
+				return errutil.Wrapf(err, "failed to update data key %q", dataKey.Name)
+			}
+		}
+
+		newDataKey, key, err := encryption.NewDataKey(secretKey)
+		if err != nil {
+			return err
+		}
+		if err := sqlstore.CreateDataKeyInSession(session, newDataKey); err != nil {
+			return errutil.Wrap("failed to create data key", err)
+		}
+		r.name, r.key = newDataKey.Name, key
+
+		for i, col := range secretColumns {
+			rotated[i], err = r.rotateColumn(session, col)
+			if err != nil {
+				return errutil.Wrapf(err, "failed to re-encrypt %s.%s", col.table, col.column)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// clear the data keys cached by this process
+	encryption.SetKeyStore(sqlStore)
+
+	logger.Info("\n")
+	logger.Infof("%s Created a new data key\n", color.GreenString("✔"))
+	for i, col := range secretColumns {
+		if rotated[i] > 0 {
+			logger.Infof("%s Re-encrypted %d %s\n", color.GreenString("✔"), rotated[i], col.description)
+		}
+	}
+
+	if newSecretKey != "" {
+		logger.Infof("%s Re-encrypted %d data keys with the new secret_key\n", color.GreenString("✔"), dataKeysCount)
+		logger.Info("\n")
+		logger.Warn("Warning: Set secret_key to the new key in the configuration of all Grafana servers " +
+			"using this database and restart them, secrets can't be decrypted with the old secret_key anymore")
+		return nil
+	}
+
+	logger.Info("\n")
+	logger.Warn("Warning: Restart all Grafana servers using this database to encrypt new secrets with the new data key")
+	return nil
+}
+
+func (r *keyRotator) rotateColumn(session *sqlstore.DBSession, col secretColumn) (int, error) {
+	var rows []map[string][]byte
+
+	session.Cols("id", col.column)
+	session.Table(col.table)
+	session.Where(col.column + " IS NOT NULL")
+	if err := session.Find(&rows); err != nil {
+		return 0, err
+	}
+
+	var rowsUpdated int
+	for _, row := range rows {
+		if len(row[col.column]) == 0 {
+			continue
+		}
+
+		value, err := col.reencrypt(r, row[col.column])
+		if err != nil {
+			return 0, errutil.Wrapf(err, "row with id %s", string(row["id"]))
+		}
+
+		session.Table(col.table)
+		session.Where("id = ?", string(row["id"]))
+		session.Cols(col.column)
+		if _, err := session.Update(map[string]interface{}{col.column: value}); err != nil {
+			return 0, err
+		}
+
+		rowsUpdated++
+	}
+
+	return rowsUpdated, nil
+}
+
+func (r *keyRotator) reencrypt(payload []byte) ([]byte, error) {
+	decrypted, err := encryption.DecryptWithDataKeys(payload, r.dataKeys, setting.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.EncryptWithDataKey(decrypted, r.name, r.key)
+}
+
+// reencryptJSONMap re-encrypts the values of secure json data
+func reencryptJSONMap(r *keyRotator, value []byte) (interface{}, error) {
+	var secureJSONData map[string][]byte
+	if err := json.Unmarshal(value, &secureJSONData); err != nil {
+		return nil, err
+	}
+
+	for k, v := range secureJSONData {
+		encrypted, err := r.reencrypt(v)
+		if err != nil {
+			return nil, errutil.Wrapf(err, "key %q", k)
+		}
+		secureJSONData[k] = encrypted
+	}
+
+	return json.Marshal(secureJSONData)
+}
+
+// reencryptBase64 re-encrypts base64 encoded secrets
+func reencryptBase64(r *keyRotator, value []byte) (interface{}, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(value))
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := r.reencrypt(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func reencryptBytes(r *keyRotator, value []byte) (interface{}, error) {
+	return r.reencrypt(value)
+}
@@ -0,0 +1,135 @@
+package secretsmigrations
+
+import (
+	"encoding/base64"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/securedata"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/infra/encryption"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func newCliContext(t *testing.T, args ...string) *utils.ContextCommandLine {
+	t.Helper()
+
+	flagSet := flag.NewFlagSet("Test", 0)
+	require.NoError(t, flagSet.Parse(args))
+	return &utils.ContextCommandLine{Context: cli.NewContext(&cli.App{Name: "Test"}, flagSet, nil)}
+}
+
+func TestRotateSecretsKeyCommand(t *testing.T) {
+	secretKey := setting.SecretKey
+	setting.SecretKey = "old_secret_key"
+	t.Cleanup(func() { setting.SecretKey = secretKey })
+
+	store := sqlstore.InitTestDB(t)
+	session := store.NewSession()
+	defer session.Close()
+
+	// a secret encrypted directly with the secret_key, before data keys existed
+	legacyEncrypted, err := util.Encrypt([]byte("legacy"), "old_secret_key")
+	require.NoError(t, err)
+
+	datasources := []*models.DataSource{
+		{Type: "prometheus", Name: "envelope", Uid: "envelope", SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"password": "envelope"})},
+		{Type: "prometheus", Name: "legacy", Uid: "legacy", SecureJsonData: securejsondata.SecureJsonData{"password": legacyEncrypted}},
+		{Type: "prometheus", Name: "none", Uid: "none"},
+	}
+	for _, ds := range datasources {
+		ds.Created = time.Now()
+		ds.Updated = time.Now()
+	}
+	_, err = session.Insert(&datasources)
+	require.NoError(t, err)
+
+	token, err := encryption.Encrypt([]byte("access-token"))
+	require.NoError(t, err)
+	_, err = session.Insert(&models.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth", AuthId: "1",
+		OAuthAccessToken: base64.StdEncoding.EncodeToString(token), Created: time.Now()})
+	require.NoError(t, err)
+
+	dashboard, err := securedata.Encrypt([]byte(`{"title":"snapshot"}`))
+	require.NoError(t, err)
+	_, err = session.Insert(&models.DashboardSnapshot{Key: "key", DeleteKey: "delete", Name: "snapshot",
+		DashboardEncrypted: dashboard, Expires: time.Now(), Created: time.Now(), Updated: time.Now()})
+	require.NoError(t, err)
+
+	assertSecrets := func(t *testing.T) {
+		t.Helper()
+
+		var dss []*models.DataSource
+		require.NoError(t, session.SQL("select * from data_source").Find(&dss))
+		require.Len(t, dss, 3)
+		for _, ds := range dss {
+			decrypted := ds.SecureJsonData.Decrypt()
+			switch ds.Name {
+			case "envelope", "legacy":
+				assert.Equal(t, ds.Name, decrypted["password"])
+				assert.Equal(t, byte('#'), ds.SecureJsonData["password"][0], "expected secret to be encrypted with a data key")
+			default:
+				assert.Empty(t, decrypted)
+			}
+		}
+
+		var userAuth models.UserAuth
+		_, err := session.Table("user_auth").Get(&userAuth)
+		require.NoError(t, err)
+		decoded, err := base64.StdEncoding.DecodeString(userAuth.OAuthAccessToken)
+		require.NoError(t, err)
+		decrypted, err := encryption.Decrypt(decoded)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", string(decrypted))
+
+		var snapshot models.DashboardSnapshot
+		_, err = session.Table("dashboard_snapshot").Get(&snapshot)
+		require.NoError(t, err)
+		json, err := snapshot.DashboardJSON()
+		require.NoError(t, err)
+		assert.Equal(t, "snapshot", json.Get("title").MustString())
+	}
+
+	t.Run("Re-encrypts secrets with a new data key", func(t *testing.T) {
+		active, err := store.GetActiveDataKey()
+		require.NoError(t, err)
+
+		require.NoError(t, RotateSecretsKey(newCliContext(t), store))
+
+		rotated, err := store.GetActiveDataKey()
+		require.NoError(t, err)
+		assert.NotEqual(t, active.Name, rotated.Name)
+
+		var dss []*models.DataSource
+		require.NoError(t, session.SQL("select * from data_source where name = 'envelope'").Find(&dss))
+		require.Len(t, dss, 1)
+		assert.Contains(t, string(dss[0].SecureJsonData["password"]), "#"+rotated.Name+"#")
+
+		assertSecrets(t)
+	})
+
+	t.Run("Re-encrypts data keys with a new secret_key", func(t *testing.T) {
+		require.NoError(t, RotateSecretsKey(newCliContext(t, "new_secret_key"), store))
+
+		var dataKeys []*encryption.DataKey
+		require.NoError(t, session.Find(&dataKeys))
+		require.Len(t, dataKeys, 3)
+		for _, dataKey := range dataKeys {
+			_, err := encryption.DecryptDataKey(dataKey, "new_secret_key")
+			require.NoError(t, err)
+		}
+
+		setting.SecretKey = "new_secret_key"
+		encryption.SetKeyStore(store)
+		assertSecrets(t)
+	})
+}
@@ -1,16 +1,15 @@
 package securedata
 
 import (
-	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/infra/encryption"
 )
 
 type SecureData []byte
 
 func Encrypt(data []byte) (SecureData, error) {
-	return util.Encrypt(data, setting.SecretKey)
+	return encryption.Encrypt(data)
 }
 
 func (s SecureData) Decrypt() ([]byte, error) {
-	return util.Decrypt(s, setting.SecretKey)
+	return encryption.Decrypt(s)
 }
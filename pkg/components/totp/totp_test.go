@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 secret of the test vectors of RFC 6238, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// the last 6 digits of the 8 digits codes of RFC 6238
+	for unix, expected := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok := Validate(rfcSecret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(rfcSecret, "050 471", now.Add(Period))
+	assert.True(t, ok, "codes of the previous step should be accepted")
+
+	_, ok = Validate(rfcSecret, "050471", now.Add(3*Period))
+	assert.False(t, ok, "old codes should be refused")
+
+	_, ok = Validate(rfcSecret, "000000", now)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "", now)
+	assert.False(t, ok)
+
+	_, ok = Validate("not base32!", "050471", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, err = Code(secret, 1)
+	require.NoError(t, err)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI(rfcSecret, "Grafana", "admin@example.com")
+	assert.Equal(t, "otpauth://totp/Grafana:admin@example.com?algorithm=SHA1&digits=6&issuer=Grafana&period=30&secret="+rfcSecret, uri)
+}
@@ -19,6 +19,11 @@ type OrgUpdated struct {
 	Name      string    `json:"name"`
 }
 
+type OrgDeleted struct {
+	Timestamp time.Time `json:"timestamp"`
+	Id        int64     `json:"id"`
+}
+
 type UserCreated struct {
 	Timestamp time.Time `json:"timestamp"`
 	Id        int64     `json:"id"`
@@ -0,0 +1,319 @@
+// Package encryption encrypts the secrets stored by Grafana with envelope encryption.
+//
+// Secrets are encrypted with data keys, which are stored in the database encrypted with the
+// secret_key of the configuration. Changing the secret_key then only requires re-encrypting the
+// data keys, and the data key used to encrypt new secrets can be rotated.
+//
+// Values encrypted before data keys were introduced are encrypted directly with the secret_key,
+// and can still be decrypted.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// envelopePrefix starts and ends the name of the data key that encrypted a value.
+	// It's never the first character of values encrypted directly with the secret_key,
+	// which start with an alphanumeric salt.
+	envelopePrefix = '#'
+
+	dataKeyLength     = 32
+	dataKeyNameLength = 16
+)
+
+var (
+	// ErrDataKeyNotFound is returned if a data key doesn't exist
+	ErrDataKeyNotFound = errors.New("data key not found")
+
+	mu       sync.RWMutex
+	keyStore KeyStore
+	active   *activeDataKey
+	dataKeys = map[string][]byte{}
+)
+
+// DataKey is a key used to encrypt secrets. Data keys are stored encrypted with the secret_key,
+// and their ids increase with every new key, so the id is the version of the key.
+type DataKey struct {
+	Id           int64
+	Name         string
+	Active       bool
+	EncryptedKey []byte
+	Created      time.Time
+	Updated      time.Time
+}
+
+// KeyStore stores the data keys.
+type KeyStore interface {
+	// GetDataKey returns the data key with the name, or ErrDataKeyNotFound.
+	GetDataKey(name string) (*DataKey, error)
+	// GetActiveDataKey returns the latest active data key, or ErrDataKeyNotFound.
+	GetActiveDataKey() (*DataKey, error)
+	// CreateDataKey stores a new active data key, deactivating the other data keys.
+	CreateDataKey(key *DataKey) error
+}
+
+type activeDataKey struct {
+	name string
+	key  []byte
+}
+
+// SetKeyStore sets the store of the data keys used to encrypt and decrypt secrets,
+// and clears the data keys cached from the previous store.
+// Until a key store is set, secrets are encrypted directly with the secret_key.
+func SetKeyStore(store KeyStore) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	keyStore = store
+	active = nil
+	dataKeys = map[string][]byte{}
+}
+
+// EnsureActiveDataKey creates an active data key if the key store doesn't have one yet.
+// It's used at startup, so secrets can be encrypted without writing to the database.
+func EnsureActiveDataKey() error {
+	_, _, err := activeKey()
+	return err
+}
+
+// Encrypt encrypts a payload with the active data key.
+func Encrypt(payload []byte) ([]byte, error) {
+	mu.RLock()
+	store := keyStore
+	mu.RUnlock()
+
+	if store == nil {
+		return util.Encrypt(payload, setting.SecretKey)
+	}
+
+	name, key, err := activeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return EncryptWithDataKey(payload, name, key)
+}
+
+// Decrypt decrypts a payload encrypted by Encrypt, or encrypted directly with the secret_key.
+func Decrypt(payload []byte) ([]byte, error) {
+	return decrypt(payload, dataKey, setting.SecretKey)
+}
+
+// NewDataKey returns a new random data key, encrypted with secretKey, and its plain key.
+func NewDataKey(secretKey string) (*DataKey, []byte, error) {
+	key := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+
+	name, err := util.GetRandomString(dataKeyNameLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := util.Encrypt(key, secretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	return &DataKey{
+		Name:         name,
+		Active:       true,
+		EncryptedKey: encryptedKey,
+		Created:      now,
+		Updated:      now,
+	}, key, nil
+}
+
+// DecryptDataKey returns the plain key of a data key encrypted with secretKey.
+func DecryptDataKey(dataKey *DataKey, secretKey string) ([]byte, error) {
+	key, err := util.Decrypt(dataKey.EncryptedKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != dataKeyLength {
+		return nil, fmt.Errorf("invalid data key %q", dataKey.Name)
+	}
+
+	return key, nil
+}
+
+// EncryptWithDataKey encrypts a payload with the data key with the name.
+func EncryptWithDataKey(payload []byte, name string, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, 0, len(name)+2+len(nonce)+len(payload)+gcm.Overhead())
+	encrypted = append(encrypted, envelopePrefix)
+	encrypted = append(encrypted, name...)
+	encrypted = append(encrypted, envelopePrefix)
+	encrypted = append(encrypted, nonce...)
+	return gcm.Seal(encrypted, nonce, payload, nil), nil
+}
+
+// DecryptWithDataKeys decrypts a payload with the plain data keys by name,
+// or with the secretKey if the payload wasn't encrypted with a data key.
+func DecryptWithDataKeys(payload []byte, keys map[string][]byte, secretKey string) ([]byte, error) {
+	return decrypt(payload, func(name string) ([]byte, error) {
+		key, ok := keys[name]
+		if !ok {
+			return nil, ErrDataKeyNotFound
+		}
+		return key, nil
+	}, secretKey)
+}
+
+func decrypt(payload []byte, getKey func(name string) ([]byte, error), secretKey string) ([]byte, error) {
+	name, encrypted, ok := parseEnvelope(payload)
+	if !ok {
+		return util.Decrypt(payload, secretKey)
+	}
+
+	key, err := getKey(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key %q: %w", name, err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, errors.New("payload too short")
+	}
+
+	decrypted, err := gcm.Open(nil, encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():], nil)
+	if err != nil {
+		// data keys decrypted with a wrong secret_key fail here
+		return nil, fmt.Errorf("failed to decrypt with data key %q: %w", name, err)
+	}
+
+	return decrypted, nil
+}
+
+func parseEnvelope(payload []byte) (string, []byte, bool) {
+	if len(payload) == 0 || payload[0] != envelopePrefix {
+		return "", nil, false
+	}
+
+	end := bytes.IndexByte(payload[1:], envelopePrefix)
+	if end < 0 {
+		return "", nil, false
+	}
+
+	return string(payload[1 : end+1]), payload[end+2:], true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// activeKey returns the active data key, creating one if the key store doesn't have any.
+// The active data key is cached until the key store is set again.
+func activeKey() (string, []byte, error) {
+	mu.RLock()
+	if active != nil {
+		defer mu.RUnlock()
+		return active.name, active.key, nil
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if active != nil {
+		return active.name, active.key, nil
+	}
+
+	if keyStore == nil {
+		return "", nil, errors.New("no data key store")
+	}
+
+	dataKey, err := keyStore.GetActiveDataKey()
+	if err != nil && !errors.Is(err, ErrDataKeyNotFound) {
+		return "", nil, err
+	}
+
+	var key []byte
+	if errors.Is(err, ErrDataKeyNotFound) {
+		dataKey, key, err = NewDataKey(setting.SecretKey)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if err := keyStore.CreateDataKey(dataKey); err != nil {
+			return "", nil, err
+		}
+	} else {
+		key, err = DecryptDataKey(dataKey, setting.SecretKey)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	active = &activeDataKey{name: dataKey.Name, key: key}
+	dataKeys[dataKey.Name] = key
+
+	return active.name, active.key, nil
+}
+
+// dataKey returns the plain data key with the name, cached once it's decrypted.
+func dataKey(name string) ([]byte, error) {
+	mu.RLock()
+	key, ok := dataKeys[name]
+	store := keyStore
+	mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if store == nil {
+		return nil, ErrDataKeyNotFound
+	}
+
+	dataKey, err := store.GetDataKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = DecryptDataKey(dataKey, setting.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if store == keyStore {
+		dataKeys[name] = key
+	}
+
+	return key, nil
+}
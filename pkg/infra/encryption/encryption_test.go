@@ -0,0 +1,157 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type fakeKeyStore struct {
+	keys    []*DataKey
+	created int
+	reads   int
+}
+
+func (f *fakeKeyStore) GetDataKey(name string) (*DataKey, error) {
+	f.reads++
+	for _, key := range f.keys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+	return nil, ErrDataKeyNotFound
+}
+
+func (f *fakeKeyStore) GetActiveDataKey() (*DataKey, error) {
+	for i := len(f.keys) - 1; i >= 0; i-- {
+		if f.keys[i].Active {
+			return f.keys[i], nil
+		}
+	}
+	return nil, ErrDataKeyNotFound
+}
+
+func (f *fakeKeyStore) CreateDataKey(key *DataKey) error {
+	for _, k := range f.keys {
+		k.Active = false
+	}
+	f.created++
+	key.Id = int64(len(f.keys) + 1)
+	f.keys = append(f.keys, key)
+	return nil
+}
+
+func TestEncryption(t *testing.T) {
+	secretKey := setting.SecretKey
+	setting.SecretKey = "secret_key"
+	t.Cleanup(func() {
+		setting.SecretKey = secretKey
+		SetKeyStore(nil)
+	})
+
+	t.Run("Without key store, payloads are encrypted with the secret_key", func(t *testing.T) {
+		SetKeyStore(nil)
+
+		encrypted, err := Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+
+		decrypted, err := util.Decrypt(encrypted, "secret_key")
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+	})
+
+	t.Run("With key store, payloads are encrypted with the active data key", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		SetKeyStore(store)
+
+		encrypted, err := Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		require.Len(t, store.keys, 1)
+		assert.Equal(t, "#"+store.keys[0].Name+"#", string(encrypted[:len(store.keys[0].Name)+2]))
+
+		decrypted, err := Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+
+		_, err = Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.created)
+
+		// the data key is read from the store once the cache is cleared
+		SetKeyStore(store)
+		decrypted, err = Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+		assert.Equal(t, 1, store.reads)
+	})
+
+	t.Run("Payloads encrypted with the secret_key can be decrypted", func(t *testing.T) {
+		SetKeyStore(&fakeKeyStore{})
+
+		encrypted, err := util.Encrypt([]byte("grafana"), "secret_key")
+		require.NoError(t, err)
+
+		decrypted, err := Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+	})
+
+	t.Run("Payloads encrypted with old data keys can be decrypted", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		SetKeyStore(store)
+
+		encrypted, err := Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+
+		dataKey, _, err := NewDataKey("secret_key")
+		require.NoError(t, err)
+		require.NoError(t, store.CreateDataKey(dataKey))
+		SetKeyStore(store)
+
+		reencrypted, err := Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+		assert.Contains(t, string(reencrypted), dataKey.Name)
+
+		decrypted, err := Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+	})
+
+	t.Run("Payloads can't be decrypted with a data key encrypted with another secret_key", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		SetKeyStore(store)
+
+		encrypted, err := Encrypt([]byte("grafana"))
+		require.NoError(t, err)
+
+		SetKeyStore(store)
+		setting.SecretKey = "another_secret_key"
+		t.Cleanup(func() { setting.SecretKey = "secret_key" })
+
+		_, err = Decrypt(encrypted)
+		require.Error(t, err)
+	})
+
+	t.Run("Payloads can be decrypted with given data keys", func(t *testing.T) {
+		dataKey, key, err := NewDataKey("secret_key")
+		require.NoError(t, err)
+
+		decryptedKey, err := DecryptDataKey(dataKey, "secret_key")
+		require.NoError(t, err)
+		assert.Equal(t, key, decryptedKey)
+
+		encrypted, err := EncryptWithDataKey([]byte("grafana"), dataKey.Name, key)
+		require.NoError(t, err)
+
+		decrypted, err := DecryptWithDataKeys(encrypted, map[string][]byte{dataKey.Name: key}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "grafana", string(decrypted))
+
+		_, err = DecryptWithDataKeys(encrypted, map[string][]byte{}, "")
+		require.ErrorIs(t, err, ErrDataKeyNotFound)
+	})
+}
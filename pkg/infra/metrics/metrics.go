@@ -111,6 +111,12 @@ var (
 	// MDataSourceProxyReqTimer is a metric summary for dataproxy request duration
 	MDataSourceProxyReqTimer prometheus.Summary
 
+	// MDataSourceQueryCacheTotal is a metric counter for the lookups in the query result cache, labeled by status
+	MDataSourceQueryCacheTotal *prometheus.CounterVec
+
+	// MApiRateLimitedTotal is a metric counter for the API requests refused by the rate limits, labeled by route group
+	MApiRateLimitedTotal *prometheus.CounterVec
+
 	// MAlertingExecutionTime is a metric summary of alert execution duration
 	MAlertingExecutionTime prometheus.Summary
 
@@ -396,6 +402,20 @@ func init() {
 		Namespace:  ExporterName,
 	})
 
+	MDataSourceQueryCacheTotal = newCounterVecStartingAtZero(
+		prometheus.CounterOpts{
+			Name:      "datasource_query_cache_total",
+			Help:      "counter for the lookups in the query result cache by status (hit or miss)",
+			Namespace: ExporterName,
+		}, []string{"status"}, "hit", "miss")
+
+	MApiRateLimitedTotal = newCounterVecStartingAtZero(
+		prometheus.CounterOpts{
+			Name:      "api_rate_limited_total",
+			Help:      "counter for the API requests refused by the rate limits by route group",
+			Namespace: ExporterName,
+		}, []string{"group"}, "query", "search", "admin")
+
 	MAlertingExecutionTime = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:       "alerting_execution_time_milliseconds",
 		Help:       "summary of alert execution duration",
@@ -561,6 +581,8 @@ func initMetricVars() {
 		MApiDashboardGet,
 		MApiDashboardSearch,
 		MDataSourceProxyReqTimer,
+		MDataSourceQueryCacheTotal,
+		MApiRateLimitedTotal,
 		MAlertingExecutionTime,
 		MApiAdminUserCreate,
 		MApiLoginPost,
@@ -3,7 +3,9 @@ package network
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"regexp"
+	"strings"
 )
 
 var reIPv4AndPort = regexp.MustCompile(`^(\d+\.\d+\.\d+\.\d+):\d+$`)
@@ -42,3 +44,72 @@ func GetIPFromAddress(input string) (net.IP, error) {
 
 	return nil, err
 }
+
+// ParseCIDRs parses a list of IP addresses and CIDR ranges, an address standing for the range of itself.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// ClientIP returns the IP address of the client of a request, from the address of its peer. The
+// X-Forwarded-For and X-Real-IP headers, which clients can forge, are only read when the peer is a
+// trusted proxy: the client is then the last address of X-Forwarded-For which isn't a trusted proxy.
+func ClientIP(remoteAddr string, header http.Header, trustedProxies []*net.IPNet) (net.IP, error) {
+	ip, err := GetIPFromAddress(remoteAddr)
+	if err != nil || !containsIP(trustedProxies, ip) {
+		return ip, err
+	}
+
+	if forwardedFor := header.Values("X-Forwarded-For"); len(forwardedFor) > 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				// the hop wasn't added by a trusted proxy, the last trusted one is the client
+				return ip, nil
+			}
+			ip = hop
+			if !containsIP(trustedProxies, ip) {
+				return ip, nil
+			}
+		}
+		return ip, nil
+	}
+
+	if realIP := net.ParseIP(strings.TrimSpace(header.Get("X-Real-IP"))); realIP != nil {
+		return realIP, nil
+	}
+	return ip, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -80,3 +80,76 @@ func TestGetIPFromAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", " 192.168.1.1 ", "", "::1", "2001:db8::/32"})
+	require.NoError(t, err)
+	require.Len(t, nets, 4)
+	assert.Equal(t, "10.0.0.0/8", nets[0].String())
+	assert.Equal(t, "192.168.1.1/32", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+	assert.Equal(t, "2001:db8::/32", nets[3].String())
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = ParseCIDRs([]string{"proxy.local"})
+	require.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := ParseCIDRs([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		remoteAddr string
+		headers    map[string][]string
+		exp        string
+	}{
+		{
+			desc:       "Peer without forwarding headers",
+			remoteAddr: "203.0.113.7:51234",
+			exp:        "203.0.113.7",
+		},
+		{
+			desc:       "Forwarding headers of an untrusted peer are ignored",
+			remoteAddr: "203.0.113.7:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"192.168.1.1"}, "X-Real-Ip": {"192.168.1.1"}},
+			exp:        "203.0.113.7",
+		},
+		{
+			desc:       "X-Real-IP of a trusted proxy",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Real-Ip": {"192.168.1.1"}},
+			exp:        "192.168.1.1",
+		},
+		{
+			desc:       "Last untrusted hop of X-Forwarded-For",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 192.168.1.1", "10.4.5.6"}, "X-Real-Ip": {"1.2.3.4"}},
+			exp:        "192.168.1.1",
+		},
+		{
+			desc:       "First hop of X-Forwarded-For when all are trusted",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.7.8.9, 10.4.5.6"}},
+			exp:        "10.7.8.9",
+		},
+		{
+			desc:       "Invalid hop of X-Forwarded-For",
+			remoteAddr: "10.1.2.3:51234",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, unknown, 10.4.5.6"}},
+			exp:        "10.4.5.6",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ip, err := ClientIP(tc.remoteAddr, tc.headers, trustedProxies)
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, ip.String())
+		})
+	}
+
+	_, err = ClientIP("", nil, trustedProxies)
+	require.Error(t, err)
+}
@@ -123,6 +123,77 @@ func (dc *databaseCache) Set(key string, value interface{}, expire time.Duration
 	return err
 }
 
+// SetIfNotExists inserts the key, relying on the unique index of cache_key to fail if it exists. An expired
+// row is replaced, with an update matching only while it is still expired.
+func (dc *databaseCache) SetIfNotExists(key string, value interface{}, expire time.Duration) (bool, error) {
+	item := &cachedItem{Val: value}
+	data, err := encodeGob(item)
+	if err != nil {
+		return false, err
+	}
+
+	session := dc.SQLStore.NewSession()
+	defer session.Close()
+
+	var expiresInSeconds int64
+	if expire != 0 {
+		expiresInSeconds = int64(expire) / int64(time.Second)
+	}
+
+	now := getTime().Unix()
+	sql := `INSERT INTO cache_data (cache_key,data,created_at,expires) VALUES(?,?,?,?)`
+	_, err = session.Exec(sql, key, data, now, expiresInSeconds)
+	if err == nil {
+		return true, nil
+	}
+	if !dc.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+		return false, err
+	}
+
+	sql = `UPDATE cache_data SET data=?, created_at=?, expires=? WHERE cache_key=? AND expires <> 0 AND (? - created_at) >= expires`
+	res, err := session.Exec(sql, data, now, expiresInSeconds, key, now)
+	if err != nil {
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+// CompareAndSwap updates the unexpired row of the key only while its data is still old.
+func (dc *databaseCache) CompareAndSwap(key string, old, new interface{}, expire time.Duration) (bool, error) {
+	oldData, err := encodeGob(&cachedItem{Val: old})
+	if err != nil {
+		return false, err
+	}
+	newData, err := encodeGob(&cachedItem{Val: new})
+	if err != nil {
+		return false, err
+	}
+
+	session := dc.SQLStore.NewSession()
+	defer session.Close()
+
+	var expiresInSeconds int64
+	if expire != 0 {
+		expiresInSeconds = int64(expire) / int64(time.Second)
+	}
+
+	now := getTime().Unix()
+	sql := `UPDATE cache_data SET data=?, created_at=?, expires=? WHERE cache_key=? AND data=? AND (expires = 0 OR (? - created_at) < expires)`
+	res, err := session.Exec(sql, newData, now, expiresInSeconds, key, oldData, now)
+	if err != nil {
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
 func (dc *databaseCache) Delete(key string) error {
 	return dc.SQLStore.WithDbSession(context.Background(), func(session *sqlstore.DBSession) error {
 		sql := "DELETE FROM cache_data WHERE cache_key=?"
@@ -132,6 +203,16 @@ func (dc *databaseCache) Delete(key string) error {
 	})
 }
 
+func (dc *databaseCache) DeletePrefix(prefix string) error {
+	return dc.SQLStore.WithDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		// compare the start of the keys, instead of using LIKE which would need the wildcards of prefix escaped
+		sql := "DELETE FROM cache_data WHERE SUBSTR(cache_key, 1, ?) = ?"
+		_, err := session.Exec(sql, len(prefix), prefix)
+
+		return err
+	})
+}
+
 // CacheData is the struct representing the table in the database
 type CacheData struct {
 	CacheKey  string
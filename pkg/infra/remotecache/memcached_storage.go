@@ -1,6 +1,8 @@
 package remotecache
 
 import (
+	"bytes"
+	"errors"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -44,6 +46,62 @@ func (s *memcachedStorage) Set(key string, val interface{}, expires time.Duratio
 	return s.c.Set(memcachedItem)
 }
 
+// SetIfNotExists sets value to given key in the cache unless it already exists.
+func (s *memcachedStorage) SetIfNotExists(key string, val interface{}, expires time.Duration) (bool, error) {
+	item := &cachedItem{Val: val}
+	bytes, err := encodeGob(item)
+	if err != nil {
+		return false, err
+	}
+
+	var expiresInSeconds int64
+	if expires != 0 {
+		expiresInSeconds = int64(expires) / int64(time.Second)
+	}
+
+	err = s.c.Add(newItem(key, bytes, int32(expiresInSeconds)))
+	if errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CompareAndSwap replaces the value of the key in the cache if it is still old, with the CAS of memcached.
+func (s *memcachedStorage) CompareAndSwap(key string, old, new interface{}, expires time.Duration) (bool, error) {
+	oldBytes, err := encodeGob(&cachedItem{Val: old})
+	if err != nil {
+		return false, err
+	}
+	newBytes, err := encodeGob(&cachedItem{Val: new})
+	if err != nil {
+		return false, err
+	}
+
+	memcachedItem, err := s.c.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(memcachedItem.Value, oldBytes) {
+		return false, nil
+	}
+
+	var expiresInSeconds int64
+	if expires != 0 {
+		expiresInSeconds = int64(expires) / int64(time.Second)
+	}
+
+	memcachedItem.Value = newBytes
+	memcachedItem.Expiration = int32(expiresInSeconds)
+	err = s.c.CompareAndSwap(memcachedItem)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) || errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // Get gets value by given key in the cache.
 func (s *memcachedStorage) Get(key string) (interface{}, error) {
 	memcachedItem, err := s.c.Get(key)
@@ -69,3 +127,8 @@ func (s *memcachedStorage) Get(key string) (interface{}, error) {
 func (s *memcachedStorage) Delete(key string) error {
 	return s.c.Delete(key)
 }
+
+// DeletePrefix isn't supported since memcached can't list its keys
+func (s *memcachedStorage) DeletePrefix(prefix string) error {
+	return ErrDeletePrefixNotSupported
+}
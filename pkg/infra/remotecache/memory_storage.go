@@ -0,0 +1,110 @@
+package remotecache
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+const memoryCacheType = "memory"
+
+// memoryStorage keeps the items in the memory of the Grafana instance, so they aren't shared
+// between the instances of a high availability setup.
+type memoryStorage struct {
+	// mu makes the compare and swaps atomic with the other changes
+	mu sync.Mutex
+	c  *gocache.Cache
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		c: gocache.New(defaultMaxCacheExpiration, 10*time.Minute),
+	}
+}
+
+// Set sets value to given key in the cache.
+func (s *memoryStorage) Set(key string, val interface{}, expires time.Duration) error {
+	item := &cachedItem{Val: val}
+	data, err := encodeGob(item)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.Set(key, data, expires)
+	return nil
+}
+
+// SetIfNotExists sets value to given key in the cache unless it already exists.
+func (s *memoryStorage) SetIfNotExists(key string, val interface{}, expires time.Duration) (bool, error) {
+	item := &cachedItem{Val: val}
+	data, err := encodeGob(item)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Add fails when the key holds an unexpired item
+	return s.c.Add(key, data, expires) == nil, nil
+}
+
+// CompareAndSwap replaces the value of the key in the cache if it is still old.
+func (s *memoryStorage) CompareAndSwap(key string, old, new interface{}, expires time.Duration) (bool, error) {
+	oldData, err := encodeGob(&cachedItem{Val: old})
+	if err != nil {
+		return false, err
+	}
+	newData, err := encodeGob(&cachedItem{Val: new})
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.c.Get(key)
+	if !ok || !bytes.Equal(data.([]byte), oldData) {
+		return false, nil
+	}
+	s.c.Set(key, newData, expires)
+	return true, nil
+}
+
+// Get gets value by given key in the cache.
+func (s *memoryStorage) Get(key string) (interface{}, error) {
+	data, ok := s.c.Get(key)
+	if !ok {
+		return nil, ErrCacheItemNotFound
+	}
+
+	item := &cachedItem{}
+	if err := decodeGob(data.([]byte), item); err != nil {
+		return nil, err
+	}
+
+	return item.Val, nil
+}
+
+// Delete delete a key from the cache
+func (s *memoryStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.Delete(key)
+	return nil
+}
+
+// DeletePrefix deletes the keys starting with prefix
+func (s *memoryStorage) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.c.Items() {
+		if strings.HasPrefix(key, prefix) {
+			s.c.Delete(key)
+		}
+	}
+	return nil
+}
@@ -2,31 +2,81 @@ package remotecache
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	redis "github.com/go-redis/redis"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	redis "gopkg.in/redis.v5"
 )
 
-const redisCacheType = "redis"
+const (
+	redisCacheType = "redis"
+
+	redisModeStandalone = "standalone"
+	redisModeSentinel   = "sentinel"
+	redisModeCluster    = "cluster"
+
+	// redisAddrSeparator separates the addresses of the sentinels or of the cluster nodes in addr,
+	// since commas separate the options of the connection string
+	redisAddrSeparator = "|"
+
+	// redisScanCount is the number of keys asked per SCAN when deleting keys by prefix
+	redisScanCount = 1000
+)
+
+// redisClient is implemented by redis.ClusterClient, and by singleNodeClient for the other modes.
+type redisClient interface {
+	redis.Cmdable
+	Close() error
+	// ForEachMaster calls fn for each master node, every one having a part of the keys in cluster mode.
+	ForEachMaster(fn func(client *redis.Client) error) error
+}
+
+type singleNodeClient struct {
+	*redis.Client
+}
+
+func (c singleNodeClient) ForEachMaster(fn func(client *redis.Client) error) error {
+	return fn(c.Client)
+}
 
 type redisStorage struct {
-	c *redis.Client
+	mu sync.RWMutex
+	c  redisClient
+	// newClient is set in sentinel mode, to recreate the client when the master it is connected to
+	// became a replica after a failover.
+	newClient func() redisClient
+}
+
+// redisConfig is the configuration of the client parsed from the connection string
+type redisConfig struct {
+	Options *redis.Options
+	Mode    string
+	// Addrs are the addresses of the sentinels, or of the cluster nodes
+	Addrs            []string
+	MasterName       string
+	SentinelPassword string
 }
 
-// parseRedisConnStr parses k=v pairs in csv and builds a redis Options object
-func parseRedisConnStr(connStr string) (*redis.Options, error) {
+// parseRedisConnStr parses k=v pairs in csv and builds a redis configuration
+func parseRedisConnStr(connStr string) (*redisConfig, error) {
 	keyValueCSV := strings.Split(connStr, ",")
 	options := &redis.Options{Network: "tcp"}
+	config := &redisConfig{Options: options, Mode: redisModeStandalone}
 	setTLSIsTrue := false
+	var addr, caCertPath, clientCertPath, clientKeyPath string
 	for _, rawKeyValue := range keyValueCSV {
 		keyValueTuple := strings.SplitN(rawKeyValue, "=", 2)
 		if len(keyValueTuple) != 2 {
-			if strings.HasPrefix(rawKeyValue, "password") {
+			if strings.Contains(rawKeyValue, "password") {
 				// don't log the password
 				rawKeyValue = "password******"
 			}
@@ -36,7 +86,7 @@ func parseRedisConnStr(connStr string) (*redis.Options, error) {
 		connVal := keyValueTuple[1]
 		switch connKey {
 		case "addr":
-			options.Addr = connVal
+			addr = connVal
 		case "password":
 			options.Password = connVal
 		case "db":
@@ -61,27 +111,203 @@ func parseRedisConnStr(connStr string) (*redis.Options, error) {
 			if connVal == "insecure" {
 				options.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 			}
+		case "ca_cert_path":
+			caCertPath = connVal
+		case "client_cert_path":
+			clientCertPath = connVal
+		case "client_key_path":
+			clientKeyPath = connVal
+		case "mode":
+			if connVal != redisModeStandalone && connVal != redisModeSentinel && connVal != redisModeCluster {
+				return nil, fmt.Errorf("mode must be set to '%s', '%s' or '%s' when present", redisModeStandalone, redisModeSentinel, redisModeCluster)
+			}
+			config.Mode = connVal
+		case "master_name":
+			config.MasterName = connVal
+		case "sentinel_password":
+			config.SentinelPassword = connVal
 		default:
 			return nil, fmt.Errorf("unrecognized option '%v' in redis connection string", connKey)
 		}
 	}
+
+	switch config.Mode {
+	case redisModeStandalone:
+		if strings.Contains(addr, redisAddrSeparator) {
+			return nil, fmt.Errorf("multiple addresses in addr are only supported in sentinel and cluster modes")
+		}
+		options.Addr = addr
+	case redisModeSentinel:
+		if config.MasterName == "" {
+			return nil, fmt.Errorf("master_name is required in sentinel mode")
+		}
+		config.Addrs = strings.Split(addr, redisAddrSeparator)
+	case redisModeCluster:
+		if options.DB != 0 {
+			return nil, fmt.Errorf("db is not supported in cluster mode")
+		}
+		config.Addrs = strings.Split(addr, redisAddrSeparator)
+	}
+
 	if setTLSIsTrue {
-		// Get hostname from the Addr property and set it on the configuration for TLS
-		sp := strings.Split(options.Addr, ":")
-		if len(sp) < 1 {
-			return nil, fmt.Errorf("unable to get hostname from the addr field, expected host:port, got '%v'", options.Addr)
+		options.TLSConfig = &tls.Config{}
+		// In sentinel mode, the server name is set when connecting to the sentinels and to the master,
+		// and in cluster mode the client verifies the host of each node
+		if config.Mode == redisModeStandalone {
+			// Get hostname from the Addr property and set it on the configuration for TLS
+			sp := strings.Split(options.Addr, ":")
+			if len(sp) < 1 {
+				return nil, fmt.Errorf("unable to get hostname from the addr field, expected host:port, got '%v'", options.Addr)
+			}
+			options.TLSConfig.ServerName = sp[0]
+		}
+	}
+
+	if caCertPath != "" || clientCertPath != "" || clientKeyPath != "" {
+		if options.TLSConfig == nil {
+			return nil, fmt.Errorf("ssl must be set to 'true' or 'insecure' to use certificates")
+		}
+		if err := loadRedisCertificates(options.TLSConfig, caCertPath, clientCertPath, clientKeyPath); err != nil {
+			return nil, err
 		}
-		options.TLSConfig = &tls.Config{ServerName: sp[0]}
 	}
-	return options, nil
+
+	return config, nil
+}
+
+func loadRedisCertificates(tlsConfig *tls.Config, caCertPath, clientCertPath, clientKeyPath string) error {
+	if caCertPath != "" {
+		pem, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return errutil.Wrap("failed to read redis CA certificate", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("failed to parse redis CA certificate %q", caCertPath)
+		}
+	}
+
+	if clientCertPath != "" || clientKeyPath != "" {
+		if clientCertPath == "" || clientKeyPath == "" {
+			return fmt.Errorf("client_cert_path and client_key_path must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return errutil.Wrap("failed to load redis client certificate", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return nil
 }
 
 func newRedisStorage(opts *setting.RemoteCacheOptions) (*redisStorage, error) {
-	opt, err := parseRedisConnStr(opts.ConnStr)
+	config, err := parseRedisConnStr(opts.ConnStr)
 	if err != nil {
 		return nil, err
 	}
-	return &redisStorage{c: redis.NewClient(opt)}, nil
+
+	switch config.Mode {
+	case redisModeCluster:
+		return &redisStorage{c: redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     config.Addrs,
+			Password:  config.Options.Password,
+			PoolSize:  config.Options.PoolSize,
+			TLSConfig: config.Options.TLSConfig,
+		})}, nil
+	case redisModeSentinel:
+		config.Options.Dialer = newSentinelDialer(config)
+		newClient := func() redisClient {
+			return singleNodeClient{redis.NewClient(config.Options)}
+		}
+		return &redisStorage{c: newClient(), newClient: newClient}, nil
+	}
+
+	return &redisStorage{c: singleNodeClient{redis.NewClient(config.Options)}}, nil
+}
+
+// newSentinelDialer returns a dialer connecting to the master that the sentinels know at the time of
+// the connection. It's used instead of redis.FailoverClient, which doesn't support TLS.
+func newSentinelDialer(config *redisConfig) func() (net.Conn, error) {
+	sentinels := make([]*redis.Client, len(config.Addrs))
+	for i, addr := range config.Addrs {
+		sentinels[i] = redis.NewClient(&redis.Options{
+			Addr:      addr,
+			Password:  config.SentinelPassword,
+			TLSConfig: tlsConfigForAddr(config.Options.TLSConfig, addr),
+		})
+	}
+
+	return func() (net.Conn, error) {
+		addr, err := sentinelMasterAddr(sentinels, config.MasterName)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := net.DialTimeout("tcp", addr, config.Options.DialTimeout)
+		if err != nil || config.Options.TLSConfig == nil {
+			return conn, err
+		}
+		return tls.Client(conn, tlsConfigForAddr(config.Options.TLSConfig, addr)), nil
+	}
+}
+
+// sentinelMasterAddr asks the sentinels, in turn, for the address of the master.
+func sentinelMasterAddr(sentinels []*redis.Client, masterName string) (string, error) {
+	var lastErr error
+	for _, sentinel := range sentinels {
+		cmd := redis.NewStringSliceCmd("SENTINEL", "get-master-addr-by-name", masterName)
+		if err := sentinel.Process(cmd); err != nil {
+			lastErr = err
+			continue
+		}
+
+		if hostPort := cmd.Val(); len(hostPort) == 2 {
+			return net.JoinHostPort(hostPort[0], hostPort[1]), nil
+		}
+		lastErr = fmt.Errorf("unknown redis master %q", masterName)
+	}
+
+	return "", errutil.Wrap("failed to get the redis master from the sentinels", lastErr)
+}
+
+// tlsConfigForAddr returns a copy of tlsConfig verifying the host of addr, unless verification is skipped.
+func tlsConfigForAddr(tlsConfig *tls.Config, addr string) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+
+	c := tlsConfig.Clone()
+	if !c.InsecureSkipVerify {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			c.ServerName = host
+		}
+	}
+	return c
+}
+
+func (s *redisStorage) client() redisClient {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c
+}
+
+// checkFailover recreates the client when the server it's connected to is a replica,
+// to connect to the new master.
+func (s *redisStorage) checkFailover(err error) error {
+	if err == nil || s.newClient == nil || !strings.HasPrefix(err.Error(), "READONLY") {
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.c
+	s.c = s.newClient()
+	s.mu.Unlock()
+
+	if closeErr := old.Close(); closeErr != nil {
+		return errors.New(err.Error() + ", and failed to close the previous client: " + closeErr.Error())
+	}
+	return err
 }
 
 // Set sets value to given key in session.
@@ -91,13 +317,53 @@ func (s *redisStorage) Set(key string, val interface{}, expires time.Duration) e
 	if err != nil {
 		return err
 	}
-	status := s.c.Set(key, string(value), expires)
-	return status.Err()
+	status := s.client().Set(key, string(value), expires)
+	return s.checkFailover(status.Err())
+}
+
+// SetIfNotExists sets value to given key in session unless it already exists.
+func (s *redisStorage) SetIfNotExists(key string, val interface{}, expires time.Duration) (bool, error) {
+	item := &cachedItem{Val: val}
+	value, err := encodeGob(item)
+	if err != nil {
+		return false, err
+	}
+	set, err := s.client().SetNX(key, string(value), expires).Result()
+	return set, s.checkFailover(err)
+}
+
+// redisCompareAndSwap sets the key to ARGV[2] with a TTL of ARGV[3] milliseconds, none if 0, if it is ARGV[1]
+var redisCompareAndSwap = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// CompareAndSwap replaces the value of the key in session if it is still old, with a script run atomically.
+func (s *redisStorage) CompareAndSwap(key string, old, new interface{}, expires time.Duration) (bool, error) {
+	oldValue, err := encodeGob(&cachedItem{Val: old})
+	if err != nil {
+		return false, err
+	}
+	newValue, err := encodeGob(&cachedItem{Val: new})
+	if err != nil {
+		return false, err
+	}
+
+	swapped, err := redisCompareAndSwap.Run(s.client(), []string{key},
+		string(oldValue), string(newValue), int64(expires/time.Millisecond)).Int()
+	return swapped == 1, s.checkFailover(err)
 }
 
 // Get gets value by given key in session.
 func (s *redisStorage) Get(key string) (interface{}, error) {
-	v := s.c.Get(key)
+	v := s.client().Get(key)
 
 	item := &cachedItem{}
 	err := decodeGob([]byte(v.Val()), item)
@@ -113,6 +379,43 @@ func (s *redisStorage) Get(key string) (interface{}, error) {
 
 // Delete delete a key from session.
 func (s *redisStorage) Delete(key string) error {
-	cmd := s.c.Del(key)
-	return cmd.Err()
+	cmd := s.client().Del(key)
+	return s.checkFailover(cmd.Err())
+}
+
+// DeletePrefix deletes the keys starting with prefix, scanning every master node in cluster mode.
+func (s *redisStorage) DeletePrefix(prefix string) error {
+	pattern := escapeRedisPattern(prefix) + "*"
+	err := s.client().ForEachMaster(func(client *redis.Client) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(cursor, pattern, redisScanCount).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := client.Del(keys...).Err(); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	})
+	return s.checkFailover(err)
+}
+
+// escapeRedisPattern escapes the special characters of the glob-style patterns of SCAN.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
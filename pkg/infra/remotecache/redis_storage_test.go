@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"testing"
 
+	redis "github.com/go-redis/redis"
 	"github.com/stretchr/testify/assert"
-	redis "gopkg.in/redis.v5"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_parseRedisConnStr(t *testing.T) {
@@ -87,13 +88,62 @@ func Test_parseRedisConnStr(t *testing.T) {
 	}
 
 	for reason, testCase := range cases {
-		options, err := parseRedisConnStr(testCase.InputConnStr)
+		config, err := parseRedisConnStr(testCase.InputConnStr)
 		if testCase.ShouldErr {
 			assert.Error(t, err, fmt.Sprintf("error cases should return non-nil error for test case %v", reason))
-			assert.Nil(t, options, fmt.Sprintf("error cases should return nil for redis options for test case %v", reason))
+			assert.Nil(t, config, fmt.Sprintf("error cases should return nil for redis options for test case %v", reason))
 			continue
 		}
 		assert.NoError(t, err, reason)
-		assert.EqualValues(t, testCase.OutputOptions, options, reason)
+		assert.Equal(t, redisModeStandalone, config.Mode, reason)
+		assert.EqualValues(t, testCase.OutputOptions, config.Options, reason)
 	}
 }
+
+func Test_parseRedisConnStrModes(t *testing.T) {
+	t.Run("sentinel mode should parse the addresses of the sentinels", func(t *testing.T) {
+		config, err := parseRedisConnStr("mode=sentinel,addr=sentinel-1:26379|sentinel-2:26379,master_name=mymaster,sentinel_password=s3cret,password=grafanaRocks,db=2,ssl=true")
+		require.NoError(t, err)
+		assert.Equal(t, redisModeSentinel, config.Mode)
+		assert.Equal(t, []string{"sentinel-1:26379", "sentinel-2:26379"}, config.Addrs)
+		assert.Equal(t, "mymaster", config.MasterName)
+		assert.Equal(t, "s3cret", config.SentinelPassword)
+		assert.Equal(t, "grafanaRocks", config.Options.Password)
+		assert.Equal(t, 2, config.Options.DB)
+		require.NotNil(t, config.Options.TLSConfig)
+		assert.Empty(t, config.Options.TLSConfig.ServerName)
+		assert.Equal(t, "sentinel-2", tlsConfigForAddr(config.Options.TLSConfig, "sentinel-2:26379").ServerName)
+	})
+
+	t.Run("cluster mode should parse the addresses of the nodes", func(t *testing.T) {
+		config, err := parseRedisConnStr("mode=cluster,addr=node-1:6379|node-2:6379|node-3:6379,pool_size=10")
+		require.NoError(t, err)
+		assert.Equal(t, redisModeCluster, config.Mode)
+		assert.Equal(t, []string{"node-1:6379", "node-2:6379", "node-3:6379"}, config.Addrs)
+		assert.Equal(t, 10, config.Options.PoolSize)
+	})
+
+	t.Run("cluster mode should verify the host of each node", func(t *testing.T) {
+		config, err := parseRedisConnStr("mode=cluster,addr=node-1:6379|node-2:6379,ssl=true")
+		require.NoError(t, err)
+		require.NotNil(t, config.Options.TLSConfig)
+		assert.Empty(t, config.Options.TLSConfig.ServerName)
+	})
+
+	for reason, connStr := range map[string]string{
+		"invalid mode should err":                          "mode=ring,addr=127.0.0.1:6379",
+		"sentinel mode without master name should err":     "mode=sentinel,addr=127.0.0.1:26379",
+		"multiple addresses in standalone mode should err": "addr=127.0.0.1:6379|127.0.0.1:6380",
+		"db in cluster mode should err":                    "mode=cluster,addr=127.0.0.1:6379,db=1",
+		"certificates without ssl should err":              "addr=127.0.0.1:6379,ca_cert_path=/tmp/ca.pem",
+		"missing CA certificate should err":                "addr=127.0.0.1:6379,ssl=true,ca_cert_path=/does/not/exist.pem",
+		"client certificate without key should err":        "addr=127.0.0.1:6379,ssl=true,client_cert_path=/tmp/cert.pem",
+	} {
+		_, err := parseRedisConnStr(connStr)
+		assert.Error(t, err, reason)
+	}
+}
+
+func Test_escapeRedisPattern(t *testing.T) {
+	assert.Equal(t, `org:1:\*\?\[a\]\\`, escapeRedisPattern(`org:1:*?[a]\`))
+}
@@ -20,6 +20,9 @@ var (
 	// ErrInvalidCacheType is returned if the type is invalid
 	ErrInvalidCacheType = errors.New("invalid remote cache name")
 
+	// ErrDeletePrefixNotSupported is returned by DeletePrefix if the cache can't list its keys
+	ErrDeletePrefixNotSupported = errors.New("remote cache doesn't support deleting items by prefix")
+
 	defaultMaxCacheExpiration = time.Hour * 24
 )
 
@@ -47,8 +50,20 @@ type CacheStorage interface {
 	// Set sets an object into the cache. if `expire` is set to zero it will default to 24h
 	Set(key string, value interface{}, expire time.Duration) error
 
+	// SetIfNotExists atomically sets an object into the cache unless the key already holds an unexpired
+	// object, and reports whether it was set. if `expire` is set to zero it will default to 24h
+	SetIfNotExists(key string, value interface{}, expire time.Duration) (bool, error)
+
+	// CompareAndSwap atomically replaces the unexpired object of the key with new if it is still old, and
+	// reports whether it was replaced. if `expire` is set to zero it will default to 24h
+	CompareAndSwap(key string, old, new interface{}, expire time.Duration) (bool, error)
+
 	// Delete object from cache
 	Delete(key string) error
+
+	// DeletePrefix deletes all the objects with a key starting with prefix,
+	// for example to flush the items of an organization
+	DeletePrefix(prefix string) error
 }
 
 // RemoteCache allows Grafana to cache data outside its own process
@@ -73,11 +88,36 @@ func (ds *RemoteCache) Set(key string, value interface{}, expire time.Duration)
 	return ds.client.Set(key, value, expire)
 }
 
+// SetIfNotExists sets an object into the cache unless the key already exists, and reports whether it was set.
+// if `expire` is set to zero it will default to 24h
+func (ds *RemoteCache) SetIfNotExists(key string, value interface{}, expire time.Duration) (bool, error) {
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return ds.client.SetIfNotExists(key, value, expire)
+}
+
+// CompareAndSwap replaces the object of the key with new if it is still old, and reports whether it was replaced.
+// if `expire` is set to zero it will default to 24h
+func (ds *RemoteCache) CompareAndSwap(key string, old, new interface{}, expire time.Duration) (bool, error) {
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return ds.client.CompareAndSwap(key, old, new, expire)
+}
+
 // Delete object from cache
 func (ds *RemoteCache) Delete(key string) error {
 	return ds.client.Delete(key)
 }
 
+// DeletePrefix deletes all the objects with a key starting with prefix
+func (ds *RemoteCache) DeletePrefix(prefix string) error {
+	return ds.client.DeletePrefix(prefix)
+}
+
 // Init initializes the service
 func (ds *RemoteCache) Init() error {
 	ds.log = log.New("cache.remote")
@@ -99,6 +139,19 @@ func (ds *RemoteCache) Run(ctx context.Context) error {
 }
 
 func createClient(opts *setting.RemoteCacheOptions, sqlstore *sqlstore.SQLStore) (CacheStorage, error) {
+	client, err := createStorage(opts, sqlstore)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Prefix != "" {
+		return &prefixCacheStorage{cache: client, prefix: opts.Prefix}, nil
+	}
+
+	return client, nil
+}
+
+func createStorage(opts *setting.RemoteCacheOptions, sqlstore *sqlstore.SQLStore) (CacheStorage, error) {
 	if opts.Name == redisCacheType {
 		return newRedisStorage(opts)
 	}
@@ -111,9 +164,53 @@ func createClient(opts *setting.RemoteCacheOptions, sqlstore *sqlstore.SQLStore)
 		return newDatabaseCache(sqlstore), nil
 	}
 
+	if opts.Name == memoryCacheType {
+		return newMemoryStorage(), nil
+	}
+
 	return nil, ErrInvalidCacheType
 }
 
+// prefixCacheStorage adds a prefix to the keys, so that several Grafana installs can share a cache.
+type prefixCacheStorage struct {
+	cache  CacheStorage
+	prefix string
+}
+
+func (pcs *prefixCacheStorage) Get(key string) (interface{}, error) {
+	return pcs.cache.Get(pcs.prefix + key)
+}
+
+func (pcs *prefixCacheStorage) Set(key string, value interface{}, expire time.Duration) error {
+	return pcs.cache.Set(pcs.prefix+key, value, expire)
+}
+
+func (pcs *prefixCacheStorage) SetIfNotExists(key string, value interface{}, expire time.Duration) (bool, error) {
+	return pcs.cache.SetIfNotExists(pcs.prefix+key, value, expire)
+}
+
+func (pcs *prefixCacheStorage) CompareAndSwap(key string, old, new interface{}, expire time.Duration) (bool, error) {
+	return pcs.cache.CompareAndSwap(pcs.prefix+key, old, new, expire)
+}
+
+func (pcs *prefixCacheStorage) Delete(key string) error {
+	return pcs.cache.Delete(pcs.prefix + key)
+}
+
+func (pcs *prefixCacheStorage) DeletePrefix(prefix string) error {
+	return pcs.cache.DeletePrefix(pcs.prefix + prefix)
+}
+
+// Run runs the background job of the prefixed cache, if it has one
+func (pcs *prefixCacheStorage) Run(ctx context.Context) error {
+	if backgroundjob, ok := pcs.cache.(registry.BackgroundService); ok {
+		return backgroundjob.Run(ctx)
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 // Register records a type, identified by a value for that type, under its
 // internal type name. That name will identify the concrete type of a value
 // sent or received as an interface variable. Only types that will be
@@ -46,14 +46,76 @@ func TestCachedBasedOnConfig(t *testing.T) {
 	runTestsForClient(t, client)
 }
 
+func TestMemoryCacheStorage(t *testing.T) {
+	client := createTestClient(t, &setting.RemoteCacheOptions{Name: memoryCacheType}, nil)
+	runTestsForClient(t, client)
+	canDeleteItemsByPrefix(t, client)
+}
+
 func TestInvalidCacheTypeReturnsError(t *testing.T) {
 	_, err := createClient(&setting.RemoteCacheOptions{Name: "invalid"}, nil)
 	assert.Equal(t, err, ErrInvalidCacheType)
 }
 
+func TestDeletePrefix(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+
+	for _, opts := range []*setting.RemoteCacheOptions{
+		{Name: databaseCacheType},
+		{Name: databaseCacheType, Prefix: "grafana-1:"},
+	} {
+		client := createTestClient(t, opts, sqlStore)
+		canDeleteItemsByPrefix(t, client)
+	}
+}
+
+func TestPrefixedKeys(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	first := createTestClient(t, &setting.RemoteCacheOptions{Name: databaseCacheType, Prefix: "grafana-1:"}, sqlStore)
+	second := createTestClient(t, &setting.RemoteCacheOptions{Name: databaseCacheType, Prefix: "grafana-2:"}, sqlStore)
+
+	require.NoError(t, first.Set("key1", "first", 0))
+	require.NoError(t, second.Set("key1", "second", 0))
+
+	value, err := first.Get("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	require.NoError(t, second.DeletePrefix("key"))
+
+	value, err = first.Get("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	_, err = second.Get("key1")
+	assert.Equal(t, ErrCacheItemNotFound, err)
+}
+
+func canDeleteItemsByPrefix(t *testing.T, client CacheStorage) {
+	for _, key := range []string{"org:1:a", "org:1:b", "org:10:a", "org:2:a"} {
+		require.NoError(t, client.Set(key, key, 0))
+	}
+
+	require.NoError(t, client.DeletePrefix("org:1:"))
+
+	for _, key := range []string{"org:1:a", "org:1:b"} {
+		_, err := client.Get(key)
+		assert.Equal(t, ErrCacheItemNotFound, err, key)
+	}
+
+	for _, key := range []string{"org:10:a", "org:2:a"} {
+		value, err := client.Get(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, key, value)
+		require.NoError(t, client.Delete(key))
+	}
+}
+
 func runTestsForClient(t *testing.T, client CacheStorage) {
 	canPutGetAndDeleteCachedObjects(t, client)
 	canNotFetchExpiredItems(t, client)
+	canSetItemsIfNotExists(t, client)
+	canCompareAndSwapItems(t, client)
 }
 
 func canPutGetAndDeleteCachedObjects(t *testing.T, client CacheStorage) {
@@ -90,3 +152,59 @@ func canNotFetchExpiredItems(t *testing.T, client CacheStorage) {
 	_, err = client.Get("key1")
 	assert.Equal(t, err, ErrCacheItemNotFound)
 }
+
+func canSetItemsIfNotExists(t *testing.T, client CacheStorage) {
+	set, err := client.SetIfNotExists("key1", "first", time.Second)
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	set, err = client.SetIfNotExists("key1", "second", time.Second)
+	require.NoError(t, err)
+	assert.False(t, set)
+
+	value, err := client.Get("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	<-time.After(time.Second + time.Millisecond)
+
+	// an expired item doesn't count as existing
+	set, err = client.SetIfNotExists("key1", "third", 0)
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	value, err = client.Get("key1")
+	require.NoError(t, err)
+	assert.Equal(t, "third", value)
+
+	require.NoError(t, client.Delete("key1"))
+}
+
+func canCompareAndSwapItems(t *testing.T, client CacheStorage) {
+	swapped, err := client.CompareAndSwap("key1", int64(1), int64(2), time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped, "missing items aren't swapped")
+
+	require.NoError(t, client.Set("key1", int64(1), time.Second))
+
+	swapped, err = client.CompareAndSwap("key1", int64(3), int64(2), time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = client.CompareAndSwap("key1", int64(1), int64(2), time.Second)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	value, err := client.Get("key1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+
+	<-time.After(time.Second + time.Millisecond)
+
+	// an expired item isn't swapped
+	swapped, err = client.CompareAndSwap("key1", int64(2), int64(3), time.Second)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+
+	require.NoError(t, client.Delete("key1"))
+}
@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from a directory with a subdirectory per secret
+// and a file per key, like Kubernetes secrets mounted as volumes.
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider returns a provider reading the secrets in dir.
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Get returns the trimmed content of the file <dir>/<path>/<key>.
+func (p *FileProvider) Get(path, key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || key == ".." {
+		return "", fmt.Errorf("invalid secret key %q", key)
+	}
+
+	// cleaning the path as an absolute path keeps it inside the secrets directory
+	filename := filepath.Join(p.dir, filepath.Clean("/"+path), key)
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because the file is in the configured secrets directory
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
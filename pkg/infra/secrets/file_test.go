@@ -0,0 +1,39 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "postgres"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "postgres", "password"), []byte("secret\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "outside"), []byte("outside"), 0600))
+
+	provider := NewFileProvider(dir)
+
+	t.Run("Reads the file of the key", func(t *testing.T) {
+		value, err := provider.Get("postgres", "password")
+		require.NoError(t, err)
+		assert.Equal(t, "secret", value)
+	})
+
+	t.Run("Missing files should return not found", func(t *testing.T) {
+		_, err := provider.Get("postgres", "user")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("Paths can't leave the secrets directory", func(t *testing.T) {
+		_, err := provider.Get("../"+filepath.Base(dir)+"/postgres/../..", "password")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+
+		_, err = provider.Get("postgres", "../outside")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,213 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// VaultProviderName is the name of the HashiCorp Vault provider, referenced as $__vault{path:key}
+	VaultProviderName = "vault"
+	// FileProviderName is the name of the secrets directory provider, referenced as $__secretfile{path:key}
+	FileProviderName = "secretfile"
+)
+
+var (
+	// ErrSecretNotFound is returned if a provider doesn't have the referenced secret
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrProviderNotConfigured is returned if a value references a provider that isn't configured
+	ErrProviderNotConfigured = errors.New("secrets provider is not configured")
+
+	// ErrPathNotAllowed is returned if a value references a secret outside of the path prefix of its org
+	ErrPathNotAllowed = errors.New("secret path is not allowed for the organization")
+
+	referenceRegex = regexp.MustCompile(`\$__(\w+){([^{}:]+):([^{}]+)}`)
+
+	knownProviders = map[string]bool{
+		VaultProviderName: true,
+		FileProviderName:  true,
+	}
+
+	providersMu   sync.RWMutex
+	providers     = map[string]Provider{}
+	orgPathPrefix = DefaultOrgPathPrefix
+)
+
+const (
+	// OrgIDPlaceholder is replaced with the org id in the org path prefix.
+	OrgIDPlaceholder = "{org_id}"
+	// DefaultOrgPathPrefix is the path prefix of the secrets an org can reference by default.
+	DefaultOrgPathPrefix = "orgs/" + OrgIDPlaceholder
+)
+
+// Provider reads secrets from a secrets backend.
+type Provider interface {
+	// Get returns the value of the key of the secret at path.
+	Get(path, key string) (string, error)
+}
+
+// Register makes a provider available to resolve references using its name,
+// replacing any provider registered with the same name.
+func Register(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[name] = provider
+}
+
+// Unregister removes the provider registered with the name.
+func Unregister(name string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	delete(providers, name)
+}
+
+// SetOrgPathPrefix restricts the secrets an org can reference to the paths starting
+// with prefix, where OrgIDPlaceholder is replaced with the org id. An empty prefix
+// allows every org to reference any secret.
+func SetOrgPathPrefix(prefix string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	orgPathPrefix = strings.Trim(prefix, "/")
+}
+
+// isPathAllowed returns true if the org may reference the secrets at path.
+func isPathAllowed(orgID int64, path string) bool {
+	providersMu.RLock()
+	prefix := orgPathPrefix
+	providersMu.RUnlock()
+
+	for _, segment := range strings.Split(path, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+
+	if prefix == "" {
+		return true
+	}
+
+	prefix = strings.ReplaceAll(prefix, OrgIDPlaceholder, strconv.FormatInt(orgID, 10))
+	return strings.HasPrefix(strings.Trim(path, "/")+"/", prefix+"/")
+}
+
+func getProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+func isProviderName(name string) bool {
+	if knownProviders[name] {
+		return true
+	}
+
+	_, ok := getProvider(name)
+	return ok
+}
+
+// ReferenceIndexes returns the start and end indexes of the secret references in s,
+// in the format of regexp.FindAllStringIndex.
+func ReferenceIndexes(s string) [][]int {
+	var indexes [][]int
+	for _, match := range referenceRegex.FindAllStringSubmatchIndex(s, -1) {
+		if isProviderName(s[match[2]:match[3]]) {
+			indexes = append(indexes, match[:2])
+		}
+	}
+
+	return indexes
+}
+
+// HasReference returns true if s references a secret of a secrets provider.
+func HasReference(s string) bool {
+	return len(ReferenceIndexes(s)) > 0
+}
+
+// Resolve replaces the secret references in s, like $__vault{path:key},
+// with the values read from the referenced providers. References to paths
+// outside of the path prefix of the org aren't resolved.
+func Resolve(orgID int64, s string) (string, error) {
+	if !strings.Contains(s, "$__") {
+		return s, nil
+	}
+
+	var resolveErr error
+	resolved := referenceRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		match := referenceRegex.FindStringSubmatch(reference)
+		name, path, key := match[1], match[2], match[3]
+		if !isProviderName(name) || resolveErr != nil {
+			return reference
+		}
+
+		provider, ok := getProvider(name)
+		if !ok {
+			resolveErr = fmt.Errorf("failed to resolve %q: %w", reference, ErrProviderNotConfigured)
+			return reference
+		}
+
+		if !isPathAllowed(orgID, path) {
+			resolveErr = fmt.Errorf("failed to resolve %q: %w", reference, ErrPathNotAllowed)
+			return reference
+		}
+
+		value, err := provider.Get(path, key)
+		if err != nil {
+			resolveErr = fmt.Errorf("failed to resolve %q: %w", reference, err)
+			return reference
+		}
+
+		return value
+	})
+	if resolveErr != nil {
+		return s, resolveErr
+	}
+
+	return resolved, nil
+}
+
+// ResolveValues resolves the secret references in the values of a map, like decrypted
+// secure json data. The map is returned as it is if none of its values reference secrets.
+// Values that fail to resolve are kept as they are, and the errors are returned joined.
+func ResolveValues(orgID int64, values map[string]string) (map[string]string, error) {
+	var resolved map[string]string
+	var errStrings []string
+	for k, v := range values {
+		if !HasReference(v) {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make(map[string]string, len(values))
+			for k, v := range values {
+				resolved[k] = v
+			}
+		}
+
+		value, err := Resolve(orgID, v)
+		if err != nil {
+			errStrings = append(errStrings, fmt.Sprintf("%s: %s", k, err))
+			continue
+		}
+		resolved[k] = value
+	}
+
+	if resolved == nil {
+		return values, nil
+	}
+
+	if len(errStrings) != 0 {
+		return resolved, errors.New(strings.Join(errStrings, "\n"))
+	}
+
+	return resolved, nil
+}
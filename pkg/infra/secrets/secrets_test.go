@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider map[string]string
+
+func (f fakeProvider) Get(path, key string) (string, error) {
+	value, ok := f[path+":"+key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func TestResolve(t *testing.T) {
+	Register(VaultProviderName, fakeProvider{"orgs/1/postgres:password": "pa$$word", "orgs/1/postgres:user": "grafana"})
+	t.Cleanup(func() { Unregister(VaultProviderName) })
+
+	t.Run("Resolves references", func(t *testing.T) {
+		resolved, err := Resolve(1, "$__vault{orgs/1/postgres:password}")
+		require.NoError(t, err)
+		assert.Equal(t, "pa$$word", resolved)
+
+		resolved, err = Resolve(1, "$__vault{orgs/1/postgres:user}:$__vault{orgs/1/postgres:password}")
+		require.NoError(t, err)
+		assert.Equal(t, "grafana:pa$$word", resolved)
+	})
+
+	t.Run("Keeps values without references", func(t *testing.T) {
+		for _, value := range []string{"", "password", "$__env{HOME}", "$__file{C:\\secrets\\password}", "$__other{a:b}"} {
+			resolved, err := Resolve(1, value)
+			require.NoError(t, err)
+			assert.Equal(t, value, resolved)
+		}
+	})
+
+	t.Run("Missing secrets should return error", func(t *testing.T) {
+		_, err := Resolve(1, "$__vault{orgs/1/postgres:missing}")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("References to providers that aren't configured should return error", func(t *testing.T) {
+		_, err := Resolve(1, "$__secretfile{postgres:password}")
+		require.ErrorIs(t, err, ErrProviderNotConfigured)
+	})
+
+	t.Run("Resolves values of maps", func(t *testing.T) {
+		values := map[string]string{"password": "$__vault{orgs/1/postgres:password}", "token": "plain"}
+		resolved, err := ResolveValues(1, values)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "pa$$word", "token": "plain"}, resolved)
+		assert.Equal(t, "$__vault{orgs/1/postgres:password}", values["password"])
+
+		values = map[string]string{"password": "$__vault{orgs/1/postgres:missing}", "token": "$__vault{orgs/1/postgres:user}"}
+		resolved, err = ResolveValues(1, values)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "password: failed to resolve")
+		assert.Equal(t, map[string]string{"password": "$__vault{orgs/1/postgres:missing}", "token": "grafana"}, resolved)
+	})
+
+	t.Run("References outside of the path prefix of the org should return error", func(t *testing.T) {
+		for _, value := range []string{
+			"$__vault{orgs/1/postgres:password}",
+			"$__vault{orgs/20/postgres:password}",
+			"$__vault{orgs:password}",
+			"$__vault{orgs/2/../1/postgres:password}",
+			"$__vault{postgres:password}",
+		} {
+			_, err := Resolve(2, value)
+			require.ErrorIs(t, err, ErrPathNotAllowed, value)
+		}
+	})
+
+	t.Run("An empty path prefix allows any path but parent directories", func(t *testing.T) {
+		SetOrgPathPrefix("")
+		t.Cleanup(func() { SetOrgPathPrefix(DefaultOrgPathPrefix) })
+
+		resolved, err := Resolve(2, "$__vault{orgs/1/postgres:password}")
+		require.NoError(t, err)
+		assert.Equal(t, "pa$$word", resolved)
+
+		_, err = Resolve(2, "$__vault{orgs/../orgs/1/postgres:password}")
+		require.ErrorIs(t, err, ErrPathNotAllowed)
+	})
+
+	t.Run("Registered providers can be referenced", func(t *testing.T) {
+		Register("custom", fakeProvider{"orgs/1/a:b": "c"})
+		t.Cleanup(func() { Unregister("custom") })
+
+		assert.True(t, HasReference("$__custom{orgs/1/a:b}"))
+		resolved, err := Resolve(1, "$__custom{orgs/1/a:b}")
+		require.NoError(t, err)
+		assert.Equal(t, "c", resolved)
+	})
+}
@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var logger = log.New("infra.secrets")
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         "SecretsService",
+		Instance:     &SecretsService{},
+		InitPriority: registry.High,
+	})
+}
+
+// SecretsService registers the secrets providers enabled in the configuration,
+// so that secure settings can reference secrets stored outside of Grafana.
+type SecretsService struct {
+	Cfg *setting.Cfg `inject:""`
+}
+
+// Init registers the configured secrets providers.
+func (s *SecretsService) Init() error {
+	SetOrgPathPrefix(s.Cfg.Secrets.OrgPathPrefix)
+
+	if s.Cfg.Secrets.FileDir != "" {
+		logger.Info("Secrets directory provider enabled", "dir", s.Cfg.Secrets.FileDir)
+		Register(FileProviderName, NewFileProvider(s.Cfg.Secrets.FileDir))
+	}
+
+	if s.Cfg.Secrets.Vault.URL != "" {
+		provider, err := NewVaultProvider(s.Cfg.Secrets.Vault)
+		if err != nil {
+			return err
+		}
+		logger.Info("Vault secrets provider enabled", "url", s.Cfg.Secrets.Vault.URL)
+		Register(VaultProviderName, provider)
+	}
+
+	return nil
+}
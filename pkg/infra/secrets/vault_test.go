@@ -0,0 +1,156 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// vaultStub serves secrets like the KV secrets engine of Vault
+func vaultStub(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/grafana/postgres":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"v2-secret","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/kv/grafana/postgres":
+			_, _ = w.Write([]byte(`{"data":{"password":"v1-secret"}}`))
+		case "/v1/secret/data/grafana/a b?c#d":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"escaped-secret"}}}`))
+		case "/v1/secret/data/grafana/deleted":
+			_, _ = w.Write([]byte(`{"data":{"data":null,"metadata":{"version":2}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestVaultProvider(t *testing.T) {
+	requests := 0
+	server := vaultStub(t, &requests)
+
+	t.Run("Reads secrets of kv version 2", func(t *testing.T) {
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: server.URL, Token: "token", Mount: "secret", KVVersion: 2})
+		require.NoError(t, err)
+
+		value, err := provider.Get("grafana/postgres", "password")
+		require.NoError(t, err)
+		assert.Equal(t, "v2-secret", value)
+
+		value, err = provider.Get("/grafana/postgres/", "port")
+		require.NoError(t, err)
+		assert.Equal(t, "5432", value)
+
+		_, err = provider.Get("grafana/postgres", "user")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+
+		_, err = provider.Get("grafana/deleted", "password")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+
+		_, err = provider.Get("grafana/missing", "password")
+		require.ErrorIs(t, err, ErrSecretNotFound)
+	})
+
+	t.Run("Escapes the segments of the path", func(t *testing.T) {
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: server.URL, Token: "token", Mount: "secret", KVVersion: 2})
+		require.NoError(t, err)
+
+		value, err := provider.Get("grafana/a b?c#d", "password")
+		require.NoError(t, err)
+		assert.Equal(t, "escaped-secret", value)
+
+		for _, path := range []string{"grafana/../sys/policy", "grafana/./postgres", "grafana//postgres"} {
+			_, err := provider.Get(path, "password")
+			require.Error(t, err, path)
+			assert.Contains(t, err.Error(), "invalid secret path", path)
+		}
+	})
+
+	t.Run("Reads secrets of kv version 1", func(t *testing.T) {
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: server.URL, Token: "token", Mount: "/kv/", KVVersion: 1})
+		require.NoError(t, err)
+
+		value, err := provider.Get("grafana/postgres", "password")
+		require.NoError(t, err)
+		assert.Equal(t, "v1-secret", value)
+	})
+
+	t.Run("Caches secrets", func(t *testing.T) {
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: server.URL, Token: "token", Mount: "secret", KVVersion: 2, CacheTTL: time.Minute})
+		require.NoError(t, err)
+
+		requests = 0
+		for i := 0; i < 3; i++ {
+			_, err := provider.Get("grafana/postgres", "password")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("Slow reads don't block the reads of other paths", func(t *testing.T) {
+		release := make(chan struct{})
+		slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/secret/data/grafana/slow" {
+				<-release
+			}
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"secret"}}}`))
+		}))
+		t.Cleanup(slowServer.Close)
+		t.Cleanup(func() { close(release) })
+
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: slowServer.URL, Token: "token", Mount: "secret", KVVersion: 2, CacheTTL: time.Minute})
+		require.NoError(t, err)
+
+		go func() {
+			_, _ = provider.Get("grafana/slow", "password")
+		}()
+
+		done := make(chan error)
+		go func() {
+			_, err := provider.Get("grafana/fast", "password")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the read of grafana/fast waited for the read of grafana/slow")
+		}
+	})
+
+	t.Run("Errors of vault should be returned", func(t *testing.T) {
+		provider, err := NewVaultProvider(setting.VaultSettings{URL: server.URL, Token: "invalid", Mount: "secret", KVVersion: 2})
+		require.NoError(t, err)
+
+		_, err = provider.Get("grafana/postgres", "password")
+		require.Error(t, err)
+		assert.Equal(t, "vault responded with status 403: permission denied", err.Error())
+	})
+
+	t.Run("Invalid settings should return error", func(t *testing.T) {
+		_, err := NewVaultProvider(setting.VaultSettings{URL: "vault:8200", KVVersion: 2})
+		require.Error(t, err)
+
+		_, err = NewVaultProvider(setting.VaultSettings{URL: server.URL, KVVersion: 3})
+		require.Error(t, err)
+	})
+}
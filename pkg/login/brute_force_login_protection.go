@@ -7,26 +7,41 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 )
 
-var (
-	maxInvalidLoginAttempts int64 = 5
-	loginAttemptsWindow           = time.Minute * 5
-)
-
 var validateLoginAttempts = func(query *models.LoginUserQuery) error {
 	if query.Cfg.DisableBruteForceLoginProtection {
 		return nil
 	}
 
+	lockout := query.Cfg.LoginLockout
+	since := time.Now().Add(-lockout.Window)
+
 	loginAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
 		Username: query.Username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
+		Since:    since,
 	}
 
 	if err := bus.Dispatch(&loginAttemptCountQuery); err != nil {
 		return err
 	}
 
-	if loginAttemptCountQuery.Result >= maxInvalidLoginAttempts {
+	if loginAttemptCountQuery.Result >= lockout.MaxUserAttempts {
+		return ErrTooManyLoginAttempts
+	}
+
+	if lockout.MaxIPAttempts == 0 || query.IpAddress == "" {
+		return nil
+	}
+
+	ipAttemptCountQuery := models.GetIPLoginAttemptCountQuery{
+		IpAddress: query.IpAddress,
+		Since:     since,
+	}
+
+	if err := bus.Dispatch(&ipAttemptCountQuery); err != nil {
+		return err
+	}
+
+	if ipAttemptCountQuery.Result >= lockout.MaxIPAttempts {
 		return ErrTooManyLoginAttempts
 	}
 
@@ -43,5 +58,141 @@ var saveInvalidLoginAttempt = func(query *models.LoginUserQuery) error {
 		IpAddress: query.IpAddress,
 	}
 
-	return bus.Dispatch(&loginAttemptCommand)
+	if err := bus.Dispatch(&loginAttemptCommand); err != nil {
+		return err
+	}
+
+	if err := notifyLoginLockout(query); err != nil {
+		loginLogger.Error("Failed to notify of login lockout", "err", err)
+	}
+
+	return nil
+}
+
+// notifyLoginLockout sends the lockout emails when the invalid login attempt blocks the username or the IP address.
+// The attempts aren't saved any more once blocked, so the count reaches the max once per lockout.
+func notifyLoginLockout(query *models.LoginUserQuery) error {
+	lockout := query.Cfg.LoginLockout
+	if !lockout.NotifyUser && !lockout.NotifyAdmins {
+		return nil
+	}
+
+	since := time.Now().Add(-lockout.Window)
+
+	userAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
+		Username: query.Username,
+		Since:    since,
+	}
+	if err := bus.Dispatch(&userAttemptCountQuery); err != nil {
+		return err
+	}
+
+	if userAttemptCountQuery.Result == lockout.MaxUserAttempts {
+		if err := bus.Dispatch(&models.SendLoginLockoutEmailCommand{
+			Username:  query.Username,
+			IpAddress: query.IpAddress,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if lockout.MaxIPAttempts == 0 || query.IpAddress == "" {
+		return nil
+	}
+
+	ipAttemptCountQuery := models.GetIPLoginAttemptCountQuery{
+		IpAddress: query.IpAddress,
+		Since:     since,
+	}
+	if err := bus.Dispatch(&ipAttemptCountQuery); err != nil {
+		return err
+	}
+
+	if ipAttemptCountQuery.Result == lockout.MaxIPAttempts {
+		return bus.Dispatch(&models.SendLoginLockoutEmailCommand{
+			IpAddress: query.IpAddress,
+		})
+	}
+
+	return nil
+}
+
+// ReserveLoginAttempt saves a login attempt before its credentials are checked, and returns
+// ErrTooManyLoginAttempts when the username or the IP address of the query is locked out. The attempt is counted
+// before the check so that concurrent attempts can't all pass it, and must be released with ReleaseLoginAttempt
+// when the credentials are valid. It returns an ID of 0 when brute force login protection is disabled.
+func ReserveLoginAttempt(query *models.LoginUserQuery) (int64, error) {
+	if query.Cfg.DisableBruteForceLoginProtection {
+		return 0, nil
+	}
+
+	loginAttemptCommand := models.CreateLoginAttemptCommand{
+		Username:  query.Username,
+		IpAddress: query.IpAddress,
+	}
+	if err := bus.Dispatch(&loginAttemptCommand); err != nil {
+		return 0, err
+	}
+	id := loginAttemptCommand.Result.Id
+
+	locked, err := isLoginAttemptLockedOut(query)
+	if err != nil || locked {
+		// the attempts aren't saved once blocked
+		if releaseErr := ReleaseLoginAttempt(id); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 0, ErrTooManyLoginAttempts
+	}
+
+	return id, nil
+}
+
+// isLoginAttemptLockedOut returns whether there are more attempts than allowed, including the reserved one.
+func isLoginAttemptLockedOut(query *models.LoginUserQuery) (bool, error) {
+	lockout := query.Cfg.LoginLockout
+	since := time.Now().Add(-lockout.Window)
+
+	userAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
+		Username: query.Username,
+		Since:    since,
+	}
+	if err := bus.Dispatch(&userAttemptCountQuery); err != nil {
+		return false, err
+	}
+	if userAttemptCountQuery.Result > lockout.MaxUserAttempts {
+		return true, nil
+	}
+
+	if lockout.MaxIPAttempts == 0 || query.IpAddress == "" {
+		return false, nil
+	}
+
+	ipAttemptCountQuery := models.GetIPLoginAttemptCountQuery{
+		IpAddress: query.IpAddress,
+		Since:     since,
+	}
+	if err := bus.Dispatch(&ipAttemptCountQuery); err != nil {
+		return false, err
+	}
+	return ipAttemptCountQuery.Result > lockout.MaxIPAttempts, nil
+}
+
+// ReleaseLoginAttempt deletes an attempt saved by ReserveLoginAttempt whose credentials were valid.
+func ReleaseLoginAttempt(id int64) error {
+	if id == 0 {
+		return nil
+	}
+	return bus.Dispatch(&models.DeleteLoginAttemptsCommand{Id: id})
+}
+
+// FailLoginAttempt keeps an attempt saved by ReserveLoginAttempt whose credentials were invalid, and notifies of
+// the lockout it causes.
+func FailLoginAttempt(query *models.LoginUserQuery) error {
+	if query.Cfg.DisableBruteForceLoginProtection {
+		return nil
+	}
+	return notifyLoginLockout(query)
 }
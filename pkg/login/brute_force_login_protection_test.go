@@ -2,6 +2,7 @@ package login
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -10,6 +11,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const maxInvalidLoginAttempts int64 = 5
+
 func TestValidateLoginAttempts(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -67,6 +70,38 @@ func TestValidateLoginAttempts(t *testing.T) {
 	}
 }
 
+func TestValidateLoginAttemptsFromIPAddress(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+	withLoginAttempts(t, 0)
+
+	ipAttempts := int64(0)
+	bus.AddHandler("test", func(query *models.GetIPLoginAttemptCountQuery) error {
+		assert.Equal(t, "192.168.1.1", query.IpAddress)
+		query.Result = ipAttempts
+		return nil
+	})
+
+	cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+	query := &models.LoginUserQuery{Username: "user", IpAddress: "192.168.1.1", Cfg: cfg}
+
+	t.Run("When the IP addresses have no max", func(t *testing.T) {
+		ipAttempts = 100
+		require.NoError(t, validateLoginAttempts(query))
+	})
+
+	cfg.LoginLockout.MaxIPAttempts = 20
+
+	t.Run("When the IP address login attempt count is less than max", func(t *testing.T) {
+		ipAttempts = 19
+		require.NoError(t, validateLoginAttempts(query))
+	})
+
+	t.Run("When the IP address login attempt count equals max", func(t *testing.T) {
+		ipAttempts = 20
+		require.Equal(t, ErrTooManyLoginAttempts, validateLoginAttempts(query))
+	})
+}
+
 func TestSaveInvalidLoginAttempt(t *testing.T) {
 	t.Run("When brute force protection enabled", func(t *testing.T) {
 		t.Cleanup(func() { bus.ClearBusHandlers() })
@@ -109,6 +144,58 @@ func TestSaveInvalidLoginAttempt(t *testing.T) {
 
 		require.Nil(t, createLoginAttemptCmd)
 	})
+
+	t.Run("When the attempt locks the username and the IP address out", func(t *testing.T) {
+		t.Cleanup(func() { bus.ClearBusHandlers() })
+
+		bus.AddHandler("test", func(cmd *models.CreateLoginAttemptCommand) error {
+			return nil
+		})
+		withLoginAttempts(t, maxInvalidLoginAttempts)
+		bus.AddHandler("test", func(query *models.GetIPLoginAttemptCountQuery) error {
+			query.Result = 20
+			return nil
+		})
+		var lockouts []*models.SendLoginLockoutEmailCommand
+		bus.AddHandler("test", func(cmd *models.SendLoginLockoutEmailCommand) error {
+			lockouts = append(lockouts, cmd)
+			return nil
+		})
+
+		cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+		cfg.LoginLockout.MaxIPAttempts = 20
+		query := &models.LoginUserQuery{Username: "user", IpAddress: "192.168.1.1", Cfg: cfg}
+
+		require.NoError(t, saveInvalidLoginAttempt(query))
+		require.Empty(t, lockouts)
+
+		cfg.LoginLockout.NotifyAdmins = true
+		require.NoError(t, saveInvalidLoginAttempt(query))
+		require.Equal(t, []*models.SendLoginLockoutEmailCommand{
+			{Username: "user", IpAddress: "192.168.1.1"},
+			{IpAddress: "192.168.1.1"},
+		}, lockouts)
+	})
+
+	t.Run("When the attempt doesn't lock out", func(t *testing.T) {
+		t.Cleanup(func() { bus.ClearBusHandlers() })
+
+		bus.AddHandler("test", func(cmd *models.CreateLoginAttemptCommand) error {
+			return nil
+		})
+		withLoginAttempts(t, maxInvalidLoginAttempts+1)
+		var lockouts []*models.SendLoginLockoutEmailCommand
+		bus.AddHandler("test", func(cmd *models.SendLoginLockoutEmailCommand) error {
+			lockouts = append(lockouts, cmd)
+			return nil
+		})
+
+		cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+		cfg.LoginLockout.NotifyUser = true
+
+		require.NoError(t, saveInvalidLoginAttempt(&models.LoginUserQuery{Username: "user", Cfg: cfg}))
+		require.Empty(t, lockouts)
+	})
 }
 
 func cfgWithBruteForceLoginProtectionDisabled(t *testing.T) *setting.Cfg {
@@ -122,6 +209,10 @@ func cfgWithBruteForceLoginProtectionEnabled(t *testing.T) *setting.Cfg {
 	t.Helper()
 	cfg := setting.NewCfg()
 	require.False(t, cfg.DisableBruteForceLoginProtection)
+	cfg.LoginLockout = setting.LoginLockoutSettings{
+		MaxUserAttempts: maxInvalidLoginAttempts,
+		Window:          5 * time.Minute,
+	}
 	return cfg
 }
 
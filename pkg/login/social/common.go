@@ -15,6 +15,7 @@ import (
 
 var (
 	errMissingGroupMembership = Error{"user not a member of one of the required groups"}
+	errMissingOrgRole         = Error{"user has no role in any organization"}
 )
 
 type httpGetResponse struct {
@@ -75,28 +76,60 @@ func (s *SocialBase) httpGet(client *http.Client, url string) (response httpGetR
 }
 
 func (s *SocialBase) searchJSONForAttr(attributePath string, data []byte) (string, error) {
+	val, err := s.searchJSON(attributePath, data)
+	if err != nil {
+		return "", err
+	}
+
+	strVal, ok := val.(string)
+	if ok {
+		return strVal, nil
+	}
+
+	return "", nil
+}
+
+// searchJSONForStringArrayAttr returns the strings of the array found at the path, or the string found there.
+func (s *SocialBase) searchJSONForStringArrayAttr(attributePath string, data []byte) ([]string, error) {
+	val, err := s.searchJSON(attributePath, data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if strVal, ok := item.(string); ok {
+				result = append(result, strVal)
+			}
+		}
+		return result, nil
+	case string:
+		return []string{v}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *SocialBase) searchJSON(attributePath string, data []byte) (interface{}, error) {
 	if attributePath == "" {
-		return "", errors.New("no attribute path specified")
+		return nil, errors.New("no attribute path specified")
 	}
 
 	if len(data) == 0 {
-		return "", errors.New("empty user info JSON response provided")
+		return nil, errors.New("empty user info JSON response provided")
 	}
 
 	var buf interface{}
 	if err := json.Unmarshal(data, &buf); err != nil {
-		return "", errutil.Wrap("failed to unmarshal user info JSON response", err)
+		return nil, errutil.Wrap("failed to unmarshal user info JSON response", err)
 	}
 
 	val, err := jmespath.Search(attributePath, buf)
 	if err != nil {
-		return "", errutil.Wrapf(err, "failed to search user info JSON response with provided path: %q", attributePath)
+		return nil, errutil.Wrapf(err, "failed to search user info JSON response with provided path: %q", attributePath)
 	}
 
-	strVal, ok := val.(string)
-	if ok {
-		return strVal, nil
-	}
-
-	return "", nil
+	return val, nil
 }
@@ -19,15 +19,18 @@ import (
 
 type SocialGenericOAuth struct {
 	*SocialBase
-	allowedOrganizations []string
-	apiUrl               string
-	emailAttributeName   string
-	emailAttributePath   string
-	loginAttributePath   string
-	nameAttributePath    string
-	roleAttributePath    string
-	idTokenAttributeName string
-	teamIds              []int
+	allowedOrganizations      []string
+	apiUrl                    string
+	emailAttributeName        string
+	emailAttributePath        string
+	loginAttributePath        string
+	nameAttributePath         string
+	roleAttributePath         string
+	orgRolesAttributePath     string
+	grafanaAdminAttributePath string
+	groupsAttributePath       string
+	idTokenAttributeName      string
+	teamIds                   []int
 }
 
 func (s *SocialGenericOAuth) Type() int {
@@ -150,6 +153,36 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 				userInfo.Role = role
 			}
 		}
+
+		if len(userInfo.OrgRoles) == 0 {
+			orgRoles, err := s.extractOrgRoles(data)
+			if err != nil {
+				s.log.Error("Failed to extract organization roles", "error", err)
+			} else if len(orgRoles) != 0 {
+				s.log.Debug("Setting user info organization roles from extracted organization roles")
+				userInfo.OrgRoles = orgRoles
+			}
+		}
+
+		if userInfo.IsGrafanaAdmin == nil {
+			isGrafanaAdmin, err := s.extractGrafanaAdmin(data)
+			if err != nil {
+				s.log.Error("Failed to extract Grafana Admin flag", "error", err)
+			} else if isGrafanaAdmin != nil {
+				s.log.Debug("Setting user info Grafana Admin flag from extracted flag")
+				userInfo.IsGrafanaAdmin = isGrafanaAdmin
+			}
+		}
+
+		if len(userInfo.Groups) == 0 {
+			groups, err := s.extractGroups(data)
+			if err != nil {
+				s.log.Error("Failed to extract groups", "error", err)
+			} else if len(groups) != 0 {
+				s.log.Debug("Setting user info groups from extracted groups")
+				userInfo.Groups = groups
+			}
+		}
 	}
 
 	if userInfo.Email == "" {
@@ -174,6 +207,10 @@ func (s *SocialGenericOAuth) UserInfo(client *http.Client, token *oauth2.Token)
 		return nil, errors.New("user not a member of one of the required organizations")
 	}
 
+	if s.orgRolesAttributePath != "" && len(userInfo.OrgRoles) == 0 {
+		return nil, &errMissingOrgRole
+	}
+
 	s.log.Debug("User info result", "result", userInfo)
 	return userInfo, nil
 }
@@ -342,6 +379,52 @@ func (s *SocialGenericOAuth) extractRole(data *UserInfoJson) (string, error) {
 	return role, nil
 }
 
+// extractOrgRoles returns the valid roles of the object, keyed by organization ID or name, found with the org roles path.
+func (s *SocialGenericOAuth) extractOrgRoles(data *UserInfoJson) (map[string]string, error) {
+	if s.orgRolesAttributePath == "" {
+		return nil, nil
+	}
+
+	val, err := s.searchJSON(s.orgRolesAttributePath, data.rawJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	orgRoles := map[string]string{}
+	values, _ := val.(map[string]interface{})
+	for org, value := range values {
+		// Expressions may return an empty role, or null, for the organizations the user doesn't qualify for
+		if role, ok := value.(string); ok && models.RoleType(role).IsValid() {
+			orgRoles[org] = role
+		}
+	}
+	return orgRoles, nil
+}
+
+func (s *SocialGenericOAuth) extractGrafanaAdmin(data *UserInfoJson) (*bool, error) {
+	if s.grafanaAdminAttributePath == "" {
+		return nil, nil
+	}
+
+	val, err := s.searchJSON(s.grafanaAdminAttributePath, data.rawJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if isGrafanaAdmin, ok := val.(bool); ok {
+		return &isGrafanaAdmin, nil
+	}
+	return nil, nil
+}
+
+func (s *SocialGenericOAuth) extractGroups(data *UserInfoJson) ([]string, error) {
+	if s.groupsAttributePath == "" {
+		return nil, nil
+	}
+
+	return s.searchJSONForStringArrayAttr(s.groupsAttributePath, data.rawJSON)
+}
+
 func (s *SocialGenericOAuth) FetchPrivateEmail(client *http.Client) (string, error) {
 	type Record struct {
 		Email       string `json:"email"`
@@ -2,6 +2,7 @@ package social
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -181,6 +182,61 @@ func TestSearchJSONForRole(t *testing.T) {
 	})
 }
 
+func TestSearchJSONForGroups(t *testing.T) {
+	t.Run("Given a generic OAuth provider", func(t *testing.T) {
+		provider := SocialGenericOAuth{
+			SocialBase: &SocialBase{
+				log: newLogger("generic_oauth_test", log15.LvlDebug),
+			},
+		}
+
+		tests := []struct {
+			Name                 string
+			UserInfoJSONResponse []byte
+			GroupsAttributePath  string
+			ExpectedResult       []string
+			ExpectedError        string
+		}{
+			{
+				Name:                 "Given an invalid user info JSON response",
+				UserInfoJSONResponse: []byte("{"),
+				GroupsAttributePath:  "groups",
+				ExpectedError:        "failed to unmarshal user info JSON response: unexpected end of JSON input",
+			},
+			{
+				Name:                 "Given a user info JSON response with a groups array",
+				UserInfoJSONResponse: []byte(`{"groups": ["admins", "editors", 3]}`),
+				GroupsAttributePath:  "groups",
+				ExpectedResult:       []string{"admins", "editors"},
+			},
+			{
+				Name:                 "Given a user info JSON response with a single group claim",
+				UserInfoJSONResponse: []byte(`{"attributes": {"department": "engineering"}}`),
+				GroupsAttributePath:  "attributes.department",
+				ExpectedResult:       []string{"engineering"},
+			},
+			{
+				Name:                 "Given a user info JSON response without groups",
+				UserInfoJSONResponse: []byte(`{"login": "grafana"}`),
+				GroupsAttributePath:  "groups",
+			},
+		}
+
+		for _, test := range tests {
+			provider.groupsAttributePath = test.GroupsAttributePath
+			t.Run(test.Name, func(t *testing.T) {
+				actualResult, err := provider.extractGroups(&UserInfoJson{rawJSON: test.UserInfoJSONResponse})
+				if test.ExpectedError == "" {
+					require.NoError(t, err, "Testing case %q", test.Name)
+				} else {
+					require.EqualError(t, err, test.ExpectedError, "Testing case %q", test.Name)
+				}
+				require.Equal(t, test.ExpectedResult, actualResult)
+			})
+		}
+	})
+}
+
 func TestUserInfoSearchesForEmailAndRole(t *testing.T) {
 	t.Run("Given a generic OAuth provider", func(t *testing.T) {
 		provider := SocialGenericOAuth{
@@ -601,3 +657,79 @@ func TestPayloadCompression(t *testing.T) {
 		})
 	}
 }
+
+func TestUserInfoSearchesForOrgRolesAndGrafanaAdmin(t *testing.T) {
+	provider := SocialGenericOAuth{
+		SocialBase: &SocialBase{
+			log: newLogger("generic_oauth_test", log15.LvlDebug),
+		},
+		orgRolesAttributePath:     `{"1": contains(groups[*], 'admins') && 'Admin' || 'Viewer', "Ops": contains(groups[*], 'ops') && 'Editor' || null, "3": 'Owner'}`,
+		grafanaAdminAttributePath: "contains(groups[*], 'admins')",
+	}
+
+	tests := []struct {
+		Name                   string
+		ResponseBody           interface{}
+		ExpectedOrgRoles       map[string]string
+		ExpectedIsGrafanaAdmin *bool
+	}{
+		{
+			Name: "Given a member of every group, map every organization",
+			ResponseBody: map[string]interface{}{
+				"email":  "john.doe@example.com",
+				"groups": []string{"admins", "ops"},
+			},
+			ExpectedOrgRoles:       map[string]string{"1": "Admin", "Ops": "Editor"},
+			ExpectedIsGrafanaAdmin: boolPtr(true),
+		},
+		{
+			Name: "Given a member of no group, map the organizations with a role",
+			ResponseBody: map[string]interface{}{
+				"email":  "john.doe@example.com",
+				"groups": []string{},
+			},
+			ExpectedOrgRoles:       map[string]string{"1": "Viewer"},
+			ExpectedIsGrafanaAdmin: boolPtr(false),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			body, err := json.Marshal(test.ResponseBody)
+			require.NoError(t, err)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, err := w.Write(body)
+				require.NoError(t, err)
+			}))
+			t.Cleanup(ts.Close)
+			provider.apiUrl = ts.URL
+
+			actualResult, err := provider.UserInfo(ts.Client(), &oauth2.Token{})
+			require.NoError(t, err)
+			require.Equal(t, test.ExpectedOrgRoles, actualResult.OrgRoles)
+			require.Equal(t, test.ExpectedIsGrafanaAdmin, actualResult.IsGrafanaAdmin)
+		})
+	}
+
+	t.Run("Given no role in any organization, refuse the user", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`{"email": "john.doe@example.com", "groups": ["ops"]}`))
+			require.NoError(t, err)
+		}))
+		t.Cleanup(ts.Close)
+
+		provider := provider
+		provider.apiUrl = ts.URL
+		provider.orgRolesAttributePath = `{"1": contains(groups[*], 'admins') && 'Admin' || null}`
+
+		_, err := provider.UserInfo(ts.Client(), &oauth2.Token{})
+		var sErr *Error
+		require.True(t, errors.As(err, &sErr))
+		require.Equal(t, errMissingOrgRole.Error(), sErr.Error())
+	})
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -29,6 +29,9 @@ type BasicUserInfo struct {
 	Company string
 	Role    string
 	Groups  []string
+	// OrgRoles are the roles of the user by organization ID or name
+	OrgRoles       map[string]string
+	IsGrafanaAdmin *bool
 }
 
 type SocialConnector interface {
@@ -92,6 +95,7 @@ func NewOAuthService() {
 			AuthUrl:            sec.Key("auth_url").String(),
 			TokenUrl:           sec.Key("token_url").String(),
 			ApiUrl:             sec.Key("api_url").String(),
+			IntrospectionUrl:   sec.Key("introspection_url").String(),
 			Enabled:            sec.Key("enabled").MustBool(),
 			EmailAttributeName: sec.Key("email_attribute_name").String(),
 			EmailAttributePath: sec.Key("email_attribute_path").String(),
@@ -177,16 +181,19 @@ func NewOAuthService() {
 		// Generic - Uses the same scheme as GitHub.
 		if name == "generic_oauth" {
 			SocialMap["generic_oauth"] = &SocialGenericOAuth{
-				SocialBase:           newSocialBase(name, &config, info),
-				apiUrl:               info.ApiUrl,
-				emailAttributeName:   info.EmailAttributeName,
-				emailAttributePath:   info.EmailAttributePath,
-				nameAttributePath:    sec.Key("name_attribute_path").String(),
-				roleAttributePath:    info.RoleAttributePath,
-				loginAttributePath:   sec.Key("login_attribute_path").String(),
-				idTokenAttributeName: sec.Key("id_token_attribute_name").String(),
-				teamIds:              sec.Key("team_ids").Ints(","),
-				allowedOrganizations: util.SplitString(sec.Key("allowed_organizations").String()),
+				SocialBase:                newSocialBase(name, &config, info),
+				apiUrl:                    info.ApiUrl,
+				emailAttributeName:        info.EmailAttributeName,
+				emailAttributePath:        info.EmailAttributePath,
+				nameAttributePath:         sec.Key("name_attribute_path").String(),
+				roleAttributePath:         info.RoleAttributePath,
+				orgRolesAttributePath:     sec.Key("org_roles_attribute_path").String(),
+				grafanaAdminAttributePath: sec.Key("grafana_admin_attribute_path").String(),
+				groupsAttributePath:       sec.Key("groups_attribute_path").String(),
+				loginAttributePath:        sec.Key("login_attribute_path").String(),
+				idTokenAttributeName:      sec.Key("id_token_attribute_name").String(),
+				teamIds:                   sec.Key("team_ids").Ints(","),
+				allowedOrganizations:      util.SplitString(sec.Key("allowed_organizations").String()),
 			}
 		}
 
@@ -8,6 +8,7 @@ import (
 
 	macaron "gopkg.in/macaron.v1"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
@@ -106,6 +107,29 @@ func Auth(options *AuthOptions) macaron.Handler {
 	}
 }
 
+var scopeParamRegexp = regexp.MustCompile(`{(\w+)}`)
+
+// Authorize creates a middleware that allows access if the signed in user has a permission
+// for the action on the scope. Parameters of the scope like {id} are replaced with the
+// parameters of the route, so datasources:id:{id} is the scope of the data source in the route.
+func Authorize(action string, scope string) macaron.Handler {
+	return func(c *models.ReqContext) {
+		scope := scopeParamRegexp.ReplaceAllStringFunc(scope, func(param string) string {
+			return c.Params(":" + strings.Trim(param, "{}"))
+		})
+
+		query := models.EvaluatePermissionQuery{User: c.SignedInUser, Action: action, Scope: scope}
+		if err := bus.Dispatch(&query); err != nil {
+			c.JsonApiErr(500, "Failed to evaluate permissions", err)
+			return
+		}
+
+		if !query.Result {
+			accessForbidden(c)
+		}
+	}
+}
+
 // AdminOrFeatureEnabled creates a middleware that allows access
 // if the signed in user is either an Org Admin or if the
 // feature flag is enabled.
@@ -123,6 +123,36 @@ func TestMiddlewareAuth(t *testing.T) {
 		sc.fakeReq("GET", "/api/snapshot").exec()
 		assert.Equal(t, 200, sc.resp.Code)
 	})
+
+	middlewareScenario(t, "Authorize with the permission should return 200", func(
+		t *testing.T, sc *scenarioContext) {
+		var evaluated models.EvaluatePermissionQuery
+		bus.AddHandler("test", func(query *models.EvaluatePermissionQuery) error {
+			evaluated = *query
+			query.Result = true
+			return nil
+		})
+
+		sc.m.Get("/api/datasources/:id", Authorize("datasources:read", "datasources:id:{id}"), sc.defaultHandler)
+		sc.fakeReq("GET", "/api/datasources/5").exec()
+
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, "datasources:read", evaluated.Action)
+		assert.Equal(t, "datasources:id:5", evaluated.Scope)
+	})
+
+	middlewareScenario(t, "Authorize without the permission should return 403", func(
+		t *testing.T, sc *scenarioContext) {
+		bus.AddHandler("test", func(query *models.EvaluatePermissionQuery) error {
+			query.Result = false
+			return nil
+		})
+
+		sc.m.Get("/api/datasources/:id", Authorize("datasources:read", "datasources:id:{id}"), sc.defaultHandler)
+		sc.fakeReq("GET", "/api/datasources/5").exec()
+
+		assert.Equal(t, 403, sc.resp.Code)
+	})
 }
 
 func TestRemoveForceLoginparams(t *testing.T) {
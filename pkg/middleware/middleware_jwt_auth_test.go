@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestMiddlewareJWTAuth(t *testing.T) {
+	const userID int64 = 12
+	const orgID int64 = 2
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	require.NoError(t, err)
+	sign := func(t *testing.T, claims map[string]interface{}) string {
+		token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+
+	configure := func(cfg *setting.Cfg) {
+		cfg.JWTAuth = setting.JWTAuthSettings{
+			Enabled:           true,
+			HeaderName:        "X-JWT-Assertion",
+			KeyFile:           keyFile,
+			Leeway:            time.Minute,
+			LoginClaim:        "sub",
+			EmailClaim:        "email",
+			NameClaim:         "name",
+			RoleAttributePath: "contains(roles[*], 'admin') && 'Admin' || 'Viewer'",
+			AutoSignUp:        true,
+		}
+	}
+
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub":   "jwt-user",
+			"email": "jwt@example.com",
+			"roles": []string{"admin"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	middlewareScenario(t, "Valid JWT provisions the user", func(t *testing.T, sc *scenarioContext) {
+		require.NoError(t, sc.contextHandler.JWTAuthService.Init())
+
+		var extUser *models.ExternalUserInfo
+		bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+			extUser = cmd.ExternalUser
+			assert.True(t, cmd.SignupAllowed)
+			cmd.Result = &models.User{Id: userID}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: orgID}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set("X-JWT-Assertion", sign(t, claims()))
+		sc.exec()
+
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, userID, sc.context.UserId)
+		assert.Equal(t, orgID, sc.context.OrgId)
+
+		require.NotNil(t, extUser)
+		assert.Equal(t, "jwt", extUser.AuthModule)
+		assert.Equal(t, "jwt-user", extUser.Login)
+		assert.Equal(t, "jwt@example.com", extUser.Email)
+		assert.Equal(t, models.ROLE_ADMIN, extUser.OrgRoles[1])
+	}, configure)
+
+	middlewareScenario(t, "Bearer tokens are accepted in the Authorization header", func(t *testing.T, sc *scenarioContext) {
+		require.NoError(t, sc.contextHandler.JWTAuthService.Init())
+
+		bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+			cmd.Result = &models.User{Id: userID}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{UserId: query.UserId, OrgId: orgID}
+			return nil
+		})
+
+		sc.fakeReq("GET", "/").withAuthorizationHeader("Bearer " + sign(t, claims())).exec()
+
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, sc.context.IsSignedIn)
+	}, func(cfg *setting.Cfg) {
+		configure(cfg)
+		cfg.JWTAuth.HeaderName = "Authorization"
+	})
+
+	middlewareScenario(t, "Expired JWT is refused", func(t *testing.T, sc *scenarioContext) {
+		require.NoError(t, sc.contextHandler.JWTAuthService.Init())
+
+		expired := claims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set("X-JWT-Assertion", sign(t, expired))
+		sc.exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+		assert.Equal(t, contexthandler.InvalidJWT, sc.respJson["message"])
+	}, configure)
+
+	middlewareScenario(t, "Unknown users are refused without auto sign up", func(t *testing.T, sc *scenarioContext) {
+		require.NoError(t, sc.contextHandler.JWTAuthService.Init())
+
+		bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+			assert.False(t, cmd.SignupAllowed)
+			return login.ErrInvalidCredentials
+		})
+
+		sc.fakeReq("GET", "/")
+		sc.req.Header.Set("X-JWT-Assertion", sign(t, claims()))
+		sc.exec()
+
+		assert.Equal(t, 401, sc.resp.Code)
+	}, func(cfg *setting.Cfg) {
+		configure(cfg)
+		cfg.JWTAuth.AutoSignUp = false
+	})
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareOAuthSessionValidation(t *testing.T) {
+	const userID int64 = 12
+
+	active := true
+	introspections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspections++
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]bool{"active": active}))
+	}))
+	t.Cleanup(server.Close)
+
+	oldOAuthService := setting.OAuthService
+	t.Cleanup(func() { setting.OAuthService = oldOAuthService })
+	setting.OAuthService = &setting.OAuther{OAuthInfos: map[string]*setting.OAuthInfo{
+		"generic_oauth": {ClientId: "grafana", IntrospectionUrl: server.URL},
+	}}
+
+	configure := func(cfg *setting.Cfg) {
+		cfg.OAuthValidateSession = true
+		cfg.OAuthValidateSessionInterval = time.Minute
+	}
+
+	setupSession := func(sc *scenarioContext) {
+		sc.withTokenSessionCookie("token")
+		sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+			return &models.UserToken{Id: 1, UserId: userID, UnhashedToken: unhashedToken}, nil
+		}
+
+		bus.AddHandler("test", func(query *models.GetSignedInUserQuery) error {
+			query.Result = &models.SignedInUser{OrgId: 2, UserId: userID}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+			query.Result = &models.UserAuth{
+				UserId:           userID,
+				AuthModule:       "oauth_generic_oauth",
+				OAuthAccessToken: "access",
+				OAuthExpiry:      time.Now().Add(time.Hour),
+			}
+			return nil
+		})
+	}
+
+	middlewareScenario(t, "Sessions with an active OAuth token are kept and checked once per interval", func(t *testing.T, sc *scenarioContext) {
+		setupSession(sc)
+		active, introspections = true, 0
+
+		sc.fakeReq("GET", "/").exec()
+		assert.True(t, sc.context.IsSignedIn)
+
+		sc.fakeReq("GET", "/").exec()
+		assert.True(t, sc.context.IsSignedIn)
+		assert.Equal(t, 1, introspections)
+	}, configure)
+
+	middlewareScenario(t, "Sessions with a revoked OAuth token are revoked", func(t *testing.T, sc *scenarioContext) {
+		setupSession(sc)
+		active, introspections = false, 0
+
+		var revokedUserID int64
+		sc.userAuthTokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userId int64) error {
+			revokedUserID = userId
+			return nil
+		}
+
+		sc.fakeReq("GET", "/").exec()
+
+		assert.False(t, sc.context.IsSignedIn)
+		assert.Equal(t, userID, revokedUserID)
+		assert.Contains(t, sc.resp.Header().Get("Set-Cookie"), "grafana_session=;")
+	}, configure)
+}
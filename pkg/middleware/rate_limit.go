@@ -1,12 +1,18 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"golang.org/x/time/rate"
 	"gopkg.in/macaron.v1"
 
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ratelimit"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 type getTimeFn func() time.Time
@@ -23,3 +29,39 @@ func RateLimit(rps, burst int, getTime getTimeFn) macaron.Handler {
 		}
 	}
 }
+
+// RateLimitRoute limits the rate of the requests of every user, API key or organization to the route group,
+// as configured in the rate_limits settings.
+func RateLimitRoute(rateLimitService *ratelimit.RateLimitService) func(group string) macaron.Handler {
+	return func(group string) macaron.Handler {
+		return func(c *models.ReqContext) {
+			allowed, retryAfter, err := rateLimitService.Allow(group, rateLimitKey(c, rateLimitService.Cfg))
+			if err != nil {
+				// The API stays available when the rate limits can't be checked
+				c.Logger.Warn("Failed to check rate limit", "group", group, "error", err)
+				return
+			}
+			if !allowed {
+				metrics.MApiRateLimitedTotal.WithLabelValues(group).Inc()
+				c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				c.JsonApiErr(429, "Rate limit reached", nil)
+				return
+			}
+		}
+	}
+}
+
+// rateLimitKey returns the key of the bucket of the request: its organization, or else its API key,
+// its user, or its IP address for anonymous requests. The forwarding headers of the request are only trusted
+// from the proxies of the login lockout settings.
+func rateLimitKey(c *models.ReqContext, cfg *setting.Cfg) string {
+	switch {
+	case cfg.RateLimits.KeyBy == "org" && c.OrgId != 0:
+		return fmt.Sprintf("org:%d", c.OrgId)
+	case c.ApiKeyId != 0:
+		return fmt.Sprintf("api_key:%d", c.ApiKeyId)
+	case c.IsSignedIn && c.UserId != 0:
+		return fmt.Sprintf("user:%d", c.UserId)
+	}
+	return "ip:" + c.ClientIPAddress(cfg.LoginLockout.TrustedProxies)
+}
@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/ratelimit"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/stretchr/testify/assert"
@@ -89,3 +92,78 @@ func TestRateLimitMiddleware(t *testing.T) {
 		}
 	})
 }
+
+func TestRateLimitRouteMiddleware(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.RateLimits = setting.RateLimitSettings{
+		Enabled: true,
+		KeyBy:   "user",
+		Storage: "local",
+		Limits: map[string]setting.RateLimit{
+			setting.RateLimitGroupQuery: {RPS: 1, Burst: 2},
+		},
+	}
+	rateLimitService := &ratelimit.RateLimitService{Cfg: cfg}
+	require.NoError(t, rateLimitService.Init())
+
+	m := macaron.New()
+	m.Use(macaron.Renderer(macaron.RenderOptions{
+		Directory: "",
+		Delims:    macaron.Delims{Left: "[[", Right: "]]"},
+	}))
+	m.Use(getContextHandler(t, cfg).Middleware)
+	signIn := func(c *models.ReqContext) {
+		userID, err := strconv.ParseInt(c.Req.Header.Get("X-User-Id"), 10, 64)
+		require.NoError(t, err)
+		c.SignedInUser = &models.SignedInUser{UserId: userID, OrgId: 1}
+		c.IsSignedIn = true
+	}
+	m.Post("/api/ds/query", signIn, RateLimitRoute(rateLimitService)(setting.RateLimitGroupQuery), func(c *models.ReqContext) {
+		c.JSON(200, map[string]interface{}{"message": "OK"})
+	})
+
+	doReq := func(userID string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/api/ds/query", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-User-Id", userID)
+		m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, 200, doReq("1").Code)
+	assert.Equal(t, 200, doReq("1").Code)
+
+	resp := doReq("1")
+	assert.Equal(t, 429, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+
+	// other users have buckets of their own
+	assert.Equal(t, 200, doReq("2").Code)
+}
+
+func TestRateLimitKey(t *testing.T) {
+	cfg := setting.NewCfg()
+	_, proxy, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	cfg.LoginLockout.TrustedProxies = []*net.IPNet{proxy}
+
+	newContext := func(remoteAddr, forwardedFor string) *models.ReqContext {
+		req, err := http.NewRequest("GET", "/", nil)
+		require.NoError(t, err)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		return &models.ReqContext{
+			Context:      &macaron.Context{Req: macaron.Request{Request: req}},
+			SignedInUser: &models.SignedInUser{},
+		}
+	}
+
+	t.Run("Anonymous requests are keyed on the connection IP when the forwarding headers aren't trusted", func(t *testing.T) {
+		assert.Equal(t, "ip:192.168.1.10", rateLimitKey(newContext("192.168.1.10:52000", "203.0.113.7"), cfg))
+	})
+
+	t.Run("Anonymous requests are keyed on the forwarded IP behind a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "ip:203.0.113.7", rateLimitKey(newContext("10.0.0.2:52000", "203.0.113.7"), cfg))
+	})
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrRoleNameTaken           = errors.New("role name is taken")
+	ErrRoleUidTaken            = errors.New("role uid is taken")
+	ErrRoleInvalidUid          = errors.New("role uid contains invalid characters or is too long")
+	ErrRoleAssignmentNotFound  = errors.New("role assignment not found")
+	ErrRoleAssignmentExists    = errors.New("role is already assigned")
+	ErrInvalidRoleAssignment   = errors.New("a role must be assigned to exactly one of a user, a team, an API key or a built-in role")
+	ErrInvalidBuiltinRole      = errors.New("invalid built-in role")
+	ErrRoleAssigneeNotFound    = errors.New("the user, team or API key isn't part of the organization")
+	ErrInvalidPermissionAction = errors.New("permission action is required")
+)
+
+// BuiltinRoleGrafanaAdmin is the built-in role of Grafana server admins.
+// The other built-in roles are the organization roles Viewer, Editor and Admin.
+const BuiltinRoleGrafanaAdmin = "Grafana Admin"
+
+// IsValidBuiltinRole returns true if role is an organization role or the Grafana Admin role.
+func IsValidBuiltinRole(role string) bool {
+	return role == BuiltinRoleGrafanaAdmin || RoleType(role).IsValid()
+}
+
+// Permission allows an action, like datasources:read, on the resources matched by a scope,
+// like datasources:id:1. Scopes ending with * match all the scopes starting with the same prefix.
+type Permission struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+}
+
+// Role is a custom role, made of permissions, defined in an organization.
+type Role struct {
+	Id          int64  `json:"id"`
+	OrgId       int64  `json:"orgId"`
+	Uid         string `json:"uid"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// RolePermission is a permission of a custom role.
+type RolePermission struct {
+	Id     int64
+	RoleId int64
+	Action string
+	Scope  string
+}
+
+// TableName returns the name of the table of role permissions.
+func (p RolePermission) TableName() string {
+	return "permission"
+}
+
+// RoleAssignment assigns a role to one of a user, a team, an API key or a built-in role.
+type RoleAssignment struct {
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	RoleId      int64     `json:"roleId"`
+	UserId      int64     `json:"userId"`
+	TeamId      int64     `json:"teamId"`
+	ApiKeyId    int64     `json:"apiKeyId"`
+	BuiltinRole string    `json:"builtinRole"`
+	Created     time.Time `json:"created"`
+}
+
+// Valid returns true if the assignment has exactly one assignee.
+func (a RoleAssignment) Valid() bool {
+	assignees := 0
+	for _, assigned := range []bool{a.UserId > 0, a.TeamId > 0, a.ApiKeyId > 0, a.BuiltinRole != ""} {
+		if assigned {
+			assignees++
+		}
+	}
+
+	return assignees == 1
+}
+
+// ---------------------
+// COMMANDS
+
+type CreateRoleCommand struct {
+	Uid         string       `json:"uid"`
+	Name        string       `json:"name" binding:"Required"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	OrgId       int64        `json:"-"`
+
+	Result *RoleDTO `json:"-"`
+}
+
+type UpdateRoleCommand struct {
+	Uid         string       `json:"-"`
+	Name        string       `json:"name" binding:"Required"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	OrgId       int64        `json:"-"`
+
+	Result *RoleDTO `json:"-"`
+}
+
+type DeleteRoleCommand struct {
+	Uid   string
+	OrgId int64
+}
+
+type AddRoleAssignmentCommand struct {
+	RoleUid     string `json:"-"`
+	UserId      int64  `json:"userId"`
+	TeamId      int64  `json:"teamId"`
+	ApiKeyId    int64  `json:"apiKeyId"`
+	BuiltinRole string `json:"builtinRole"`
+	OrgId       int64  `json:"-"`
+
+	Result *RoleAssignment `json:"-"`
+}
+
+type RemoveRoleAssignmentCommand struct {
+	RoleUid string
+	Id      int64
+	OrgId   int64
+}
+
+// ---------------------
+// QUERIES
+
+type GetRolesQuery struct {
+	OrgId  int64
+	Result []*Role
+}
+
+type GetRoleByUidQuery struct {
+	Uid    string
+	OrgId  int64
+	Result *RoleDTO
+}
+
+type GetRoleAssignmentsQuery struct {
+	RoleUid string
+	OrgId   int64
+	Result  []*RoleAssignment
+}
+
+// GetUserPermissionsQuery returns the permissions of the custom roles assigned to a user,
+// the teams of the user, an API key or the built-in roles of the user.
+type GetUserPermissionsQuery struct {
+	OrgId        int64
+	UserId       int64
+	ApiKeyId     int64
+	BuiltinRoles []string
+	Result       []Permission
+}
+
+// EvaluatePermissionQuery evaluates if a user has a permission for an action on a scope,
+// granted by a built-in role or a custom role.
+type EvaluatePermissionQuery struct {
+	User   *SignedInUser
+	Action string
+	Scope  string
+	Result bool
+}
+
+// ---------------------
+// DTO
+
+type RoleDTO struct {
+	Id          int64        `json:"id"`
+	OrgId       int64        `json:"orgId"`
+	Uid         string       `json:"uid"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/secrets"
 )
 
 var (
@@ -167,10 +169,15 @@ type GetOrCreateNotificationStateQuery struct {
 	Result *AlertNotificationState
 }
 
-// decryptedValue returns decrypted value from secureSettings
+// DecryptedValue returns decrypted value from secureSettings, with references
+// to secrets of secrets providers resolved
 func (an *AlertNotification) DecryptedValue(field string, fallback string) string {
 	if value, ok := an.SecureSettings.DecryptedValue(field); ok {
-		return value
+		resolved, err := secrets.Resolve(an.OrgId, value)
+		if err != nil {
+			log.New("alerting.notifier").Error("Failed to resolve secret", "notifier", an.Name, "field", field, "error", err)
+		}
+		return resolved
 	}
 	return fallback
 }
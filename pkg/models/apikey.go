@@ -18,6 +18,10 @@ type ApiKey struct {
 	Created time.Time
 	Updated time.Time
 	Expires *int64
+
+	// ServiceAccountId is set for the tokens of service accounts, which get the role of the service account
+	ServiceAccountId *int64
+	LastUsedAt       *time.Time
 }
 
 // ---------------------
@@ -29,6 +33,8 @@ type AddApiKeyCommand struct {
 	Key           string   `json:"-"`
 	SecondsToLive int64    `json:"secondsToLive"`
 
+	ServiceAccountId *int64 `json:"-"`
+
 	Result *ApiKey `json:"-"`
 }
 
@@ -37,6 +43,10 @@ type DeleteApiKeyCommand struct {
 	OrgId int64 `json:"-"`
 }
 
+type UpdateApiKeyLastUsedAtCommand struct {
+	Id int64
+}
+
 // ----------------------
 // QUERIES
 
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	AuditLogOutcomeSuccess = "success"
+	AuditLogOutcomeFailure = "failure"
+)
+
+// AuditLogEntry records an API call changing a resource.
+type AuditLogEntry struct {
+	Id        int64  `json:"id"`
+	OrgId     int64  `json:"orgId"`
+	UserId    int64  `json:"userId"`
+	UserLogin string `json:"userLogin"`
+	ApiKeyId  int64  `json:"apiKeyId"`
+
+	// Action is create, update or delete
+	Action string `json:"action"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+
+	ResourceType  string `json:"resourceType"`
+	ResourceUid   string `json:"resourceUid"`
+	BeforeSummary string `json:"beforeSummary"`
+	AfterSummary  string `json:"afterSummary"`
+
+	ClientIp string    `json:"clientIp"`
+	Status   int       `json:"status"`
+	Outcome  string    `json:"outcome"`
+	Created  time.Time `json:"created"`
+}
+
+// ---------------------
+// COMMANDS
+
+type AddAuditLogEntryCommand struct {
+	Entry *AuditLogEntry
+}
+
+type DeleteOldAuditLogEntriesCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
+
+// ---------------------
+// QUERIES
+
+type SearchAuditLogQuery struct {
+	OrgId        int64
+	UserId       int64
+	Action       string
+	ResourceType string
+	ResourceUid  string
+	Outcome      string
+	From         time.Time
+	To           time.Time
+	Page         int
+	PerPage      int
+
+	Result SearchAuditLogQueryResult
+}
+
+type SearchAuditLogQueryResult struct {
+	TotalCount int64            `json:"totalCount"`
+	Entries    []*AuditLogEntry `json:"entries"`
+	Page       int              `json:"page"`
+	PerPage    int              `json:"perPage"`
+}
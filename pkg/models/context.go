@@ -1,9 +1,11 @@
 package models
 
 import (
+	"net"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/macaron.v1"
@@ -78,3 +80,14 @@ func (ctx *ReqContext) HasHelpFlag(flag HelpFlags1) bool {
 func (ctx *ReqContext) TimeRequest(timer prometheus.Summary) {
 	ctx.Data["perfmon.timer"] = timer
 }
+
+// ClientIPAddress returns the IP address of the client, or an empty string when it isn't known. The
+// forwarding headers are only read from the trusted proxies, see network.ClientIP.
+func (ctx *ReqContext) ClientIPAddress(trustedProxies []*net.IPNet) string {
+	ip, err := network.ClientIP(ctx.Req.RemoteAddr, ctx.Req.Header, trustedProxies)
+	if err != nil {
+		return ""
+	}
+
+	return ip.String()
+}
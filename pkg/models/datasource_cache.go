@@ -12,7 +12,9 @@ import (
 
 	"github.com/grafana/grafana-aws-sdk/pkg/sigv4"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics/metricutil"
+	"github.com/grafana/grafana/pkg/infra/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -225,7 +227,7 @@ func (ds *DataSource) GetTLSConfig() (*tls.Config, error) {
 	}
 
 	if tlsClientAuth || tlsAuthWithCACert {
-		decrypted := ds.SecureJsonData.Decrypt()
+		decrypted := ds.resolveSecrets(ds.SecureJsonData.Decrypt())
 		if tlsAuthWithCACert && len(decrypted["tlsCACert"]) > 0 {
 			caPool := x509.NewCertPool()
 			ok := caPool.AppendCertsFromPEM([]byte(decrypted["tlsCACert"]))
@@ -255,7 +257,7 @@ func (ds *DataSource) getCustomHeaders() map[string]string {
 		return headers
 	}
 
-	decrypted := ds.SecureJsonData.Decrypt()
+	decrypted := ds.resolveSecrets(ds.SecureJsonData.Decrypt())
 	index := 1
 	for {
 		headerNameSuffix := fmt.Sprintf("httpHeaderName%d", index)
@@ -290,8 +292,24 @@ var dsDecryptionCache = secureJSONDecryptionCache{
 	cache: make(map[int64]cachedDecryptedJSON),
 }
 
-// DecryptedValues returns cached decrypted values from secureJsonData.
+// DecryptedValues returns cached decrypted values from secureJsonData,
+// with references to secrets of secrets providers resolved.
 func (ds *DataSource) DecryptedValues() map[string]string {
+	return ds.resolveSecrets(ds.decryptedValues())
+}
+
+// resolveSecrets resolves references to secrets of secrets providers in decrypted values.
+// Values that can't be resolved are kept as they are.
+func (ds *DataSource) resolveSecrets(values map[string]string) map[string]string {
+	resolved, err := secrets.ResolveValues(ds.OrgId, values)
+	if err != nil {
+		log.New("datasource").Error("Failed to resolve secrets", "datasource", ds.Name, "error", err)
+	}
+
+	return resolved
+}
+
+func (ds *DataSource) decryptedValues() map[string]string {
 	dsDecryptionCache.Lock()
 	defer dsDecryptionCache.Unlock()
 
@@ -5,11 +5,14 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/stretchr/testify/assert"
@@ -307,6 +310,36 @@ func TestDataSource_DecryptedValue(t *testing.T) {
 		require.True(t, ok)
 		assert.Empty(t, password)
 	})
+
+	t.Run("When secureJsonData references secrets, they should be resolved every time", func(t *testing.T) {
+		ClearDSDecryptionCache()
+		dir := t.TempDir()
+		secrets.Register(secrets.FileProviderName, secrets.NewFileProvider(dir))
+		t.Cleanup(func() { secrets.Unregister(secrets.FileProviderName) })
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "orgs", "1", "influxdb"), 0750))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "orgs", "1", "influxdb", "password"), []byte("secret"), 0600))
+
+		ds := DataSource{
+			Id:       1,
+			OrgId:    1,
+			Type:     DS_INFLUXDB_08,
+			JsonData: simplejson.New(),
+			User:     "user",
+			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{
+				"password": "$__secretfile{orgs/1/influxdb:password}",
+			}),
+		}
+
+		password, ok := ds.DecryptedValue("password")
+		require.True(t, ok)
+		assert.Equal(t, "secret", password)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "orgs", "1", "influxdb", "password"), []byte("rotated"), 0600))
+
+		password, ok = ds.DecryptedValue("password")
+		require.True(t, ok)
+		assert.Equal(t, "rotated", password)
+	})
 }
 
 func clearDSProxyCache(t *testing.T) {
@@ -0,0 +1,10 @@
+package models
+
+import (
+	"errors"
+)
+
+var ErrJWTInvalid = errors.New("invalid JWT")
+
+// JWTClaims are the claims of a verified JWT, decoded from JSON.
+type JWTClaims map[string]interface{}
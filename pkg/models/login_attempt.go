@@ -11,6 +11,15 @@ type LoginAttempt struct {
 	Created   int64
 }
 
+// LoginLockout is a username or an IP address whose logins are blocked after too many failed attempts.
+type LoginLockout struct {
+	Username     string    `json:"username,omitempty"`
+	IpAddress    string    `json:"ipAddress,omitempty"`
+	Attempts     int64     `json:"attempts"`
+	FirstAttempt time.Time `json:"firstAttempt"`
+	LastAttempt  time.Time `json:"lastAttempt"`
+}
+
 // ---------------------
 // COMMANDS
 
@@ -26,6 +35,15 @@ type DeleteOldLoginAttemptsCommand struct {
 	DeletedRows int64
 }
 
+// DeleteLoginAttemptsCommand clears the failed login attempts with a username or from an IP address,
+// or the single attempt with an Id.
+type DeleteLoginAttemptsCommand struct {
+	Id          int64
+	Username    string
+	IpAddress   string
+	DeletedRows int64
+}
+
 // ---------------------
 // QUERIES
 
@@ -34,3 +52,18 @@ type GetUserLoginAttemptCountQuery struct {
 	Since    time.Time
 	Result   int64
 }
+
+type GetIPLoginAttemptCountQuery struct {
+	IpAddress string
+	Since     time.Time
+	Result    int64
+}
+
+// GetLoginLockoutsQuery returns the usernames and IP addresses with at least MaxUserAttempts and
+// MaxIPAttempts failed login attempts since Since. A max of 0 doesn't return any lockout.
+type GetLoginLockoutsQuery struct {
+	Since           time.Time
+	MaxUserAttempts int64
+	MaxIPAttempts   int64
+	Result          []*LoginLockout
+}
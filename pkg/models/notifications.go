@@ -47,3 +47,17 @@ type ValidateResetPasswordCodeQuery struct {
 	Code   string
 	Result *User
 }
+
+// SendLoginLockoutEmailCommand notifies of the lockout of a username after failed logins,
+// or of an IP address when Username is empty.
+type SendLoginLockoutEmailCommand struct {
+	Username  string
+	IpAddress string
+}
+
+// SendNewLoginEmailCommand notifies a user of a login from a new IP address or user agent.
+type SendNewLoginEmailCommand struct {
+	User      *User
+	IpAddress string
+	UserAgent string
+}
@@ -23,6 +23,9 @@ type Org struct {
 	State    string
 	Country  string
 
+	// RequireTotp requires the members of the organization to use two-factor authentication
+	RequireTotp bool
+
 	Created time.Time
 	Updated time.Time
 }
@@ -78,9 +81,10 @@ type OrgDTO struct {
 }
 
 type OrgDetailsDTO struct {
-	Id      int64   `json:"id"`
-	Name    string  `json:"name"`
-	Address Address `json:"address"`
+	Id          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Address     Address `json:"address"`
+	RequireTotp bool    `json:"requireTotp"`
 }
 
 type UserOrgDTO struct {
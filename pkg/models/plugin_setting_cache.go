@@ -1,11 +1,26 @@
 package models
 
+import (
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/secrets"
+)
+
 var pluginSettingDecryptionCache = secureJSONDecryptionCache{
 	cache: make(map[int64]cachedDecryptedJSON),
 }
 
-// DecryptedValues returns cached decrypted values from secureJsonData.
+// DecryptedValues returns cached decrypted values from secureJsonData,
+// with references to secrets of secrets providers resolved.
 func (ps *PluginSetting) DecryptedValues() map[string]string {
+	json, err := secrets.ResolveValues(ps.OrgId, ps.decryptedValues())
+	if err != nil {
+		log.New("plugins").Error("Failed to resolve secrets", "pluginId", ps.PluginId, "error", err)
+	}
+
+	return json
+}
+
+func (ps *PluginSetting) decryptedValues() map[string]string {
 	pluginSettingDecryptionCache.Lock()
 	defer pluginSettingDecryptionCache.Unlock()
 
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrServiceAccountNotFound      = errors.New("service account not found")
+	ErrServiceAccountAlreadyExists = errors.New("service account with the same name already exists")
+	ErrServiceAccountTokenNotFound = errors.New("service account token not found")
+	ErrServiceAccountRoleTooHigh   = errors.New("cannot convert an API key with a role higher than your own")
+)
+
+// ServiceAccountLoginPrefix is the prefix of the logins of service accounts
+const ServiceAccountLoginPrefix = "sa-"
+
+// ---------------------
+// COMMANDS
+
+type CreateServiceAccountCommand struct {
+	Name  string   `json:"name" binding:"Required"`
+	Role  RoleType `json:"role" binding:"Required"`
+	OrgId int64    `json:"-"`
+
+	Result *ServiceAccountDTO `json:"-"`
+}
+
+type UpdateServiceAccountCommand struct {
+	Name       string    `json:"name"`
+	Role       *RoleType `json:"role"`
+	IsDisabled *bool     `json:"isDisabled"`
+	Id         int64     `json:"-"`
+	OrgId      int64     `json:"-"`
+
+	Result *ServiceAccountDTO `json:"-"`
+}
+
+type DeleteServiceAccountCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+// AddServiceAccountTokenCommand is the body of the requests creating service account tokens,
+// which are added with AddApiKeyCommand.
+type AddServiceAccountTokenCommand struct {
+	Name          string `json:"name" binding:"Required"`
+	SecondsToLive int64  `json:"secondsToLive"`
+}
+
+type DeleteServiceAccountTokenCommand struct {
+	Id               int64
+	ServiceAccountId int64
+	OrgId            int64
+}
+
+// ConvertApiKeysToServiceAccountsCommand converts the API keys of an organization to tokens
+// of new service accounts with the role of the keys. It converts only one key if ApiKeyId is set,
+// and returns ErrServiceAccountRoleTooHigh without converting any key when one has a role higher than MaxRole.
+type ConvertApiKeysToServiceAccountsCommand struct {
+	OrgId    int64
+	ApiKeyId int64
+	MaxRole  RoleType
+
+	Result []*ServiceAccountDTO
+}
+
+// ---------------------
+// QUERIES
+
+type GetServiceAccountsQuery struct {
+	OrgId  int64
+	Result []*ServiceAccountDTO
+}
+
+type GetServiceAccountByIdQuery struct {
+	Id     int64
+	OrgId  int64
+	Result *ServiceAccountDTO
+}
+
+type GetServiceAccountTokensQuery struct {
+	ServiceAccountId int64
+	OrgId            int64
+	Result           []*ApiKey
+}
+
+// ------------------------
+// DTO & Projections
+
+type ServiceAccountDTO struct {
+	Id         int64    `json:"id"`
+	OrgId      int64    `json:"orgId"`
+	Name       string   `json:"name"`
+	Login      string   `json:"login"`
+	Role       RoleType `json:"role"`
+	IsDisabled bool     `json:"isDisabled"`
+	Tokens     int64    `json:"tokens"`
+}
+
+type ServiceAccountTokenDTO struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Created    time.Time  `json:"created"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrTeamGroupAlreadyAdded = errors.New("group is already added to this team")
+	ErrTeamGroupNotFound     = errors.New("team group not found")
+)
+
+// TeamGroup links a team to a group of an external auth provider, such as an LDAP group DN,
+// an OAuth group or a claim value. The users of the group are synchronized as external members of the team.
+type TeamGroup struct {
+	Id      int64
+	OrgId   int64
+	TeamId  int64
+	GroupId string
+
+	Created time.Time
+	Updated time.Time
+}
+
+// ---------------------
+// COMMANDS
+
+type AddTeamGroupCommand struct {
+	GroupId string `json:"groupId" binding:"Required"`
+	OrgId   int64  `json:"-"`
+	TeamId  int64  `json:"-"`
+}
+
+type RemoveTeamGroupCommand struct {
+	OrgId   int64
+	TeamId  int64
+	GroupId string
+}
+
+// ----------------------
+// QUERIES
+
+type GetTeamGroupsQuery struct {
+	OrgId  int64
+	TeamId int64
+	Result []*TeamGroupDTO
+}
+
+// GetTeamGroupsByGroupIdsQuery returns the links of all organizations matching one of the groups,
+// compared case-insensitively.
+type GetTeamGroupsByGroupIdsQuery struct {
+	GroupIds []string
+	Result   []*TeamGroupDTO
+}
+
+// ----------------------
+// Projections and DTOs
+
+type TeamGroupDTO struct {
+	OrgId   int64  `json:"orgId"`
+	TeamId  int64  `json:"teamId"`
+	GroupId string `json:"groupId"`
+}
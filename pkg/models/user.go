@@ -33,6 +33,9 @@ type User struct {
 	HelpFlags1    HelpFlags1
 	IsDisabled    bool
 
+	// IsServiceAccount is set for the service accounts, which can't log in
+	IsServiceAccount bool
+
 	IsAdmin bool
 	OrgId   int64
 
@@ -149,7 +152,8 @@ type SearchUsersQuery struct {
 	Limit      int
 	AuthModule string
 
-	IsDisabled *bool
+	IsDisabled     *bool
+	IsGrafanaAdmin *bool
 
 	Result SearchUserQueryResult
 }
@@ -184,6 +188,9 @@ type SignedInUser struct {
 	HelpFlags1     HelpFlags1
 	LastSeenAt     time.Time
 	Teams          []int64
+
+	IsServiceAccount bool
+	IsDisabled       bool
 }
 
 func (u *SignedInUser) ShouldUpdateLastSeenAt() bool {
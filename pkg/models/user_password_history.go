@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserPasswordHistory is a password a user had, kept to refuse its reuse. Password is the
+// encoded password, as in User.
+type UserPasswordHistory struct {
+	Id       int64
+	UserId   int64
+	Password string
+	Salt     string
+	Created  time.Time
+}
+
+// ---------------------
+// COMMANDS
+
+// SeedUserPasswordHistoryCommand records the current password of the users without password history,
+// as set at Created, so that the maximum age of the passwords starts when it is enabled.
+type SeedUserPasswordHistoryCommand struct {
+	Created    time.Time
+	SeededRows int64
+}
+
+// ---------------------
+// QUERIES
+
+// GetUserPasswordHistoryQuery returns the last passwords of the user, the most recent first.
+type GetUserPasswordHistoryQuery struct {
+	UserId int64
+	Limit  int
+	Result []*UserPasswordHistory
+}
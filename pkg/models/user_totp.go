@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserTotpNotFound    = errors.New("two-factor authentication not set up")
+	ErrUserTotpCodeInvalid = errors.New("invalid two-factor authentication code")
+	ErrUserTotpCodeUsed    = errors.New("two-factor authentication code already used")
+)
+
+// UserTotp is the TOTP authenticator of a user, which is enrolled when Secret is saved
+// and protects the logins of the user once Enabled.
+type UserTotp struct {
+	Id     int64
+	UserId int64
+	// Secret is the base32 secret, encrypted with the secret key
+	Secret  []byte
+	Enabled bool
+	// RecoveryCodes is the JSON array of the hashes of the unused recovery codes
+	RecoveryCodes string
+	// LastUsedStep is the time step of the last code used, codes of earlier steps are refused
+	LastUsedStep int64
+	Created      time.Time
+	Updated      time.Time
+}
+
+type UserTotpStatusDTO struct {
+	Enabled           bool `json:"enabled"`
+	EnrollmentPending bool `json:"enrollmentPending"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// ---------------------
+// COMMANDS
+
+// SaveUserTotpSecretCommand starts the enrollment of a new authenticator of the user,
+// replacing the one being enrolled.
+type SaveUserTotpSecretCommand struct {
+	UserId int64
+	Secret []byte
+}
+
+type EnableUserTotpCommand struct {
+	UserId        int64
+	RecoveryCodes string
+	Step          int64
+}
+
+// UseUserTotpStepCommand records the time step of a code used by the user, it returns
+// ErrUserTotpCodeUsed if a code of the step or of a later one was already used.
+type UseUserTotpStepCommand struct {
+	UserId int64
+	Step   int64
+}
+
+// UpdateUserTotpRecoveryCodesCommand replaces the recovery codes of a user, and returns ErrUserTotpCodeUsed when they
+// aren't PreviousRecoveryCodes any more because of a concurrent update.
+type UpdateUserTotpRecoveryCodesCommand struct {
+	UserId                int64
+	RecoveryCodes         string
+	PreviousRecoveryCodes string
+}
+
+type DeleteUserTotpCommand struct {
+	UserId int64
+}
+
+type UpdateOrgRequireTotpCommand struct {
+	OrgId    int64
+	Required bool
+}
+
+// ---------------------
+// QUERIES
+
+type GetUserTotpQuery struct {
+	UserId int64
+	Result *UserTotp
+}
+
+// IsTotpRequiredForUserQuery checks whether any of the organizations of the user requires
+// two-factor authentication.
+type IsTotpRequiredForUserQuery struct {
+	UserId int64
+	Result bool
+}
@@ -35,7 +35,7 @@ func (tw *DatasourcePluginWrapper) Query(ctx context.Context, ds *models.DataSou
 			Id:                      ds.Id,
 			OrgId:                   ds.OrgId,
 			JsonData:                string(jsonData),
-			DecryptedSecureJsonData: ds.SecureJsonData.Decrypt(),
+			DecryptedSecureJsonData: ds.DecryptedValues(),
 		},
 		TimeRange: &datasource.TimeRange{
 			FromRaw:     query.TimeRange.From,
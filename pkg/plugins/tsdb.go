@@ -187,8 +187,18 @@ type DataTimeSeries struct {
 type DataResponse struct {
 	Results map[string]DataQueryResult `json:"results"`
 	Message string                     `json:"message,omitempty"`
+
+	// CacheStatus is DataCacheHit or DataCacheMiss when query caching is enabled for the data source
+	CacheStatus string `json:"-"`
+	// CachedAt is the time the response was cached, for cache hits
+	CachedAt time.Time `json:"-"`
 }
 
+const (
+	DataCacheHit  = "HIT"
+	DataCacheMiss = "MISS"
+)
+
 type DataPlugin interface {
 	DataQuery(ctx context.Context, ds *models.DataSource, query DataQuery) (DataResponse, error)
 }
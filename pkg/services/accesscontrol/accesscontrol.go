@@ -0,0 +1,242 @@
+// Package accesscontrol evaluates the fine-grained permissions of users.
+//
+// A permission allows an action, like datasources:read, on the resources matched by a scope,
+// like datasources:id:1. Permissions are granted by built-in roles, which keep the access of
+// the organization roles Viewer, Editor and Admin and of Grafana Admins, and by custom roles
+// assigned to users, teams, API keys or built-in roles.
+package accesscontrol
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// Data sources
+	ActionDatasourcesRead   = "datasources:read"
+	ActionDatasourcesCreate = "datasources:create"
+	ActionDatasourcesWrite  = "datasources:write"
+	ActionDatasourcesDelete = "datasources:delete"
+
+	// Dashboards, scoped to folders. It's granted in addition to the folder permissions.
+	ActionDashboardsCreate = "dashboards:create"
+
+	// Current organization
+	ActionOrgsWrite            = "orgs:write"
+	ActionOrgsPreferencesRead  = "orgs.preferences:read"
+	ActionOrgsPreferencesWrite = "orgs.preferences:write"
+	ActionOrgUsersRead         = "org.users:read"
+	ActionOrgUsersAdd          = "org.users:add"
+	ActionOrgUsersRoleUpdate   = "org.users.role:update"
+	ActionOrgUsersRemove       = "org.users:remove"
+
+	// API keys
+	ActionAPIKeysRead   = "apikeys:read"
+	ActionAPIKeysCreate = "apikeys:create"
+	ActionAPIKeysDelete = "apikeys:delete"
+
+	// Service accounts and their tokens
+	ActionServiceAccountsRead   = "serviceaccounts:read"
+	ActionServiceAccountsCreate = "serviceaccounts:create"
+	ActionServiceAccountsWrite  = "serviceaccounts:write"
+	ActionServiceAccountsDelete = "serviceaccounts:delete"
+
+	// Teams
+	ActionTeamsRead   = "teams:read"
+	ActionTeamsCreate = "teams:create"
+	ActionTeamsWrite  = "teams:write"
+	ActionTeamsDelete = "teams:delete"
+
+	// Plugin settings, dashboards and metrics
+	ActionPluginsManage = "plugins:manage"
+
+	// Alerts and notification channels
+	ActionAlertingManage = "alerting:manage"
+
+	ActionPlaylistsWrite            = "playlists:write"
+	ActionAnnotationsGraphiteCreate = "annotations.graphite:create"
+	ActionAnnotationsBulkDelete     = "annotations.bulk:delete"
+	ActionSnapshotsDelete           = "snapshots:delete"
+
+	// Custom roles and their assignments
+	ActionRolesRead  = "roles:read"
+	ActionRolesWrite = "roles:write"
+
+	// Users of the Grafana server
+	ActionUsersRead             = "users:read"
+	ActionUsersCreate           = "users:create"
+	ActionUsersWrite            = "users:write"
+	ActionUsersDelete           = "users:delete"
+	ActionUsersDisable          = "users:disable"
+	ActionUsersEnable           = "users:enable"
+	ActionUsersPasswordUpdate   = "users.password:update"
+	ActionUsersPermissionsWrite = "users.permissions:write"
+	ActionUsersAuthTokensRead   = "users.authtoken:read"
+	ActionUsersAuthTokensWrite  = "users.authtoken:write"
+	ActionUsersQuotasRead       = "users.quotas:read"
+	ActionUsersQuotasWrite      = "users.quotas:write"
+	ActionUsersTotpDelete       = "users.totp:delete"
+)
+
+const (
+	// ScopeAll matches all the resources
+	ScopeAll = "*"
+
+	ScopeDatasourcesAll     = "datasources:*"
+	ScopeDatasourcesID      = "datasources:id:{id}"
+	ScopeDatasourcesUID     = "datasources:uid:{uid}"
+	ScopeDatasourcesName    = "datasources:name:{name}"
+	ScopeFoldersAll         = "folders:*"
+	ScopeUsersAll           = "users:*"
+	ScopeUsersID            = "users:id:{id}"
+	ScopeOrgUsersID         = "users:id:{userId}"
+	ScopeServiceAccountsAll = "serviceaccounts:*"
+	ScopeServiceAccountsID  = "serviceaccounts:id:{serviceAccountId}"
+	ScopeTeamsAll           = "teams:*"
+	ScopeTeamsID            = "teams:id:{teamId}"
+	ScopeRolesAll           = "roles:*"
+	ScopeRolesUID           = "roles:uid:{roleUid}"
+)
+
+var logger = log.New("accesscontrol")
+
+func init() {
+	registry.RegisterService(&AccessControl{})
+}
+
+// AccessControl evaluates the permissions of users.
+type AccessControl struct {
+	Cfg *setting.Cfg `inject:""`
+	Bus bus.Bus      `inject:""`
+
+	builtinRoles map[string][]models.Permission
+}
+
+// Init builds the permissions of the built-in roles and handles the evaluation of permissions.
+func (ac *AccessControl) Init() error {
+	ac.builtinRoles = BuiltinRoles(ac.Cfg)
+	ac.Bus.AddHandler(ac.evaluatePermissionQuery)
+	return nil
+}
+
+func (ac *AccessControl) evaluatePermissionQuery(query *models.EvaluatePermissionQuery) error {
+	allowed, err := ac.Evaluate(query.User, query.Action, query.Scope)
+	if err != nil {
+		return err
+	}
+
+	query.Result = allowed
+	return nil
+}
+
+// Evaluate returns true if the user has a permission for the action on the scope.
+// The permissions of an action on any scope are enough for an empty scope.
+func (ac *AccessControl) Evaluate(user *models.SignedInUser, action string, scope string) (bool, error) {
+	builtinRoles := UserBuiltinRoles(user)
+	for _, role := range builtinRoles {
+		if hasPermission(ac.builtinRoles[role], action, scope) {
+			return true, nil
+		}
+	}
+
+	permissions, err := ac.getCustomPermissions(user, builtinRoles)
+	if err != nil {
+		return false, err
+	}
+
+	allowed := hasPermission(permissions, action, scope)
+	if !allowed {
+		logger.Debug("Permission denied", "userId", user.UserId, "apiKeyId", user.ApiKeyId, "orgId", user.OrgId, "action", action, "scope", scope)
+	}
+
+	return allowed, nil
+}
+
+// GetUserPermissions returns the permissions of the user, from the built-in roles and the custom roles.
+func (ac *AccessControl) GetUserPermissions(user *models.SignedInUser) ([]models.Permission, error) {
+	builtinRoles := UserBuiltinRoles(user)
+
+	var permissions []models.Permission
+	for _, role := range builtinRoles {
+		permissions = append(permissions, ac.builtinRoles[role]...)
+	}
+
+	custom, err := ac.getCustomPermissions(user, builtinRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(permissions, custom...), nil
+}
+
+// GetBuiltinRoles returns the permissions of the built-in roles.
+func (ac *AccessControl) GetBuiltinRoles() map[string][]models.Permission {
+	return ac.builtinRoles
+}
+
+func (ac *AccessControl) getCustomPermissions(user *models.SignedInUser, builtinRoles []string) ([]models.Permission, error) {
+	query := models.GetUserPermissionsQuery{
+		OrgId:        user.OrgId,
+		UserId:       user.UserId,
+		ApiKeyId:     user.ApiKeyId,
+		BuiltinRoles: builtinRoles,
+	}
+	if err := ac.Bus.Dispatch(&query); err != nil {
+		return nil, fmt.Errorf("failed to get permissions of user: %w", err)
+	}
+
+	return query.Result, nil
+}
+
+// UserBuiltinRoles returns the built-in roles of a user. The organization roles include the
+// lower ones, so an Editor has the built-in roles Viewer and Editor.
+func UserBuiltinRoles(user *models.SignedInUser) []string {
+	var roles []string
+	for _, role := range []models.RoleType{models.ROLE_VIEWER, models.ROLE_EDITOR, models.ROLE_ADMIN} {
+		if user.OrgRole.Includes(role) {
+			roles = append(roles, string(role))
+		}
+	}
+
+	if user.IsGrafanaAdmin {
+		roles = append(roles, models.BuiltinRoleGrafanaAdmin)
+	}
+
+	return roles
+}
+
+// ScopeMatches returns true if a permission with the scope pattern applies to the scope.
+// Patterns ending with :* match all the scopes with the same prefix, and * or an empty
+// pattern match all the scopes. An empty scope is matched by all the patterns.
+func ScopeMatches(pattern string, scope string) bool {
+	if scope == "" || pattern == "" || pattern == ScopeAll || pattern == scope {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, ":*") {
+		return strings.HasPrefix(scope, strings.TrimSuffix(pattern, "*"))
+	}
+
+	return false
+}
+
+// ScopeFolderID returns the scope of the folder with the id. The General folder has the id 0.
+func ScopeFolderID(id int64) string {
+	return fmt.Sprintf("folders:id:%d", id)
+}
+
+func hasPermission(permissions []models.Permission, action string, scope string) bool {
+	for _, p := range permissions {
+		if p.Action == action && ScopeMatches(p.Scope, scope) {
+			return true
+		}
+	}
+
+	return false
+}
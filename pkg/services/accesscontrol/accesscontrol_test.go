@@ -0,0 +1,148 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeMatches(t *testing.T) {
+	tcs := []struct {
+		pattern string
+		scope   string
+		matches bool
+	}{
+		{pattern: "datasources:id:1", scope: "datasources:id:1", matches: true},
+		{pattern: "datasources:id:1", scope: "datasources:id:2", matches: false},
+		{pattern: "datasources:id:1", scope: "datasources:id:10", matches: false},
+		{pattern: "datasources:*", scope: "datasources:id:1", matches: true},
+		{pattern: "datasources:id:*", scope: "datasources:id:1", matches: true},
+		{pattern: "datasources:id:*", scope: "datasources:*", matches: false},
+		{pattern: "datasources:*", scope: "folders:id:1", matches: false},
+		{pattern: "datasources:id:1*", scope: "datasources:id:10", matches: false},
+		{pattern: "*", scope: "folders:id:1", matches: true},
+		{pattern: "", scope: "folders:id:1", matches: true},
+		{pattern: "folders:id:1", scope: "", matches: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.pattern+" "+tc.scope, func(t *testing.T) {
+			assert.Equal(t, tc.matches, ScopeMatches(tc.pattern, tc.scope))
+		})
+	}
+}
+
+func TestUserBuiltinRoles(t *testing.T) {
+	assert.Equal(t, []string{"Viewer"}, UserBuiltinRoles(&models.SignedInUser{OrgRole: models.ROLE_VIEWER}))
+	assert.Equal(t, []string{"Viewer", "Editor"}, UserBuiltinRoles(&models.SignedInUser{OrgRole: models.ROLE_EDITOR}))
+	assert.Equal(t, []string{"Viewer", "Editor", "Admin", "Grafana Admin"},
+		UserBuiltinRoles(&models.SignedInUser{OrgRole: models.ROLE_ADMIN, IsGrafanaAdmin: true}))
+	assert.Equal(t, []string{"Grafana Admin"}, UserBuiltinRoles(&models.SignedInUser{IsGrafanaAdmin: true}))
+}
+
+func TestEvaluate(t *testing.T) {
+	setup := func(t *testing.T, cfg *setting.Cfg, custom []models.Permission) (*AccessControl, *models.GetUserPermissionsQuery) {
+		t.Helper()
+
+		var lastQuery models.GetUserPermissionsQuery
+		ac := &AccessControl{Cfg: cfg, Bus: bus.New()}
+		ac.Bus.AddHandler(func(query *models.GetUserPermissionsQuery) error {
+			lastQuery = *query
+			query.Result = custom
+			return nil
+		})
+		require.NoError(t, ac.Init())
+
+		return ac, &lastQuery
+	}
+
+	t.Run("Built-in roles keep the access of the organization roles", func(t *testing.T) {
+		ac, _ := setup(t, setting.NewCfg(), nil)
+
+		tcs := []struct {
+			role    models.RoleType
+			action  string
+			scope   string
+			allowed bool
+		}{
+			{role: models.ROLE_VIEWER, action: ActionAlertingManage, allowed: false},
+			{role: models.ROLE_EDITOR, action: ActionAlertingManage, allowed: true},
+			{role: models.ROLE_EDITOR, action: ActionDatasourcesRead, scope: "datasources:id:1", allowed: false},
+			{role: models.ROLE_ADMIN, action: ActionDatasourcesRead, scope: "datasources:id:1", allowed: true},
+			{role: models.ROLE_ADMIN, action: ActionAlertingManage, allowed: true},
+			{role: models.ROLE_EDITOR, action: ActionTeamsCreate, allowed: false},
+			{role: models.ROLE_ADMIN, action: ActionUsersWrite, scope: "users:id:1", allowed: false},
+		}
+
+		for _, tc := range tcs {
+			allowed, err := ac.Evaluate(&models.SignedInUser{OrgId: 1, OrgRole: tc.role}, tc.action, tc.scope)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, allowed, "%s %s %s", tc.role, tc.action, tc.scope)
+		}
+	})
+
+	t.Run("Grafana Admins can manage users", func(t *testing.T) {
+		ac, _ := setup(t, setting.NewCfg(), nil)
+
+		allowed, err := ac.Evaluate(&models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_VIEWER, IsGrafanaAdmin: true}, ActionUsersWrite, "users:id:1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Editors can create teams if editors can admin", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.EditorsCanAdmin = true
+		ac, _ := setup(t, cfg, nil)
+
+		allowed, err := ac.Evaluate(&models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR}, ActionTeamsCreate, "")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = ac.Evaluate(&models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_VIEWER}, ActionTeamsCreate, "")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("Custom roles grant permissions on their scopes", func(t *testing.T) {
+		ac, query := setup(t, setting.NewCfg(), []models.Permission{
+			{Action: ActionDatasourcesRead, Scope: "datasources:id:1"},
+			{Action: ActionDashboardsCreate, Scope: "folders:id:2"},
+		})
+		user := &models.SignedInUser{OrgId: 1, UserId: 2, OrgRole: models.ROLE_EDITOR}
+
+		allowed, err := ac.Evaluate(user, ActionDatasourcesRead, "datasources:id:1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, models.GetUserPermissionsQuery{
+			OrgId:        1,
+			UserId:       2,
+			BuiltinRoles: []string{"Viewer", "Editor"},
+			Result:       query.Result,
+		}, *query)
+
+		allowed, err = ac.Evaluate(user, ActionDatasourcesRead, "datasources:id:2")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = ac.Evaluate(user, ActionDatasourcesRead, ScopeDatasourcesAll)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = ac.Evaluate(user, ActionDashboardsCreate, ScopeFolderID(2))
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("API keys get the permissions of their custom roles", func(t *testing.T) {
+		ac, query := setup(t, setting.NewCfg(), []models.Permission{{Action: ActionAlertingManage}})
+
+		allowed, err := ac.Evaluate(&models.SignedInUser{OrgId: 1, ApiKeyId: 3, OrgRole: models.ROLE_VIEWER}, ActionAlertingManage, "")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, int64(3), query.ApiKeyId)
+	})
+}
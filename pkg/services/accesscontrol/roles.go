@@ -0,0 +1,77 @@
+package accesscontrol
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// BuiltinRoles returns the permissions of the built-in roles, which grant the access of the
+// organization roles and of Grafana Admins. Users have the built-in roles of the organization
+// roles included in their role, so an Admin also has the permissions of Viewers and Editors.
+func BuiltinRoles(cfg *setting.Cfg) map[string][]models.Permission {
+	var teams []models.Permission
+	for _, action := range []string{ActionTeamsRead, ActionTeamsWrite, ActionTeamsDelete} {
+		teams = append(teams, models.Permission{Action: action, Scope: ScopeTeamsAll})
+	}
+	createTeams := models.Permission{Action: ActionTeamsCreate, Scope: ScopeAll}
+
+	var viewer []models.Permission
+	editor := []models.Permission{
+		{Action: ActionAlertingManage, Scope: ScopeAll},
+		{Action: ActionPlaylistsWrite, Scope: ScopeAll},
+		{Action: ActionAnnotationsGraphiteCreate, Scope: ScopeAll},
+		{Action: ActionSnapshotsDelete, Scope: ScopeAll},
+	}
+	var admin []models.Permission
+
+	if cfg.EditorsCanAdmin {
+		// the team guardian checks which teams the members can administrate
+		viewer = append(viewer, teams...)
+		editor = append(editor, createTeams)
+	} else {
+		admin = append(admin, teams...)
+		admin = append(admin, createTeams)
+	}
+
+	admin = append(admin,
+		models.Permission{Action: ActionDatasourcesRead, Scope: ScopeDatasourcesAll},
+		models.Permission{Action: ActionDatasourcesCreate, Scope: ScopeAll},
+		models.Permission{Action: ActionDatasourcesWrite, Scope: ScopeDatasourcesAll},
+		models.Permission{Action: ActionDatasourcesDelete, Scope: ScopeDatasourcesAll},
+		models.Permission{Action: ActionOrgsWrite, Scope: ScopeAll},
+		models.Permission{Action: ActionOrgsPreferencesRead, Scope: ScopeAll},
+		models.Permission{Action: ActionOrgsPreferencesWrite, Scope: ScopeAll},
+		models.Permission{Action: ActionOrgUsersRead, Scope: ScopeUsersAll},
+		models.Permission{Action: ActionOrgUsersAdd, Scope: ScopeUsersAll},
+		models.Permission{Action: ActionOrgUsersRoleUpdate, Scope: ScopeUsersAll},
+		models.Permission{Action: ActionOrgUsersRemove, Scope: ScopeUsersAll},
+		models.Permission{Action: ActionAPIKeysRead, Scope: ScopeAll},
+		models.Permission{Action: ActionAPIKeysCreate, Scope: ScopeAll},
+		models.Permission{Action: ActionAPIKeysDelete, Scope: ScopeAll},
+		models.Permission{Action: ActionServiceAccountsRead, Scope: ScopeServiceAccountsAll},
+		models.Permission{Action: ActionServiceAccountsCreate, Scope: ScopeAll},
+		models.Permission{Action: ActionServiceAccountsWrite, Scope: ScopeServiceAccountsAll},
+		models.Permission{Action: ActionServiceAccountsDelete, Scope: ScopeServiceAccountsAll},
+		models.Permission{Action: ActionPluginsManage, Scope: ScopeAll},
+		models.Permission{Action: ActionAnnotationsBulkDelete, Scope: ScopeAll},
+		models.Permission{Action: ActionRolesRead, Scope: ScopeRolesAll},
+		models.Permission{Action: ActionRolesWrite, Scope: ScopeRolesAll},
+	)
+
+	var grafanaAdmin []models.Permission
+	for _, action := range []string{
+		ActionUsersRead, ActionUsersCreate, ActionUsersWrite, ActionUsersDelete,
+		ActionUsersDisable, ActionUsersEnable, ActionUsersPasswordUpdate, ActionUsersPermissionsWrite,
+		ActionUsersAuthTokensRead, ActionUsersAuthTokensWrite, ActionUsersQuotasRead, ActionUsersQuotasWrite,
+		ActionUsersTotpDelete,
+	} {
+		grafanaAdmin = append(grafanaAdmin, models.Permission{Action: action, Scope: ScopeUsersAll})
+	}
+
+	return map[string][]models.Permission{
+		string(models.ROLE_VIEWER):     viewer,
+		string(models.ROLE_EDITOR):     editor,
+		string(models.ROLE_ADMIN):      admin,
+		models.BuiltinRoleGrafanaAdmin: grafanaAdmin,
+	}
+}
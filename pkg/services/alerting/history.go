@@ -0,0 +1,128 @@
+package alerting
+
+import (
+	"sort"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// RuleHistorySummary holds the aggregated state history of a single alert rule
+// within a time range.
+type RuleHistorySummary struct {
+	AlertID          int64  `json:"alertId"`
+	AlertName        string `json:"alertName"`
+	DashboardID      int64  `json:"dashboardId"`
+	PanelID          int64  `json:"panelId"`
+	Transitions      int    `json:"transitions"`
+	AlertingCount    int    `json:"alertingCount"`
+	ResolvedCount    int    `json:"resolvedCount"`
+	TimeInAlertingMs int64  `json:"timeInAlertingMs"`
+	MTTRMs           int64  `json:"mttrMs"`
+}
+
+// SortStateHistory sorts alert state annotations by time, oldest first.
+func SortStateHistory(items []*annotations.ItemDTO) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Time == items[j].Time {
+			return items[i].Id < items[j].Id
+		}
+		return items[i].Time < items[j].Time
+	})
+}
+
+// SummarizeStateHistory computes per rule aggregates from alert state annotations
+// in the time range [from, to], given in epoch milliseconds.
+//
+// The state of a rule at the start of the range is taken from the previous state of
+// its first transition. Time in alerting is clipped to the range. MTTR is the mean
+// duration of the alerting periods that both started and were resolved in the range.
+func SummarizeStateHistory(items []*annotations.ItemDTO, from, to int64) []*RuleHistorySummary {
+	byRule := make(map[int64][]*annotations.ItemDTO)
+	order := make([]int64, 0)
+	for _, item := range items {
+		if item.AlertId == 0 {
+			continue
+		}
+		if _, exists := byRule[item.AlertId]; !exists {
+			order = append(order, item.AlertId)
+		}
+		byRule[item.AlertId] = append(byRule[item.AlertId], item)
+	}
+
+	result := make([]*RuleHistorySummary, 0, len(order))
+	for _, alertID := range order {
+		transitions := byRule[alertID]
+		SortStateHistory(transitions)
+		result = append(result, summarizeRuleHistory(transitions, from, to))
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TimeInAlertingMs > result[j].TimeInAlertingMs
+	})
+
+	return result
+}
+
+func summarizeRuleHistory(transitions []*annotations.ItemDTO, from, to int64) *RuleHistorySummary {
+	first := transitions[0]
+	summary := &RuleHistorySummary{
+		AlertID:     first.AlertId,
+		AlertName:   first.AlertName,
+		DashboardID: first.DashboardId,
+		PanelID:     first.PanelId,
+		Transitions: len(transitions),
+	}
+
+	if from == 0 || from > first.Time {
+		from = first.Time
+	}
+
+	alerting := isAlertingState(first.PrevState)
+	// periodStart is the start of the current alerting period within the range,
+	// periodStartKnown is false when the period began before the range.
+	periodStart := from
+	periodStartKnown := false
+	var repairTotal int64
+	var repairCount int64
+
+	for _, t := range transitions {
+		ts := t.Time
+		if ts < from {
+			ts = from
+		}
+		if ts > to {
+			ts = to
+		}
+
+		nowAlerting := isAlertingState(t.NewState)
+		switch {
+		case !alerting && nowAlerting:
+			summary.AlertingCount++
+			periodStart = ts
+			periodStartKnown = true
+		case alerting && !nowAlerting:
+			summary.ResolvedCount++
+			summary.TimeInAlertingMs += ts - periodStart
+			if periodStartKnown {
+				repairTotal += ts - periodStart
+				repairCount++
+			}
+		}
+		alerting = nowAlerting
+	}
+
+	if alerting && to > periodStart {
+		summary.TimeInAlertingMs += to - periodStart
+	}
+
+	if repairCount > 0 {
+		summary.MTTRMs = repairTotal / repairCount
+	}
+
+	return summary
+}
+
+func isAlertingState(state string) bool {
+	return models.AlertStateType(state) == models.AlertStateAlerting
+}
@@ -0,0 +1,67 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+func TestSummarizeStateHistory(t *testing.T) {
+	transition := func(id, alertID, time int64, prevState, newState string) *annotations.ItemDTO {
+		return &annotations.ItemDTO{
+			Id:        id,
+			AlertId:   alertID,
+			AlertName: "rule",
+			Time:      time,
+			PrevState: prevState,
+			NewState:  newState,
+		}
+	}
+
+	t.Run("resolved alerting periods count towards MTTR", func(t *testing.T) {
+		items := []*annotations.ItemDTO{
+			transition(4, 1, 900, "alerting", "ok"),
+			transition(1, 1, 100, "ok", "pending"),
+			transition(2, 1, 200, "pending", "alerting"),
+			transition(3, 1, 500, "alerting", "ok"),
+			transition(5, 1, 700, "ok", "alerting"),
+		}
+
+		result := SummarizeStateHistory(items, 0, 1000)
+		require.Len(t, result, 1)
+		summary := result[0]
+		assert.Equal(t, int64(1), summary.AlertID)
+		assert.Equal(t, 5, summary.Transitions)
+		assert.Equal(t, 2, summary.AlertingCount)
+		assert.Equal(t, 2, summary.ResolvedCount)
+		assert.Equal(t, int64(500), summary.TimeInAlertingMs)
+		assert.Equal(t, int64(250), summary.MTTRMs)
+	})
+
+	t.Run("alerting periods are clipped to the time range", func(t *testing.T) {
+		items := []*annotations.ItemDTO{
+			transition(1, 1, 300, "alerting", "ok"),
+			transition(2, 1, 600, "ok", "alerting"),
+			transition(3, 2, 400, "ok", "no_data"),
+		}
+
+		result := SummarizeStateHistory(items, 100, 1000)
+		require.Len(t, result, 2)
+
+		summary := result[0]
+		assert.Equal(t, int64(1), summary.AlertID)
+		assert.Equal(t, 1, summary.AlertingCount)
+		assert.Equal(t, 1, summary.ResolvedCount)
+		assert.Equal(t, int64(600), summary.TimeInAlertingMs)
+		// the resolved period started before the range, so MTTR is unknown
+		assert.Equal(t, int64(0), summary.MTTRMs)
+
+		summary = result[1]
+		assert.Equal(t, int64(2), summary.AlertID)
+		assert.Equal(t, 0, summary.AlertingCount)
+		assert.Equal(t, int64(0), summary.TimeInAlertingMs)
+	})
+}
@@ -0,0 +1,188 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "matrix",
+		Name:        "Matrix",
+		Description: "Sends notifications to a Matrix room using the client-server API",
+		Heading:     "Matrix settings",
+		Info:        "The user owning the access token must have joined the room.",
+		Factory:     NewMatrixNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:        "Homeserver URL",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "https://matrix.org",
+				PropertyName: "homeserverUrl",
+				Required:     true,
+			},
+			{
+				Label:        "Room ID",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "!roomid:matrix.org",
+				PropertyName: "roomId",
+				Required:     true,
+			},
+			{
+				Label:        "Access token",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				PropertyName: "accessToken",
+				Required:     true,
+				Secure:       true,
+			},
+			{
+				Label:   "Message type",
+				Element: alerting.ElementTypeSelect,
+				SelectOptions: []alerting.SelectOption{
+					{
+						Value: "m.notice",
+						Label: "Notice",
+					},
+					{
+						Value: "m.text",
+						Label: "Text",
+					},
+				},
+				Description:  "Bots usually send notices, which clients display less prominently and other bots ignore",
+				PropertyName: "msgType",
+			},
+		},
+	})
+}
+
+// NewMatrixNotifier is the constructor for the Matrix notifier.
+func NewMatrixNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
+	homeserverURL := strings.TrimSuffix(model.Settings.Get("homeserverUrl").MustString(), "/")
+	if homeserverURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find homeserver url property in settings"}
+	}
+	roomID := model.Settings.Get("roomId").MustString()
+	if roomID == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find room id property in settings"}
+	}
+	accessToken := model.DecryptedValue("accessToken", model.Settings.Get("accessToken").MustString())
+	if accessToken == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find access token property in settings"}
+	}
+
+	msgType := model.Settings.Get("msgType").MustString("m.notice")
+	if msgType != "m.notice" && msgType != "m.text" {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid value for msgType: %q", msgType)}
+	}
+
+	return &MatrixNotifier{
+		NotifierBase:  NewNotifierBase(model),
+		HomeserverURL: homeserverURL,
+		RoomID:        roomID,
+		AccessToken:   accessToken,
+		MsgType:       msgType,
+		log:           log.New("alerting.notifier.matrix"),
+	}, nil
+}
+
+// MatrixNotifier is responsible for sending
+// alert notifications to a Matrix room.
+type MatrixNotifier struct {
+	NotifierBase
+	HomeserverURL string
+	RoomID        string
+	AccessToken   string
+	MsgType       string
+	log           log.Logger
+}
+
+// Notify sends an alert notification to Matrix.
+func (mn *MatrixNotifier) Notify(evalContext *alerting.EvalContext) error {
+	mn.log.Info("Executing matrix notification", "ruleId", evalContext.Rule.ID, "notification", mn.Name)
+
+	plain, formatted := mn.buildMessage(evalContext)
+	body := map[string]interface{}{
+		"msgtype":        mn.MsgType,
+		"body":           plain,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	// The transaction id makes retried requests idempotent, so every
+	// notification needs a new one.
+	cmd := &models.SendWebhookSync{
+		Url: fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message/%s",
+			mn.HomeserverURL, url.PathEscape(mn.RoomID), util.GenerateShortUID()),
+		Body:       string(data),
+		HttpMethod: http.MethodPut,
+		HttpHeader: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", mn.AccessToken),
+		},
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		mn.log.Error("Failed to send matrix notification", "error", err, "webhook", mn.Name)
+		return err
+	}
+
+	return nil
+}
+
+// buildMessage returns the plain text and the HTML version of the message.
+func (mn *MatrixNotifier) buildMessage(evalContext *alerting.EvalContext) (string, string) {
+	var plain, formatted strings.Builder
+
+	title := evalContext.GetNotificationTitle()
+	if ruleURL, err := evalContext.GetRuleURL(); err == nil {
+		fmt.Fprintf(&plain, "%s\n%s\n", title, ruleURL)
+		fmt.Fprintf(&formatted, "<strong><a href=\"%s\">%s</a></strong><br>",
+			html.EscapeString(ruleURL), html.EscapeString(title))
+	} else {
+		fmt.Fprintf(&plain, "%s\n", title)
+		fmt.Fprintf(&formatted, "<strong>%s</strong><br>", html.EscapeString(title))
+	}
+
+	if evalContext.Rule.State != models.AlertStateOK && evalContext.Rule.Message != "" {
+		fmt.Fprintf(&plain, "%s\n", evalContext.Rule.Message)
+		fmt.Fprintf(&formatted, "%s<br>", html.EscapeString(evalContext.Rule.Message))
+	}
+
+	if len(evalContext.EvalMatches) > 0 {
+		formatted.WriteString("<ul>")
+		for _, evt := range evalContext.EvalMatches {
+			fmt.Fprintf(&plain, "%s: %s\n", evt.Metric, evt.Value.FullString())
+			fmt.Fprintf(&formatted, "<li>%s: %s</li>", html.EscapeString(evt.Metric), html.EscapeString(evt.Value.FullString()))
+		}
+		formatted.WriteString("</ul>")
+	}
+
+	if evalContext.Error != nil {
+		fmt.Fprintf(&plain, "Error message: %s\n", evalContext.Error.Error())
+		fmt.Fprintf(&formatted, "<strong>Error message:</strong> %s<br>", html.EscapeString(evalContext.Error.Error()))
+	}
+
+	if mn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		fmt.Fprintf(&plain, "%s\n", evalContext.ImagePublicURL)
+		fmt.Fprintf(&formatted, "<a href=\"%s\">Graph</a>", html.EscapeString(evalContext.ImagePublicURL))
+	}
+
+	return plain.String(), formatted.String()
+}
@@ -0,0 +1,88 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/validations"
+)
+
+func TestMatrixNotifier(t *testing.T) {
+	t.Run("empty settings should return error", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{ }`))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "matrix_testing",
+			Type:     "matrix",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewMatrixNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid message type should return error", func(t *testing.T) {
+		json := `
+		{
+			"homeserverUrl": "https://matrix.example.com",
+			"roomId": "!abc:matrix.example.com",
+			"accessToken": "token",
+			"msgType": "m.image"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "matrix_testing",
+			Type:     "matrix",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewMatrixNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("from settings", func(t *testing.T) {
+		json := `
+		{
+			"homeserverUrl": "https://matrix.example.com/",
+			"roomId": "!abc:matrix.example.com",
+			"accessToken": "token"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "matrix_testing",
+			Type:     "matrix",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewMatrixNotifier(model)
+		require.NoError(t, err)
+		matrixNotifier := not.(*MatrixNotifier)
+
+		assert.Equal(t, "matrix_testing", matrixNotifier.Name)
+		assert.Equal(t, "matrix", matrixNotifier.Type)
+		assert.Equal(t, "https://matrix.example.com", matrixNotifier.HomeserverURL)
+		assert.Equal(t, "!abc:matrix.example.com", matrixNotifier.RoomID)
+		assert.Equal(t, "token", matrixNotifier.AccessToken)
+		assert.Equal(t, "m.notice", matrixNotifier.MsgType)
+
+		evalContext := alerting.NewEvalContext(context.Background(), &alerting.Rule{
+			Name:    "Rule",
+			State:   models.AlertStateAlerting,
+			Message: "<b>escaped</b>",
+		}, &validations.OSSPluginRequestValidator{})
+		evalContext.IsTestRun = true
+
+		plain, formatted := matrixNotifier.buildMessage(evalContext)
+		assert.Contains(t, plain, "[Alerting] Rule\n")
+		assert.Contains(t, plain, "<b>escaped</b>\n")
+		assert.Contains(t, formatted, "&lt;b&gt;escaped&lt;/b&gt;<br>")
+	})
+}
@@ -0,0 +1,188 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "mattermost",
+		Name:        "Mattermost",
+		Description: "Sends notifications to Mattermost via incoming webhooks using message attachments",
+		Heading:     "Mattermost settings",
+		Factory:     NewMattermostNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:        "Url",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "Mattermost incoming webhook url",
+				PropertyName: "url",
+				Required:     true,
+				Secure:       true,
+			},
+			{
+				Label:        "Channel",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Override the default channel of the webhook, e.g. town-square or @username",
+				PropertyName: "channel",
+			},
+			{
+				Label:        "Username",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Override the username of the webhook, requires the Mattermost setting EnablePostUsernameOverride",
+				PropertyName: "username",
+			},
+			{
+				Label:        "Icon URL",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Override the profile picture of the webhook, requires the Mattermost setting EnablePostIconOverride",
+				PropertyName: "iconUrl",
+			},
+			{
+				Label:        "Mention",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Prepend mentions to the message when alerting, e.g. @channel, @here or @username",
+				PropertyName: "mention",
+			},
+		},
+	})
+}
+
+// NewMattermostNotifier is the constructor for the Mattermost notifier.
+func NewMattermostNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
+	url := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+
+	return &MattermostNotifier{
+		NotifierBase: NewNotifierBase(model),
+		URL:          url,
+		Channel:      model.Settings.Get("channel").MustString(),
+		Username:     model.Settings.Get("username").MustString(),
+		IconURL:      model.Settings.Get("iconUrl").MustString(),
+		Mention:      model.Settings.Get("mention").MustString(),
+		log:          log.New("alerting.notifier.mattermost"),
+	}, nil
+}
+
+// MattermostNotifier is responsible for sending
+// alert notifications to Mattermost.
+type MattermostNotifier struct {
+	NotifierBase
+	URL      string
+	Channel  string
+	Username string
+	IconURL  string
+	Mention  string
+	log      log.Logger
+}
+
+// Notify sends an alert notification to Mattermost.
+func (mn *MattermostNotifier) Notify(evalContext *alerting.EvalContext) error {
+	mn.log.Info("Executing mattermost notification", "ruleId", evalContext.Rule.ID, "notification", mn.Name)
+
+	body, err := mn.buildBody(evalContext)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        mn.URL,
+		Body:       string(data),
+		HttpMethod: http.MethodPost,
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		mn.log.Error("Failed to send mattermost notification", "error", err, "webhook", mn.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (mn *MattermostNotifier) buildBody(evalContext *alerting.EvalContext) (map[string]interface{}, error) {
+	ruleURL, err := evalContext.GetRuleURL()
+	if err != nil {
+		mn.log.Error("Failed get rule link", "error", err)
+		return nil, err
+	}
+
+	attachment := map[string]interface{}{
+		"fallback":    evalContext.GetNotificationTitle(),
+		"color":       evalContext.GetStateModel().Color,
+		"title":       evalContext.GetNotificationTitle(),
+		"title_link":  ruleURL,
+		"fields":      attachmentFields(evalContext),
+		"footer":      "Grafana v" + setting.BuildVersion,
+		"footer_icon": "https://grafana.com/assets/img/fav32.png",
+	}
+	if evalContext.Rule.State != models.AlertStateOK {
+		attachment["text"] = evalContext.Rule.Message
+	}
+	if mn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		attachment["image_url"] = evalContext.ImagePublicURL
+	}
+
+	body := map[string]interface{}{
+		"attachments": []map[string]interface{}{attachment},
+	}
+	if mn.Mention != "" && evalContext.Rule.State == models.AlertStateAlerting {
+		body["text"] = mn.Mention
+	}
+	if mn.Channel != "" {
+		body["channel"] = mn.Channel
+	}
+	if mn.Username != "" {
+		body["username"] = mn.Username
+	}
+	if mn.IconURL != "" {
+		body["icon_url"] = mn.IconURL
+	}
+
+	return body, nil
+}
+
+// attachmentFields returns the eval matches and the evaluation error, if any,
+// as fields of a Slack-compatible message attachment.
+func attachmentFields(evalContext *alerting.EvalContext) []map[string]interface{} {
+	fields := make([]map[string]interface{}, 0)
+	fieldLimitCount := 4
+	for index, evt := range evalContext.EvalMatches {
+		fields = append(fields, map[string]interface{}{
+			"title": evt.Metric,
+			"value": evt.Value.FullString(),
+			"short": true,
+		})
+		if index > fieldLimitCount {
+			break
+		}
+	}
+
+	if evalContext.Error != nil {
+		fields = append(fields, map[string]interface{}{
+			"title": "Error message",
+			"value": evalContext.Error.Error(),
+			"short": false,
+		})
+	}
+
+	return fields
+}
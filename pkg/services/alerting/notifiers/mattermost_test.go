@@ -0,0 +1,75 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/validations"
+)
+
+func TestMattermostNotifier(t *testing.T) {
+	t.Run("empty settings should return error", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{ }`))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "mattermost_testing",
+			Type:     "mattermost",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewMattermostNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("from settings", func(t *testing.T) {
+		json := `
+		{
+			"url": "https://mattermost.example.com/hooks/abc",
+			"channel": "alerts",
+			"username": "grafana",
+			"iconUrl": "https://grafana.com/assets/img/fav32.png",
+			"mention": "@channel"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "mattermost_testing",
+			Type:     "mattermost",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewMattermostNotifier(model)
+		require.NoError(t, err)
+		mattermostNotifier := not.(*MattermostNotifier)
+
+		assert.Equal(t, "mattermost_testing", mattermostNotifier.Name)
+		assert.Equal(t, "mattermost", mattermostNotifier.Type)
+		assert.Equal(t, "https://mattermost.example.com/hooks/abc", mattermostNotifier.URL)
+		assert.Equal(t, "alerts", mattermostNotifier.Channel)
+		assert.Equal(t, "grafana", mattermostNotifier.Username)
+		assert.Equal(t, "https://grafana.com/assets/img/fav32.png", mattermostNotifier.IconURL)
+		assert.Equal(t, "@channel", mattermostNotifier.Mention)
+
+		evalContext := alerting.NewEvalContext(context.Background(), &alerting.Rule{
+			Name:    "Rule",
+			State:   models.AlertStateAlerting,
+			Message: "Something is wrong",
+		}, &validations.OSSPluginRequestValidator{})
+		evalContext.IsTestRun = true
+
+		body, err := mattermostNotifier.buildBody(evalContext)
+		require.NoError(t, err)
+		assert.Equal(t, "@channel", body["text"])
+		assert.Equal(t, "alerts", body["channel"])
+		attachments := body["attachments"].([]map[string]interface{})
+		require.Len(t, attachments, 1)
+		assert.Equal(t, "[Alerting] Rule", attachments[0]["title"])
+		assert.Equal(t, "Something is wrong", attachments[0]["text"])
+	})
+}
@@ -0,0 +1,137 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "rocketchat",
+		Name:        "Rocket.Chat",
+		Description: "Sends notifications to Rocket.Chat via incoming webhook integrations",
+		Heading:     "Rocket.Chat settings",
+		Factory:     NewRocketChatNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:        "Url",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "Rocket.Chat incoming webhook url",
+				PropertyName: "url",
+				Required:     true,
+				Secure:       true,
+			},
+			{
+				Label:        "Channel",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Override the default channel of the integration, use #channel-name or @username",
+				PropertyName: "channel",
+			},
+			{
+				Label:        "Alias",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Display name of the message sender",
+				PropertyName: "alias",
+			},
+			{
+				Label:        "Avatar URL",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "URL of an image to use as the avatar of the message sender",
+				PropertyName: "avatarUrl",
+			},
+		},
+	})
+}
+
+// NewRocketChatNotifier is the constructor for the Rocket.Chat notifier.
+func NewRocketChatNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
+	url := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if url == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+
+	return &RocketChatNotifier{
+		NotifierBase: NewNotifierBase(model),
+		URL:          url,
+		Channel:      model.Settings.Get("channel").MustString(),
+		Alias:        model.Settings.Get("alias").MustString(),
+		AvatarURL:    model.Settings.Get("avatarUrl").MustString(),
+		log:          log.New("alerting.notifier.rocketchat"),
+	}, nil
+}
+
+// RocketChatNotifier is responsible for sending
+// alert notifications to Rocket.Chat.
+type RocketChatNotifier struct {
+	NotifierBase
+	URL       string
+	Channel   string
+	Alias     string
+	AvatarURL string
+	log       log.Logger
+}
+
+// Notify sends an alert notification to Rocket.Chat.
+func (rn *RocketChatNotifier) Notify(evalContext *alerting.EvalContext) error {
+	rn.log.Info("Executing rocket.chat notification", "ruleId", evalContext.Rule.ID, "notification", rn.Name)
+
+	ruleURL, err := evalContext.GetRuleURL()
+	if err != nil {
+		rn.log.Error("Failed get rule link", "error", err)
+		return err
+	}
+
+	attachment := map[string]interface{}{
+		"title":      evalContext.GetNotificationTitle(),
+		"title_link": ruleURL,
+		"color":      evalContext.GetStateModel().Color,
+		"fields":     attachmentFields(evalContext),
+	}
+	if evalContext.Rule.State != models.AlertStateOK {
+		attachment["text"] = evalContext.Rule.Message
+	}
+	if rn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		attachment["image_url"] = evalContext.ImagePublicURL
+	}
+
+	body := map[string]interface{}{
+		"text":        evalContext.GetNotificationTitle(),
+		"attachments": []map[string]interface{}{attachment},
+	}
+	if rn.Channel != "" {
+		body["channel"] = rn.Channel
+	}
+	if rn.Alias != "" {
+		body["alias"] = rn.Alias
+	}
+	if rn.AvatarURL != "" {
+		body["avatar"] = rn.AvatarURL
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        rn.URL,
+		Body:       string(data),
+		HttpMethod: http.MethodPost,
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		rn.log.Error("Failed to send rocket.chat notification", "error", err, "webhook", rn.Name)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestRocketChatNotifier(t *testing.T) {
+	t.Run("empty settings should return error", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{ }`))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "rocketchat_testing",
+			Type:     "rocketchat",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewRocketChatNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("from settings", func(t *testing.T) {
+		json := `
+		{
+			"url": "https://rocket.example.com/hooks/abc/def",
+			"channel": "#alerts",
+			"alias": "Grafana",
+			"avatarUrl": "https://grafana.com/assets/img/fav32.png"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "rocketchat_testing",
+			Type:     "rocketchat",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewRocketChatNotifier(model)
+		require.NoError(t, err)
+		rocketChatNotifier := not.(*RocketChatNotifier)
+
+		assert.Equal(t, "rocketchat_testing", rocketChatNotifier.Name)
+		assert.Equal(t, "rocketchat", rocketChatNotifier.Type)
+		assert.Equal(t, "https://rocket.example.com/hooks/abc/def", rocketChatNotifier.URL)
+		assert.Equal(t, "#alerts", rocketChatNotifier.Channel)
+		assert.Equal(t, "Grafana", rocketChatNotifier.Alias)
+		assert.Equal(t, "https://grafana.com/assets/img/fav32.png", rocketChatNotifier.AvatarURL)
+	})
+}
@@ -0,0 +1,272 @@
+package notifiers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+const (
+	snsTargetTopic = "sns"
+	snsTargetQueue = "sqs"
+)
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "sns",
+		Name:        "AWS SNS/SQS",
+		Description: "Publishes notifications to an AWS SNS topic or SQS queue using signature version 4 signed HTTP requests",
+		Heading:     "AWS SNS/SQS settings",
+		Info: "The message is the same JSON document the webhook notifier sends. " +
+			"Set the endpoint to target SNS/SQS-compatible services such as localstack.",
+		Factory: NewSNSNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:   "Target",
+				Element: alerting.ElementTypeSelect,
+				SelectOptions: []alerting.SelectOption{
+					{
+						Value: snsTargetTopic,
+						Label: "SNS topic",
+					},
+					{
+						Value: snsTargetQueue,
+						Label: "SQS queue",
+					},
+				},
+				PropertyName: "target",
+			},
+			{
+				Label:        "Region",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "us-east-1",
+				PropertyName: "region",
+				Required:     true,
+			},
+			{
+				Label:        "Topic ARN",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "arn:aws:sns:us-east-1:123456789012:grafana",
+				Description:  "Required when publishing to an SNS topic",
+				PropertyName: "topicArn",
+				ShowWhen: alerting.ShowWhen{
+					Field: "target",
+					Is:    snsTargetTopic,
+				},
+			},
+			{
+				Label:        "Queue URL",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "https://sqs.us-east-1.amazonaws.com/123456789012/grafana",
+				Description:  "Required when sending to an SQS queue",
+				PropertyName: "queueUrl",
+				ShowWhen: alerting.ShowWhen{
+					Field: "target",
+					Is:    snsTargetQueue,
+				},
+			},
+			{
+				Label:        "Endpoint",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "https://sns.us-east-1.amazonaws.com",
+				Description:  "Override the SNS endpoint, e.g. for a local SNS-compatible service",
+				PropertyName: "endpoint",
+				ShowWhen: alerting.ShowWhen{
+					Field: "target",
+					Is:    snsTargetTopic,
+				},
+			},
+			{
+				Label:        "Access key",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				PropertyName: "accessKey",
+				Required:     true,
+			},
+			{
+				Label:        "Secret key",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypePassword,
+				PropertyName: "secretKey",
+				Required:     true,
+				Secure:       true,
+			},
+		},
+	})
+}
+
+// NewSNSNotifier is the constructor for the AWS SNS/SQS notifier.
+func NewSNSNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
+	target := model.Settings.Get("target").MustString(snsTargetTopic)
+	region := model.Settings.Get("region").MustString()
+	if region == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find region property in settings"}
+	}
+	accessKey := model.Settings.Get("accessKey").MustString()
+	secretKey := model.DecryptedValue("secretKey", model.Settings.Get("secretKey").MustString())
+	if accessKey == "" || secretKey == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find access key and secret key properties in settings"}
+	}
+
+	n := &SNSNotifier{
+		NotifierBase: NewNotifierBase(model),
+		Target:       target,
+		Region:       region,
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		log:          log.New("alerting.notifier.sns"),
+	}
+
+	switch target {
+	case snsTargetTopic:
+		n.TopicArn = model.Settings.Get("topicArn").MustString()
+		if n.TopicArn == "" {
+			return nil, alerting.ValidationError{Reason: "Could not find topic ARN property in settings"}
+		}
+		n.Endpoint = model.Settings.Get("endpoint").MustString(fmt.Sprintf("https://sns.%s.amazonaws.com", region))
+	case snsTargetQueue:
+		n.Endpoint = model.Settings.Get("queueUrl").MustString()
+		if n.Endpoint == "" {
+			return nil, alerting.ValidationError{Reason: "Could not find queue URL property in settings"}
+		}
+	default:
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid value for target: %q", target)}
+	}
+
+	if _, err := url.Parse(n.Endpoint); err != nil {
+		return nil, alerting.ValidationError{Reason: "Invalid endpoint URL", Err: err}
+	}
+
+	return n, nil
+}
+
+// SNSNotifier is responsible for sending alert
+// notifications to AWS SNS topics or SQS queues.
+type SNSNotifier struct {
+	NotifierBase
+	Target    string
+	Region    string
+	Endpoint  string
+	TopicArn  string
+	AccessKey string
+	SecretKey string
+	log       log.Logger
+}
+
+// Notify publishes an alert notification to SNS or SQS.
+func (sn *SNSNotifier) Notify(evalContext *alerting.EvalContext) error {
+	sn.log.Info("Executing sns notification", "ruleId", evalContext.Rule.ID, "notification", sn.Name, "target", sn.Target)
+
+	message, err := sn.buildMessage(evalContext)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := sn.buildRequest(evalContext.GetNotificationTitle(), message, time.Now())
+	if err != nil {
+		sn.log.Error("Failed to sign sns request", "error", err)
+		return err
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		sn.log.Error("Failed to send sns notification", "error", err, "webhook", sn.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (sn *SNSNotifier) buildMessage(evalContext *alerting.EvalContext) (string, error) {
+	bodyJSON := simplejson.New()
+	bodyJSON.Set("title", evalContext.GetNotificationTitle())
+	bodyJSON.Set("ruleId", evalContext.Rule.ID)
+	bodyJSON.Set("ruleName", evalContext.Rule.Name)
+	bodyJSON.Set("state", evalContext.Rule.State)
+	bodyJSON.Set("evalMatches", evalContext.EvalMatches)
+	bodyJSON.Set("orgId", evalContext.Rule.OrgID)
+	bodyJSON.Set("dashboardId", evalContext.Rule.DashboardID)
+	bodyJSON.Set("panelId", evalContext.Rule.PanelID)
+
+	tags := make(map[string]string)
+	for _, tag := range evalContext.Rule.AlertRuleTags {
+		tags[tag.Key] = tag.Value
+	}
+	bodyJSON.Set("tags", tags)
+
+	if ruleURL, err := evalContext.GetRuleURL(); err == nil {
+		bodyJSON.Set("ruleUrl", ruleURL)
+	}
+	if sn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		bodyJSON.Set("imageUrl", evalContext.ImagePublicURL)
+	}
+	if evalContext.Rule.Message != "" {
+		bodyJSON.Set("message", evalContext.Rule.Message)
+	}
+
+	body, err := bodyJSON.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// buildRequest returns the webhook command for the SNS Publish or the SQS SendMessage
+// query API action, signed with AWS signature version 4.
+func (sn *SNSNotifier) buildRequest(subject, message string, signTime time.Time) (*models.SendWebhookSync, error) {
+	data := url.Values{}
+	service := sn.Target
+	if sn.Target == snsTargetQueue {
+		data.Set("Action", "SendMessage")
+		data.Set("Version", "2012-11-05")
+		data.Set("MessageBody", message)
+	} else {
+		data.Set("Action", "Publish")
+		data.Set("Version", "2010-03-31")
+		data.Set("TopicArn", sn.TopicArn)
+		// SNS rejects subjects longer than 100 characters.
+		if runes := []rune(subject); len(runes) > 100 {
+			subject = string(runes[:100])
+		}
+		data.Set("Subject", subject)
+		data.Set("Message", message)
+	}
+	body := data.Encode()
+
+	const contentType = "application/x-www-form-urlencoded; charset=utf-8"
+	req, err := http.NewRequest(http.MethodPost, sn.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials(sn.AccessKey, sn.SecretKey, ""))
+	if _, err := signer.Sign(req, bytes.NewReader([]byte(body)), service, sn.Region, signTime); err != nil {
+		return nil, err
+	}
+
+	return &models.SendWebhookSync{
+		Url:         sn.Endpoint,
+		Body:        body,
+		HttpMethod:  http.MethodPost,
+		ContentType: contentType,
+		HttpHeader: map[string]string{
+			"Authorization": req.Header.Get("Authorization"),
+			"X-Amz-Date":    req.Header.Get("X-Amz-Date"),
+		},
+	}, nil
+}
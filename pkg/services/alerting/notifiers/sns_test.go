@@ -0,0 +1,113 @@
+package notifiers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestSNSNotifier(t *testing.T) {
+	t.Run("empty settings should return error", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{ }`))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "sns_testing",
+			Type:     "sns",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewSNSNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("sns target without topic should return error", func(t *testing.T) {
+		json := `{ "region": "us-east-1", "accessKey": "AKID", "secretKey": "secret" }`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "sns_testing",
+			Type:     "sns",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewSNSNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("sns topic from settings", func(t *testing.T) {
+		json := `
+		{
+			"region": "us-east-1",
+			"topicArn": "arn:aws:sns:us-east-1:123456789012:grafana",
+			"accessKey": "AKID",
+			"secretKey": "secret"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "sns_testing",
+			Type:     "sns",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewSNSNotifier(model)
+		require.NoError(t, err)
+		snsNotifier := not.(*SNSNotifier)
+
+		assert.Equal(t, "sns_testing", snsNotifier.Name)
+		assert.Equal(t, "sns", snsNotifier.Type)
+		assert.Equal(t, snsTargetTopic, snsNotifier.Target)
+		assert.Equal(t, "https://sns.us-east-1.amazonaws.com", snsNotifier.Endpoint)
+		assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:grafana", snsNotifier.TopicArn)
+
+		cmd, err := snsNotifier.buildRequest("[Alerting] Rule", `{"state":"alerting"}`, time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, "https://sns.us-east-1.amazonaws.com", cmd.Url)
+		assert.Equal(t, "20210301T120000Z", cmd.HttpHeader["X-Amz-Date"])
+		assert.Contains(t, cmd.HttpHeader["Authorization"], "Credential=AKID/20210301/us-east-1/sns/aws4_request")
+
+		values, err := url.ParseQuery(cmd.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "Publish", values.Get("Action"))
+		assert.Equal(t, "[Alerting] Rule", values.Get("Subject"))
+		assert.Equal(t, `{"state":"alerting"}`, values.Get("Message"))
+	})
+
+	t.Run("sqs queue against a local endpoint", func(t *testing.T) {
+		json := `
+		{
+			"target": "sqs",
+			"region": "eu-west-1",
+			"queueUrl": "http://localhost:4566/000000000000/grafana",
+			"accessKey": "AKID",
+			"secretKey": "secret"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "sns_testing",
+			Type:     "sns",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewSNSNotifier(model)
+		require.NoError(t, err)
+		snsNotifier := not.(*SNSNotifier)
+
+		cmd, err := snsNotifier.buildRequest("[Alerting] Rule", `{"state":"alerting"}`, time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:4566/000000000000/grafana", cmd.Url)
+		assert.Contains(t, cmd.HttpHeader["Authorization"], "Credential=AKID/20210301/eu-west-1/sqs/aws4_request")
+
+		values, err := url.ParseQuery(cmd.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "SendMessage", values.Get("Action"))
+		assert.Equal(t, `{"state":"alerting"}`, values.Get("MessageBody"))
+	})
+}
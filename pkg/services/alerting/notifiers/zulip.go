@@ -0,0 +1,189 @@
+package notifiers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func init() {
+	alerting.RegisterNotifier(&alerting.NotifierPlugin{
+		Type:        "zulip",
+		Name:        "Zulip",
+		Description: "Sends notifications to a Zulip stream or to users through the Zulip messages API",
+		Heading:     "Zulip settings",
+		Info:        "Create a generic bot in Zulip and subscribe it to the stream that should receive the alerts.",
+		Factory:     NewZulipNotifier,
+		Options: []alerting.NotifierOption{
+			{
+				Label:        "Url",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "https://yourorg.zulipchat.com",
+				Description:  "Base URL of your Zulip server",
+				PropertyName: "url",
+				Required:     true,
+			},
+			{
+				Label:        "Bot email",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Placeholder:  "grafana-bot@yourorg.zulipchat.com",
+				PropertyName: "botEmail",
+				Required:     true,
+			},
+			{
+				Label:        "Bot API key",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				PropertyName: "apiKey",
+				Required:     true,
+				Secure:       true,
+			},
+			{
+				Label:        "Stream",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Stream to send the message to, leave empty to send private messages to the recipients below",
+				PropertyName: "stream",
+			},
+			{
+				Label:        "Topic",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Topic of the stream message, defaults to the alert rule name",
+				PropertyName: "topic",
+			},
+			{
+				Label:        "Recipients",
+				Element:      alerting.ElementTypeInput,
+				InputType:    alerting.InputTypeText,
+				Description:  "Email addresses of users (comma separated) that receive a private message when no stream is set",
+				PropertyName: "recipients",
+			},
+		},
+	})
+}
+
+// NewZulipNotifier is the constructor for the Zulip notifier.
+func NewZulipNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
+	serverURL := strings.TrimSuffix(model.Settings.Get("url").MustString(), "/")
+	if serverURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+	botEmail := model.Settings.Get("botEmail").MustString()
+	if botEmail == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find bot email property in settings"}
+	}
+	apiKey := model.DecryptedValue("apiKey", model.Settings.Get("apiKey").MustString())
+	if apiKey == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find bot API key property in settings"}
+	}
+
+	stream := model.Settings.Get("stream").MustString()
+	recipients := []string{}
+	for _, r := range strings.Split(model.Settings.Get("recipients").MustString(), ",") {
+		r = strings.TrimSpace(r)
+		if r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	if stream == "" && len(recipients) == 0 {
+		return nil, alerting.ValidationError{Reason: "Either a stream or at least one recipient must be set"}
+	}
+
+	return &ZulipNotifier{
+		NotifierBase: NewNotifierBase(model),
+		URL:          serverURL,
+		BotEmail:     botEmail,
+		APIKey:       apiKey,
+		Stream:       stream,
+		Topic:        model.Settings.Get("topic").MustString(),
+		Recipients:   recipients,
+		log:          log.New("alerting.notifier.zulip"),
+	}, nil
+}
+
+// ZulipNotifier is responsible for sending
+// alert notifications to Zulip.
+type ZulipNotifier struct {
+	NotifierBase
+	URL        string
+	BotEmail   string
+	APIKey     string
+	Stream     string
+	Topic      string
+	Recipients []string
+	log        log.Logger
+}
+
+// Notify sends an alert notification to Zulip.
+func (zn *ZulipNotifier) Notify(evalContext *alerting.EvalContext) error {
+	zn.log.Info("Executing zulip notification", "ruleId", evalContext.Rule.ID, "notification", zn.Name)
+
+	data := url.Values{}
+	if zn.Stream != "" {
+		topic := zn.Topic
+		if topic == "" {
+			topic = evalContext.Rule.Name
+		}
+		data.Set("type", "stream")
+		data.Set("to", zn.Stream)
+		data.Set("topic", topic)
+	} else {
+		data.Set("type", "private")
+		data.Set("to", strings.Join(zn.Recipients, ","))
+	}
+	data.Set("content", zn.buildContent(evalContext))
+
+	cmd := &models.SendWebhookSync{
+		Url:         zn.URL + "/api/v1/messages",
+		User:        zn.BotEmail,
+		Password:    zn.APIKey,
+		Body:        data.Encode(),
+		HttpMethod:  http.MethodPost,
+		ContentType: "application/x-www-form-urlencoded",
+	}
+
+	if err := bus.DispatchCtx(evalContext.Ctx, cmd); err != nil {
+		zn.log.Error("Failed to send zulip notification", "error", err, "webhook", zn.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (zn *ZulipNotifier) buildContent(evalContext *alerting.EvalContext) string {
+	var b strings.Builder
+
+	title := evalContext.GetNotificationTitle()
+	if ruleURL, err := evalContext.GetRuleURL(); err == nil {
+		fmt.Fprintf(&b, "**[%s](%s)**\n", title, ruleURL)
+	} else {
+		fmt.Fprintf(&b, "**%s**\n", title)
+	}
+
+	if evalContext.Rule.State != models.AlertStateOK && evalContext.Rule.Message != "" {
+		fmt.Fprintf(&b, "%s\n", evalContext.Rule.Message)
+	}
+
+	for _, evt := range evalContext.EvalMatches {
+		fmt.Fprintf(&b, "* %s: %s\n", evt.Metric, evt.Value.FullString())
+	}
+
+	if evalContext.Error != nil {
+		fmt.Fprintf(&b, "**Error message:** %s\n", evalContext.Error.Error())
+	}
+
+	if zn.NeedsImage() && evalContext.ImagePublicURL != "" {
+		fmt.Fprintf(&b, "[Graph](%s)\n", evalContext.ImagePublicURL)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package notifiers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/validations"
+)
+
+func TestZulipNotifier(t *testing.T) {
+	t.Run("empty settings should return error", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{ }`))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "zulip_testing",
+			Type:     "zulip",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewZulipNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("stream or recipients are required", func(t *testing.T) {
+		json := `
+		{
+			"url": "https://zulip.example.com",
+			"botEmail": "grafana-bot@zulip.example.com",
+			"apiKey": "secret"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "zulip_testing",
+			Type:     "zulip",
+			Settings: settingsJSON,
+		}
+
+		_, err = NewZulipNotifier(model)
+		require.Error(t, err)
+	})
+
+	t.Run("from settings", func(t *testing.T) {
+		json := `
+		{
+			"url": "https://zulip.example.com/",
+			"botEmail": "grafana-bot@zulip.example.com",
+			"apiKey": "secret",
+			"stream": "alerts",
+			"recipients": "a@example.com, b@example.com"
+		}`
+		settingsJSON, err := simplejson.NewJson([]byte(json))
+		require.NoError(t, err)
+		model := &models.AlertNotification{
+			Name:     "zulip_testing",
+			Type:     "zulip",
+			Settings: settingsJSON,
+		}
+
+		not, err := NewZulipNotifier(model)
+		require.NoError(t, err)
+		zulipNotifier := not.(*ZulipNotifier)
+
+		assert.Equal(t, "zulip_testing", zulipNotifier.Name)
+		assert.Equal(t, "zulip", zulipNotifier.Type)
+		assert.Equal(t, "https://zulip.example.com", zulipNotifier.URL)
+		assert.Equal(t, "grafana-bot@zulip.example.com", zulipNotifier.BotEmail)
+		assert.Equal(t, "secret", zulipNotifier.APIKey)
+		assert.Equal(t, "alerts", zulipNotifier.Stream)
+		assert.Equal(t, []string{"a@example.com", "b@example.com"}, zulipNotifier.Recipients)
+
+		evalContext := alerting.NewEvalContext(context.Background(), &alerting.Rule{
+			Name:    "Rule",
+			State:   models.AlertStateAlerting,
+			Message: "Something is wrong",
+		}, &validations.OSSPluginRequestValidator{})
+		evalContext.IsTestRun = true
+		evalContext.EvalMatches = []*alerting.EvalMatch{
+			{Metric: "cpu", Value: null.FloatFrom(95)},
+		}
+
+		content := zulipNotifier.buildContent(evalContext)
+		assert.Contains(t, content, "**[[Alerting] Rule](")
+		assert.Contains(t, content, "Something is wrong\n")
+		assert.Contains(t, content, "* cpu: 95.000000\n")
+	})
+}
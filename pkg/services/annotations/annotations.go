@@ -31,8 +31,13 @@ type ItemQuery struct {
 	Tags         []string `json:"tags"`
 	Type         string   `json:"type"`
 	MatchAny     bool     `json:"matchAny"`
+	// DashboardIds, PrevStates and NewStates match any of their values when they are set
+	DashboardIds []int64  `json:"dashboardIds"`
+	PrevStates   []string `json:"prevStates"`
+	NewStates    []string `json:"newStates"`
 
-	Limit int64 `json:"limit"`
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
 }
 
 type DeleteParams struct {
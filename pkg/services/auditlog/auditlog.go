@@ -0,0 +1,298 @@
+// Package auditlog records the API calls changing resources: who made the call, in which
+// organization, on which resource, the summaries of the resource before and after the change,
+// and the outcome.
+//
+// The middleware records all the POST, PUT, PATCH and DELETE requests to /api. It finds the type
+// and the UID of the resource in the route, and the summary after the change in the JSON body of
+// the request. Handlers that know better can set them with SetResource, SetBefore and SetAfter.
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"gopkg.in/macaron.v1"
+)
+
+const dataKey = "auditlog.entry"
+
+// redacted replaces the values of the sensitive fields in the summaries
+const redacted = "[REDACTED]"
+
+var (
+	logger = log.New("auditlog")
+	// exportLogger writes the entries to the log modes configured in the [log] section
+	exportLogger = log.New("audit")
+)
+
+// notAudited are the prefixes of the routes that don't change resources, like queries, or
+// that proxy requests to data sources and plugins.
+var notAudited = []string{
+	"/api/ds/query",
+	"/api/tsdb/query",
+	"/api/dashboards/calculate-diff",
+	"/api/alerts/test",
+	"/api/alert-notifications/test",
+	"/api/datasources/proxy/",
+	"/api/datasources/:id/resources",
+	"/api/plugins/:pluginId/resources",
+	"/api/frontend/",
+	"/api/live/",
+}
+
+// sensitiveFields are redacted from the summaries. Fields containing these words are redacted.
+var sensitiveFields = []string{"password", "secret", "token", "key", "securejsondata"}
+
+type entry struct {
+	resourceType  string
+	resourceUID   string
+	beforeSummary string
+	afterSummary  string
+}
+
+// Middleware returns the named middleware recording the requests to a route in the audit log.
+func Middleware(cfg *setting.Cfg) func(route string) macaron.Handler {
+	return func(route string) macaron.Handler {
+		return func(c *macaron.Context) {
+			if !cfg.AuditLog.Enabled || !isAudited(c.Req.Method, route) {
+				return
+			}
+
+			e := &entry{}
+			e.resourceType, e.resourceUID = resourceFromRoute(route, c.Params)
+			e.afterSummary = requestSummary(c.Req.Request, cfg.AuditLog.MaxSummaryLength)
+			c.Data[dataKey] = e
+
+			c.Next()
+
+			reqCtx, ok := c.Data["ctx"].(*models.ReqContext)
+			if !ok {
+				return
+			}
+
+			status := c.Resp.Status()
+			outcome := models.AuditLogOutcomeSuccess
+			if status >= 400 {
+				outcome = models.AuditLogOutcomeFailure
+			}
+
+			logEntry := &models.AuditLogEntry{
+				OrgId:         reqCtx.OrgId,
+				UserId:        reqCtx.UserId,
+				UserLogin:     reqCtx.Login,
+				ApiKeyId:      reqCtx.ApiKeyId,
+				Action:        action(c.Req.Method),
+				Method:        c.Req.Method,
+				Route:         truncate(route, 255),
+				ResourceType:  truncate(e.resourceType, 190),
+				ResourceUid:   truncate(e.resourceUID, 190),
+				BeforeSummary: truncate(e.beforeSummary, cfg.AuditLog.MaxSummaryLength),
+				AfterSummary:  truncate(e.afterSummary, cfg.AuditLog.MaxSummaryLength),
+				ClientIp:      truncate(reqCtx.ClientIPAddress(cfg.LoginLockout.TrustedProxies), 190),
+				Status:        status,
+				Outcome:       outcome,
+				Created:       time.Now(),
+			}
+
+			if err := bus.Dispatch(&models.AddAuditLogEntryCommand{Entry: logEntry}); err != nil {
+				logger.Error("Failed to save audit log entry", "route", route, "error", err)
+			}
+
+			if cfg.AuditLog.ExportToLog {
+				exportLogger.Info("Audit log entry",
+					"orgId", logEntry.OrgId,
+					"userId", logEntry.UserId,
+					"uname", logEntry.UserLogin,
+					"apiKeyId", logEntry.ApiKeyId,
+					"action", logEntry.Action,
+					"method", logEntry.Method,
+					"route", logEntry.Route,
+					"resourceType", logEntry.ResourceType,
+					"resourceUid", logEntry.ResourceUid,
+					"before", logEntry.BeforeSummary,
+					"after", logEntry.AfterSummary,
+					"clientIp", logEntry.ClientIp,
+					"status", logEntry.Status,
+					"outcome", logEntry.Outcome,
+				)
+			}
+		}
+	}
+}
+
+// SetResource sets the type and the UID of the resource changed by the request,
+// for example when the route doesn't contain the UID.
+func SetResource(c *models.ReqContext, resourceType string, uid string) {
+	if e, ok := c.Data[dataKey].(*entry); ok {
+		e.resourceType = resourceType
+		e.resourceUID = uid
+	}
+}
+
+// SetBefore sets the summary of the resource before the change. Sensitive fields are redacted.
+func SetBefore(c *models.ReqContext, summary interface{}) {
+	if e, ok := c.Data[dataKey].(*entry); ok {
+		e.beforeSummary = summarize(summary)
+	}
+}
+
+// SetAfter sets the summary of the resource after the change, instead of the body of the request.
+// Sensitive fields are redacted.
+func SetAfter(c *models.ReqContext, summary interface{}) {
+	if e, ok := c.Data[dataKey].(*entry); ok {
+		e.afterSummary = summarize(summary)
+	}
+}
+
+func isAudited(method string, route string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if !strings.HasPrefix(route, "/api/") {
+		return false
+	}
+
+	for _, prefix := range notAudited {
+		if strings.HasPrefix(route, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func action(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "update"
+	}
+}
+
+// resourceFromRoute returns the first segment of the route after /api, or /api/admin, as the
+// type of the resource, and the value of the first parameter of the route as its UID.
+// For example /api/datasources/uid/:uid returns datasources and the value of :uid.
+func resourceFromRoute(route string, params func(string) string) (string, string) {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	if len(segments) > 1 && segments[0] == "admin" {
+		segments = segments[1:]
+	}
+
+	resourceType := segments[0]
+	if strings.HasPrefix(resourceType, ":") {
+		resourceType = ""
+	}
+
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			return resourceType, params(segment)
+		}
+	}
+
+	return resourceType, ""
+}
+
+// requestSummary returns the redacted JSON body of the request, and restores the body for the handlers.
+func requestSummary(req *http.Request, maxLength int) string {
+	if req.Body == nil || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return ""
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Warn("Failed to read request body", "error", err)
+	}
+	if err := req.Body.Close(); err != nil {
+		logger.Warn("Failed to close request body", "error", err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return ""
+	}
+
+	return truncate(summarize(value), maxLength)
+}
+
+func summarize(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	// round trip through JSON to redact structs the same way as request bodies
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return ""
+	}
+
+	data, err = json.Marshal(redact(generic))
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if isSensitive(field) {
+				v[field] = redacted
+				continue
+			}
+			v[field] = redact(fieldValue)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+
+	return value
+}
+
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, sensitive := range sensitiveFields {
+		if strings.Contains(field, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// truncate cuts s to at most maxLength bytes, without splitting a UTF-8 character.
+func truncate(s string, maxLength int) string {
+	if maxLength <= 0 || len(s) <= maxLength {
+		return s
+	}
+
+	for maxLength > 0 && !utf8.RuneStart(s[maxLength]) {
+		maxLength--
+	}
+
+	return s[:maxLength]
+}
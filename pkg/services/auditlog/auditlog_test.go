@@ -0,0 +1,145 @@
+package auditlog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+)
+
+func TestMiddleware(t *testing.T) {
+	const route = "/api/datasources/:id"
+
+	auditScenario := func(t *testing.T, enabled bool, method string, body string, handler macaron.Handler) []*models.AuditLogEntry {
+		t.Helper()
+
+		bus.ClearBusHandlers()
+		t.Cleanup(bus.ClearBusHandlers)
+
+		var entries []*models.AuditLogEntry
+		bus.AddHandler("test", func(cmd *models.AddAuditLogEntryCommand) error {
+			entries = append(entries, cmd.Entry)
+			return nil
+		})
+
+		cfg := setting.NewCfg()
+		cfg.AuditLog = setting.AuditLogSettings{Enabled: enabled, MaxSummaryLength: 4096}
+
+		m := macaron.New()
+		m.Use(macaron.Renderer())
+		m.Use(func(c *macaron.Context) {
+			reqCtx := &models.ReqContext{
+				Context:      c,
+				SignedInUser: &models.SignedInUser{OrgId: 2, UserId: 3, Login: "editor"},
+			}
+			c.Data["ctx"] = reqCtx
+			c.Map(reqCtx)
+		})
+		m.Handle(method, route, []macaron.Handler{Middleware(cfg)(route), handler})
+
+		req, err := http.NewRequest(method, "/api/datasources/5", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		req.RemoteAddr = "192.168.1.10:52000"
+		m.ServeHTTP(httptest.NewRecorder(), req)
+
+		return entries
+	}
+
+	t.Run("Records the changes with redacted summaries", func(t *testing.T) {
+		var handlerBody []byte
+		entries := auditScenario(t, true, http.MethodPut, `{"name":"prometheus","basicAuthPassword":"secret"}`, func(c *models.ReqContext) {
+			var err error
+			handlerBody, err = c.Req.Body().Bytes()
+			require.NoError(t, err)
+			SetBefore(c, map[string]interface{}{"name": "old", "secureJsonData": map[string]string{"password": "secret"}})
+			c.JSON(200, "ok")
+		})
+
+		assert.JSONEq(t, `{"name":"prometheus","basicAuthPassword":"secret"}`, string(handlerBody))
+		require.Len(t, entries, 1)
+		e := entries[0]
+		assert.Equal(t, int64(2), e.OrgId)
+		assert.Equal(t, int64(3), e.UserId)
+		assert.Equal(t, "editor", e.UserLogin)
+		assert.Equal(t, "update", e.Action)
+		assert.Equal(t, route, e.Route)
+		assert.Equal(t, "datasources", e.ResourceType)
+		assert.Equal(t, "5", e.ResourceUid)
+		assert.JSONEq(t, `{"name":"old","secureJsonData":"[REDACTED]"}`, e.BeforeSummary)
+		assert.JSONEq(t, `{"name":"prometheus","basicAuthPassword":"[REDACTED]"}`, e.AfterSummary)
+		assert.Equal(t, "192.168.1.10", e.ClientIp, "the forwarding headers of untrusted clients are ignored")
+		assert.Equal(t, 200, e.Status)
+		assert.Equal(t, models.AuditLogOutcomeSuccess, e.Outcome)
+	})
+
+	t.Run("Records failed changes", func(t *testing.T) {
+		entries := auditScenario(t, true, http.MethodDelete, "", func(c *models.ReqContext) {
+			SetResource(c, "datasources", "prom-uid")
+			c.JSON(403, "forbidden")
+		})
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, "delete", entries[0].Action)
+		assert.Equal(t, "prom-uid", entries[0].ResourceUid)
+		assert.Equal(t, 403, entries[0].Status)
+		assert.Equal(t, models.AuditLogOutcomeFailure, entries[0].Outcome)
+	})
+
+	t.Run("Doesn't record reads", func(t *testing.T) {
+		entries := auditScenario(t, true, http.MethodGet, "", func(c *models.ReqContext) {
+			c.JSON(200, "ok")
+		})
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Doesn't record when disabled", func(t *testing.T) {
+		entries := auditScenario(t, false, http.MethodPut, "{}", func(c *models.ReqContext) {
+			c.JSON(200, "ok")
+		})
+		assert.Empty(t, entries)
+	})
+}
+
+func TestIsAudited(t *testing.T) {
+	assert.True(t, isAudited(http.MethodPost, "/api/dashboards/db"))
+	assert.False(t, isAudited(http.MethodGet, "/api/dashboards/uid/:uid"))
+	assert.False(t, isAudited(http.MethodPost, "/api/ds/query"))
+	assert.False(t, isAudited(http.MethodPost, "/api/datasources/proxy/:id/*"))
+	assert.False(t, isAudited(http.MethodPost, "/login"))
+}
+
+func TestResourceFromRoute(t *testing.T) {
+	params := func(name string) string { return "value of " + name }
+
+	tests := []struct {
+		route        string
+		resourceType string
+		resourceUID  string
+	}{
+		{"/api/dashboards/db", "dashboards", ""},
+		{"/api/dashboards/uid/:uid", "dashboards", "value of :uid"},
+		{"/api/admin/users/:id/password", "users", "value of :id"},
+		{"/api/admin", "admin", ""},
+	}
+
+	for _, tc := range tests {
+		resourceType, resourceUID := resourceFromRoute(tc.route, params)
+		assert.Equal(t, tc.resourceType, resourceType, tc.route)
+		assert.Equal(t, tc.resourceUID, resourceUID, tc.route)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 0))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	assert.Equal(t, "a", truncate("aé", 2))
+}
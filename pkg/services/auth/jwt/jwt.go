@@ -0,0 +1,124 @@
+// Package jwt verifies the JWTs authenticating requests, with the keys of a JWK set or of a key file.
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const ServiceName = "JWTAuthService"
+
+var logger = log.New("auth.jwt")
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         ServiceName,
+		Instance:     &AuthService{},
+		InitPriority: registry.Medium,
+	})
+}
+
+// AuthService verifies the JWTs sent to authenticate requests.
+type AuthService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	keySet keySet
+	now    func() time.Time
+}
+
+func (s *AuthService) Init() error {
+	s.now = time.Now
+	if !s.Cfg.JWTAuth.Enabled {
+		return nil
+	}
+
+	ks, err := newKeySet(s.Cfg.JWTAuth)
+	if err != nil {
+		return err
+	}
+	s.keySet = ks
+
+	return nil
+}
+
+func newKeySet(cfg setting.JWTAuthSettings) (keySet, error) {
+	switch {
+	case cfg.JWKSetURL != "":
+		return newRemoteKeySet(cfg.JWKSetURL, cfg.CacheTTL), nil
+	case cfg.JWKSetFile != "":
+		return loadJWKSetFile(cfg.JWKSetFile)
+	case cfg.KeyFile != "":
+		return loadKeyFile(cfg.KeyFile)
+	}
+	return nil, fmt.Errorf("no JWT key source configured")
+}
+
+// Verify checks the signature of the token, its issuer, audience and expiry, and returns its claims.
+// Errors wrap models.ErrJWTInvalid when the token is invalid.
+func (s *AuthService) Verify(ctx context.Context, strToken string) (models.JWTClaims, error) {
+	token, err := jwt.ParseSigned(strToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrJWTInvalid, err)
+	}
+
+	keyID := ""
+	if len(token.Headers) > 0 {
+		keyID = token.Headers[0].KeyID
+	}
+
+	keys, err := s.keySet.Keys(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no key found for key ID %q", models.ErrJWTInvalid, keyID)
+	}
+
+	var claims models.JWTClaims
+	var registered jwt.Claims
+	verified := false
+	for _, key := range keys {
+		if err := token.Claims(key.Key, &claims, &registered); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: invalid signature", models.ErrJWTInvalid)
+	}
+
+	if err := s.validate(registered); err != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrJWTInvalid, err)
+	}
+
+	return claims, nil
+}
+
+func (s *AuthService) validate(claims jwt.Claims) error {
+	cfg := s.Cfg.JWTAuth
+
+	if claims.Expiry == nil {
+		return fmt.Errorf("missing expiry")
+	}
+
+	if err := claims.ValidateWithLeeway(jwt.Expected{Issuer: cfg.Issuer, Time: s.now()}, cfg.Leeway); err != nil {
+		return err
+	}
+
+	if len(cfg.Audiences) == 0 {
+		return nil
+	}
+	for _, audience := range cfg.Audiences {
+		if claims.Audience.Contains(audience) {
+			return nil
+		}
+	}
+	return jwt.ErrInvalidAudience
+}
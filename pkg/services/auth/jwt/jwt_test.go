@@ -0,0 +1,211 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type testKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+func newTestKey(t *testing.T, id string) testKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testKey{id: id, key: key}
+}
+
+func (k testKey) jwk() jose.JSONWebKey {
+	return jose.JSONWebKey{Key: k.key.Public(), KeyID: k.id, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+func (k testKey) sign(t *testing.T, claims interface{}) string {
+	t.Helper()
+
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if k.id != "" {
+		opts = opts.WithHeader("kid", k.id)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: k.key}, opts)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func writeJWKSetFile(t *testing.T, keys ...testKey) string {
+	t.Helper()
+
+	set := jose.JSONWebKeySet{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func newTestService(t *testing.T, cb func(*setting.JWTAuthSettings)) *AuthService {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.JWTAuth = setting.JWTAuthSettings{
+		Enabled:  true,
+		CacheTTL: time.Hour,
+		Leeway:   time.Minute,
+	}
+	cb(&cfg.JWTAuth)
+
+	s := &AuthService{Cfg: cfg}
+	require.NoError(t, s.Init())
+	return s
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "42",
+		"email": "jwt@example.com",
+		"iss":   "https://idp.example.com",
+		"aud":   "grafana",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key := newTestKey(t, "key-1")
+	otherKey := newTestKey(t, "key-2")
+	jwksFile := writeJWKSetFile(t, key)
+
+	s := newTestService(t, func(cfg *setting.JWTAuthSettings) {
+		cfg.JWKSetFile = jwksFile
+		cfg.Issuer = "https://idp.example.com"
+		cfg.Audiences = []string{"other", "grafana"}
+	})
+
+	t.Run("Valid tokens return their claims", func(t *testing.T) {
+		claims, err := s.Verify(context.Background(), key.sign(t, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, "jwt@example.com", claims["email"])
+	})
+
+	for name, claimsFn := range map[string]func(map[string]interface{}){
+		"expired":         func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"without expiry":  func(c map[string]interface{}) { delete(c, "exp") },
+		"not yet valid":   func(c map[string]interface{}) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"of other issuer": func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"for other audience": func(c map[string]interface{}) {
+			c["aud"] = []string{"api"}
+		},
+	} {
+		t.Run("Tokens "+name+" are invalid", func(t *testing.T) {
+			claims := validClaims()
+			claimsFn(claims)
+			_, err := s.Verify(context.Background(), key.sign(t, claims))
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, models.ErrJWTInvalid))
+		})
+	}
+
+	t.Run("Tokens signed by unknown keys are invalid", func(t *testing.T) {
+		_, err := s.Verify(context.Background(), otherKey.sign(t, validClaims()))
+		assert.True(t, errors.Is(err, models.ErrJWTInvalid))
+
+		forged := newTestKey(t, key.id)
+		_, err = s.Verify(context.Background(), forged.sign(t, validClaims()))
+		assert.True(t, errors.Is(err, models.ErrJWTInvalid))
+	})
+
+	t.Run("Malformed tokens are invalid", func(t *testing.T) {
+		_, err := s.Verify(context.Background(), "a.b.c")
+		assert.True(t, errors.Is(err, models.ErrJWTInvalid))
+	})
+}
+
+func TestVerifyWithKeyFile(t *testing.T) {
+	key := newTestKey(t, "")
+	der, err := x509.MarshalPKIXPublicKey(key.key.Public())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	s := newTestService(t, func(cfg *setting.JWTAuthSettings) {
+		cfg.KeyFile = path
+	})
+
+	claims, err := s.Verify(context.Background(), key.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims["sub"])
+}
+
+func TestVerifyWithJWKSetURL(t *testing.T) {
+	key := newTestKey(t, "key-1")
+	rotatedKey := newTestKey(t, "key-2")
+
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{key.jwk()}}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, json.NewEncoder(w).Encode(set))
+	}))
+	t.Cleanup(server.Close)
+
+	s := newTestService(t, func(cfg *setting.JWTAuthSettings) {
+		cfg.JWKSetURL = server.URL
+	})
+	now := time.Now()
+	s.keySet.(*remoteKeySet).now = func() time.Time { return now }
+
+	t.Run("Keys are cached", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := s.Verify(context.Background(), key.sign(t, validClaims()))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("Unknown keys are fetched again after the minimum interval", func(t *testing.T) {
+		set.Keys = append(set.Keys, rotatedKey.jwk())
+
+		_, err := s.Verify(context.Background(), rotatedKey.sign(t, validClaims()))
+		require.Error(t, err)
+		assert.Equal(t, 1, requests)
+
+		now = now.Add(2 * jwksMinRefreshInterval)
+		_, err = s.Verify(context.Background(), rotatedKey.sign(t, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("Keys are fetched again after the TTL", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		_, err := s.Verify(context.Background(), key.sign(t, validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, 3, requests)
+	})
+}
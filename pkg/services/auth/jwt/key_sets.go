@@ -0,0 +1,173 @@
+package jwt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// jwksMinRefreshInterval limits how often the key set of the URL is fetched again
+// when a token is signed with an unknown key, like after a key rotation
+const jwksMinRefreshInterval = time.Minute
+
+// keySet returns the keys that can verify the signature of tokens signed with the key ID.
+type keySet interface {
+	Keys(ctx context.Context, keyID string) ([]jose.JSONWebKey, error)
+}
+
+// staticKeySet is a key set read from a file.
+type staticKeySet struct {
+	jose.JSONWebKeySet
+}
+
+func (ks *staticKeySet) Keys(_ context.Context, keyID string) ([]jose.JSONWebKey, error) {
+	return keysByID(ks.JSONWebKeySet, keyID), nil
+}
+
+// keysByID returns the keys with the key ID, or all of them for tokens without key ID.
+func keysByID(set jose.JSONWebKeySet, keyID string) []jose.JSONWebKey {
+	if keyID == "" {
+		return set.Keys
+	}
+	return set.Key(keyID)
+}
+
+func loadJWKSetFile(path string) (*staticKeySet, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path comes from the configuration
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWK set %q: %w", path, err)
+	}
+
+	return &staticKeySet{JSONWebKeySet: set}, nil
+}
+
+// loadKeyFile reads a public key, or a certificate, in PEM format.
+func loadKeyFile(path string) (*staticKeySet, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path comes from the configuration
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %q", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %q", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %q: %w", path, err)
+	}
+
+	return &staticKeySet{JSONWebKeySet: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key}}}}, nil
+}
+
+// remoteKeySet is the key set of a JWKS URL, cached for the TTL.
+type remoteKeySet struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	set       jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func newRemoteKeySet(url string, cacheTTL time.Duration) *remoteKeySet {
+	return &remoteKeySet{
+		url:      url,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		cacheTTL: cacheTTL,
+		now:      time.Now,
+	}
+}
+
+func (ks *remoteKeySet) Keys(ctx context.Context, keyID string) ([]jose.JSONWebKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	age := ks.now().Sub(ks.fetchedAt)
+	if !ks.fetchedAt.IsZero() && age < ks.cacheTTL {
+		keys := keysByID(ks.set, keyID)
+		if len(keys) > 0 || age < jwksMinRefreshInterval {
+			return keys, nil
+		}
+	}
+
+	set, err := ks.fetch(ctx)
+	if err != nil {
+		// keep using the cached keys while the URL is unavailable
+		if !ks.fetchedAt.IsZero() {
+			logger.Warn("Failed to refresh JWK set, using cached keys", "url", ks.url, "error", err)
+			return keysByID(ks.set, keyID), nil
+		}
+		return nil, err
+	}
+
+	ks.set = set
+	ks.fetchedAt = ks.now()
+	return keysByID(ks.set, keyID), nil
+}
+
+func (ks *remoteKeySet) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var set jose.JSONWebKeySet
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return set, err
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return set, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return set, fmt.Errorf("failed to fetch JWK set %q: status %d", ks.url, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return set, fmt.Errorf("failed to parse JWK set %q: %w", ks.url, err)
+	}
+	if len(set.Keys) == 0 {
+		return set, errors.New("empty JWK set")
+	}
+
+	return set, nil
+}
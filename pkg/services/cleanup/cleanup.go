@@ -50,6 +50,7 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.cleanUpOldAnnotations(ctxWithTimeout)
 			srv.expireOldUserInvites()
 			srv.deleteStaleShortURLs()
+			srv.deleteOldAuditLogEntries()
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func() {
 					srv.deleteOldLoginAttempts()
@@ -135,8 +136,14 @@ func (srv *CleanUpService) deleteOldLoginAttempts() {
 		return
 	}
 
+	// the attempts are kept while they count towards lockouts
+	keep := time.Minute * 10
+	if srv.Cfg.LoginLockout.Window > keep {
+		keep = srv.Cfg.LoginLockout.Window
+	}
+
 	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+		OlderThan: time.Now().Add(-keep),
 	}
 	if err := bus.Dispatch(&cmd); err != nil {
 		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
@@ -158,6 +165,21 @@ func (srv *CleanUpService) expireOldUserInvites() {
 	}
 }
 
+func (srv *CleanUpService) deleteOldAuditLogEntries() {
+	if srv.Cfg.AuditLog.MaxAge <= 0 {
+		return
+	}
+
+	cmd := models.DeleteOldAuditLogEntriesCommand{
+		OlderThan: time.Now().Add(-srv.Cfg.AuditLog.MaxAge),
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		srv.log.Error("Problem deleting old audit log entries", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted old audit log entries", "rows affected", cmd.DeletedRows)
+	}
+}
+
 func (srv *CleanUpService) deleteStaleShortURLs() {
 	cmd := models.DeleteShortUrlCommand{
 		OlderThan: time.Now().Add(-time.Hour * 24 * 7),
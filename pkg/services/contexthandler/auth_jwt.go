@@ -0,0 +1,156 @@
+package contexthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/jmespath/go-jmespath"
+)
+
+const (
+	InvalidJWT = "invalid JWT"
+
+	authModuleJWT = "jwt"
+)
+
+// initContextWithJWT signs in the user of the JWT of the configured header, provisioning the user from its claims.
+func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgID int64) bool {
+	cfg := h.Cfg.JWTAuth
+	if !cfg.Enabled || cfg.HeaderName == "" {
+		return false
+	}
+
+	token := ctx.Req.Header.Get(cfg.HeaderName)
+	if parts := strings.SplitN(token, " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		token = parts[1]
+	}
+	// The Authorization header may hold API keys or basic auth credentials instead
+	if token == "" || strings.Count(token, ".") != 2 {
+		return false
+	}
+
+	claims, err := h.JWTAuthService.Verify(ctx.Req.Context(), token)
+	if err != nil {
+		ctx.Logger.Debug("Failed to verify JWT", "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	extUser, err := jwtExternalUser(cfg, claims)
+	if err != nil {
+		ctx.Logger.Debug("Failed to get user from JWT claims", "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+
+	upsert := &models.UpsertUserCommand{
+		ReqContext:    ctx,
+		SignupAllowed: cfg.AutoSignUp,
+		ExternalUser:  extUser,
+	}
+	if err := bus.Dispatch(upsert); err != nil {
+		if errors.Is(err, login.ErrInvalidCredentials) {
+			ctx.JsonApiErr(401, "User not found", nil)
+		} else {
+			ctx.Logger.Error("Failed to provision user from JWT", "login", extUser.Login, "error", err)
+			ctx.JsonApiErr(500, "Failed to provision user", err)
+		}
+		return true
+	}
+
+	query := models.GetSignedInUserQuery{UserId: upsert.Result.Id, OrgId: orgID}
+	if err := bus.Dispatch(&query); err != nil {
+		ctx.Logger.Error("Failed to get signed in user", "id", upsert.Result.Id, "org", orgID, "error", err)
+		ctx.JsonApiErr(401, InvalidJWT, err)
+		return true
+	}
+	if query.Result.IsDisabled {
+		ctx.JsonApiErr(401, "User is disabled", nil)
+		return true
+	}
+
+	ctx.SignedInUser = query.Result
+	ctx.IsSignedIn = true
+	return true
+}
+
+// jwtExternalUser maps the claims of a JWT to the user to provision.
+func jwtExternalUser(cfg setting.JWTAuthSettings, claims models.JWTClaims) (*models.ExternalUserInfo, error) {
+	subject, _ := claims["sub"].(string)
+	loginName, _ := claims[cfg.LoginClaim].(string)
+	email, _ := claims[cfg.EmailClaim].(string)
+	name, _ := claims[cfg.NameClaim].(string)
+
+	if loginName == "" {
+		loginName = email
+	}
+	if loginName == "" {
+		return nil, fmt.Errorf("missing %q claim", cfg.LoginClaim)
+	}
+	if subject == "" {
+		subject = loginName
+	}
+
+	extUser := &models.ExternalUserInfo{
+		AuthModule: authModuleJWT,
+		AuthId:     subject,
+		Login:      loginName,
+		Email:      email,
+		Name:       name,
+		OrgRoles:   map[int64]models.RoleType{},
+	}
+
+	if cfg.RoleAttributePath == "" && cfg.GroupsAttributePath == "" {
+		return extUser, nil
+	}
+
+	// JMESPath works on the types of encoding/json, so the claims are decoded again
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if cfg.RoleAttributePath != "" {
+		value, err := jmespath.Search(cfg.RoleAttributePath, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search JWT claims with role_attribute_path: %w", err)
+		}
+		if role, ok := value.(string); ok && models.RoleType(role).IsValid() {
+			// like OAuth, the role applies to the auto-assigned organization, or to the main one
+			orgID := int64(1)
+			if setting.AutoAssignOrg && setting.AutoAssignOrgId > 0 {
+				orgID = int64(setting.AutoAssignOrgId)
+			}
+			extUser.OrgRoles[orgID] = models.RoleType(role)
+		}
+	}
+
+	if cfg.GroupsAttributePath != "" {
+		value, err := jmespath.Search(cfg.GroupsAttributePath, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search JWT claims with groups_attribute_path: %w", err)
+		}
+		switch groups := value.(type) {
+		case []interface{}:
+			for _, group := range groups {
+				if g, ok := group.(string); ok {
+					extUser.Groups = append(extUser.Groups, g)
+				}
+			}
+		case string:
+			extUser.Groups = []string{groups}
+		}
+	}
+
+	return extUser, nil
+}
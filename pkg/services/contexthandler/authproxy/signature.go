@@ -0,0 +1,170 @@
+package authproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// NonceCachePrefix is a prefix for the cache key of the signatures already used
+	NonceCachePrefix = "auth-proxy-nonce:%s"
+
+	signatureTypeJWT = "jwt"
+)
+
+// VerifySignature checks, when signed headers are configured, that the request holds a valid signature of the proxy
+// which was not used before.
+func (auth *AuthProxy) VerifySignature() error {
+	if auth.cfg.AuthProxySignatureHeader == "" {
+		return nil
+	}
+
+	signature := auth.ctx.Req.Header.Get(auth.cfg.AuthProxySignatureHeader)
+	if signature == "" {
+		return newError("proxy authentication required", fmt.Errorf(
+			"request for user (%s) has no auth proxy signature", auth.header,
+		))
+	}
+
+	var nonce string
+	var err error
+	if auth.cfg.AuthProxySignatureType == signatureTypeJWT {
+		nonce, err = auth.verifyJWTSignature(signature)
+	} else {
+		nonce, err = auth.verifyHMACSignature(signature)
+	}
+	if err != nil {
+		return newError("proxy authentication required", fmt.Errorf(
+			"invalid auth proxy signature for user (%s): %w", auth.header, err,
+		))
+	}
+
+	return auth.useNonce(nonce)
+}
+
+// verifyHMACSignature checks a signature of the form t=<timestamp>,nonce=<nonce>,sig=<hex HMAC-SHA256>,
+// and returns its nonce.
+func (auth *AuthProxy) verifyHMACSignature(signature string) (string, error) {
+	var timestamp, nonce, sig string
+	for _, part := range strings.Split(signature, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "nonce":
+			nonce = kv[1]
+		case "sig":
+			sig = kv[1]
+		}
+	}
+	if timestamp == "" || nonce == "" || sig == "" {
+		return "", errors.New("malformed signature")
+	}
+
+	given, err := hex.DecodeString(sig)
+	if err != nil {
+		return "", errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, []byte(auth.cfg.AuthProxySignatureSecret))
+	if _, err := mac.Write([]byte(auth.signedMessage(timestamp, nonce))); err != nil {
+		return "", err
+	}
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return "", errors.New("signature mismatch")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("malformed timestamp")
+	}
+	if err := auth.checkSignatureTime(time.Unix(unix, 0)); err != nil {
+		return "", err
+	}
+
+	return nonce, nil
+}
+
+// signedMessage returns the lines of the timestamp, the nonce, and the values of the auth proxy headers.
+func (auth *AuthProxy) signedMessage(timestamp, nonce string) string {
+	lines := []string{timestamp, nonce, auth.header}
+	for _, field := range supportedHeaderFields {
+		if h := auth.cfg.AuthProxyHeaders[field]; h != "" {
+			lines = append(lines, auth.ctx.Req.Header.Get(h))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verifyJWTSignature checks a JWT signed with HS256, whose subject is the auth proxy header, and returns its ID.
+func (auth *AuthProxy) verifyJWTSignature(signature string) (string, error) {
+	token, err := jwt.ParseSigned(signature)
+	if err != nil {
+		return "", err
+	}
+
+	var claims jwt.Claims
+	if err := token.Claims([]byte(auth.cfg.AuthProxySignatureSecret), &claims); err != nil {
+		return "", err
+	}
+
+	if claims.Subject != auth.header {
+		return "", errors.New("subject mismatch")
+	}
+	if claims.ID == "" {
+		return "", errors.New("missing jti claim")
+	}
+	if claims.IssuedAt == nil {
+		return "", errors.New("missing iat claim")
+	}
+	if err := auth.checkSignatureTime(claims.IssuedAt.Time()); err != nil {
+		return "", err
+	}
+	if claims.Expiry != nil && time.Now().After(claims.Expiry.Time()) {
+		return "", jwt.ErrExpired
+	}
+
+	return claims.ID, nil
+}
+
+// checkSignatureTime checks that the signature was made within the maximum age, in either direction
+// to allow for clock skew.
+func (auth *AuthProxy) checkSignatureTime(signedAt time.Time) error {
+	age := time.Since(signedAt)
+	if age > auth.cfg.AuthProxySignatureMaxAge || -age > auth.cfg.AuthProxySignatureMaxAge {
+		return errors.New("signature timestamp out of the allowed range")
+	}
+	return nil
+}
+
+// useNonce refuses signatures whose nonce was already used, and remembers the nonce for as long as
+// its signature can be valid.
+func (auth *AuthProxy) useNonce(nonce string) error {
+	hashedNonce, err := HashCacheKey(nonce)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(NonceCachePrefix, hashedNonce)
+
+	// the nonce is stored atomically, so that concurrent requests replaying a signature can't all use it
+	set, err := auth.remoteCache.SetIfNotExists(key, true, 2*auth.cfg.AuthProxySignatureMaxAge)
+	if err != nil {
+		return newError("failed to store the auth proxy signature", err)
+	}
+	if !set {
+		return newError("proxy authentication required", fmt.Errorf(
+			"auth proxy signature for user (%s) was already used", auth.header,
+		))
+	}
+	return nil
+}
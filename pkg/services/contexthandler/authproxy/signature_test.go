@@ -0,0 +1,176 @@
+package authproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const signatureSecret = "shared-secret"
+
+func hmacSignature(t *testing.T, signedAt time.Time, nonce string, values ...string) string {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(signatureSecret))
+	message := timestamp + "\n" + nonce
+	for _, value := range values {
+		message += "\n" + value
+	}
+	_, err := mac.Write([]byte(message))
+	require.NoError(t, err)
+
+	return fmt.Sprintf("t=%s,nonce=%s,sig=%s", timestamp, nonce, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func jwtSignature(t *testing.T, secret string, claims jwt.Claims) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(secret)}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+func prepareSignedMiddleware(t *testing.T, cache *remotecache.RemoteCache, signatureType, signature string) *AuthProxy {
+	t.Helper()
+
+	return prepareMiddleware(t, cache, func(req *http.Request, cfg *setting.Cfg) {
+		cfg.AuthProxyHeaders = map[string]string{"Groups": "X-WEBAUTH-GROUPS", "Name": "X-WEBAUTH-NAME"}
+		cfg.AuthProxySignatureHeader = "X-WEBAUTH-SIGNATURE"
+		cfg.AuthProxySignatureType = signatureType
+		cfg.AuthProxySignatureSecret = signatureSecret
+		cfg.AuthProxySignatureMaxAge = 30 * time.Second
+
+		req.Header.Set("X-WEBAUTH-NAME", "Mark")
+		req.Header.Set("X-WEBAUTH-GROUPS", "grafana-core-team")
+		if signature != "" {
+			req.Header.Set("X-WEBAUTH-SIGNATURE", signature)
+		}
+	})
+}
+
+func requireProxyAuthError(t *testing.T, err error) {
+	t.Helper()
+
+	require.Error(t, err)
+	var e Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, "proxy authentication required", e.Message)
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Run("Requests are not checked without signature header", func(t *testing.T) {
+		auth := prepareMiddleware(t, remotecache.NewFakeStore(t), nil)
+		require.NoError(t, auth.VerifySignature())
+	})
+
+	t.Run("Requests without signature are refused", func(t *testing.T) {
+		auth := prepareSignedMiddleware(t, remotecache.NewFakeStore(t), "hmac", "")
+		requireProxyAuthError(t, auth.VerifySignature())
+	})
+
+	t.Run("With HMAC signatures", func(t *testing.T) {
+		cache := remotecache.NewFakeStore(t)
+
+		t.Run("Valid signatures are accepted once", func(t *testing.T) {
+			signature := hmacSignature(t, time.Now(), "nonce-1", hdrName, "Mark", "grafana-core-team")
+
+			auth := prepareSignedMiddleware(t, cache, "hmac", signature)
+			require.NoError(t, auth.VerifySignature())
+
+			auth = prepareSignedMiddleware(t, cache, "hmac", signature)
+			requireProxyAuthError(t, auth.VerifySignature())
+		})
+
+		t.Run("Concurrent requests replaying a signature are accepted once", func(t *testing.T) {
+			cache := &remotecache.RemoteCache{Cfg: &setting.Cfg{
+				RemoteCacheOptions: &setting.RemoteCacheOptions{Name: "memory"},
+			}}
+			require.NoError(t, cache.Init())
+			signature := hmacSignature(t, time.Now(), "nonce-concurrent", hdrName, "Mark", "grafana-core-team")
+
+			var wg sync.WaitGroup
+			var accepted int32
+			for i := 0; i < 20; i++ {
+				auth := prepareSignedMiddleware(t, cache, "hmac", signature)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if auth.VerifySignature() == nil {
+						atomic.AddInt32(&accepted, 1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, int32(1), accepted)
+		})
+
+		for name, signature := range map[string]string{
+			"of other headers": hmacSignature(t, time.Now(), "nonce-2", hdrName, "Mark", "grafana-admins"),
+			"too old":          hmacSignature(t, time.Now().Add(-time.Minute), "nonce-3", hdrName, "Mark", "grafana-core-team"),
+			"in the future":    hmacSignature(t, time.Now().Add(time.Minute), "nonce-4", hdrName, "Mark", "grafana-core-team"),
+			"malformed":        "t=1,sig=zz",
+		} {
+			t.Run("Signatures "+name+" are refused", func(t *testing.T) {
+				auth := prepareSignedMiddleware(t, cache, "hmac", signature)
+				requireProxyAuthError(t, auth.VerifySignature())
+			})
+		}
+	})
+
+	t.Run("With JWT signatures", func(t *testing.T) {
+		cache := remotecache.NewFakeStore(t)
+		claims := func(id string) jwt.Claims {
+			return jwt.Claims{Subject: hdrName, ID: id, IssuedAt: jwt.NewNumericDate(time.Now())}
+		}
+
+		t.Run("Valid signatures are accepted once", func(t *testing.T) {
+			signature := jwtSignature(t, signatureSecret, claims("jti-1"))
+
+			auth := prepareSignedMiddleware(t, cache, "jwt", signature)
+			require.NoError(t, auth.VerifySignature())
+
+			auth = prepareSignedMiddleware(t, cache, "jwt", signature)
+			requireProxyAuthError(t, auth.VerifySignature())
+		})
+
+		otherSubject := claims("jti-2")
+		otherSubject.Subject = "admin"
+		withoutID := claims("")
+		tooOld := claims("jti-3")
+		tooOld.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		expired := claims("jti-4")
+		expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Second))
+
+		for name, signature := range map[string]string{
+			"of other key":     jwtSignature(t, "other-secret", claims("jti-5")),
+			"of other subject": jwtSignature(t, signatureSecret, otherSubject),
+			"without ID":       jwtSignature(t, signatureSecret, withoutID),
+			"too old":          jwtSignature(t, signatureSecret, tooOld),
+			"expired":          jwtSignature(t, signatureSecret, expired),
+		} {
+			t.Run("Signatures "+name+" are refused", func(t *testing.T) {
+				auth := prepareSignedMiddleware(t, cache, "jwt", signature)
+				requireProxyAuthError(t, auth.VerifySignature())
+			})
+		}
+	})
+}
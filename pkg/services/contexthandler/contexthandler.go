@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/middleware/cookies"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/auth/jwt"
 	"github.com/grafana/grafana/pkg/services/contexthandler/authproxy"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/rendering"
@@ -32,6 +33,9 @@ const (
 
 const ServiceName = "ContextHandler"
 
+// apiKeyLastUsedAtInterval is how often the last use of an API key is saved
+const apiKeyLastUsedAtInterval = time.Minute
+
 func init() {
 	registry.Register(&registry.Descriptor{
 		Name:         ServiceName,
@@ -47,6 +51,7 @@ type ContextHandler struct {
 	RemoteCache      *remotecache.RemoteCache `inject:""`
 	RenderService    rendering.Service        `inject:""`
 	SQLStore         *sqlstore.SQLStore       `inject:""`
+	JWTAuthService   *jwt.AuthService         `inject:""`
 
 	// GetTime returns the current time.
 	// Stubbable by tests.
@@ -87,7 +92,10 @@ func (h *ContextHandler) Middleware(c *macaron.Context) {
 	// then look for api key in session (special case for render calls via api)
 	// then test if anonymous access is enabled
 	switch {
+	case h.Cfg.SCIM.Enabled && strings.HasPrefix(ctx.Req.URL.Path, "/api/scim/"):
+		// the identity providers authenticate with the SCIM token, which the SCIM routes check
 	case h.initContextWithRenderAuth(ctx):
+	case h.initContextWithJWT(ctx, orgID):
 	case h.initContextWithAPIKey(ctx):
 	case h.initContextWithBasicAuth(ctx, orgID):
 	case h.initContextWithAuthProxy(ctx, orgID):
@@ -96,6 +104,10 @@ func (h *ContextHandler) Middleware(c *macaron.Context) {
 	}
 
 	ctx.Logger = log.New("context", "userId", ctx.UserId, "orgId", ctx.OrgId, "uname", ctx.Login)
+	if ctx.ApiKeyId != 0 {
+		// attribute the requests to the API key or the token of the service account
+		ctx.Logger = ctx.Logger.New("apiKeyId", ctx.ApiKeyId)
+	}
 	ctx.Data["ctx"] = ctx
 
 	c.Map(ctx)
@@ -183,6 +195,36 @@ func (h *ContextHandler) initContextWithAPIKey(ctx *models.ReqContext) bool {
 		return true
 	}
 
+	if apikey.LastUsedAt == nil || apikey.LastUsedAt.Before(getTime().Add(-apiKeyLastUsedAtInterval)) {
+		if err := bus.Dispatch(&models.UpdateApiKeyLastUsedAtCommand{Id: apikey.Id}); err != nil {
+			ctx.Logger.Warn("Failed to update last use of API key", "id", apikey.Id, "error", err)
+		}
+	}
+
+	if apikey.ServiceAccountId != nil {
+		// the tokens of service accounts sign in the service account, with its role
+		query := models.GetSignedInUserQuery{UserId: *apikey.ServiceAccountId, OrgId: apikey.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			ctx.JsonApiErr(401, InvalidAPIKey, err)
+			return true
+		}
+
+		if !query.Result.IsServiceAccount || query.Result.OrgId != apikey.OrgId {
+			ctx.JsonApiErr(401, InvalidAPIKey, nil)
+			return true
+		}
+
+		if query.Result.IsDisabled {
+			ctx.JsonApiErr(401, "Service account is disabled", nil)
+			return true
+		}
+
+		ctx.IsSignedIn = true
+		ctx.SignedInUser = query.Result
+		ctx.ApiKeyId = apikey.Id
+		return true
+	}
+
 	ctx.IsSignedIn = true
 	ctx.SignedInUser = &models.SignedInUser{}
 	ctx.OrgRole = apikey.Role
@@ -208,9 +250,10 @@ func (h *ContextHandler) initContextWithBasicAuth(ctx *models.ReqContext, orgID
 	}
 
 	authQuery := models.LoginUserQuery{
-		Username: username,
-		Password: password,
-		Cfg:      h.Cfg,
+		Username:  username,
+		Password:  password,
+		IpAddress: ctx.ClientIPAddress(h.Cfg.LoginLockout.TrustedProxies),
+		Cfg:       h.Cfg,
 	}
 	if err := bus.Dispatch(&authQuery); err != nil {
 		ctx.Logger.Debug(
@@ -228,6 +271,20 @@ func (h *ContextHandler) initContextWithBasicAuth(ctx *models.ReqContext, orgID
 
 	user := authQuery.User
 
+	// The password alone doesn't authenticate users with two-factor authentication
+	if h.Cfg.Totp.Enabled && authQuery.AuthModule == "grafana" {
+		protected, err := isTotpProtected(user.Id)
+		if err != nil {
+			ctx.Logger.Error("Failed to get two-factor authentication", "id", user.Id, "error", err)
+			ctx.JsonApiErr(500, "Failed to get two-factor authentication", err)
+			return true
+		}
+		if protected {
+			ctx.JsonApiErr(401, "Basic authentication is disabled for users with two-factor authentication", nil)
+			return true
+		}
+	}
+
 	query := models.GetSignedInUserQuery{UserId: user.Id, OrgId: orgID}
 	if err := bus.Dispatch(&query); err != nil {
 		ctx.Logger.Error(
@@ -244,6 +301,22 @@ func (h *ContextHandler) initContextWithBasicAuth(ctx *models.ReqContext, orgID
 	return true
 }
 
+// isTotpProtected checks whether the user enabled two-factor authentication or must enable it.
+func isTotpProtected(userID int64) (bool, error) {
+	query := models.GetUserTotpQuery{UserId: userID}
+	if err := bus.Dispatch(&query); err == nil && query.Result.Enabled {
+		return true, nil
+	} else if err != nil && !errors.Is(err, models.ErrUserTotpNotFound) {
+		return false, err
+	}
+
+	requiredQuery := models.IsTotpRequiredForUserQuery{UserId: userID}
+	if err := bus.Dispatch(&requiredQuery); err != nil {
+		return false, err
+	}
+	return requiredQuery.Result, nil
+}
+
 func (h *ContextHandler) initContextWithToken(ctx *models.ReqContext, orgID int64) bool {
 	if h.Cfg.LoginCookieName == "" {
 		return false
@@ -269,6 +342,10 @@ func (h *ContextHandler) initContextWithToken(ctx *models.ReqContext, orgID int6
 		return false
 	}
 
+	if h.Cfg.OAuthValidateSession && !h.validateOAuthSession(ctx, token) {
+		return false
+	}
+
 	ctx.SignedInUser = query.Result
 	ctx.IsSignedIn = true
 	ctx.UserToken = token
@@ -393,6 +470,14 @@ func (h *ContextHandler) initContextWithAuthProxy(ctx *models.ReqContext, orgID
 		return true
 	}
 
+	// Check the signed assertion of the proxy, if required
+	if err := auth.VerifySignature(); err != nil {
+		h.handleError(ctx, err, 407, func(details error) {
+			logger.Error("Failed to verify auth proxy signature", "message", err.Error(), "error", details)
+		})
+		return true
+	}
+
 	id, err := logUserIn(auth, username, logger, false)
 	if err != nil {
 		h.handleError(ctx, err, 407, nil)
@@ -0,0 +1,46 @@
+package contexthandler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+)
+
+// validateOAuthSession checks, at most once per interval, that the OAuth token of the user of the session
+// is still valid at the identity provider, and revokes the sessions of the user otherwise.
+// It returns false when the session was revoked.
+func (h *ContextHandler) validateOAuthSession(ctx *models.ReqContext, token *models.UserToken) bool {
+	// The token is shared by the sessions of the user, so they are validated together
+	cacheKey := fmt.Sprintf("oauth-session-validated-%d", token.UserId)
+	if _, err := h.RemoteCache.Get(cacheKey); err == nil {
+		return true
+	}
+
+	// The entry is written before the validation, so that the other requests of the user, also on other
+	// instances, don't refresh the token concurrently. The session is kept when the identity provider
+	// can't tell, and checked again after the interval.
+	if err := h.RemoteCache.Set(cacheKey, true, h.Cfg.OAuthValidateSessionInterval); err != nil {
+		ctx.Logger.Warn("Failed to cache OAuth session validation", "userId", token.UserId, "error", err)
+	}
+
+	err := oauthtoken.ValidateOAuthToken(ctx.Req.Context(), token.UserId)
+	if errors.Is(err, oauthtoken.ErrTokenRevoked) {
+		ctx.Logger.Info("Revoking sessions of user refused by the identity provider", "userId", token.UserId, "error", err)
+		if err := h.AuthTokenService.RevokeAllUserTokens(ctx.Req.Context(), token.UserId); err != nil {
+			ctx.Logger.Error("Failed to revoke user sessions", "userId", token.UserId, "error", err)
+		}
+		if err := h.RemoteCache.Delete(cacheKey); err != nil {
+			ctx.Logger.Warn("Failed to delete OAuth session validation", "userId", token.UserId, "error", err)
+		}
+		cookies.WriteSessionCookie(ctx, h.Cfg, "", -1)
+		return false
+	}
+	if err != nil {
+		ctx.Logger.Warn("Failed to validate OAuth token of session", "userId", token.UserId, "error", err)
+	}
+
+	return true
+}
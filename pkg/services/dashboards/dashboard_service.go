@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -158,10 +159,25 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 	}
 
 	guard := guardian.New(dash.GetDashboardIdForSavePermissionCheck(), dto.OrgId, dto.User)
-	if canSave, err := guard.CanSave(); err != nil || !canSave {
-		if err != nil {
+	canSave, err := guard.CanSave()
+	if err != nil {
+		return nil, err
+	}
+
+	// new dashboards can also be created with the permission to create dashboards in the folder
+	if !canSave && dash.Id == 0 && !dash.IsFolder {
+		query := models.EvaluatePermissionQuery{
+			User:   dto.User,
+			Action: accesscontrol.ActionDashboardsCreate,
+			Scope:  accesscontrol.ScopeFolderID(dash.FolderId),
+		}
+		if err := bus.Dispatch(&query); err != nil {
 			return nil, err
 		}
+		canSave = query.Result
+	}
+
+	if !canSave {
 		return nil, models.ErrDashboardUpdateAccessDenied
 	}
 
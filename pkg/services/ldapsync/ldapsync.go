@@ -0,0 +1,272 @@
+// Package ldapsync synchronizes the LDAP users with the directory in the background,
+// so that users who don't log in are kept up to date and deprovisioned.
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// usersPageSize is the number of users read at once from the database
+	usersPageSize = 1000
+	// searchBatchSize is the number of users searched at once in LDAP
+	searchBatchSize = 100
+)
+
+var logger = log.New("ldapsync")
+
+// getLDAPConfig gets LDAP config
+var getLDAPConfig = multildap.GetConfig
+
+// newLDAP creates multiple LDAP instance
+var newLDAP = multildap.New
+
+// the schedule of [auth.ldap] sync_cron, with optional seconds
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+func init() {
+	registry.RegisterService(&LDAPSyncService{})
+}
+
+// LDAPSyncService synchronizes the LDAP users on the schedule of [auth.ldap] sync_cron.
+type LDAPSyncService struct {
+	Cfg               *setting.Cfg                  `inject:""`
+	ServerLockService *serverlock.ServerLockService `inject:""`
+	AuthTokenService  models.UserTokenService       `inject:""`
+
+	schedule cron.Schedule
+
+	mu       sync.RWMutex
+	lastSync *SyncResult
+}
+
+// SyncResult summarizes a synchronization of the LDAP users.
+type SyncResult struct {
+	Started         time.Time `json:"started"`
+	Elapsed         string    `json:"elapsed"`
+	Users           int       `json:"users"`
+	Synced          int       `json:"synced"`
+	DisabledUserIds []int64   `json:"disabledUserIds"`
+	FailedUserIds   []int64   `json:"failedUserIds"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// SyncStatus is the status of the background synchronization on this server.
+type SyncStatus struct {
+	Enabled  bool        `json:"enabled"`
+	Schedule string      `json:"schedule"`
+	NextSync *time.Time  `json:"nextSync,omitempty"`
+	LastSync *SyncResult `json:"lastSync,omitempty"`
+}
+
+func (s *LDAPSyncService) Init() error {
+	if s.IsDisabled() {
+		return nil
+	}
+
+	schedule, err := cronParser.Parse(strings.Trim(setting.LDAPSyncCron, `"`))
+	if err != nil {
+		return fmt.Errorf("invalid LDAP sync_cron %q: %w", setting.LDAPSyncCron, err)
+	}
+	s.schedule = schedule
+
+	return nil
+}
+
+// IsDisabled disables the background synchronization, LDAP users are still synchronized when they log in.
+func (s *LDAPSyncService) IsDisabled() bool {
+	return !setting.LDAPEnabled || !setting.LDAPActiveSyncEnabled
+}
+
+func (s *LDAPSyncService) Run(ctx context.Context) error {
+	for {
+		next := s.schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+			// only one of the servers of a HA setup synchronizes the users at each run
+			maxInterval := s.schedule.Next(next).Sub(next) / 2
+			err := s.ServerLockService.LockAndExecute(ctx, "ldap user sync", maxInterval, func() {
+				s.Sync(ctx)
+			})
+			if err != nil {
+				logger.Error("Failed to lock and execute the synchronization of LDAP users", "error", err)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Status returns the schedule of the synchronization and the result of the last one run by this server.
+func (s *LDAPSyncService) Status() SyncStatus {
+	status := SyncStatus{
+		Enabled:  !s.IsDisabled(),
+		Schedule: strings.Trim(setting.LDAPSyncCron, `"`),
+	}
+
+	if status.Enabled && s.schedule != nil {
+		next := s.schedule.Next(time.Now())
+		status.NextSync = &next
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status.LastSync = s.lastSync
+
+	return status
+}
+
+// Sync refreshes the LDAP users found in the directory, and disables the ones missing from it.
+func (s *LDAPSyncService) Sync(ctx context.Context) *SyncResult {
+	result := &SyncResult{
+		Started:         time.Now(),
+		DisabledUserIds: []int64{},
+		FailedUserIds:   []int64{},
+	}
+
+	logger.Info("Synchronizing LDAP users")
+	if err := s.sync(ctx, result); err != nil {
+		logger.Error("Failed to synchronize LDAP users", "error", err)
+		result.Error = err.Error()
+	}
+	result.Elapsed = time.Since(result.Started).String()
+
+	logger.Info("Synchronized LDAP users", "users", result.Users, "synced", result.Synced,
+		"disabled", len(result.DisabledUserIds), "failed", len(result.FailedUserIds), "elapsed", result.Elapsed)
+
+	s.mu.Lock()
+	s.lastSync = result
+	s.mu.Unlock()
+
+	return result
+}
+
+func (s *LDAPSyncService) sync(ctx context.Context, result *SyncResult) error {
+	config, err := getLDAPConfig(s.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// the users are all read first, as disabling users changes the pages
+	users, err := getLDAPUsers()
+	if err != nil {
+		return err
+	}
+	result.Users = len(users)
+
+	ldap := newLDAP(config.Servers)
+	found := 0
+	var missing []*models.UserSearchHitDTO
+	for start := 0; start < len(users); start += searchBatchSize {
+		end := start + searchBatchSize
+		if end > len(users) {
+			end = len(users)
+		}
+		batch := users[start:end]
+
+		logins := make([]string, 0, len(batch))
+		for _, user := range batch {
+			logins = append(logins, user.Login)
+		}
+
+		// nobody is disabled when the directory can't be searched
+		extUsers, err := ldap.Users(logins)
+		if err != nil {
+			return err
+		}
+
+		extUsersByLogin := make(map[string]*models.ExternalUserInfo, len(extUsers))
+		for _, extUser := range extUsers {
+			extUsersByLogin[strings.ToLower(extUser.Login)] = extUser
+		}
+
+		for _, user := range batch {
+			extUser, ok := extUsersByLogin[strings.ToLower(user.Login)]
+			if !ok {
+				missing = append(missing, user)
+				continue
+			}
+			found++
+
+			// refreshes the info, roles and teams of the user
+			upsert := &models.UpsertUserCommand{ExternalUser: extUser, SignupAllowed: false}
+			if err := bus.Dispatch(upsert); err != nil {
+				logger.Error("Failed to synchronize LDAP user", "login", user.Login, "error", err)
+				result.FailedUserIds = append(result.FailedUserIds, user.Id)
+				continue
+			}
+			result.Synced++
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// protects against a misconfigured search disabling all the users
+	if found == 0 {
+		return errors.New("none of the users were found in LDAP, no user was disabled")
+	}
+
+	for _, user := range missing {
+		// the Grafana admin can't be disabled, like when syncing it from the admin page
+		if user.Login == s.Cfg.AdminUser {
+			logger.Warn("Refusing to disable the Grafana admin missing from LDAP", "login", user.Login)
+			continue
+		}
+
+		logger.Info("Disabling user missing from LDAP", "login", user.Login)
+		if err := bus.Dispatch(&models.DisableUserCommand{UserId: user.Id, IsDisabled: true}); err != nil {
+			logger.Error("Failed to disable user missing from LDAP", "login", user.Login, "error", err)
+			result.FailedUserIds = append(result.FailedUserIds, user.Id)
+			continue
+		}
+		result.DisabledUserIds = append(result.DisabledUserIds, user.Id)
+	}
+
+	if len(result.DisabledUserIds) == 0 {
+		return nil
+	}
+
+	return s.AuthTokenService.BatchRevokeAllUserTokens(ctx, result.DisabledUserIds)
+}
+
+// getLDAPUsers pages through the enabled LDAP users.
+func getLDAPUsers() ([]*models.UserSearchHitDTO, error) {
+	isDisabled := false
+	var users []*models.UserSearchHitDTO
+
+	for page := 1; ; page++ {
+		query := &models.SearchUsersQuery{
+			AuthModule: models.AuthModuleLDAP,
+			IsDisabled: &isDisabled,
+			Limit:      usersPageSize,
+			Page:       page,
+		}
+		if err := bus.Dispatch(query); err != nil {
+			return nil, err
+		}
+
+		users = append(users, query.Result.Users...)
+		if len(query.Result.Users) < usersPageSize {
+			return users, nil
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ldapMock struct {
+	multildap.IMultiLDAP
+	users []*models.ExternalUserInfo
+	err   error
+}
+
+func (m *ldapMock) Users(logins []string) ([]*models.ExternalUserInfo, error) {
+	var result []*models.ExternalUserInfo
+	for _, user := range m.users {
+		for _, login := range logins {
+			if user.Login == login {
+				result = append(result, user)
+			}
+		}
+	}
+	return result, m.err
+}
+
+type syncScenario struct {
+	service  *LDAPSyncService
+	ldap     *ldapMock
+	users    []*models.UserSearchHitDTO
+	upserted []string
+	disabled []int64
+	revoked  []int64
+}
+
+func setupSyncScenario(t *testing.T) *syncScenario {
+	t.Helper()
+
+	sc := &syncScenario{ldap: &ldapMock{}}
+
+	origGetLDAPConfig, origNewLDAP := getLDAPConfig, newLDAP
+	t.Cleanup(func() { getLDAPConfig, newLDAP = origGetLDAPConfig, origNewLDAP })
+	getLDAPConfig = func(*setting.Cfg) (*ldap.Config, error) {
+		return &ldap.Config{}, nil
+	}
+	newLDAP = func([]*ldap.ServerConfig) multildap.IMultiLDAP {
+		return sc.ldap
+	}
+
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.SearchUsersQuery) error {
+		assert.Equal(t, models.AuthModuleLDAP, query.AuthModule)
+		assert.False(t, *query.IsDisabled)
+		if query.Page == 1 {
+			query.Result.Users = sc.users
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UpsertUserCommand) error {
+		assert.False(t, cmd.SignupAllowed)
+		if cmd.ExternalUser.Login == "failing" {
+			return errors.New("upsert failed")
+		}
+		sc.upserted = append(sc.upserted, cmd.ExternalUser.Login)
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.DisableUserCommand) error {
+		assert.True(t, cmd.IsDisabled)
+		sc.disabled = append(sc.disabled, cmd.UserId)
+		return nil
+	})
+
+	tokenService := auth.NewFakeUserAuthTokenService()
+	tokenService.BatchRevokedTokenProvider = func(ctx context.Context, userIds []int64) error {
+		sc.revoked = append(sc.revoked, userIds...)
+		return nil
+	}
+
+	cfg := setting.NewCfg()
+	cfg.AdminUser = "admin"
+	sc.service = &LDAPSyncService{Cfg: cfg, AuthTokenService: tokenService}
+
+	return sc
+}
+
+func TestSync(t *testing.T) {
+	t.Run("Users are refreshed, or disabled when missing from LDAP", func(t *testing.T) {
+		sc := setupSyncScenario(t)
+		sc.users = []*models.UserSearchHitDTO{
+			{Id: 1, Login: "found"},
+			{Id: 2, Login: "Missing"},
+			{Id: 3, Login: "admin"},
+			{Id: 4, Login: "failing"},
+		}
+		sc.ldap.users = []*models.ExternalUserInfo{{Login: "found"}, {Login: "failing"}}
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Empty(t, result.Error)
+		assert.Equal(t, 4, result.Users)
+		assert.Equal(t, 1, result.Synced)
+		assert.Equal(t, []int64{2}, result.DisabledUserIds)
+		assert.Equal(t, []int64{4}, result.FailedUserIds)
+		assert.Equal(t, []string{"found"}, sc.upserted)
+		assert.Equal(t, []int64{2}, sc.disabled)
+		assert.Equal(t, []int64{2}, sc.revoked)
+
+		assert.Equal(t, result, sc.service.Status().LastSync)
+	})
+
+	t.Run("Nobody is disabled when LDAP can't be searched", func(t *testing.T) {
+		sc := setupSyncScenario(t)
+		sc.users = []*models.UserSearchHitDTO{{Id: 1, Login: "user"}}
+		sc.ldap.err = errors.New("LDAP unavailable")
+
+		result := sc.service.Sync(context.Background())
+
+		assert.Equal(t, "LDAP unavailable", result.Error)
+		assert.Empty(t, sc.disabled)
+		assert.Empty(t, sc.revoked)
+	})
+
+	t.Run("Nobody is disabled when no user is found in LDAP", func(t *testing.T) {
+		sc := setupSyncScenario(t)
+		sc.users = []*models.UserSearchHitDTO{{Id: 1, Login: "user1"}, {Id: 2, Login: "user2"}}
+
+		result := sc.service.Sync(context.Background())
+
+		require.NotEmpty(t, result.Error)
+		assert.Empty(t, sc.disabled)
+		assert.Empty(t, sc.revoked)
+	})
+}
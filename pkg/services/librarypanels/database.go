@@ -175,6 +175,9 @@ func (lps *LibraryPanelService) deleteLibraryPanel(c *models.ReqContext, uid str
 		if _, err := session.Exec("DELETE FROM library_panel_dashboard WHERE librarypanel_id=?", panel.ID); err != nil {
 			return err
 		}
+		if _, err := session.Exec("DELETE FROM library_panel_provisioning WHERE org_id=? AND uid=?", panel.OrgID, panel.UID); err != nil {
+			return err
+		}
 
 		result, err := session.Exec("DELETE FROM library_panel WHERE id=?", panel.ID)
 		if err != nil {
@@ -266,9 +269,10 @@ func (lps *LibraryPanelService) deleteLibraryPanelsInFolder(c *models.ReqContext
 		}
 
 		var panelIDs []struct {
-			ID int64 `xorm:"id"`
+			ID  int64  `xorm:"id"`
+			UID string `xorm:"uid"`
 		}
-		err = session.SQL("SELECT id from library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId).Find(&panelIDs)
+		err = session.SQL("SELECT id, uid from library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId).Find(&panelIDs)
 		if err != nil {
 			return err
 		}
@@ -277,6 +281,10 @@ func (lps *LibraryPanelService) deleteLibraryPanelsInFolder(c *models.ReqContext
 			if err != nil {
 				return err
 			}
+			_, err = session.Exec("DELETE FROM library_panel_provisioning WHERE org_id=? AND uid=?", c.SignedInUser.OrgId, panelID.UID)
+			if err != nil {
+				return err
+			}
 		}
 		if _, err := session.Exec("DELETE FROM library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId); err != nil {
 			return err
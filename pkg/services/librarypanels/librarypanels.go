@@ -273,4 +273,22 @@ func (lps *LibraryPanelService) AddMigration(mg *migrator.Migrator) {
 
 	mg.AddMigration("create library_panel_dashboard table v1", migrator.NewAddTableMigration(libraryPanelDashboardV1))
 	mg.AddMigration("add index library_panel_dashboard librarypanel_id & dashboard_id", migrator.NewAddIndexMigration(libraryPanelDashboardV1, libraryPanelDashboardV1.Indices[0]))
+
+	libraryPanelProvisioningV1 := migrator.Table{
+		Name: "library_panel_provisioning",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "external_id", Type: migrator.DB_Text, Nullable: false},
+			{Name: "check_sum", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "updated", Type: migrator.DB_Int, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "uid"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create library_panel_provisioning table v1", migrator.NewAddTableMigration(libraryPanelProvisioningV1))
+	mg.AddMigration("add index library_panel_provisioning org_id & uid", migrator.NewAddIndexMigration(libraryPanelProvisioningV1, libraryPanelProvisioningV1.Indices[0]))
 }
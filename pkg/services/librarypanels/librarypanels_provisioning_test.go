@@ -0,0 +1,113 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisionedLibraryPanels(t *testing.T) {
+	testScenario(t, "When provisioning a library panel that does not exist, it should be created",
+		func(t *testing.T, sc scenarioContext) {
+			cmd := getProvisioningCommand(sc.folder.Id, "provisioned", "Provisioned Panel")
+			err := sc.service.SaveProvisionedLibraryPanel(cmd)
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "provisioned"})
+			resp := sc.service.getHandler(sc.reqContext)
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, "Provisioned Panel", result.Result.Name)
+			require.Equal(t, sc.folder.Id, result.Result.FolderID)
+			require.Equal(t, int64(1), result.Result.Version)
+			require.Equal(t, "Provisioned Panel", result.Result.Model["title"])
+
+			provisioned, err := sc.service.GetProvisionedLibraryPanels()
+			require.NoError(t, err)
+			require.Len(t, provisioned, 1)
+			require.Equal(t, "provisioned", provisioned[0].UID)
+			require.Equal(t, "checksum", provisioned[0].CheckSum)
+		})
+
+	testScenario(t, "When provisioning a library panel that exists, it should be updated",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.SaveProvisionedLibraryPanel(getProvisioningCommand(sc.folder.Id, "provisioned", "Provisioned Panel"))
+			require.NoError(t, err)
+
+			cmd := getProvisioningCommand(0, "provisioned", "Renamed Panel")
+			cmd.CheckSum = "changed"
+			err = sc.service.SaveProvisionedLibraryPanel(cmd)
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "provisioned"})
+			resp := sc.service.getHandler(sc.reqContext)
+			result := validateAndUnMarshalResponse(t, resp)
+			require.Equal(t, "Renamed Panel", result.Result.Name)
+			require.Equal(t, int64(0), result.Result.FolderID)
+			require.Equal(t, int64(2), result.Result.Version)
+
+			provisioned, err := sc.service.GetProvisionedLibraryPanels()
+			require.NoError(t, err)
+			require.Len(t, provisioned, 1)
+			require.Equal(t, "changed", provisioned[0].CheckSum)
+		})
+
+	testScenario(t, "When deleting a provisioned library panel, it should be deleted",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.SaveProvisionedLibraryPanel(getProvisioningCommand(sc.folder.Id, "provisioned", "Provisioned Panel"))
+			require.NoError(t, err)
+
+			err = sc.service.DeleteProvisionedLibraryPanel(1, "provisioned")
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "provisioned"})
+			resp := sc.service.getHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+
+			provisioned, err := sc.service.GetProvisionedLibraryPanels()
+			require.NoError(t, err)
+			require.Empty(t, provisioned)
+		})
+
+	testScenario(t, "When deleting a provisioned library panel that is connected to a dashboard, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.SaveProvisionedLibraryPanel(getProvisioningCommand(sc.folder.Id, "provisioned", "Provisioned Panel"))
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "provisioned", ":dashboardId": "1"})
+			resp := sc.service.connectHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			err = sc.service.DeleteProvisionedLibraryPanel(1, "provisioned")
+			require.ErrorIs(t, err, errLibraryPanelHasConnectedDashboards)
+
+			resp = sc.service.getHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+		})
+
+	testScenario(t, "When deleting a provisioned library panel through the API, it should no longer be provisioned",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.SaveProvisionedLibraryPanel(getProvisioningCommand(sc.folder.Id, "provisioned", "Provisioned Panel"))
+			require.NoError(t, err)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": "provisioned"})
+			resp := sc.service.deleteHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			provisioned, err := sc.service.GetProvisionedLibraryPanels()
+			require.NoError(t, err)
+			require.Empty(t, provisioned)
+		})
+}
+
+func getProvisioningCommand(folderID int64, uid, name string) *SaveProvisionedLibraryPanelCommand {
+	return &SaveProvisionedLibraryPanelCommand{
+		OrgID:      1,
+		FolderID:   folderID,
+		UID:        uid,
+		Name:       name,
+		Model:      json.RawMessage(`{"type": "text", "title": "From file"}`),
+		ExternalID: "/etc/grafana/library-panels/" + uid + ".json",
+		CheckSum:   "checksum",
+	}
+}
@@ -88,6 +88,16 @@ type libraryPanelDashboard struct {
 	CreatedBy int64
 }
 
+// LibraryPanelProvisioning is the model for library panels created by provisioning.
+type LibraryPanelProvisioning struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	OrgID      int64  `xorm:"org_id"`
+	UID        string `xorm:"uid"`
+	ExternalID string `xorm:"external_id"`
+	CheckSum   string `xorm:"check_sum"`
+	Updated    int64
+}
+
 var (
 	// errLibraryPanelAlreadyExists is an error for when the user tries to add a library panel that already exists.
 	errLibraryPanelAlreadyExists = errors.New("library panel with that name already exists")
@@ -103,6 +113,8 @@ var (
 	ErrFolderHasConnectedLibraryPanels = errors.New("folder contains library panels that are linked to dashboards")
 	// errLibraryPanelVersionMismatch is an error for when a library panel has been changed by someone else.
 	errLibraryPanelVersionMismatch = errors.New("the library panel has been changed by someone else")
+	// errLibraryPanelHasConnectedDashboards is an error for when provisioning deletes a library panel that is linked to dashboards.
+	errLibraryPanelHasConnectedDashboards = errors.New("library panel is linked to dashboards")
 )
 
 // Commands
@@ -121,3 +133,14 @@ type patchLibraryPanelCommand struct {
 	Model    json.RawMessage `json:"model"`
 	Version  int64           `json:"version" binding:"Required"`
 }
+
+// SaveProvisionedLibraryPanelCommand is the command for adding or updating a LibraryPanel from provisioning
+type SaveProvisionedLibraryPanelCommand struct {
+	OrgID      int64
+	FolderID   int64
+	UID        string
+	Name       string
+	Model      json.RawMessage
+	ExternalID string
+	CheckSum   string
+}
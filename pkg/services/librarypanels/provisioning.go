@@ -0,0 +1,96 @@
+package librarypanels
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// GetProvisionedLibraryPanels returns the library panels created by provisioning.
+func (lps *LibraryPanelService) GetProvisionedLibraryPanels() ([]*LibraryPanelProvisioning, error) {
+	var provisioned []*LibraryPanelProvisioning
+	err := lps.SQLStore.WithDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		return session.Find(&provisioned)
+	})
+
+	return provisioned, err
+}
+
+// SaveProvisionedLibraryPanel adds or updates a Library Panel from provisioning. Folder
+// permissions aren't checked as provisioning acts on behalf of the server admin.
+func (lps *LibraryPanelService) SaveProvisionedLibraryPanel(cmd *SaveProvisionedLibraryPanelCommand) error {
+	return lps.SQLStore.WithTransactionalDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		libraryPanel := LibraryPanel{
+			OrgID:    cmd.OrgID,
+			FolderID: cmd.FolderID,
+			UID:      cmd.UID,
+			Name:     cmd.Name,
+			Model:    cmd.Model,
+			Version:  1,
+			Created:  time.Now(),
+			Updated:  time.Now(),
+		}
+		if err := syncTitleWithName(&libraryPanel); err != nil {
+			return err
+		}
+
+		panelInDB, err := getLibraryPanel(session, cmd.UID, cmd.OrgID)
+		switch {
+		case errors.Is(err, errLibraryPanelNotFound):
+			_, err = session.Insert(&libraryPanel)
+		case err != nil:
+			return err
+		default:
+			libraryPanel.ID = panelInDB.ID
+			libraryPanel.Version = panelInDB.Version + 1
+			libraryPanel.Created = panelInDB.Created
+			libraryPanel.CreatedBy = panelInDB.CreatedBy
+			_, err = session.ID(panelInDB.ID).AllCols().Update(&libraryPanel)
+		}
+		if err != nil {
+			if lps.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+				return errLibraryPanelAlreadyExists
+			}
+			return err
+		}
+
+		provisioning := LibraryPanelProvisioning{
+			OrgID:      cmd.OrgID,
+			UID:        cmd.UID,
+			ExternalID: cmd.ExternalID,
+			CheckSum:   cmd.CheckSum,
+			Updated:    time.Now().Unix(),
+		}
+		if _, err := session.Exec("DELETE FROM library_panel_provisioning WHERE org_id=? AND uid=?", cmd.OrgID, cmd.UID); err != nil {
+			return err
+		}
+		_, err = session.Insert(&provisioning)
+		return err
+	})
+}
+
+// DeleteProvisionedLibraryPanel deletes a Library Panel created by provisioning. Library panels
+// that are still linked to dashboards are kept, as deleting them would break the dashboards.
+func (lps *LibraryPanelService) DeleteProvisionedLibraryPanel(orgID int64, uid string) error {
+	return lps.SQLStore.WithTransactionalDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		if _, err := session.Exec("DELETE FROM library_panel_provisioning WHERE org_id=? AND uid=?", orgID, uid); err != nil {
+			return err
+		}
+
+		panel, err := getLibraryPanel(session, uid, orgID)
+		if errors.Is(err, errLibraryPanelNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if panel.ConnectedDashboards > 0 {
+			return errLibraryPanelHasConnectedDashboards
+		}
+
+		_, err = session.Exec("DELETE FROM library_panel WHERE id=?", panel.ID)
+		return err
+	})
+}
@@ -107,3 +107,23 @@ func alertInstanceMigration(mg *migrator.Migrator) {
 	mg.AddMigration("add index in alert_instance table on def_org_id, def_uid and current_state columns", migrator.NewAddIndexMigration(alertInstance, alertInstance.Indices[0]))
 	mg.AddMigration("add index in alert_instance table on def_org_id, current_state columns", migrator.NewAddIndexMigration(alertInstance, alertInstance.Indices[1]))
 }
+
+func alertDefinitionProvisioningMigration(mg *migrator.Migrator) {
+	alertDefinitionProvisioning := migrator.Table{
+		Name: "alert_definition_provisioning",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "alert_definition_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "external_id", Type: migrator.DB_Text, Nullable: false},
+			{Name: "check_sum", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "updated", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "alert_definition_uid"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_definition_provisioning table", migrator.NewAddTableMigration(alertDefinitionProvisioning))
+	mg.AddMigration("add unique index in alert_definition_provisioning on org_id and alert_definition_uid columns", migrator.NewAddIndexMigration(alertDefinitionProvisioning, alertDefinitionProvisioning.Indices[0]))
+}
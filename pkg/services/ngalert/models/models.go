@@ -86,12 +86,14 @@ type DeleteAlertDefinitionByUIDCommand struct {
 }
 
 // SaveAlertDefinitionCommand is the query for saving a new alert definition.
+// If UID is empty a new one is generated.
 type SaveAlertDefinitionCommand struct {
 	Title           string       `json:"title"`
 	OrgID           int64        `json:"-"`
 	Condition       string       `json:"condition"`
 	Data            []AlertQuery `json:"data"`
 	IntervalSeconds *int64       `json:"intervalSeconds"`
+	UID             string       `json:"-"`
 
 	Result *AlertDefinition
 }
@@ -131,6 +133,30 @@ type UpdateAlertDefinitionPausedCommand struct {
 	ResultCount int64
 }
 
+// AlertDefinitionProvisioning is the model for tracking alert definitions created by provisioning.
+type AlertDefinitionProvisioning struct {
+	ID                 int64  `xorm:"pk autoincr 'id'"`
+	OrgID              int64  `xorm:"org_id"`
+	AlertDefinitionUID string `xorm:"alert_definition_uid"`
+	ExternalID         string `xorm:"external_id"`
+	CheckSum           string
+	Updated            int64
+}
+
+// ListProvisionedAlertDefinitionsQuery is the query for listing the provisioning metadata of all alert definitions.
+type ListProvisionedAlertDefinitionsQuery struct {
+	Result []*AlertDefinitionProvisioning
+}
+
+// SaveProvisionedAlertDefinitionCommand is the command for marking an alert definition as provisioned
+// from the file in ExternalID.
+type SaveProvisionedAlertDefinitionCommand struct {
+	OrgID      int64
+	UID        string
+	ExternalID string
+	CheckSum   string
+}
+
 // Condition contains backend expressions and queries and the RefID
 // of the query or expression that will be evaluated.
 type Condition struct {
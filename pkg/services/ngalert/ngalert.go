@@ -43,6 +43,7 @@ type AlertNG struct {
 	DataService     *tsdb.Service            `inject:""`
 	Log             log.Logger
 	schedule        schedule.ScheduleService
+	store           store.Store
 }
 
 func init() {
@@ -55,7 +56,7 @@ func (ng *AlertNG) Init() error {
 
 	baseInterval := baseIntervalSeconds * time.Second
 
-	store := store.DBstore{BaseInterval: baseInterval, DefaultIntervalSeconds: defaultIntervalSeconds, SQLStore: ng.SQLStore}
+	ng.store = store.DBstore{BaseInterval: baseInterval, DefaultIntervalSeconds: defaultIntervalSeconds, SQLStore: ng.SQLStore}
 
 	schedCfg := schedule.SchedulerCfg{
 		C:            clock.New(),
@@ -63,7 +64,7 @@ func (ng *AlertNG) Init() error {
 		Logger:       ng.Log,
 		MaxAttempts:  maxAttempts,
 		Evaluator:    eval.Evaluator{Cfg: ng.Cfg},
-		Store:        store,
+		Store:        ng.store,
 	}
 	ng.schedule = schedule.NewScheduler(schedCfg, ng.DataService)
 
@@ -73,7 +74,7 @@ func (ng *AlertNG) Init() error {
 		RouteRegister:   ng.RouteRegister,
 		DataService:     ng.DataService,
 		Schedule:        ng.schedule,
-		Store:           store}
+		Store:           ng.store}
 	api.RegisterAPIEndpoints()
 
 	return nil
@@ -85,6 +86,12 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	return ng.schedule.Ticker(ctx)
 }
 
+// Store returns the store for alert definitions and instances.
+// It is nil if the service is disabled.
+func (ng *AlertNG) Store() store.Store {
+	return ng.store
+}
+
 // IsDisabled returns true if the alerting service is disable for this instance.
 func (ng *AlertNG) IsDisabled() bool {
 	if ng.Cfg == nil {
@@ -104,4 +111,6 @@ func (ng *AlertNG) AddMigration(mg *migrator.Migrator) {
 	addAlertDefinitionVersionMigrations(mg)
 	// Create alert_instance table
 	alertInstanceMigration(mg)
+	// Create alert_definition_provisioning table
+	alertDefinitionProvisioningMigration(mg)
 }
@@ -19,9 +19,15 @@ var TimeNow = time.Now
 // AlertDefinitionMaxTitleLength is the maximum length of the alert definition titles
 const AlertDefinitionMaxTitleLength = 190
 
+// AlertDefinitionMaxUIDLength is the maximum length of the alert definition UIDs
+const AlertDefinitionMaxUIDLength = 40
+
 // ErrEmptyTitleError is an error returned if the alert definition title is empty
 var ErrEmptyTitleError = errors.New("title is empty")
 
+// ErrInvalidUID is an error returned if a provided alert definition UID is not valid
+var ErrInvalidUID = errors.New("uid contains illegal characters or is too long")
+
 // Store is the interface for persisting alert definitions and instances
 type Store interface {
 	DeleteAlertDefinitionByUID(*models.DeleteAlertDefinitionByUIDCommand) error
@@ -35,6 +41,8 @@ type Store interface {
 	SaveAlertInstance(cmd *models.SaveAlertInstanceCommand) error
 	ValidateAlertDefinition(*models.AlertDefinition, bool) error
 	UpdateAlertDefinitionPaused(*models.UpdateAlertDefinitionPausedCommand) error
+	GetProvisionedAlertDefinitions(*models.ListProvisionedAlertDefinitionsQuery) error
+	SaveProvisionedAlertDefinition(*models.SaveProvisionedAlertDefinitionCommand) error
 }
 
 // DBstore stores the alert definitions and instances in the database.
@@ -77,6 +85,11 @@ func (st DBstore) DeleteAlertDefinitionByUID(cmd *models.DeleteAlertDefinitionBy
 		if err != nil {
 			return err
 		}
+
+		_, err = sess.Exec("DELETE FROM alert_definition_provisioning WHERE org_id = ? AND alert_definition_uid = ?", cmd.OrgID, cmd.UID)
+		if err != nil {
+			return err
+		}
 		return nil
 	})
 }
@@ -104,9 +117,15 @@ func (st DBstore) SaveAlertDefinition(cmd *models.SaveAlertDefinitionCommand) er
 
 		var initialVersion int64 = 1
 
-		uid, err := generateNewAlertDefinitionUID(sess, cmd.OrgID)
-		if err != nil {
-			return fmt.Errorf("failed to generate UID for alert definition %q: %w", cmd.Title, err)
+		uid := cmd.UID
+		if uid == "" {
+			var err error
+			uid, err = generateNewAlertDefinitionUID(sess, cmd.OrgID)
+			if err != nil {
+				return fmt.Errorf("failed to generate UID for alert definition %q: %w", cmd.Title, err)
+			}
+		} else if len(uid) > AlertDefinitionMaxUIDLength || !util.IsValidShortUID(uid) {
+			return ErrInvalidUID
 		}
 
 		alertDefinition := &models.AlertDefinition{
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// GetProvisionedAlertDefinitions is a handler for retrieving the provisioning metadata of all alert definitions.
+func (st DBstore) GetProvisionedAlertDefinitions(query *models.ListProvisionedAlertDefinitionsQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		provisioned := make([]*models.AlertDefinitionProvisioning, 0)
+		if err := sess.Find(&provisioned); err != nil {
+			return err
+		}
+
+		query.Result = provisioned
+		return nil
+	})
+}
+
+// SaveProvisionedAlertDefinition is a handler for marking an alert definition as provisioned.
+// It replaces any existing provisioning metadata of the alert definition.
+func (st DBstore) SaveProvisionedAlertDefinition(cmd *models.SaveProvisionedAlertDefinitionCommand) error {
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		provisioning := &models.AlertDefinitionProvisioning{
+			OrgID:              cmd.OrgID,
+			AlertDefinitionUID: cmd.UID,
+			ExternalID:         cmd.ExternalID,
+			CheckSum:           cmd.CheckSum,
+			Updated:            TimeNow().Unix(),
+		}
+
+		existing := models.AlertDefinitionProvisioning{OrgID: cmd.OrgID, AlertDefinitionUID: cmd.UID}
+		has, err := sess.Get(&existing)
+		if err != nil {
+			return err
+		}
+
+		if has {
+			_, err = sess.ID(existing.ID).Cols("external_id", "check_sum", "updated").Update(provisioning)
+			return err
+		}
+
+		_, err = sess.Insert(provisioning)
+		return err
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/events"
@@ -22,6 +23,8 @@ var mailTemplates *template.Template
 var tmplResetPassword = "reset_password.html"
 var tmplSignUpStarted = "signup_started.html"
 var tmplWelcomeOnSignUp = "welcome_on_signup.html"
+var tmplLoginLockout = "login_lockout.html"
+var tmplNewLogin = "new_login.html"
 
 func init() {
 	registry.RegisterService(&NotificationService{})
@@ -44,6 +47,8 @@ func (ns *NotificationService) Init() error {
 	ns.Bus.AddHandler(ns.sendResetPasswordEmail)
 	ns.Bus.AddHandler(ns.validateResetPasswordCode)
 	ns.Bus.AddHandler(ns.sendEmailCommandHandler)
+	ns.Bus.AddHandler(ns.sendLoginLockoutEmail)
+	ns.Bus.AddHandler(ns.sendNewLoginEmail)
 
 	ns.Bus.AddHandlerCtx(ns.sendEmailCommandHandlerSync)
 	ns.Bus.AddHandlerCtx(ns.SendWebhookSync)
@@ -163,6 +168,96 @@ func (ns *NotificationService) sendResetPasswordEmail(cmd *models.SendResetPassw
 	})
 }
 
+// sendLoginLockoutEmail notifies the user whose logins are blocked and the Grafana Admins, as configured.
+func (ns *NotificationService) sendLoginLockoutEmail(cmd *models.SendLoginLockoutEmailCommand) error {
+	send := func(email, name string, isAdmin bool) error {
+		return ns.sendEmailCommandHandler(&models.SendEmailCommand{
+			To:       []string{email},
+			Template: tmplLoginLockout,
+			Data: map[string]interface{}{
+				"Name":      name,
+				"Username":  cmd.Username,
+				"IpAddress": cmd.IpAddress,
+				"Window":    formatLockoutWindow(ns.Cfg.LoginLockout.Window),
+				"IsAdmin":   isAdmin,
+			},
+		})
+	}
+
+	notified := map[string]bool{}
+
+	if ns.Cfg.LoginLockout.NotifyUser && cmd.Username != "" {
+		userQuery := models.GetUserByLoginQuery{LoginOrEmail: cmd.Username}
+		err := bus.Dispatch(&userQuery)
+		if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+			return err
+		}
+
+		if err == nil && userQuery.Result.Email != "" {
+			if err := send(userQuery.Result.Email, userQuery.Result.NameOrFallback(), false); err != nil {
+				return err
+			}
+			notified[userQuery.Result.Email] = true
+		}
+	}
+
+	if !ns.Cfg.LoginLockout.NotifyAdmins {
+		return nil
+	}
+
+	isGrafanaAdmin, isDisabled := true, false
+	adminsQuery := models.SearchUsersQuery{IsGrafanaAdmin: &isGrafanaAdmin, IsDisabled: &isDisabled}
+	if err := bus.Dispatch(&adminsQuery); err != nil {
+		return err
+	}
+
+	for _, admin := range adminsQuery.Result.Users {
+		if admin.Email == "" || notified[admin.Email] {
+			continue
+		}
+
+		name := admin.Name
+		if name == "" {
+			name = admin.Login
+		}
+		if err := send(admin.Email, name, true); err != nil {
+			return err
+		}
+		notified[admin.Email] = true
+	}
+
+	return nil
+}
+
+// formatLockoutWindow formats durations like 5m or 1h30m without their zero units.
+func formatLockoutWindow(window time.Duration) string {
+	formatted := window.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
+
+func (ns *NotificationService) sendNewLoginEmail(cmd *models.SendNewLoginEmailCommand) error {
+	if cmd.User.Email == "" {
+		return nil
+	}
+
+	return ns.sendEmailCommandHandler(&models.SendEmailCommand{
+		To:       []string{cmd.User.Email},
+		Template: tmplNewLogin,
+		Data: map[string]interface{}{
+			"Name":      cmd.User.NameOrFallback(),
+			"IpAddress": cmd.IpAddress,
+			"UserAgent": cmd.UserAgent,
+			"Time":      time.Now().UTC().Format(time.RFC1123),
+		},
+	})
+}
+
 func (ns *NotificationService) validateResetPasswordCode(query *models.ValidateResetPasswordCodeQuery) error {
 	login := getLoginForEmailCode(query.Code)
 	if login == "" {
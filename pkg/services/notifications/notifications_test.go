@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
@@ -33,4 +34,71 @@ func TestNotificationService(t *testing.T) {
 		assert.Equal(t, "Reset your Grafana password - asd@asd.com", sentMsg.Subject)
 		assert.NotContains(t, sentMsg.Body, "Subject")
 	})
+
+	t.Run("When sending login lockout emails", func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+		ns.Cfg.LoginLockout.Window = 5 * time.Minute
+		ns.Cfg.LoginLockout.NotifyUser = true
+		ns.Cfg.LoginLockout.NotifyAdmins = true
+
+		bus.AddHandler("test", func(query *models.GetUserByLoginQuery) error {
+			if query.LoginOrEmail != "user" {
+				return models.ErrUserNotFound
+			}
+			query.Result = &models.User{Login: "user", Email: "user@example.com"}
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.SearchUsersQuery) error {
+			require.True(t, *query.IsGrafanaAdmin)
+			require.False(t, *query.IsDisabled)
+			query.Result.Users = []*models.UserSearchHitDTO{
+				{Login: "admin", Email: "admin@example.com"},
+				{Login: "user", Email: "user@example.com"},
+			}
+			return nil
+		})
+
+		err := ns.sendLoginLockoutEmail(&models.SendLoginLockoutEmailCommand{Username: "user", IpAddress: "192.168.1.1"})
+		require.NoError(t, err)
+
+		userMsg := <-ns.mailQueue
+		assert.Equal(t, []string{"user@example.com"}, userMsg.To)
+		assert.Equal(t, "Grafana logins of user are blocked", userMsg.Subject)
+		assert.Contains(t, userMsg.Body, "The logins to your Grafana account are blocked")
+		assert.Contains(t, userMsg.Body, "<b>5m</b>")
+
+		adminMsg := <-ns.mailQueue
+		assert.Equal(t, []string{"admin@example.com"}, adminMsg.To)
+		assert.Contains(t, adminMsg.Body, "The logins of the user <b>user</b> are blocked")
+		assert.Empty(t, ns.mailQueue)
+
+		err = ns.sendLoginLockoutEmail(&models.SendLoginLockoutEmailCommand{IpAddress: "192.168.1.1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Grafana logins from 192.168.1.1 are blocked", (<-ns.mailQueue).Subject)
+		assert.Equal(t, "Grafana logins from 192.168.1.1 are blocked", (<-ns.mailQueue).Subject)
+		assert.Empty(t, ns.mailQueue)
+	})
+
+	t.Run("When sending new login email", func(t *testing.T) {
+		err := ns.sendNewLoginEmail(&models.SendNewLoginEmailCommand{
+			User:      &models.User{Name: "User", Email: "user@example.com"},
+			IpAddress: "192.168.1.1",
+			UserAgent: "Mozilla/5.0",
+		})
+		require.NoError(t, err)
+
+		sentMsg := <-ns.mailQueue
+		assert.Equal(t, "New login to your Grafana account", sentMsg.Subject)
+		assert.Contains(t, sentMsg.Body, "Hi User,")
+		assert.Contains(t, sentMsg.Body, "<b>192.168.1.1</b>")
+		assert.Contains(t, sentMsg.Body, "<b>Mozilla/5.0</b>")
+	})
+}
+
+func TestFormatLockoutWindow(t *testing.T) {
+	assert.Equal(t, "5m", formatLockoutWindow(5*time.Minute))
+	assert.Equal(t, "1h", formatLockoutWindow(time.Hour))
+	assert.Equal(t, "1h30m", formatLockoutWindow(90*time.Minute))
+	assert.Equal(t, "45s", formatLockoutWindow(45*time.Second))
 }
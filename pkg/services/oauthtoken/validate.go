@@ -0,0 +1,221 @@
+package oauthtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrTokenRevoked is returned when the identity provider no longer accepts the OAuth token of the user.
+var ErrTokenRevoked = errors.New("OAuth token revoked by the identity provider")
+
+// validations serializes the validations of the tokens of each user, since identity providers rotating
+// refresh tokens refuse the refresh token used by a concurrent refresh.
+var validations singleflight.Group
+
+// validationTimeout bounds the validations, which don't stop with the request that started them.
+var validationTimeout = 30 * time.Second
+
+// ValidateOAuthToken checks that the OAuth token of the user is still valid at the identity provider,
+// with the introspection URL of the provider when set, or else by refreshing the token.
+// Errors wrap ErrTokenRevoked when the identity provider refused the token, and nil is returned
+// for users without OAuth token, or whose token can't be checked. The error of ctx is returned when
+// it's done before the validation.
+func ValidateOAuthToken(ctx context.Context, userID int64) error {
+	result := validations.DoChan(strconv.FormatInt(userID, 10), func() (interface{}, error) {
+		// The validation is shared by the concurrent requests of the user, so the cancellation of
+		// one of them mustn't fail it for the others
+		validationCtx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+		defer cancel()
+		return nil, validateOAuthToken(validationCtx, userID)
+	})
+
+	select {
+	case r := <-result:
+		return r.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func validateOAuthToken(ctx context.Context, userID int64) error {
+	authInfoQuery := &models.GetAuthInfoQuery{UserId: userID}
+	if err := bus.Dispatch(authInfoQuery); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	authInfo := authInfoQuery.Result
+	if !strings.HasPrefix(authInfo.AuthModule, "oauth_") || authInfo.OAuthAccessToken == "" {
+		return nil
+	}
+	if setting.OAuthService == nil {
+		return nil
+	}
+	info, ok := setting.OAuthService.OAuthInfos[strings.TrimPrefix(authInfo.AuthModule, "oauth_")]
+	if !ok {
+		return nil
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  authInfo.OAuthAccessToken,
+		Expiry:       authInfo.OAuthExpiry,
+		RefreshToken: authInfo.OAuthRefreshToken,
+		TokenType:    authInfo.OAuthTokenType,
+	}
+	if info.IntrospectionUrl == "" && token.RefreshToken == "" {
+		return nil
+	}
+
+	client, err := social.GetOAuthHttpClient(authInfo.AuthModule)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+
+	// Without introspection, a successful refresh is the proof that the user is still allowed in
+	if token.RefreshToken != "" && (info.IntrospectionUrl == "" || !token.Valid()) {
+		if token, err = refreshToken(ctx, authInfo, token); err != nil {
+			return err
+		}
+	}
+
+	if info.IntrospectionUrl == "" {
+		return nil
+	}
+
+	active, err := introspectToken(ctx, client, info, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fmt.Errorf("%w: token is not active", ErrTokenRevoked)
+	}
+	return nil
+}
+
+// refreshToken refreshes the token, even when it has not expired yet, and persists the new token.
+func refreshToken(ctx context.Context, authInfo *models.UserAuth, token *oauth2.Token) (*oauth2.Token, error) {
+	connect, err := social.GetConnector(authInfo.AuthModule)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := *token
+	expired.Expiry = time.Now().Add(-time.Minute)
+	refreshed, err := connect.TokenSource(ctx, &expired).Token()
+	if err != nil {
+		if !isInvalidGrant(err) {
+			return nil, err
+		}
+		// Another Grafana instance may have used the refresh token meanwhile, and saved the new one
+		if rotated, rotatedErr := rotatedToken(authInfo.UserId, token); rotatedErr != nil || rotated != nil {
+			return rotated, rotatedErr
+		}
+		return nil, fmt.Errorf("%w: %s", ErrTokenRevoked, err)
+	}
+
+	if !tokensEq(token, refreshed) {
+		updateAuthCommand := &models.UpdateAuthInfoCommand{
+			UserId:     authInfo.UserId,
+			AuthModule: authInfo.AuthModule,
+			AuthId:     authInfo.AuthId,
+			OAuthToken: refreshed,
+		}
+		if err := bus.Dispatch(updateAuthCommand); err != nil {
+			return nil, err
+		}
+	}
+	return refreshed, nil
+}
+
+// rotatedToken returns the saved token of the user when its refresh token is no longer the one of token,
+// nil otherwise.
+func rotatedToken(userID int64, token *oauth2.Token) (*oauth2.Token, error) {
+	authInfoQuery := &models.GetAuthInfoQuery{UserId: userID}
+	if err := bus.Dispatch(authInfoQuery); err != nil {
+		return nil, err
+	}
+
+	authInfo := authInfoQuery.Result
+	if authInfo.OAuthRefreshToken == "" || authInfo.OAuthRefreshToken == token.RefreshToken {
+		return nil, nil
+	}
+	return &oauth2.Token{
+		AccessToken:  authInfo.OAuthAccessToken,
+		Expiry:       authInfo.OAuthExpiry,
+		RefreshToken: authInfo.OAuthRefreshToken,
+		TokenType:    authInfo.OAuthTokenType,
+	}, nil
+}
+
+// isInvalidGrant tells whether the token endpoint refused the refresh token (RFC 6749 section 5.2),
+// as opposed to errors of the endpoint or of the client configuration.
+func isInvalidGrant(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) || retrieveErr.Response == nil || retrieveErr.Response.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(retrieveErr.Body, &body) == nil {
+		return body.Error == "invalid_grant"
+	}
+	// Some providers answer with a form encoded body
+	values, err := url.ParseQuery(string(retrieveErr.Body))
+	return err == nil && values.Get("error") == "invalid_grant"
+}
+
+// introspectToken asks the introspection endpoint of the provider whether the access token is active (RFC 7662).
+func introspectToken(ctx context.Context, client *http.Client, info *setting.OAuthInfo, accessToken string) (bool, error) {
+	form := url.Values{
+		"token":           {accessToken},
+		"token_type_hint": {"access_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, info.IntrospectionUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(info.ClientId), url.QueryEscape(info.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("token introspection failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Active bool `json:"active"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to parse token introspection response: %w", err)
+	}
+	return result.Active, nil
+}
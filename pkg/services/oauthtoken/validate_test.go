@@ -0,0 +1,250 @@
+package oauthtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+type fakeIdP struct {
+	mu             sync.Mutex
+	refreshRevoked bool
+	refreshDelay   time.Duration
+	onRefresh      func()
+	active         bool
+	refreshes      int
+	introspections int
+}
+
+func (idp *fakeIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/token":
+		idp.refreshes++
+		time.Sleep(idp.refreshDelay)
+		if idp.onRefresh != nil {
+			idp.onRefresh()
+		}
+		if idp.refreshRevoked {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","token_type":"Bearer","expires_in":3600}`))
+	case "/introspect":
+		idp.introspections++
+		user, _, _ := r.BasicAuth()
+		if user != "grafana" || r.FormValue("token") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]bool{"active": idp.active})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupValidationTest(t *testing.T, introspection bool, authInfo *models.UserAuth) (*fakeIdP, *models.UpdateAuthInfoCommand) {
+	t.Helper()
+
+	idp := &fakeIdP{active: true}
+	server := httptest.NewServer(idp)
+	t.Cleanup(server.Close)
+
+	oldRaw, oldOAuthService := setting.Raw, setting.OAuthService
+	t.Cleanup(func() {
+		setting.Raw, setting.OAuthService = oldRaw, oldOAuthService
+		delete(social.SocialMap, "generic_oauth")
+	})
+
+	setting.Raw = ini.Empty()
+	sec, err := setting.Raw.NewSection("auth.generic_oauth")
+	require.NoError(t, err)
+	_, err = sec.NewKey("enabled", "true")
+	require.NoError(t, err)
+	_, err = sec.NewKey("client_id", "grafana")
+	require.NoError(t, err)
+	_, err = sec.NewKey("token_url", server.URL+"/token")
+	require.NoError(t, err)
+	if introspection {
+		_, err = sec.NewKey("introspection_url", server.URL+"/introspect")
+		require.NoError(t, err)
+	}
+	social.NewOAuthService()
+
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+		if authInfo == nil {
+			return models.ErrUserNotFound
+		}
+		query.Result = authInfo
+		return nil
+	})
+	updated := &models.UpdateAuthInfoCommand{}
+	bus.AddHandler("test", func(cmd *models.UpdateAuthInfoCommand) error {
+		*updated = *cmd
+		return nil
+	})
+
+	return idp, updated
+}
+
+func oauthAuthInfo() *models.UserAuth {
+	return &models.UserAuth{
+		UserId:            1,
+		AuthModule:        "oauth_generic_oauth",
+		AuthId:            "42",
+		OAuthAccessToken:  "access",
+		OAuthRefreshToken: "refresh",
+		OAuthTokenType:    "Bearer",
+		OAuthExpiry:       time.Now().Add(time.Hour),
+	}
+}
+
+func TestValidateOAuthToken(t *testing.T) {
+	t.Run("Users without OAuth token are not checked", func(t *testing.T) {
+		idp, _ := setupValidationTest(t, false, nil)
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+
+		authInfo := oauthAuthInfo()
+		authInfo.AuthModule = "ldap"
+		idp, _ = setupValidationTest(t, false, authInfo)
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+		assert.Zero(t, idp.refreshes)
+	})
+
+	t.Run("Tokens without refresh token or introspection are not checked", func(t *testing.T) {
+		authInfo := oauthAuthInfo()
+		authInfo.OAuthRefreshToken = ""
+		idp, _ := setupValidationTest(t, false, authInfo)
+
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+		assert.Zero(t, idp.refreshes)
+	})
+
+	t.Run("Refreshable tokens are valid, and the refreshed token is saved", func(t *testing.T) {
+		idp, updated := setupValidationTest(t, false, oauthAuthInfo())
+
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+		assert.Equal(t, 1, idp.refreshes)
+		require.NotNil(t, updated.OAuthToken)
+		assert.Equal(t, "new-access", updated.OAuthToken.AccessToken)
+		assert.Equal(t, "new-refresh", updated.OAuthToken.RefreshToken)
+	})
+
+	t.Run("Tokens that can no longer be refreshed are revoked", func(t *testing.T) {
+		idp, updated := setupValidationTest(t, false, oauthAuthInfo())
+		idp.refreshRevoked = true
+
+		err := ValidateOAuthToken(context.Background(), 1)
+		assert.True(t, errors.Is(err, ErrTokenRevoked))
+		assert.Nil(t, updated.OAuthToken)
+	})
+
+	t.Run("Tokens refreshed meanwhile by another instance are not revoked", func(t *testing.T) {
+		authInfo := oauthAuthInfo()
+		idp, updated := setupValidationTest(t, false, authInfo)
+		idp.refreshRevoked = true
+		idp.onRefresh = func() { authInfo.OAuthRefreshToken = "rotated-refresh" }
+
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+		assert.Nil(t, updated.OAuthToken)
+	})
+
+	t.Run("Concurrent validations of a user refresh the token once", func(t *testing.T) {
+		idp, _ := setupValidationTest(t, false, oauthAuthInfo())
+		idp.refreshDelay = 100 * time.Millisecond
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, ValidateOAuthToken(context.Background(), 1))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, idp.refreshes)
+	})
+
+	t.Run("Canceling the request that started a validation doesn't fail it for the others", func(t *testing.T) {
+		idp, updated := setupValidationTest(t, false, oauthAuthInfo())
+		idp.refreshDelay = 200 * time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled := make(chan error)
+		go func() {
+			canceled <- ValidateOAuthToken(ctx, 1)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		waiting := make(chan error)
+		go func() {
+			waiting <- ValidateOAuthToken(context.Background(), 1)
+		}()
+		cancel()
+
+		assert.True(t, errors.Is(<-canceled, context.Canceled))
+		require.NoError(t, <-waiting)
+		assert.Equal(t, 1, idp.refreshes)
+		require.NotNil(t, updated.OAuthToken)
+		assert.Equal(t, "new-refresh", updated.OAuthToken.RefreshToken)
+	})
+
+	t.Run("Validations time out", func(t *testing.T) {
+		idp, _ := setupValidationTest(t, false, oauthAuthInfo())
+		idp.refreshDelay = 200 * time.Millisecond
+		validationTimeout = 50 * time.Millisecond
+		t.Cleanup(func() { validationTimeout = 30 * time.Second })
+
+		err := ValidateOAuthToken(context.Background(), 1)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrTokenRevoked))
+	})
+
+	t.Run("Active tokens are valid with introspection", func(t *testing.T) {
+		idp, _ := setupValidationTest(t, true, oauthAuthInfo())
+
+		require.NoError(t, ValidateOAuthToken(context.Background(), 1))
+		assert.Equal(t, 1, idp.introspections)
+		assert.Zero(t, idp.refreshes)
+	})
+
+	t.Run("Inactive tokens are revoked with introspection", func(t *testing.T) {
+		idp, _ := setupValidationTest(t, true, oauthAuthInfo())
+		idp.active = false
+
+		err := ValidateOAuthToken(context.Background(), 1)
+		assert.True(t, errors.Is(err, ErrTokenRevoked))
+	})
+
+	t.Run("Errors of the identity provider don't revoke tokens", func(t *testing.T) {
+		authInfo := oauthAuthInfo()
+		authInfo.OAuthRefreshToken = ""
+		idp, _ := setupValidationTest(t, true, authInfo)
+		setting.OAuthService.OAuthInfos["generic_oauth"].ClientId = "unknown"
+
+		err := ValidateOAuthToken(context.Background(), 1)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrTokenRevoked))
+		assert.Equal(t, 1, idp.introspections)
+	})
+}
@@ -0,0 +1,221 @@
+// Package passwordpolicy enforces the password policy of the built-in users: the length, the character
+// classes and the reuse of their passwords, the passwords known from data breaches, and their maximum age.
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	registry.RegisterService(&PasswordPolicyService{})
+}
+
+// sha1Line matches the lines of the breached passwords file holding a SHA-1 hash, optionally followed
+// by the number of times the password was seen, as in the downloads of Have I Been Pwned.
+var sha1Line = regexp.MustCompile(`^([0-9A-Fa-f]{40})(:\d+)?$`)
+
+// ValidationError is returned for passwords which don't comply with the policy, its message can be
+// shown to the users.
+type ValidationError struct {
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+type PasswordPolicyService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log      log.Logger
+	breached map[[sha1.Size]byte]struct{}
+	now      func() time.Time
+}
+
+func (s *PasswordPolicyService) Init() error {
+	s.log = log.New("password-policy")
+	s.now = time.Now
+	s.breached = map[[sha1.Size]byte]struct{}{}
+
+	if !s.Cfg.PasswordPolicy.Enabled {
+		return nil
+	}
+	if s.Cfg.PasswordPolicy.MaxAge > 0 {
+		if err := s.seedPasswordHistory(); err != nil {
+			return err
+		}
+	}
+	if s.Cfg.PasswordPolicy.BreachedPasswordsFile == "" {
+		return nil
+	}
+	return s.loadBreachedPasswords(s.Cfg.PasswordPolicy.BreachedPasswordsFile)
+}
+
+// seedPasswordHistory records the current password of the users without password history as set now,
+// so that enabling the maximum age doesn't expire the passwords of the existing users all at once.
+func (s *PasswordPolicyService) seedPasswordHistory() error {
+	cmd := models.SeedUserPasswordHistoryCommand{Created: s.now()}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return fmt.Errorf("failed to seed the password history: %w", err)
+	}
+	if cmd.SeededRows > 0 {
+		s.log.Info("Started the maximum age of the passwords without history", "users", cmd.SeededRows)
+	}
+	return nil
+}
+
+// loadBreachedPasswords reads the file of the breached passwords, whose lines are either passwords
+// or their SHA-1 hashes. Empty lines and lines starting with # are ignored.
+func (s *PasswordPolicyService) loadBreachedPasswords(path string) error {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path comes from the configuration
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the breached passwords file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			s.log.Warn("Failed to close the breached passwords file", "path", path, "error", err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if match := sha1Line.FindStringSubmatch(line); match != nil {
+			var hash [sha1.Size]byte
+			if _, err := hex.Decode(hash[:], []byte(match[1])); err != nil {
+				return err
+			}
+			s.breached[hash] = struct{}{}
+			continue
+		}
+		// nolint:gosec
+		// SHA-1 is only used to match the hashes of the breached passwords lists
+		s.breached[sha1.Sum([]byte(line))] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read the breached passwords file: %w", err)
+	}
+
+	s.log.Info("Loaded breached passwords", "path", path, "count", len(s.breached))
+	return nil
+}
+
+// Validate checks that a new password complies with the policy, it returns a ValidationError
+// otherwise. The user is nil for the users being created, whose passwords have no history.
+func (s *PasswordPolicyService) Validate(password string, user *models.User) error {
+	policy := s.Cfg.PasswordPolicy
+	if !policy.Enabled {
+		return nil
+	}
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		return ValidationError{fmt.Sprintf("Password must be at least %d characters long", policy.MinLength)}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case policy.RequireUppercase && !upper:
+		return ValidationError{"Password must contain an uppercase letter"}
+	case policy.RequireLowercase && !lower:
+		return ValidationError{"Password must contain a lowercase letter"}
+	case policy.RequireDigit && !digit:
+		return ValidationError{"Password must contain a digit"}
+	case policy.RequireSymbol && !symbol:
+		return ValidationError{"Password must contain a symbol"}
+	}
+
+	// nolint:gosec
+	// SHA-1 is only used to match the hashes of the breached passwords lists
+	if _, ok := s.breached[sha1.Sum([]byte(password))]; ok {
+		return ValidationError{"Password is known from data breaches, choose another one"}
+	}
+
+	if user == nil || policy.HistoryCount == 0 {
+		return nil
+	}
+	return s.validateHistory(password, user, policy.HistoryCount)
+}
+
+// validateHistory refuses the current password of the user, and the previous ones kept by the policy.
+func (s *PasswordPolicyService) validateHistory(password string, user *models.User, count int) error {
+	reused := ValidationError{fmt.Sprintf("Password must differ from the last %d passwords", count)}
+
+	if user.Password != "" {
+		encoded, err := util.EncodePassword(password, user.Salt)
+		if err != nil {
+			return err
+		}
+		if encoded == user.Password {
+			return reused
+		}
+	}
+
+	query := models.GetUserPasswordHistoryQuery{UserId: user.Id, Limit: count}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+	for _, entry := range query.Result {
+		encoded, err := util.EncodePassword(password, entry.Salt)
+		if err != nil {
+			return err
+		}
+		if encoded == entry.Password {
+			return reused
+		}
+	}
+	return nil
+}
+
+// IsExpired checks whether the user must change their password, because it was set longer ago than
+// the maximum age of the policy. The history of the passwords set before it was kept is seeded at
+// startup, passwords still without history don't expire.
+func (s *PasswordPolicyService) IsExpired(user *models.User) (bool, error) {
+	policy := s.Cfg.PasswordPolicy
+	if !policy.Enabled || policy.MaxAge == 0 || user.Password == "" {
+		return false, nil
+	}
+
+	query := models.GetUserPasswordHistoryQuery{UserId: user.Id, Limit: 1}
+	if err := bus.Dispatch(&query); err != nil {
+		return false, err
+	}
+	if len(query.Result) == 0 {
+		return false, nil
+	}
+
+	return s.now().Sub(query.Result[0].Created) > policy.MaxAge, nil
+}
@@ -0,0 +1,194 @@
+package passwordpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, policy setting.PasswordPolicySettings) *PasswordPolicyService {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	policy.Enabled = true
+	cfg.PasswordPolicy = policy
+
+	s := &PasswordPolicyService{Cfg: cfg}
+	require.NoError(t, s.Init())
+	return s
+}
+
+func requireValidationError(t *testing.T, err error, message string) {
+	t.Helper()
+
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected a validation error, got %v", err)
+	assert.Equal(t, message, validationErr.Message)
+}
+
+func encodedPassword(t *testing.T, password, salt string) string {
+	t.Helper()
+
+	encoded, err := util.EncodePassword(password, salt)
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Passwords are not checked when the policy is disabled", func(t *testing.T) {
+		s := newTestService(t, setting.PasswordPolicySettings{MinLength: 12})
+		s.Cfg.PasswordPolicy.Enabled = false
+
+		require.NoError(t, s.Validate("short", nil))
+	})
+
+	t.Run("Passwords must be long enough", func(t *testing.T) {
+		s := newTestService(t, setting.PasswordPolicySettings{MinLength: 8})
+
+		requireValidationError(t, s.Validate("seven77", nil), "Password must be at least 8 characters long")
+		require.NoError(t, s.Validate("éééééééé", nil))
+	})
+
+	t.Run("Passwords must contain the required character classes", func(t *testing.T) {
+		s := newTestService(t, setting.PasswordPolicySettings{
+			RequireUppercase: true,
+			RequireLowercase: true,
+			RequireDigit:     true,
+			RequireSymbol:    true,
+		})
+
+		requireValidationError(t, s.Validate("lower1!", nil), "Password must contain an uppercase letter")
+		requireValidationError(t, s.Validate("UPPER1!", nil), "Password must contain a lowercase letter")
+		requireValidationError(t, s.Validate("Letters!", nil), "Password must contain a digit")
+		requireValidationError(t, s.Validate("Letters1", nil), "Password must contain a symbol")
+		require.NoError(t, s.Validate("Letters1!", nil))
+	})
+
+	t.Run("Breached passwords are refused", func(t *testing.T) {
+		hash := sha1.Sum([]byte("hashed-password"))
+		lines := []string{
+			"# breached passwords",
+			"plain-password",
+			"",
+			strings.ToUpper(hex.EncodeToString(hash[:])) + ":42",
+		}
+		path := filepath.Join(t.TempDir(), "breached.txt")
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+
+		s := newTestService(t, setting.PasswordPolicySettings{BreachedPasswordsFile: path})
+
+		for _, password := range []string{"plain-password", "hashed-password"} {
+			requireValidationError(t, s.Validate(password, nil), "Password is known from data breaches, choose another one")
+		}
+		require.NoError(t, s.Validate("# breached passwords", nil))
+		require.NoError(t, s.Validate("other-password", nil))
+	})
+
+	t.Run("Init fails when the breached passwords file is missing", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PasswordPolicy = setting.PasswordPolicySettings{
+			Enabled:               true,
+			BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt"),
+		}
+
+		s := &PasswordPolicyService{Cfg: cfg}
+		require.Error(t, s.Init())
+	})
+
+	t.Run("The last passwords of the user are refused", func(t *testing.T) {
+		t.Cleanup(bus.ClearBusHandlers)
+		bus.AddHandler("test", func(query *models.GetUserPasswordHistoryQuery) error {
+			history := []*models.UserPasswordHistory{
+				{Password: encodedPassword(t, "previous-1", "old-salt"), Salt: "old-salt"},
+				{Password: encodedPassword(t, "previous-2", "salt"), Salt: "salt"},
+				{Password: encodedPassword(t, "previous-3", "salt"), Salt: "salt"},
+			}
+			query.Result = history[:query.Limit]
+			return nil
+		})
+
+		s := newTestService(t, setting.PasswordPolicySettings{HistoryCount: 2})
+		user := &models.User{Id: 1, Salt: "salt", Password: encodedPassword(t, "current", "salt")}
+
+		for _, password := range []string{"current", "previous-1", "previous-2"} {
+			requireValidationError(t, s.Validate(password, user), "Password must differ from the last 2 passwords")
+		}
+		require.NoError(t, s.Validate("previous-3", user))
+		require.NoError(t, s.Validate("current", nil))
+	})
+}
+
+func TestIsExpired(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	now := time.Now()
+	var history []*models.UserPasswordHistory
+	bus.AddHandler("test", func(query *models.GetUserPasswordHistoryQuery) error {
+		query.Result = history
+		return nil
+	})
+
+	var seeded []time.Time
+	bus.AddHandler("test", func(cmd *models.SeedUserPasswordHistoryCommand) error {
+		seeded = append(seeded, cmd.Created)
+		return nil
+	})
+
+	s := newTestService(t, setting.PasswordPolicySettings{MaxAge: 24 * time.Hour})
+	s.now = func() time.Time { return now }
+	user := &models.User{Id: 1, Password: "encoded", Created: now.Add(-48 * time.Hour)}
+
+	t.Run("The password history is seeded when the maximum age is set", func(t *testing.T) {
+		require.Len(t, seeded, 1)
+
+		newTestService(t, setting.PasswordPolicySettings{})
+		assert.Len(t, seeded, 1)
+	})
+
+	t.Run("Passwords without history don't expire", func(t *testing.T) {
+		history = nil
+
+		expired, err := s.IsExpired(user)
+		require.NoError(t, err)
+		assert.False(t, expired)
+	})
+
+	t.Run("Passwords expire after the maximum age", func(t *testing.T) {
+		history = []*models.UserPasswordHistory{{Created: now.Add(-time.Hour)}}
+		expired, err := s.IsExpired(user)
+		require.NoError(t, err)
+		assert.False(t, expired)
+
+		history = []*models.UserPasswordHistory{{Created: now.Add(-25 * time.Hour)}}
+		expired, err = s.IsExpired(user)
+		require.NoError(t, err)
+		assert.True(t, expired)
+	})
+
+	t.Run("Passwords don't expire without maximum age", func(t *testing.T) {
+		s.Cfg.PasswordPolicy.MaxAge = 0
+		t.Cleanup(func() { s.Cfg.PasswordPolicy.MaxAge = 24 * time.Hour })
+
+		expired, err := s.IsExpired(user)
+		require.NoError(t, err)
+		assert.False(t, expired)
+	})
+
+	t.Run("Users without password don't expire", func(t *testing.T) {
+		expired, err := s.IsExpired(&models.User{Id: 2, Created: now.Add(-48 * time.Hour)})
+		require.NoError(t, err)
+		assert.False(t, expired)
+	})
+}
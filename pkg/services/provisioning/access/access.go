@@ -0,0 +1,475 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Provision orgs, users, teams and dashboard permissions
+func Provision(configDirectory string) error {
+	ap := newAccessProvisioner(log.New("provisioning.access"))
+	return ap.applyChanges(configDirectory)
+}
+
+// AccessProvisioner is responsible for provisioning orgs, users, teams and
+// dashboard permissions. Provisioning is additive: orgs, users and org memberships
+// missing from the configuration are left untouched, while team members and
+// dashboard permissions are replaced by the configured ones.
+type AccessProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+}
+
+func newAccessProvisioner(log log.Logger) AccessProvisioner {
+	return AccessProvisioner{
+		log:         log,
+		cfgProvider: &configReader{log: log},
+	}
+}
+
+func (ap *AccessProvisioner) applyChanges(configPath string) error {
+	configs, err := ap.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	// orgs and users are applied for all files first, so teams and permissions
+	// can refer to the ones provisioned in other files
+	for _, cfg := range configs {
+		if err := ap.provisionOrgs(cfg.Orgs); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := ap.provisionUsers(cfg.Users); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := ap.provisionTeams(cfg.Teams); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := ap.provisionPermissions(cfg.Permissions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionOrgs(orgs []*orgFromConfig) error {
+	for _, org := range orgs {
+		query := &models.GetOrgByNameQuery{Name: org.Name}
+		err := bus.Dispatch(query)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, models.ErrOrgNotFound) {
+			return err
+		}
+
+		ap.log.Info("Creating org from configuration", "name", org.Name)
+		if err := bus.Dispatch(&models.CreateOrgCommand{Name: org.Name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionUsers(users []*userFromConfig) error {
+	for _, user := range users {
+		u, err := ap.provisionUser(user)
+		if err != nil {
+			return fmt.Errorf("failed to provision user %q: %w", userIdentifier(user), err)
+		}
+
+		if err := ap.provisionUserOrgs(u, user.Orgs); err != nil {
+			return fmt.Errorf("failed to provision org roles of user %q: %w", userIdentifier(user), err)
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionUser(user *userFromConfig) (*models.User, error) {
+	query := &models.GetUserByLoginQuery{LoginOrEmail: userIdentifier(user)}
+	err := bus.Dispatch(query)
+	if errors.Is(err, models.ErrUserNotFound) {
+		ap.log.Info("Creating user from configuration", "login", user.Login, "email", user.Email)
+
+		password := user.Password
+		if password == "" {
+			// users without a password have to log in through an auth provider
+			// or reset their password
+			if password, err = util.GetRandomString(32); err != nil {
+				return nil, err
+			}
+		}
+
+		cmd := &models.CreateUserCommand{
+			Login:        userIdentifier(user),
+			Email:        user.Email,
+			Name:         user.Name,
+			Password:     password,
+			IsAdmin:      user.GrafanaAdmin != nil && *user.GrafanaAdmin,
+			SkipOrgSetup: true,
+		}
+		if err := bus.DispatchCtx(context.Background(), cmd); err != nil {
+			return nil, err
+		}
+		return &cmd.Result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing := query.Result
+	if (user.Name != "" && user.Name != existing.Name) || (user.Email != "" && user.Email != existing.Email) {
+		ap.log.Debug("Updating user from configuration", "login", existing.Login)
+		cmd := &models.UpdateUserCommand{
+			UserId: existing.Id,
+			Login:  existing.Login,
+			Name:   existing.Name,
+			Email:  existing.Email,
+			Theme:  existing.Theme,
+		}
+		if user.Name != "" {
+			cmd.Name = user.Name
+		}
+		if user.Email != "" {
+			cmd.Email = user.Email
+		}
+		if err := bus.Dispatch(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.GrafanaAdmin != nil && *user.GrafanaAdmin != existing.IsAdmin {
+		ap.log.Debug("Updating Grafana admin permission of user from configuration", "login", existing.Login, "isGrafanaAdmin", *user.GrafanaAdmin)
+		cmd := &models.UpdateUserPermissionsCommand{UserId: existing.Id, IsGrafanaAdmin: *user.GrafanaAdmin}
+		if err := bus.Dispatch(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+func (ap *AccessProvisioner) provisionUserOrgs(user *models.User, orgs []*userOrgFromConfig) error {
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	query := &models.GetUserOrgListQuery{UserId: user.Id}
+	if err := bus.Dispatch(query); err != nil {
+		return err
+	}
+
+	roles := make(map[int64]models.RoleType, len(query.Result))
+	for _, org := range query.Result {
+		roles[org.OrgId] = org.Role
+	}
+
+	for _, org := range orgs {
+		orgID, err := resolveOrgID(org.OrgID, org.OrgName)
+		if err != nil {
+			return err
+		}
+
+		role, member := roles[orgID]
+		switch {
+		case !member:
+			cmd := &models.AddOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: org.Role}
+			if err := bus.Dispatch(cmd); err != nil {
+				return err
+			}
+		case role != org.Role:
+			cmd := &models.UpdateOrgUserCommand{OrgId: orgID, UserId: user.Id, Role: org.Role}
+			if err := bus.Dispatch(cmd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionTeams(teams []*teamFromConfig) error {
+	for _, team := range teams {
+		if err := ap.provisionTeam(team); err != nil {
+			return fmt.Errorf("failed to provision team %q: %w", team.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionTeam(team *teamFromConfig) error {
+	orgID, err := resolveOrgID(team.OrgID, team.OrgName)
+	if err != nil {
+		return err
+	}
+
+	teamID, err := ap.upsertTeam(orgID, team)
+	if err != nil {
+		return err
+	}
+
+	membersQuery := &models.GetTeamMembersQuery{OrgId: orgID, TeamId: teamID}
+	if err := bus.Dispatch(membersQuery); err != nil {
+		return err
+	}
+
+	existing := make(map[int64]*models.TeamMemberDTO, len(membersQuery.Result))
+	for _, member := range membersQuery.Result {
+		existing[member.UserId] = member
+	}
+
+	declared := make(map[int64]bool, len(team.Members))
+	for _, member := range team.Members {
+		userQuery := &models.GetUserByLoginQuery{LoginOrEmail: member.Login}
+		if err := bus.Dispatch(userQuery); err != nil {
+			return fmt.Errorf("failed to find member %q: %w", member.Login, err)
+		}
+
+		userID := userQuery.Result.Id
+		declared[userID] = true
+
+		var permission models.PermissionType
+		if member.Admin {
+			permission = models.PERMISSION_ADMIN
+		}
+
+		current, ok := existing[userID]
+		switch {
+		case !ok:
+			cmd := &models.AddTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID, Permission: permission}
+			if err := bus.Dispatch(cmd); err != nil {
+				return err
+			}
+		case current.Permission != permission:
+			cmd := &models.UpdateTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID, Permission: permission}
+			if err := bus.Dispatch(cmd); err != nil {
+				return err
+			}
+		}
+	}
+
+	for userID, member := range existing {
+		// members synced from an external auth provider are managed by it
+		if declared[userID] || member.External {
+			continue
+		}
+
+		ap.log.Debug("Removing team member missing from configuration", "team", team.Name, "login", member.Login)
+		cmd := &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID}
+		if err := bus.Dispatch(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) upsertTeam(orgID int64, team *teamFromConfig) (int64, error) {
+	query := &models.SearchTeamsQuery{OrgId: orgID, Name: team.Name, Limit: 1}
+	if err := bus.Dispatch(query); err != nil {
+		return 0, err
+	}
+
+	if len(query.Result.Teams) == 0 {
+		ap.log.Info("Creating team from configuration", "name", team.Name, "orgId", orgID)
+		cmd := &models.CreateTeamCommand{OrgId: orgID, Name: team.Name, Email: team.Email}
+		if err := bus.Dispatch(cmd); err != nil {
+			return 0, err
+		}
+		return cmd.Result.Id, nil
+	}
+
+	existing := query.Result.Teams[0]
+	if existing.Email != team.Email {
+		cmd := &models.UpdateTeamCommand{OrgId: orgID, Id: existing.Id, Name: team.Name, Email: team.Email}
+		if err := bus.Dispatch(cmd); err != nil {
+			return 0, err
+		}
+	}
+
+	return existing.Id, nil
+}
+
+func (ap *AccessProvisioner) provisionPermissions(permissions []*permissionsFromConfig) error {
+	for _, p := range permissions {
+		if err := ap.provisionDashboardPermissions(p); err != nil {
+			return fmt.Errorf("failed to provision permissions of %q: %w", permissionsTarget(p), err)
+		}
+	}
+
+	return nil
+}
+
+func (ap *AccessProvisioner) provisionDashboardPermissions(p *permissionsFromConfig) error {
+	orgID, err := resolveOrgID(p.OrgID, p.OrgName)
+	if err != nil {
+		return err
+	}
+
+	uid := p.DashboardUID
+	if uid == "" {
+		uid = p.FolderUID
+	}
+
+	dashQuery := &models.GetDashboardQuery{Uid: uid, OrgId: orgID}
+	if err := bus.Dispatch(dashQuery); err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			// the dashboard might be provisioned later, permissions are applied again
+			// after dashboards have been provisioned
+			ap.log.Warn("Dashboard or folder for permissions not found", "uid", uid, "orgId", orgID)
+			return nil
+		}
+		return err
+	}
+	dashboard := dashQuery.Result
+	if dashboard.IsFolder != (p.FolderUID != "") {
+		return fmt.Errorf("%q is not a %s", uid, permissionsKind(p))
+	}
+
+	items, err := ap.permissionItems(orgID, dashboard.Id, p.Items)
+	if err != nil {
+		return err
+	}
+
+	aclQuery := &models.GetDashboardAclInfoListQuery{OrgID: orgID, DashboardID: dashboard.Id}
+	if err := bus.Dispatch(aclQuery); err != nil {
+		return err
+	}
+
+	if sameAcl(items, aclQuery.Result, dashboard.Id) {
+		return nil
+	}
+
+	ap.log.Info("Updating permissions from configuration", permissionsKind(p), uid, "orgId", orgID)
+	return bus.Dispatch(&models.UpdateDashboardAclCommand{DashboardID: dashboard.Id, Items: items})
+}
+
+func (ap *AccessProvisioner) permissionItems(orgID, dashboardID int64, items []*permissionItemFromConfig) ([]*models.DashboardAcl, error) {
+	now := time.Now()
+	acl := make([]*models.DashboardAcl, 0, len(items))
+
+	for _, item := range items {
+		permission, err := parsePermission(item.Permission)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &models.DashboardAcl{
+			OrgID:       orgID,
+			DashboardID: dashboardID,
+			Permission:  permission,
+			Created:     now,
+			Updated:     now,
+		}
+
+		switch {
+		case item.Role != "":
+			role := models.RoleType(item.Role)
+			entry.Role = &role
+		case item.Team != "":
+			query := &models.SearchTeamsQuery{OrgId: orgID, Name: item.Team, Limit: 1}
+			if err := bus.Dispatch(query); err != nil {
+				return nil, err
+			}
+			if len(query.Result.Teams) == 0 {
+				return nil, fmt.Errorf("team %q: %w", item.Team, models.ErrTeamNotFound)
+			}
+			entry.TeamID = query.Result.Teams[0].Id
+		case item.User != "":
+			query := &models.GetUserByLoginQuery{LoginOrEmail: item.User}
+			if err := bus.Dispatch(query); err != nil {
+				return nil, fmt.Errorf("user %q: %w", item.User, err)
+			}
+			entry.UserID = query.Result.Id
+		}
+
+		acl = append(acl, entry)
+	}
+
+	return acl, nil
+}
+
+// sameAcl returns true if the ACL items of the dashboard itself, ignoring the ones
+// inherited from its folder, are the same as the provisioned ones.
+func sameAcl(items []*models.DashboardAcl, existing []*models.DashboardAclInfoDTO, dashboardID int64) bool {
+	key := func(userID, teamID int64, role *models.RoleType, permission models.PermissionType) string {
+		r := ""
+		if role != nil {
+			r = string(*role)
+		}
+		return fmt.Sprintf("%d/%d/%s/%d", userID, teamID, r, permission)
+	}
+
+	wanted := make(map[string]bool, len(items))
+	for _, item := range items {
+		wanted[key(item.UserID, item.TeamID, item.Role, item.Permission)] = true
+	}
+
+	current := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		if item.Inherited || item.DashboardId != dashboardID {
+			continue
+		}
+		current[key(item.UserId, item.TeamId, item.Role, item.Permission)] = true
+	}
+
+	if len(wanted) != len(current) {
+		return false
+	}
+	for k := range wanted {
+		if !current[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func permissionsKind(p *permissionsFromConfig) string {
+	if p.FolderUID != "" {
+		return "folder"
+	}
+	return "dashboard"
+}
+
+func permissionsTarget(p *permissionsFromConfig) string {
+	return permissionsKind(p) + " " + p.FolderUID + p.DashboardUID
+}
+
+func resolveOrgID(orgID int64, orgName string) (int64, error) {
+	if orgID == 0 && orgName != "" {
+		getOrg := &models.GetOrgByNameQuery{Name: orgName}
+		if err := bus.Dispatch(getOrg); err != nil {
+			return 0, fmt.Errorf("org %q: %w", orgName, err)
+		}
+		return getOrg.Result.Id, nil
+	}
+	if orgID < 1 {
+		return 1, nil
+	}
+
+	getOrg := &models.GetOrgByIdQuery{Id: orgID}
+	if err := bus.Dispatch(getOrg); err != nil {
+		return 0, fmt.Errorf("org %d: %w", orgID, err)
+	}
+	return orgID, nil
+}
@@ -0,0 +1,166 @@
+package access
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestProvisionAccess(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	require.NoError(t, sqlstore.CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+
+	require.NoError(t, os.Setenv("TEST_PASSWORD", "secret"))
+	t.Cleanup(func() { _ = os.Unsetenv("TEST_PASSWORD") })
+
+	t.Run("Creates orgs, users and teams", func(t *testing.T) {
+		require.NoError(t, Provision(correctProperties))
+
+		org := getOrg(t, "Engineering")
+
+		jdoe := getUser(t, "jdoe")
+		assert.Equal(t, "John Doe", jdoe.Name)
+		assert.False(t, jdoe.IsAdmin)
+		assert.Equal(t, map[int64]models.RoleType{1: models.ROLE_VIEWER, org.Id: models.ROLE_EDITOR}, getUserOrgRoles(t, jdoe.Id))
+
+		admin := getUser(t, "admin@example.com")
+		assert.True(t, admin.IsAdmin)
+		assert.Empty(t, getUserOrgRoles(t, admin.Id))
+
+		team := getTeam(t, org.Id, "Backend")
+		assert.Equal(t, "backend@example.com", team.Email)
+		assert.Equal(t, map[string]models.PermissionType{
+			"jdoe":              models.PERMISSION_ADMIN,
+			"admin@example.com": 0,
+		}, getTeamMembers(t, org.Id, team.Id))
+	})
+
+	t.Run("Updates users and teams and removes members missing from the configuration", func(t *testing.T) {
+		org := getOrg(t, "Engineering")
+		jdoe := getUser(t, "jdoe")
+		team := getTeam(t, org.Id, "Backend")
+
+		require.NoError(t, bus.Dispatch(&models.UpdateOrgUserCommand{OrgId: org.Id, UserId: jdoe.Id, Role: models.ROLE_VIEWER}))
+		require.NoError(t, bus.Dispatch(&models.UpdateUserPermissionsCommand{UserId: jdoe.Id, IsGrafanaAdmin: true}))
+		require.NoError(t, bus.Dispatch(&models.UpdateTeamCommand{OrgId: org.Id, Id: team.Id, Name: team.Name, Email: "old@example.com"}))
+
+		other := &models.CreateUserCommand{Login: "other", SkipOrgSetup: true}
+		require.NoError(t, bus.DispatchCtx(context.Background(), other))
+		require.NoError(t, bus.Dispatch(&models.AddTeamMemberCommand{OrgId: org.Id, TeamId: team.Id, UserId: other.Result.Id}))
+
+		require.NoError(t, Provision(correctProperties))
+
+		assert.Equal(t, models.ROLE_EDITOR, getUserOrgRoles(t, jdoe.Id)[org.Id])
+		// the configuration doesn't set grafana_admin for jdoe
+		assert.True(t, getUser(t, "jdoe").IsAdmin)
+		assert.Equal(t, "backend@example.com", getTeam(t, org.Id, "Backend").Email)
+		assert.NotContains(t, getTeamMembers(t, org.Id, team.Id), "other")
+	})
+
+	t.Run("Replaces permissions of provisioned folders", func(t *testing.T) {
+		org := getOrg(t, "Engineering")
+		folder := &models.SaveDashboardCommand{
+			OrgId:    org.Id,
+			IsFolder: true,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"uid":   "backend",
+				"title": "Backend",
+			}),
+		}
+		require.NoError(t, bus.Dispatch(folder))
+
+		require.NoError(t, Provision(correctProperties))
+
+		query := &models.GetDashboardAclInfoListQuery{OrgID: org.Id, DashboardID: folder.Result.Id}
+		require.NoError(t, bus.Dispatch(query))
+		require.Len(t, query.Result, 3)
+
+		team := getTeam(t, org.Id, "Backend")
+		admin := getUser(t, "admin@example.com")
+		acl := map[string]models.PermissionType{}
+		for _, item := range query.Result {
+			switch {
+			case item.TeamId != 0:
+				assert.Equal(t, team.Id, item.TeamId)
+				acl["team"] = item.Permission
+			case item.UserId != 0:
+				assert.Equal(t, admin.Id, item.UserId)
+				acl["user"] = item.Permission
+			default:
+				acl[string(*item.Role)] = item.Permission
+			}
+		}
+		assert.Equal(t, map[string]models.PermissionType{
+			"team":   models.PERMISSION_EDIT,
+			"user":   models.PERMISSION_ADMIN,
+			"Viewer": models.PERMISSION_VIEW,
+		}, acl)
+
+		assert.True(t, sameAcl(aclFromInfo(query.Result), query.Result, folder.Result.Id))
+	})
+}
+
+func getOrg(t *testing.T, name string) *models.Org {
+	t.Helper()
+
+	query := &models.GetOrgByNameQuery{Name: name}
+	require.NoError(t, bus.Dispatch(query))
+	return query.Result
+}
+
+func getUser(t *testing.T, loginOrEmail string) *models.User {
+	t.Helper()
+
+	query := &models.GetUserByLoginQuery{LoginOrEmail: loginOrEmail}
+	require.NoError(t, bus.Dispatch(query))
+	return query.Result
+}
+
+func getUserOrgRoles(t *testing.T, userID int64) map[int64]models.RoleType {
+	t.Helper()
+
+	query := &models.GetUserOrgListQuery{UserId: userID}
+	require.NoError(t, bus.Dispatch(query))
+	roles := map[int64]models.RoleType{}
+	for _, org := range query.Result {
+		roles[org.OrgId] = org.Role
+	}
+	return roles
+}
+
+func getTeam(t *testing.T, orgID int64, name string) *models.TeamDTO {
+	t.Helper()
+
+	query := &models.SearchTeamsQuery{OrgId: orgID, Name: name}
+	require.NoError(t, bus.Dispatch(query))
+	require.Len(t, query.Result.Teams, 1)
+	return query.Result.Teams[0]
+}
+
+func getTeamMembers(t *testing.T, orgID, teamID int64) map[string]models.PermissionType {
+	t.Helper()
+
+	query := &models.GetTeamMembersQuery{OrgId: orgID, TeamId: teamID}
+	require.NoError(t, bus.Dispatch(query))
+	members := map[string]models.PermissionType{}
+	for _, member := range query.Result {
+		members[member.Login] = member.Permission
+	}
+	return members
+}
+
+func aclFromInfo(infos []*models.DashboardAclInfoDTO) []*models.DashboardAcl {
+	acl := make([]*models.DashboardAcl, 0, len(infos))
+	for _, info := range infos {
+		acl = append(acl, &models.DashboardAcl{UserID: info.UserId, TeamID: info.TeamId, Role: info.Role, Permission: info.Permission})
+	}
+	return acl
+}
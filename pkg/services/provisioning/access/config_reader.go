@@ -0,0 +1,232 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*accessAsConfig, error) {
+	var configs []*accessAsConfig
+	cr.log.Debug("Looking for access provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read access provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing access provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseAccessConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating access configuration")
+	if err = validateRequiredField(configs); err != nil {
+		return nil, err
+	}
+
+	checkOrgIDAndOrgName(configs)
+
+	if err := validatePasswords(configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (cr *configReader) parseAccessConfig(path string, file os.FileInfo) (*accessAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *accessAsConfigV0
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToAccessFromConfig(filename), nil
+}
+
+// checkOrgIDAndOrgName defaults items without org to the main org. Unlike other provisioners
+// org ids aren't checked here as the orgs might be created by the same configuration.
+func checkOrgIDAndOrgName(configs []*accessAsConfig) {
+	defaultOrgID := func(orgID int64, orgName string) int64 {
+		if orgID < 1 {
+			if orgName == "" {
+				return 1
+			}
+			return 0
+		}
+		return orgID
+	}
+
+	for i := range configs {
+		for _, user := range configs[i].Users {
+			for _, org := range user.Orgs {
+				org.OrgID = defaultOrgID(org.OrgID, org.OrgName)
+			}
+		}
+
+		for _, team := range configs[i].Teams {
+			team.OrgID = defaultOrgID(team.OrgID, team.OrgName)
+		}
+
+		for _, permissions := range configs[i].Permissions {
+			permissions.OrgID = defaultOrgID(permissions.OrgID, permissions.OrgName)
+		}
+	}
+}
+
+func validateRequiredField(configs []*accessAsConfig) error {
+	for i := range configs {
+		var errStrings []string
+		for index, org := range configs[i].Orgs {
+			if org.Name == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Org item %d in configuration doesn't contain required field name", index+1),
+				)
+			}
+		}
+
+		for index, user := range configs[i].Users {
+			if user.Login == "" && user.Email == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("User item %d in configuration doesn't contain required field login or email", index+1),
+				)
+			}
+
+			for _, org := range user.Orgs {
+				if !org.Role.IsValid() {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("User item %d in configuration has invalid org role %q", index+1, org.Role),
+					)
+				}
+			}
+		}
+
+		for index, team := range configs[i].Teams {
+			if team.Name == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Team item %d in configuration doesn't contain required field name", index+1),
+				)
+			}
+
+			for _, member := range team.Members {
+				if member.Login == "" {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("Team item %d in configuration has a member without required field login", index+1),
+					)
+				}
+			}
+		}
+
+		for index, permissions := range configs[i].Permissions {
+			if (permissions.FolderUID == "") == (permissions.DashboardUID == "") {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Permissions item %d in configuration must contain exactly one of the fields folder_uid or dashboard_uid", index+1),
+				)
+			}
+
+			for _, item := range permissions.Items {
+				if err := validatePermissionItem(item); err != nil {
+					errStrings = append(
+						errStrings,
+						fmt.Sprintf("Permissions item %d in configuration %s", index+1, err),
+					)
+				}
+			}
+		}
+
+		if len(errStrings) != 0 {
+			return errors.New(strings.Join(errStrings, "\n"))
+		}
+	}
+
+	return nil
+}
+
+func validatePermissionItem(item *permissionItemFromConfig) error {
+	targets := 0
+	for _, target := range []string{item.Role, item.Team, item.User} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return errors.New("has an item which doesn't contain exactly one of the fields role, team or user")
+	}
+
+	if item.Role != "" {
+		role := models.RoleType(item.Role)
+		if role != models.ROLE_VIEWER && role != models.ROLE_EDITOR {
+			return fmt.Errorf("has an item with invalid role %q, only Viewer and Editor can be granted permissions", item.Role)
+		}
+	}
+
+	if _, err := parsePermission(item.Permission); err != nil {
+		return fmt.Errorf("has an item with %w", err)
+	}
+
+	return nil
+}
+
+func parsePermission(permission string) (models.PermissionType, error) {
+	for _, p := range []models.PermissionType{models.PERMISSION_VIEW, models.PERMISSION_EDIT, models.PERMISSION_ADMIN} {
+		if strings.EqualFold(permission, p.String()) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid permission %q, must be one of View, Edit or Admin", permission)
+}
+
+// validatePasswords makes sure passwords aren't stored in provisioning files in plain text.
+func validatePasswords(configs []*accessAsConfig) error {
+	for i := range configs {
+		for _, user := range configs[i].Users {
+			if user.Password != "" && !user.passwordFromEnv {
+				return fmt.Errorf("password of user %q in %q must be read from an environment variable", userIdentifier(user), configs[i].Path)
+			}
+		}
+	}
+	return nil
+}
+
+func userIdentifier(user *userFromConfig) string {
+	if user.Login != "" {
+		return user.Login
+	}
+	return user.Email
+}
@@ -0,0 +1,130 @@
+package access
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	invalidFields     = "./testdata/test-configs/invalid-fields"
+	plainPassword     = "./testdata/test-configs/plain-password"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestAccessAsConfig(t *testing.T) {
+	cfgProvider := &configReader{log: log.New("test logger")}
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		require.NoError(t, os.Setenv("TEST_PASSWORD", "secret"))
+		t.Cleanup(func() { _ = os.Unsetenv("TEST_PASSWORD") })
+
+		cfg, err := cfgProvider.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		require.Len(t, cfg[0].Orgs, 1)
+		assert.Equal(t, "Engineering", cfg[0].Orgs[0].Name)
+
+		require.Len(t, cfg[0].Users, 2)
+		user := cfg[0].Users[0]
+		assert.Equal(t, "jdoe", user.Login)
+		assert.Equal(t, "jdoe@example.com", user.Email)
+		assert.Equal(t, "John Doe", user.Name)
+		assert.Equal(t, "secret", user.Password)
+		assert.Nil(t, user.GrafanaAdmin)
+		require.Len(t, user.Orgs, 2)
+		assert.Equal(t, &userOrgFromConfig{OrgName: "Engineering", Role: models.ROLE_EDITOR}, user.Orgs[0])
+		assert.Equal(t, &userOrgFromConfig{OrgID: 1, Role: models.ROLE_VIEWER}, user.Orgs[1])
+		require.NotNil(t, cfg[0].Users[1].GrafanaAdmin)
+		assert.True(t, *cfg[0].Users[1].GrafanaAdmin)
+
+		require.Len(t, cfg[0].Teams, 1)
+		team := cfg[0].Teams[0]
+		assert.Equal(t, "Backend", team.Name)
+		assert.Equal(t, "Engineering", team.OrgName)
+		assert.Equal(t, int64(0), team.OrgID)
+		require.Len(t, team.Members, 2)
+		assert.True(t, team.Members[0].Admin)
+		assert.False(t, team.Members[1].Admin)
+
+		require.Len(t, cfg[0].Permissions, 1)
+		permissions := cfg[0].Permissions[0]
+		assert.Equal(t, "backend", permissions.FolderUID)
+		require.Len(t, permissions.Items, 3)
+		assert.Equal(t, "Backend", permissions.Items[0].Team)
+		assert.Equal(t, "Viewer", permissions.Items[1].Role)
+		assert.Equal(t, "admin@example.com", permissions.Items[2].User)
+	})
+
+	t.Run("Invalid fields should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(invalidFields)
+		require.Error(t, err)
+
+		errorMessages := []string{
+			"Org item 1 in configuration doesn't contain required field name",
+			"User item 1 in configuration doesn't contain required field login or email",
+			`User item 2 in configuration has invalid org role "Owner"`,
+			"Team item 1 in configuration doesn't contain required field name",
+			"Team item 1 in configuration has a member without required field login",
+			"Permissions item 1 in configuration must contain exactly one of the fields folder_uid or dashboard_uid",
+			`Permissions item 1 in configuration has an item with invalid role "Admin", only Viewer and Editor can be granted permissions`,
+			"Permissions item 1 in configuration has an item which doesn't contain exactly one of the fields role, team or user",
+			`Permissions item 1 in configuration has an item with invalid permission "Owner", must be one of View, Edit or Admin`,
+		}
+		for _, msg := range errorMessages {
+			assert.Contains(t, err.Error(), msg)
+		}
+	})
+
+	t.Run("Passwords not read from environment variables should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(plainPassword)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `password of user "jdoe"`)
+	})
+
+	t.Run("Passwords must be a single environment variable", func(t *testing.T) {
+		require.NoError(t, os.Setenv("TEST_PASSWORD", "secret"))
+		t.Cleanup(func() { _ = os.Unsetenv("TEST_PASSWORD") })
+
+		for password, valid := range map[string]bool{
+			"$TEST_PASSWORD":        true,
+			"${TEST_PASSWORD}":      true,
+			"plain$TEST_PASSWORD":   false,
+			"$TEST_PASSWORD-suffix": false,
+			"${TEST_PASSWORD}plain": false,
+			"$$TEST_PASSWORD":       false,
+		} {
+			dir := t.TempDir()
+			config := "apiVersion: 1\n\nusers:\n  - login: jdoe\n    password: \"" + password + "\"\n"
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "access.yaml"), []byte(config), 0600))
+
+			_, err := cfgProvider.readConfig(dir)
+			if valid {
+				assert.NoError(t, err, password)
+			} else {
+				assert.Error(t, err, password)
+			}
+		}
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Empty folder should return empty config", func(t *testing.T) {
+		cfg, err := cfgProvider.readConfig(emptyFolder)
+		require.NoError(t, err)
+		assert.Empty(t, cfg)
+	})
+}
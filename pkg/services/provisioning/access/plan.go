@@ -0,0 +1,77 @@
+package access
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and returns the changes
+// provisioning them would make, without making them. Memberships and permissions are
+// reported as updates of the teams, folders and dashboards they belong to.
+func Plan(configDirectory string) ([]*utils.PlanItem, error) {
+	cr := &configReader{log: log.New("provisioning.access")}
+	configs, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*utils.PlanItem
+	for _, cfg := range configs {
+		for _, org := range cfg.Orgs {
+			item := &utils.PlanItem{Action: utils.PlanUnchanged, Kind: "org", Name: org.Name}
+			query := &models.GetOrgByNameQuery{Name: org.Name}
+			switch err := bus.Dispatch(query); {
+			case errors.Is(err, models.ErrOrgNotFound):
+				item.Action = utils.PlanCreate
+			case err != nil:
+				return nil, err
+			default:
+				item.OrgID = query.Result.Id
+			}
+			plan = append(plan, item)
+		}
+
+		for _, user := range cfg.Users {
+			item := &utils.PlanItem{Action: utils.PlanUpdate, Kind: "user", Name: userIdentifier(user)}
+			query := &models.GetUserByLoginQuery{LoginOrEmail: userIdentifier(user)}
+			switch err := bus.Dispatch(query); {
+			case errors.Is(err, models.ErrUserNotFound):
+				item.Action = utils.PlanCreate
+			case err != nil:
+				return nil, err
+			}
+			plan = append(plan, item)
+		}
+
+		for _, team := range cfg.Teams {
+			item := &utils.PlanItem{Action: utils.PlanCreate, Kind: "team", Name: team.Name}
+			// the org might only be created by provisioning
+			if orgID, err := resolveOrgID(team.OrgID, team.OrgName); err == nil {
+				item.OrgID = orgID
+				query := &models.SearchTeamsQuery{OrgId: orgID, Name: team.Name, Limit: 1}
+				if err := bus.Dispatch(query); err != nil {
+					return nil, err
+				}
+				if len(query.Result.Teams) > 0 {
+					item.Action = utils.PlanUpdate
+				}
+			}
+			plan = append(plan, item)
+		}
+
+		for _, permissions := range cfg.Permissions {
+			plan = append(plan, &utils.PlanItem{
+				Action: utils.PlanUpdate,
+				Kind:   permissionsKind(permissions) + " permissions",
+				Name:   permissions.FolderUID + permissions.DashboardUID,
+				OrgID:  permissions.OrgID,
+			})
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,202 @@
+package access
+
+import (
+	"regexp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// envVarReference matches values which are a single $VAR or ${VAR} reference to an environment variable,
+// so that no part of them is written in plain text.
+var envVarReference = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*|\{[A-Za-z_][A-Za-z0-9_]*\})$`)
+
+// accessAsConfig is normalized data object for access config data. Any config version
+// should be mappable to this type.
+type accessAsConfig struct {
+	Path        string
+	Orgs        []*orgFromConfig
+	Users       []*userFromConfig
+	Teams       []*teamFromConfig
+	Permissions []*permissionsFromConfig
+}
+
+type orgFromConfig struct {
+	Name string
+}
+
+type userFromConfig struct {
+	Login    string
+	Email    string
+	Name     string
+	Password string
+	// GrafanaAdmin is nil when the configuration leaves the Grafana admin permission untouched.
+	GrafanaAdmin *bool
+	Orgs         []*userOrgFromConfig
+
+	// passwordFromEnv is true when the password is read from an environment variable
+	// instead of being written in the provisioning file.
+	passwordFromEnv bool
+}
+
+type userOrgFromConfig struct {
+	OrgID   int64
+	OrgName string
+	Role    models.RoleType
+}
+
+type teamFromConfig struct {
+	OrgID   int64
+	OrgName string
+	Name    string
+	Email   string
+	Members []*teamMemberFromConfig
+}
+
+type teamMemberFromConfig struct {
+	// Login is the login or email of the user.
+	Login string
+	Admin bool
+}
+
+type permissionsFromConfig struct {
+	OrgID        int64
+	OrgName      string
+	FolderUID    string
+	DashboardUID string
+	Items        []*permissionItemFromConfig
+}
+
+type permissionItemFromConfig struct {
+	Role       string
+	Team       string
+	User       string
+	Permission string
+}
+
+// accessAsConfigV0 is mapping for zero version configs. This is mapped to its normalised version.
+type accessAsConfigV0 struct {
+	Orgs        []*orgFromConfigV0         `json:"orgs" yaml:"orgs"`
+	Users       []*userFromConfigV0        `json:"users" yaml:"users"`
+	Teams       []*teamFromConfigV0        `json:"teams" yaml:"teams"`
+	Permissions []*permissionsFromConfigV0 `json:"permissions" yaml:"permissions"`
+}
+
+type orgFromConfigV0 struct {
+	Name values.StringValue `json:"name" yaml:"name"`
+}
+
+type userFromConfigV0 struct {
+	Login        values.StringValue     `json:"login" yaml:"login"`
+	Email        values.StringValue     `json:"email" yaml:"email"`
+	Name         values.StringValue     `json:"name" yaml:"name"`
+	Password     values.StringValue     `json:"password" yaml:"password"`
+	GrafanaAdmin *values.BoolValue      `json:"grafana_admin" yaml:"grafana_admin"`
+	Orgs         []*userOrgFromConfigV0 `json:"orgs" yaml:"orgs"`
+}
+
+type userOrgFromConfigV0 struct {
+	OrgID   values.Int64Value  `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue `json:"org_name" yaml:"org_name"`
+	Role    values.StringValue `json:"role" yaml:"role"`
+}
+
+type teamFromConfigV0 struct {
+	OrgID   values.Int64Value         `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue        `json:"org_name" yaml:"org_name"`
+	Name    values.StringValue        `json:"name" yaml:"name"`
+	Email   values.StringValue        `json:"email" yaml:"email"`
+	Members []*teamMemberFromConfigV0 `json:"members" yaml:"members"`
+}
+
+type teamMemberFromConfigV0 struct {
+	Login values.StringValue `json:"login" yaml:"login"`
+	Admin values.BoolValue   `json:"admin" yaml:"admin"`
+}
+
+type permissionsFromConfigV0 struct {
+	OrgID        values.Int64Value             `json:"org_id" yaml:"org_id"`
+	OrgName      values.StringValue            `json:"org_name" yaml:"org_name"`
+	FolderUID    values.StringValue            `json:"folder_uid" yaml:"folder_uid"`
+	DashboardUID values.StringValue            `json:"dashboard_uid" yaml:"dashboard_uid"`
+	Items        []*permissionItemFromConfigV0 `json:"items" yaml:"items"`
+}
+
+type permissionItemFromConfigV0 struct {
+	Role       values.StringValue `json:"role" yaml:"role"`
+	Team       values.StringValue `json:"team" yaml:"team"`
+	User       values.StringValue `json:"user" yaml:"user"`
+	Permission values.StringValue `json:"permission" yaml:"permission"`
+}
+
+// mapToAccessFromConfig maps config syntax to normalized accessAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *accessAsConfigV0) mapToAccessFromConfig(path string) *accessAsConfig {
+	r := &accessAsConfig{Path: path}
+	if cfg == nil {
+		return r
+	}
+
+	for _, org := range cfg.Orgs {
+		r.Orgs = append(r.Orgs, &orgFromConfig{Name: org.Name.Value()})
+	}
+
+	for _, user := range cfg.Users {
+		u := &userFromConfig{
+			Login:    user.Login.Value(),
+			Email:    user.Email.Value(),
+			Name:     user.Name.Value(),
+			Password: user.Password.Value(),
+
+			passwordFromEnv: envVarReference.MatchString(user.Password.Raw),
+		}
+		if user.GrafanaAdmin != nil {
+			grafanaAdmin := user.GrafanaAdmin.Value()
+			u.GrafanaAdmin = &grafanaAdmin
+		}
+		for _, org := range user.Orgs {
+			u.Orgs = append(u.Orgs, &userOrgFromConfig{
+				OrgID:   org.OrgID.Value(),
+				OrgName: org.OrgName.Value(),
+				Role:    models.RoleType(org.Role.Value()),
+			})
+		}
+		r.Users = append(r.Users, u)
+	}
+
+	for _, team := range cfg.Teams {
+		t := &teamFromConfig{
+			OrgID:   team.OrgID.Value(),
+			OrgName: team.OrgName.Value(),
+			Name:    team.Name.Value(),
+			Email:   team.Email.Value(),
+		}
+		for _, member := range team.Members {
+			t.Members = append(t.Members, &teamMemberFromConfig{
+				Login: member.Login.Value(),
+				Admin: member.Admin.Value(),
+			})
+		}
+		r.Teams = append(r.Teams, t)
+	}
+
+	for _, permissions := range cfg.Permissions {
+		p := &permissionsFromConfig{
+			OrgID:        permissions.OrgID.Value(),
+			OrgName:      permissions.OrgName.Value(),
+			FolderUID:    permissions.FolderUID.Value(),
+			DashboardUID: permissions.DashboardUID.Value(),
+		}
+		for _, item := range permissions.Items {
+			p.Items = append(p.Items, &permissionItemFromConfig{
+				Role:       item.Role.Value(),
+				Team:       item.Team.Value(),
+				User:       item.User.Value(),
+				Permission: item.Permission.Value(),
+			})
+		}
+		r.Permissions = append(r.Permissions, p)
+	}
+
+	return r
+}
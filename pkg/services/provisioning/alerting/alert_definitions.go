@@ -0,0 +1,200 @@
+package alerting
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// Provision alert definitions
+func Provision(configDirectory string, st store.Store) error {
+	ap := newAlertDefinitionProvisioner(log.New("provisioning.alerting"), st)
+	return ap.applyChanges(configDirectory)
+}
+
+// AlertDefinitionProvisioner is responsible for provisioning alert definitions
+type AlertDefinitionProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+	store       store.Store
+}
+
+func newAlertDefinitionProvisioner(log log.Logger, st store.Store) AlertDefinitionProvisioner {
+	return AlertDefinitionProvisioner{
+		log:         log,
+		cfgProvider: &configReader{log: log},
+		store:       st,
+	}
+}
+
+func (ap *AlertDefinitionProvisioner) apply(cfg *alertDefinitionsAsConfig, provisioned map[ngmodels.AlertDefinitionKey]bool) error {
+	if err := ap.deleteAlertDefinitions(cfg.DeleteAlertDefinitions); err != nil {
+		return err
+	}
+
+	if err := ap.mergeAlertDefinitions(cfg, provisioned); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ap *AlertDefinitionProvisioner) deleteAlertDefinitions(definitionsToDelete []*deleteAlertDefinitionConfig) error {
+	for _, def := range definitionsToDelete {
+		ap.log.Info("Deleting alert definition", "uid", def.UID)
+
+		orgID, err := resolveOrgID(def.OrgID, def.OrgName)
+		if err != nil {
+			return err
+		}
+
+		cmd := &ngmodels.DeleteAlertDefinitionByUIDCommand{UID: def.UID, OrgID: orgID}
+		if err := ap.store.DeleteAlertDefinitionByUID(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ap *AlertDefinitionProvisioner) mergeAlertDefinitions(cfg *alertDefinitionsAsConfig, provisioned map[ngmodels.AlertDefinitionKey]bool) error {
+	provisionedQuery := &ngmodels.ListProvisionedAlertDefinitionsQuery{}
+	if err := ap.store.GetProvisionedAlertDefinitions(provisionedQuery); err != nil {
+		return err
+	}
+	checkSums := make(map[ngmodels.AlertDefinitionKey]string, len(provisionedQuery.Result))
+	for _, p := range provisionedQuery.Result {
+		checkSums[ngmodels.AlertDefinitionKey{OrgID: p.OrgID, DefinitionUID: p.AlertDefinitionUID}] = p.CheckSum
+	}
+
+	for _, def := range cfg.AlertDefinitions {
+		orgID, err := resolveOrgID(def.OrgID, def.OrgName)
+		if err != nil {
+			return err
+		}
+		def.OrgID = orgID
+		key := ngmodels.AlertDefinitionKey{OrgID: orgID, DefinitionUID: def.UID}
+		provisioned[key] = true
+
+		checkSum, err := def.checkSum()
+		if err != nil {
+			return err
+		}
+
+		queries, err := def.alertQueries()
+		if err != nil {
+			return err
+		}
+
+		var intervalSeconds *int64
+		if def.IntervalSeconds > 0 {
+			intervalSeconds = &def.IntervalSeconds
+		}
+
+		query := &ngmodels.GetAlertDefinitionByUIDQuery{UID: def.UID, OrgID: orgID}
+		err = ap.store.GetAlertDefinitionByUID(query)
+		switch {
+		case errors.Is(err, ngmodels.ErrAlertDefinitionNotFound):
+			ap.log.Debug("inserting alert definition from configuration", "uid", def.UID, "title", def.Title)
+			insertCmd := &ngmodels.SaveAlertDefinitionCommand{
+				UID:             def.UID,
+				OrgID:           orgID,
+				Title:           def.Title,
+				Condition:       def.Condition,
+				Data:            queries,
+				IntervalSeconds: intervalSeconds,
+			}
+			if err := ap.store.SaveAlertDefinition(insertCmd); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case checkSums[key] == checkSum:
+			ap.log.Debug("alert definition is up to date", "uid", def.UID)
+			continue
+		default:
+			ap.log.Debug("updating alert definition from configuration", "uid", def.UID, "title", def.Title)
+			updateCmd := &ngmodels.UpdateAlertDefinitionCommand{
+				UID:             def.UID,
+				OrgID:           orgID,
+				Title:           def.Title,
+				Condition:       def.Condition,
+				Data:            queries,
+				IntervalSeconds: intervalSeconds,
+			}
+			if err := ap.store.UpdateAlertDefinition(updateCmd); err != nil {
+				return err
+			}
+		}
+
+		saveCmd := &ngmodels.SaveProvisionedAlertDefinitionCommand{
+			OrgID:      orgID,
+			UID:        def.UID,
+			ExternalID: cfg.Path,
+			CheckSum:   checkSum,
+		}
+		if err := ap.store.SaveProvisionedAlertDefinition(saveCmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handleMissingAlertDefinitions deletes the alert definitions that were provisioned before
+// but are no longer part of any provisioning file.
+func (ap *AlertDefinitionProvisioner) handleMissingAlertDefinitions(provisioned map[ngmodels.AlertDefinitionKey]bool) error {
+	query := &ngmodels.ListProvisionedAlertDefinitionsQuery{}
+	if err := ap.store.GetProvisionedAlertDefinitions(query); err != nil {
+		return err
+	}
+
+	for _, p := range query.Result {
+		key := ngmodels.AlertDefinitionKey{OrgID: p.OrgID, DefinitionUID: p.AlertDefinitionUID}
+		if provisioned[key] {
+			continue
+		}
+
+		ap.log.Debug("deleting provisioned alert definition, missing in configuration", "uid", p.AlertDefinitionUID, "path", p.ExternalID)
+		cmd := &ngmodels.DeleteAlertDefinitionByUIDCommand{UID: p.AlertDefinitionUID, OrgID: p.OrgID}
+		if err := ap.store.DeleteAlertDefinitionByUID(cmd); err != nil {
+			ap.log.Error("failed to delete alert definition", "uid", p.AlertDefinitionUID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (ap *AlertDefinitionProvisioner) applyChanges(configPath string) error {
+	configs, err := ap.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	provisioned := make(map[ngmodels.AlertDefinitionKey]bool)
+	for _, cfg := range configs {
+		if err := ap.apply(cfg, provisioned); err != nil {
+			return err
+		}
+	}
+
+	return ap.handleMissingAlertDefinitions(provisioned)
+}
+
+func resolveOrgID(orgID int64, orgName string) (int64, error) {
+	if orgID == 0 && orgName != "" {
+		getOrg := &models.GetOrgByNameQuery{Name: orgName}
+		if err := bus.Dispatch(getOrg); err != nil {
+			return 0, err
+		}
+		return getOrg.Result.Id, nil
+	}
+	if orgID < 0 {
+		return 1, nil
+	}
+	return orgID, nil
+}
@@ -0,0 +1,116 @@
+package alerting
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type fakeAlertDefinitionStore struct {
+	store.Store
+	definitions map[ngmodels.AlertDefinitionKey]*ngmodels.AlertDefinition
+	provisioned map[ngmodels.AlertDefinitionKey]*ngmodels.AlertDefinitionProvisioning
+	saved       int
+	updated     int
+	deleted     []string
+}
+
+func newFakeAlertDefinitionStore() *fakeAlertDefinitionStore {
+	return &fakeAlertDefinitionStore{
+		definitions: map[ngmodels.AlertDefinitionKey]*ngmodels.AlertDefinition{},
+		provisioned: map[ngmodels.AlertDefinitionKey]*ngmodels.AlertDefinitionProvisioning{},
+	}
+}
+
+func (f *fakeAlertDefinitionStore) GetAlertDefinitionByUID(query *ngmodels.GetAlertDefinitionByUIDQuery) error {
+	def, ok := f.definitions[ngmodels.AlertDefinitionKey{OrgID: query.OrgID, DefinitionUID: query.UID}]
+	if !ok {
+		return ngmodels.ErrAlertDefinitionNotFound
+	}
+	query.Result = def
+	return nil
+}
+
+func (f *fakeAlertDefinitionStore) SaveAlertDefinition(cmd *ngmodels.SaveAlertDefinitionCommand) error {
+	f.saved++
+	f.definitions[ngmodels.AlertDefinitionKey{OrgID: cmd.OrgID, DefinitionUID: cmd.UID}] = &ngmodels.AlertDefinition{
+		OrgID: cmd.OrgID, UID: cmd.UID, Title: cmd.Title,
+	}
+	return nil
+}
+
+func (f *fakeAlertDefinitionStore) UpdateAlertDefinition(cmd *ngmodels.UpdateAlertDefinitionCommand) error {
+	f.updated++
+	f.definitions[ngmodels.AlertDefinitionKey{OrgID: cmd.OrgID, DefinitionUID: cmd.UID}].Title = cmd.Title
+	return nil
+}
+
+func (f *fakeAlertDefinitionStore) DeleteAlertDefinitionByUID(cmd *ngmodels.DeleteAlertDefinitionByUIDCommand) error {
+	key := ngmodels.AlertDefinitionKey{OrgID: cmd.OrgID, DefinitionUID: cmd.UID}
+	f.deleted = append(f.deleted, cmd.UID)
+	delete(f.definitions, key)
+	delete(f.provisioned, key)
+	return nil
+}
+
+func (f *fakeAlertDefinitionStore) GetProvisionedAlertDefinitions(query *ngmodels.ListProvisionedAlertDefinitionsQuery) error {
+	query.Result = nil
+	for _, p := range f.provisioned {
+		query.Result = append(query.Result, p)
+	}
+	return nil
+}
+
+func (f *fakeAlertDefinitionStore) SaveProvisionedAlertDefinition(cmd *ngmodels.SaveProvisionedAlertDefinitionCommand) error {
+	f.provisioned[ngmodels.AlertDefinitionKey{OrgID: cmd.OrgID, DefinitionUID: cmd.UID}] = &ngmodels.AlertDefinitionProvisioning{
+		OrgID: cmd.OrgID, AlertDefinitionUID: cmd.UID, ExternalID: cmd.ExternalID, CheckSum: cmd.CheckSum,
+	}
+	return nil
+}
+
+func TestAlertDefinitionProvisioner(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	require.NoError(t, sqlstore.CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+	require.NoError(t, os.Setenv("TEST_TITLE", "CPU usage"))
+	t.Cleanup(func() { _ = os.Unsetenv("TEST_TITLE") })
+
+	st := newFakeAlertDefinitionStore()
+	st.provisioned[ngmodels.AlertDefinitionKey{OrgID: 1, DefinitionUID: "removed-from-file"}] = &ngmodels.AlertDefinitionProvisioning{
+		OrgID: 1, AlertDefinitionUID: "removed-from-file",
+	}
+	ap := newAlertDefinitionProvisioner(log.New("test logger"), st)
+
+	t.Run("Creates new definitions and removes the ones missing from the files", func(t *testing.T) {
+		require.NoError(t, ap.applyChanges(correctProperties))
+
+		assert.Equal(t, 1, st.saved)
+		assert.Equal(t, 0, st.updated)
+		assert.ElementsMatch(t, []string{"old-cpu-usage", "removed-from-file"}, st.deleted)
+		require.Len(t, st.provisioned, 1)
+		assert.Equal(t, "CPU usage", st.definitions[ngmodels.AlertDefinitionKey{OrgID: 1, DefinitionUID: "cpu-usage"}].Title)
+	})
+
+	t.Run("Skips unchanged definitions", func(t *testing.T) {
+		require.NoError(t, ap.applyChanges(correctProperties))
+
+		assert.Equal(t, 1, st.saved)
+		assert.Equal(t, 0, st.updated)
+	})
+
+	t.Run("Updates changed definitions", func(t *testing.T) {
+		require.NoError(t, os.Setenv("TEST_TITLE", "CPU load"))
+		require.NoError(t, ap.applyChanges(correctProperties))
+
+		assert.Equal(t, 1, st.saved)
+		assert.Equal(t, 1, st.updated)
+		assert.Equal(t, "CPU load", st.definitions[ngmodels.AlertDefinitionKey{OrgID: 1, DefinitionUID: "cpu-usage"}].Title)
+	})
+}
@@ -0,0 +1,183 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*alertDefinitionsAsConfig, error) {
+	var definitions []*alertDefinitionsAsConfig
+	cr.log.Debug("Looking for alerting provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read alerting provisioning files from directory", "path", path, "error", err)
+		return definitions, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing alerting provisioning file", "path", path, "file.Name", file.Name())
+			defs, err := cr.parseAlertingConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if defs != nil {
+				definitions = append(definitions, defs)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating alert definitions")
+	if err = validateRequiredField(definitions); err != nil {
+		return nil, err
+	}
+
+	if err := checkOrgIDAndOrgName(definitions); err != nil {
+		return nil, err
+	}
+
+	if err := validateUniqueUIDs(definitions); err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+func (cr *configReader) parseAlertingConfig(path string, file os.FileInfo) (*alertDefinitionsAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *alertDefinitionsAsConfigV0
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToAlertDefinitionsFromConfig(filename), nil
+}
+
+func checkOrgIDAndOrgName(definitions []*alertDefinitionsAsConfig) error {
+	for i := range definitions {
+		for _, def := range definitions[i].AlertDefinitions {
+			if def.OrgID < 1 {
+				if def.OrgName == "" {
+					def.OrgID = 1
+				} else {
+					def.OrgID = 0
+				}
+			} else {
+				if err := utils.CheckOrgExists(def.OrgID); err != nil {
+					return fmt.Errorf("failed to provision %q alert definition: %w", def.UID, err)
+				}
+			}
+		}
+
+		for _, def := range definitions[i].DeleteAlertDefinitions {
+			if def.OrgID < 1 {
+				if def.OrgName == "" {
+					def.OrgID = 1
+				} else {
+					def.OrgID = 0
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateRequiredField(definitions []*alertDefinitionsAsConfig) error {
+	for i := range definitions {
+		var errStrings []string
+		for index, def := range definitions[i].AlertDefinitions {
+			if def.UID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added alert definition item %d in configuration doesn't contain required field uid", index+1),
+				)
+			} else if !util.IsValidShortUID(def.UID) {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added alert definition item %d in configuration has invalid uid %q", index+1, def.UID),
+				)
+			}
+
+			if def.Title == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added alert definition item %d in configuration doesn't contain required field title", index+1),
+				)
+			}
+
+			if def.Condition == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added alert definition item %d in configuration doesn't contain required field condition", index+1),
+				)
+			}
+
+			if len(def.Data) == 0 {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Added alert definition item %d in configuration doesn't contain required field data", index+1),
+				)
+			}
+		}
+
+		for index, def := range definitions[i].DeleteAlertDefinitions {
+			if def.UID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("Deleted alert definition item %d in configuration doesn't contain required field uid", index+1),
+				)
+			}
+		}
+
+		if len(errStrings) != 0 {
+			return errors.New(strings.Join(errStrings, "\n"))
+		}
+	}
+
+	return nil
+}
+
+func validateUniqueUIDs(definitions []*alertDefinitionsAsConfig) error {
+	type key struct {
+		orgID   int64
+		orgName string
+		uid     string
+	}
+
+	seen := make(map[key]string)
+	for i := range definitions {
+		for _, def := range definitions[i].AlertDefinitions {
+			k := key{orgID: def.OrgID, orgName: def.OrgName, uid: def.UID}
+			if path, exists := seen[k]; exists {
+				return fmt.Errorf("alert definition %q is provisioned by both %q and %q", def.UID, path, definitions[i].Path)
+			}
+			seen[k] = definitions[i].Path
+		}
+	}
+
+	return nil
+}
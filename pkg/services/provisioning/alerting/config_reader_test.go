@@ -0,0 +1,97 @@
+package alerting
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	noRequiredFields  = "./testdata/test-configs/no-required-fields"
+	duplicateUIDs     = "./testdata/test-configs/duplicate-uids"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestAlertDefinitionsAsConfig(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	require.NoError(t, sqlstore.CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+
+	cfgProvider := &configReader{log: log.New("test logger")}
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		require.NoError(t, os.Setenv("TEST_TITLE", "CPU usage"))
+		t.Cleanup(func() { _ = os.Unsetenv("TEST_TITLE") })
+
+		cfg, err := cfgProvider.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		defs := cfg[0].AlertDefinitions
+		require.Len(t, defs, 1)
+		def := defs[0]
+		assert.Equal(t, "cpu-usage", def.UID)
+		assert.Equal(t, int64(1), def.OrgID)
+		assert.Equal(t, "CPU usage", def.Title)
+		assert.Equal(t, "B", def.Condition)
+		assert.Equal(t, int64(60), def.IntervalSeconds)
+		require.Len(t, def.Data, 2)
+
+		queries, err := def.alertQueries()
+		require.NoError(t, err)
+		require.Len(t, queries, 2)
+		assert.Equal(t, "A", queries[0].RefID)
+		assert.Equal(t, "B", queries[1].RefID)
+		assert.JSONEq(t, `{"datasourceUid":"-100","type":"math","expression":"$A > 80"}`, string(queries[1].Model))
+
+		toDelete := cfg[0].DeleteAlertDefinitions
+		require.Len(t, toDelete, 1)
+		assert.Equal(t, "old-cpu-usage", toDelete[0].UID)
+		assert.Equal(t, int64(1), toDelete[0].OrgID)
+	})
+
+	t.Run("Checksum changes with the definition", func(t *testing.T) {
+		def := &alertDefinitionFromConfig{UID: "cpu-usage", Title: "CPU usage"}
+		sum, err := def.checkSum()
+		require.NoError(t, err)
+
+		def.Title = "CPU"
+		changed, err := def.checkSum()
+		require.NoError(t, err)
+		assert.NotEqual(t, sum, changed)
+	})
+
+	t.Run("Missing required fields should fail", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(noRequiredFields)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "item 1 in configuration doesn't contain required field uid")
+		assert.Contains(t, err.Error(), "item 1 in configuration doesn't contain required field title")
+		assert.Contains(t, err.Error(), `item 2 in configuration has invalid uid "invalid uid"`)
+		assert.Contains(t, err.Error(), "item 2 in configuration doesn't contain required field data")
+		assert.Contains(t, err.Error(), "Deleted alert definition item 1 in configuration doesn't contain required field uid")
+	})
+
+	t.Run("Duplicate uids across files should fail", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(duplicateUIDs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `alert definition "cpu-usage" is provisioned by both`)
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Empty folder should return empty configuration", func(t *testing.T) {
+		cfg, err := cfgProvider.readConfig(emptyFolder)
+		require.NoError(t, err)
+		assert.Empty(t, cfg)
+	})
+}
@@ -0,0 +1,102 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and returns the changes
+// provisioning them would make, without making them.
+func Plan(configDirectory string, st store.Store) ([]*utils.PlanItem, error) {
+	cr := &configReader{log: log.New("provisioning.alerting")}
+	configs, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	provisionedQuery := &ngmodels.ListProvisionedAlertDefinitionsQuery{}
+	if err := st.GetProvisionedAlertDefinitions(provisionedQuery); err != nil {
+		return nil, err
+	}
+	checkSums := make(map[ngmodels.AlertDefinitionKey]string, len(provisionedQuery.Result))
+	for _, p := range provisionedQuery.Result {
+		checkSums[ngmodels.AlertDefinitionKey{OrgID: p.OrgID, DefinitionUID: p.AlertDefinitionUID}] = p.CheckSum
+	}
+
+	var plan []*utils.PlanItem
+	provisioned := make(map[ngmodels.AlertDefinitionKey]bool)
+	for _, cfg := range configs {
+		for _, def := range cfg.DeleteAlertDefinitions {
+			orgID, err := resolveOrgID(def.OrgID, def.OrgName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete %q alert definition: %w", def.UID, err)
+			}
+
+			existing, err := getAlertDefinition(st, orgID, def.UID)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				delete(checkSums, ngmodels.AlertDefinitionKey{OrgID: orgID, DefinitionUID: def.UID})
+				plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "alert definition", Name: existing.Title, OrgID: orgID})
+			}
+		}
+
+		for _, def := range cfg.AlertDefinitions {
+			orgID, err := resolveOrgID(def.OrgID, def.OrgName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to provision %q alert definition: %w", def.UID, err)
+			}
+			key := ngmodels.AlertDefinitionKey{OrgID: orgID, DefinitionUID: def.UID}
+			provisioned[key] = true
+
+			if _, err := def.alertQueries(); err != nil {
+				return nil, err
+			}
+			checkSum, err := def.checkSum()
+			if err != nil {
+				return nil, err
+			}
+
+			existing, err := getAlertDefinition(st, orgID, def.UID)
+			if err != nil {
+				return nil, err
+			}
+
+			action := utils.PlanCreate
+			if existing != nil {
+				action = utils.PlanUpdate
+				if checkSums[key] == checkSum {
+					action = utils.PlanUnchanged
+				}
+			}
+			plan = append(plan, &utils.PlanItem{Action: action, Kind: "alert definition", Name: def.Title, OrgID: orgID})
+		}
+	}
+
+	for _, p := range provisionedQuery.Result {
+		key := ngmodels.AlertDefinitionKey{OrgID: p.OrgID, DefinitionUID: p.AlertDefinitionUID}
+		if _, ok := checkSums[key]; ok && !provisioned[key] {
+			plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "alert definition", Name: p.AlertDefinitionUID, OrgID: p.OrgID})
+		}
+	}
+
+	return plan, nil
+}
+
+func getAlertDefinition(st store.Store, orgID int64, uid string) (*ngmodels.AlertDefinition, error) {
+	query := &ngmodels.GetAlertDefinitionByUIDQuery{UID: uid, OrgID: orgID}
+	err := st.GetAlertDefinitionByUID(query)
+	if errors.Is(err, ngmodels.ErrAlertDefinitionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
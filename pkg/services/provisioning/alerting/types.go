@@ -0,0 +1,123 @@
+package alerting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// alertDefinitionsAsConfig is normalized data object for alert definitions config data. Any config version
+// should be mappable to this type.
+type alertDefinitionsAsConfig struct {
+	Path                   string
+	AlertDefinitions       []*alertDefinitionFromConfig
+	DeleteAlertDefinitions []*deleteAlertDefinitionConfig
+}
+
+type deleteAlertDefinitionConfig struct {
+	UID     string
+	OrgID   int64
+	OrgName string
+}
+
+type alertDefinitionFromConfig struct {
+	UID             string
+	OrgID           int64
+	OrgName         string
+	Title           string
+	Condition       string
+	IntervalSeconds int64
+	Data            []map[string]interface{}
+}
+
+// alertDefinitionsAsConfigV0 is mapping for zero version configs. This is mapped to its normalised version.
+type alertDefinitionsAsConfigV0 struct {
+	AlertDefinitions       []*alertDefinitionFromConfigV0   `json:"alert_definitions" yaml:"alert_definitions"`
+	DeleteAlertDefinitions []*deleteAlertDefinitionConfigV0 `json:"delete_alert_definitions" yaml:"delete_alert_definitions"`
+}
+
+type deleteAlertDefinitionConfigV0 struct {
+	UID     values.StringValue `json:"uid" yaml:"uid"`
+	OrgID   values.Int64Value  `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue `json:"org_name" yaml:"org_name"`
+}
+
+type alertDefinitionFromConfigV0 struct {
+	UID             values.StringValue `json:"uid" yaml:"uid"`
+	OrgID           values.Int64Value  `json:"org_id" yaml:"org_id"`
+	OrgName         values.StringValue `json:"org_name" yaml:"org_name"`
+	Title           values.StringValue `json:"title" yaml:"title"`
+	Condition       values.StringValue `json:"condition" yaml:"condition"`
+	IntervalSeconds values.Int64Value  `json:"interval_seconds" yaml:"interval_seconds"`
+	Data            []values.JSONValue `json:"data" yaml:"data"`
+}
+
+// alertQueries converts the data of the alert definition to alert queries.
+func (def *alertDefinitionFromConfig) alertQueries() ([]models.AlertQuery, error) {
+	queries := make([]models.AlertQuery, 0, len(def.Data))
+	for i, d := range def.Data {
+		raw, err := json.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+
+		var query models.AlertQuery
+		if err := json.Unmarshal(raw, &query); err != nil {
+			return nil, fmt.Errorf("alert definition %q has invalid query %d: %w", def.UID, i+1, err)
+		}
+		queries = append(queries, query)
+	}
+
+	return queries, nil
+}
+
+// checkSum returns a checksum of the alert definition which is used to detect changes.
+func (def *alertDefinitionFromConfig) checkSum() (string, error) {
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// mapToAlertDefinitionsFromConfig maps config syntax to normalized alertDefinitionsAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *alertDefinitionsAsConfigV0) mapToAlertDefinitionsFromConfig(path string) *alertDefinitionsAsConfig {
+	r := &alertDefinitionsAsConfig{Path: path}
+	if cfg == nil {
+		return r
+	}
+
+	for _, def := range cfg.AlertDefinitions {
+		data := make([]map[string]interface{}, 0, len(def.Data))
+		for _, d := range def.Data {
+			data = append(data, d.Value())
+		}
+
+		r.AlertDefinitions = append(r.AlertDefinitions, &alertDefinitionFromConfig{
+			UID:             def.UID.Value(),
+			OrgID:           def.OrgID.Value(),
+			OrgName:         def.OrgName.Value(),
+			Title:           def.Title.Value(),
+			Condition:       def.Condition.Value(),
+			IntervalSeconds: def.IntervalSeconds.Value(),
+			Data:            data,
+		})
+	}
+
+	for _, def := range cfg.DeleteAlertDefinitions {
+		r.DeleteAlertDefinitions = append(r.DeleteAlertDefinitions, &deleteAlertDefinitionConfig{
+			UID:     def.UID.Value(),
+			OrgID:   def.OrgID.Value(),
+			OrgName: def.OrgName.Value(),
+		})
+	}
+
+	return r
+}
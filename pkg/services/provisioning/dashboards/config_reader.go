@@ -102,6 +102,10 @@ func (cr *configReader) readConfig() ([]*config, error) {
 
 		if dashboard.UpdateIntervalSeconds == 0 {
 			dashboard.UpdateIntervalSeconds = 10
+			if dashboard.Type == "git" {
+				// avoid hammering remote repositories
+				dashboard.UpdateIntervalSeconds = 60
+			}
 		}
 		if len(dashboard.FolderUID) > 0 {
 			uidUsage[dashboard.FolderUID]++
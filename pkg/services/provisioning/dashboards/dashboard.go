@@ -21,8 +21,9 @@ type DashboardProvisioner interface {
 	CleanUpOrphanedDashboards()
 }
 
-// DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(string) (DashboardProvisioner, error)
+// DashboardProvisionerFactory creates DashboardProvisioners from the directory of their configuration files
+// and the data directory of Grafana
+type DashboardProvisionerFactory func(configDirectory, dataPath string) (DashboardProvisioner, error)
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -31,8 +32,8 @@ type Provisioner struct {
 	configs     []*config
 }
 
-// New returns a new DashboardProvisioner
-func New(configDirectory string) (*Provisioner, error) {
+// New returns a new DashboardProvisioner. The git providers keep their checkouts in dataPath.
+func New(configDirectory, dataPath string) (*Provisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger}
 	configs, err := cfgReader.readConfig()
@@ -41,7 +42,7 @@ func New(configDirectory string) (*Provisioner, error) {
 		return nil, errutil.Wrap("Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger)
+	fileReaders, err := getFileReaders(configs, dataPath, logger)
 	if err != nil {
 		return nil, errutil.Wrap("Failed to initialize file readers", err)
 	}
@@ -115,7 +116,7 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 	return false
 }
 
-func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error) {
+func getFileReaders(configs []*config, dataPath string, logger log.Logger) ([]*FileReader, error) {
 	var readers []*FileReader
 
 	for _, config := range configs {
@@ -126,6 +127,12 @@ func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error)
 				return nil, errutil.Wrapf(err, "Failed to create file reader for config %v", config.Name)
 			}
 			readers = append(readers, fileReader)
+		case "git":
+			gitReader, err := NewDashboardGitReader(config, dataPath, logger.New("type", config.Type, "name", config.Name))
+			if err != nil {
+				return nil, errutil.Wrapf(err, "Failed to create git reader for config %v", config.Name)
+			}
+			readers = append(readers, gitReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
@@ -32,6 +32,8 @@ type FileReader struct {
 	log                          log.Logger
 	dashboardProvisioningService dashboards.DashboardProvisioningService
 	FoldersFromFilesStructure    bool
+	// repository is the git repository the dashboards are read from, nil for file readers.
+	repository *gitRepository
 }
 
 // NewDashboardFileReader returns a new filereader based on `config`
@@ -80,6 +82,16 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 // and applies any change to the database.
 func (fr *FileReader) walkDisk() error {
 	fr.log.Debug("Start walking disk", "path", fr.Path)
+	if fr.repository != nil {
+		fr.syncRepository()
+
+		// a failed clone doesn't stop the provisioning, the next poll tries again
+		if !fr.repository.cloned() {
+			fr.log.Warn("Dashboards repository is not cloned yet, retrying on next poll", "url", fr.repository.redactedURL())
+			return nil
+		}
+	}
+
 	resolvedPath := fr.resolvedPath()
 	if _, err := os.Stat(resolvedPath); err != nil {
 		return err
@@ -114,6 +126,22 @@ func (fr *FileReader) walkDisk() error {
 	return nil
 }
 
+// syncRepository updates the local checkout of the git repository. If that fails the
+// dashboards of the previous checkout are kept.
+func (fr *FileReader) syncRepository() {
+	if err := fr.repository.sync(); err != nil {
+		fr.log.Error("Failed to sync dashboards repository", "url", fr.repository.redactedURL(), "error", err)
+		return
+	}
+
+	revision, err := fr.repository.revision()
+	if err != nil {
+		fr.log.Warn("Failed to read revision of dashboards repository", "error", err)
+		return
+	}
+	fr.log.Debug("Synced dashboards repository", "url", fr.repository.redactedURL(), "revision", revision)
+}
+
 // storeDashboardsInFolder saves dashboards from the filesystem on disk to the folder from config
 func (fr *FileReader) storeDashboardsInFolder(filesFoundOnDisk map[string]os.FileInfo,
 	dashboardRefs map[string]*models.DashboardProvisioning, sanityChecker *provisioningSanityChecker) error {
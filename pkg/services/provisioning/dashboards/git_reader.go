@@ -0,0 +1,210 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// gitCommandTimeout is the maximum time a single git command may take.
+const gitCommandTimeout = 2 * time.Minute
+
+// gitRepository keeps a local checkout of a branch of a remote git repository
+// up to date, using the git command line client.
+type gitRepository struct {
+	URL        string
+	Branch     string
+	Username   string
+	Password   string
+	SSHKeyFile string
+	// CheckoutPath is the local directory the repository is cloned into.
+	CheckoutPath string
+	log          log.Logger
+}
+
+// NewDashboardGitReader returns a new file reader which reads dashboards from a
+// local checkout of the git repository defined in `config`. The checkout is updated
+// every time the reader walks the disk, and is kept under dataPath unless the
+// config sets its path.
+func NewDashboardGitReader(cfg *config, dataPath string, log log.Logger) (*FileReader, error) {
+	repoURL, _ := cfg.Options["url"].(string)
+	if repoURL == "" {
+		return nil, fmt.Errorf("failed to load dashboards, url param is missing or not a string")
+	}
+
+	repo := &gitRepository{
+		URL:          repoURL,
+		Branch:       optionString(cfg.Options, "branch"),
+		Username:     optionString(cfg.Options, "username"),
+		Password:     optionString(cfg.Options, "password"),
+		SSHKeyFile:   optionString(cfg.Options, "sshKeyFile"),
+		CheckoutPath: optionString(cfg.Options, "checkoutPath"),
+		log:          log,
+	}
+	if repo.CheckoutPath == "" {
+		repo.CheckoutPath = filepath.Join(dataPath, "provisioning", "dashboards", models.SlugifyTitle(cfg.Name))
+	}
+
+	// path is the directory inside the repository containing the dashboards.
+	subPath := filepath.Clean("/" + optionString(cfg.Options, "path"))
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	return &FileReader{
+		Cfg:                          cfg,
+		Path:                         filepath.Join(repo.CheckoutPath, subPath),
+		log:                          log,
+		dashboardProvisioningService: dashboards.NewProvisioningService(),
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		repository:                   repo,
+	}, nil
+}
+
+// sync clones the repository if there is no local checkout of it yet, otherwise it fetches
+// the branch and resets the checkout to it, discarding any local changes.
+func (r *gitRepository) sync() error {
+	if !r.cloned() {
+		return r.clone()
+	}
+	if !r.checkoutMatches() {
+		r.log.Info("Dashboards repository or branch changed, cloning it again", "url", r.redactedURL(), "branch", r.Branch)
+		return r.clone()
+	}
+
+	ref := "HEAD"
+	if r.Branch != "" {
+		ref = r.Branch
+	}
+
+	if _, err := r.run(r.CheckoutPath, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+	if _, err := r.run(r.CheckoutPath, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	_, err := r.run(r.CheckoutPath, "clean", "-fdx")
+	return err
+}
+
+// cloned returns whether there is a local checkout.
+func (r *gitRepository) cloned() bool {
+	_, err := os.Stat(filepath.Join(r.CheckoutPath, ".git"))
+	return err == nil
+}
+
+// checkoutMatches returns whether the local checkout is a clone of the configured URL and
+// branch. Checkouts of the default branch match any branch, since its name isn't configured.
+func (r *gitRepository) checkoutMatches() bool {
+	originURL, err := r.run(r.CheckoutPath, "config", "--get", "remote.origin.url")
+	if err != nil || strings.TrimSpace(originURL) != r.URL {
+		return false
+	}
+	if r.Branch == "" {
+		return true
+	}
+
+	branch, err := r.run(r.CheckoutPath, "rev-parse", "--abbrev-ref", "HEAD")
+	return err == nil && strings.TrimSpace(branch) == r.Branch
+}
+
+func (r *gitRepository) clone() error {
+	r.log.Info("Cloning dashboards repository", "url", r.redactedURL(), "branch", r.Branch, "path", r.CheckoutPath)
+
+	// remove leftovers of a failed clone, git refuses to clone into non-empty directories
+	if err := os.RemoveAll(r.CheckoutPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.CheckoutPath), 0750); err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--depth", "1", "--single-branch"}
+	if r.Branch != "" {
+		args = append(args, "--branch", r.Branch)
+	}
+	args = append(args, "--", r.URL, r.CheckoutPath)
+
+	_, err := r.run("", args...)
+	return err
+}
+
+// revision returns the commit hash of the local checkout.
+func (r *gitRepository) revision() (string, error) {
+	out, err := r.run(r.CheckoutPath, "rev-parse", "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+func (r *gitRepository) run(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	// nolint:gosec
+	// The arguments come from the provisioning configuration file.
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), r.env()...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := strings.ReplaceAll(strings.TrimSpace(stderr.String()), r.URL, r.redactedURL())
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, output)
+	}
+
+	return stdout.String(), nil
+}
+
+// env returns the environment for git commands. Credentials are passed as
+// configuration through the environment so they are neither stored in the
+// checkout nor visible in the process list. The GIT_CONFIG_COUNT variables
+// require git 2.31 or later, older versions ignore them.
+func (r *gitRepository) env() []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+
+	if r.Username != "" || r.Password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(r.Username + ":" + r.Password))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+
+	if r.SSHKeyFile != "" {
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o IdentitiesOnly=yes", r.SSHKeyFile))
+	}
+
+	return env
+}
+
+// redactedURL returns the repository URL without the credentials it may embed, for logging.
+func (r *gitRepository) redactedURL() string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.User == nil {
+		return r.URL
+	}
+
+	u.User = url.User("redacted")
+	return u.String()
+}
+
+func optionString(options map[string]interface{}, key string) string {
+	value, _ := options[key].(string)
+	return value
+}
@@ -0,0 +1,172 @@
+package dashboards
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and the dashboard files of
+// their providers, and returns the changes provisioning them would make, without making them.
+// Repositories of git providers are cloned to a temporary directory.
+func Plan(configDirectory string) ([]*utils.PlanItem, error) {
+	logger := log.New("provisioning.dashboard")
+	cr := &configReader{path: configDirectory, log: logger}
+	configs, err := cr.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*utils.PlanItem
+	var errStrings []string
+	for _, cfg := range configs {
+		items, err := planProvider(cfg, logger.New("type", cfg.Type, "name", cfg.Name))
+		if err != nil {
+			errStrings = append(errStrings, fmt.Sprintf("provider %q: %s", cfg.Name, err))
+			continue
+		}
+		plan = append(plan, items...)
+	}
+
+	if len(errStrings) != 0 {
+		return nil, errors.New(strings.Join(errStrings, "\n"))
+	}
+
+	return plan, nil
+}
+
+func planProvider(cfg *config, logger log.Logger) ([]*utils.PlanItem, error) {
+	var reader *FileReader
+	var err error
+	switch cfg.Type {
+	case "file":
+		reader, err = NewDashboardFileReader(cfg, logger)
+	case "git":
+		checkoutPath, err := ioutil.TempDir("", "grafana-provisioning-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := os.RemoveAll(checkoutPath); err != nil {
+				logger.Warn("Failed to remove temporary checkout", "path", checkoutPath, "error", err)
+			}
+		}()
+
+		planCfg := *cfg
+		planCfg.Options = make(map[string]interface{}, len(cfg.Options)+1)
+		for k, v := range cfg.Options {
+			planCfg.Options[k] = v
+		}
+		planCfg.Options["checkoutPath"] = filepath.Join(checkoutPath, "repository")
+
+		reader, err = NewDashboardGitReader(&planCfg, "", logger)
+		if err != nil {
+			return nil, err
+		}
+		if err := reader.repository.sync(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("type %s is not supported", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.plan()
+}
+
+// plan reads the dashboard files of the reader and compares them with the
+// dashboards it provisioned before.
+func (fr *FileReader) plan() ([]*utils.PlanItem, error) {
+	resolvedPath := fr.resolvedPath()
+	if _, err := os.Stat(resolvedPath); err != nil {
+		return nil, err
+	}
+
+	provisionedDashboardRefs, err := getProvisionedDashboardsByPath(fr.dashboardProvisioningService, fr.Cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	filesFoundOnDisk := map[string]os.FileInfo{}
+	if err := filepath.Walk(resolvedPath, createWalkFn(filesFoundOnDisk)); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(filesFoundOnDisk))
+	for path := range filesFoundOnDisk {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var plan []*utils.PlanItem
+	var errStrings []string
+	folders := make(map[string]bool)
+	for _, path := range paths {
+		folderName := fr.Cfg.Folder
+		if fr.FoldersFromFilesStructure {
+			folderName = ""
+			if dir := filepath.Dir(path); dir != resolvedPath {
+				folderName = filepath.Base(dir)
+			}
+		}
+		if folderName != "" && !folders[folderName] {
+			folders[folderName] = true
+			exists, err := folderExists(fr.Cfg.OrgID, folderName)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				plan = append(plan, &utils.PlanItem{Action: utils.PlanCreate, Kind: "folder", Name: folderName, OrgID: fr.Cfg.OrgID})
+			}
+		}
+
+		jsonFile, err := fr.readDashboardFromFile(path, filesFoundOnDisk[path].ModTime(), 0)
+		if err != nil {
+			errStrings = append(errStrings, fmt.Sprintf("invalid dashboard file %s: %s", path, err))
+			continue
+		}
+
+		action := utils.PlanCreate
+		if provisioned, ok := provisionedDashboardRefs[path]; ok {
+			action = utils.PlanUpdate
+			if provisioned.CheckSum == jsonFile.checkSum {
+				action = utils.PlanUnchanged
+			}
+		}
+		plan = append(plan, &utils.PlanItem{Action: action, Kind: "dashboard", Name: jsonFile.dashboard.Dashboard.Title, OrgID: fr.Cfg.OrgID})
+	}
+
+	if len(errStrings) != 0 {
+		return nil, errors.New(strings.Join(errStrings, "\n"))
+	}
+
+	if !fr.Cfg.DisableDeletion {
+		for path := range provisionedDashboardRefs {
+			if _, exists := filesFoundOnDisk[path]; !exists {
+				plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "dashboard", Name: path, OrgID: fr.Cfg.OrgID})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func folderExists(orgID int64, folderName string) (bool, error) {
+	query := &models.GetDashboardQuery{Slug: models.SlugifyTitle(folderName), OrgId: orgID}
+	err := bus.Dispatch(query)
+	if errors.Is(err, models.ErrDashboardNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
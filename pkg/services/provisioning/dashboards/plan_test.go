@@ -0,0 +1,70 @@
+package dashboards
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+func TestFileReaderPlan(t *testing.T) {
+	bus.ClearBusHandlers()
+	origNewDashboardProvisioningService := dashboards.NewProvisioningService
+	t.Cleanup(func() { dashboards.NewProvisioningService = origNewDashboardProvisioningService })
+	fakeService = mockDashboardProvisioningService()
+	bus.AddHandler("test", mockGetDashboardQuery)
+
+	cfg := &config{
+		Name:    "Default",
+		Type:    "file",
+		OrgID:   1,
+		Folder:  "Team A",
+		Options: map[string]interface{}{"path": oneDashboard},
+	}
+
+	reader, err := NewDashboardFileReader(cfg, log.New("test.logger"))
+	require.NoError(t, err)
+
+	t.Run("Plans new dashboards and folders", func(t *testing.T) {
+		plan, err := reader.plan()
+		require.NoError(t, err)
+		assert.Equal(t, []*utils.PlanItem{
+			{Action: utils.PlanCreate, Kind: "folder", Name: "Team A", OrgID: 1},
+			{Action: utils.PlanCreate, Kind: "dashboard", Name: "Grafana", OrgID: 1},
+		}, plan)
+		assert.Empty(t, fakeService.inserted)
+	})
+
+	t.Run("Plans unchanged and deleted dashboards", func(t *testing.T) {
+		fakeService.getDashboard = append(fakeService.getDashboard, &models.Dashboard{Slug: "team-a", IsFolder: true})
+		require.NoError(t, reader.walkDisk())
+		fakeService.provisioned["Default"] = append(fakeService.provisioned["Default"], &models.DashboardProvisioning{
+			Name: "Default", ExternalId: "/removed.json", DashboardId: 99,
+		})
+
+		plan, err := reader.plan()
+		require.NoError(t, err)
+		assert.Equal(t, []*utils.PlanItem{
+			{Action: utils.PlanUnchanged, Kind: "dashboard", Name: "Grafana", OrgID: 1},
+			{Action: utils.PlanDelete, Kind: "dashboard", Name: "/removed.json", OrgID: 1},
+		}, plan)
+	})
+
+	t.Run("Fails for invalid dashboard files", func(t *testing.T) {
+		cfg.Options["path"] = brokenDashboards
+		reader, err := NewDashboardFileReader(cfg, log.New("test.logger"))
+		require.NoError(t, err)
+
+		_, err = reader.plan()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid dashboard file")
+		assert.Contains(t, err.Error(), filepath.Base(brokenDashboards))
+	})
+}
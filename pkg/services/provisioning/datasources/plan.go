@@ -0,0 +1,73 @@
+package datasources
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and returns the changes
+// provisioning them would make, without making them.
+func Plan(configDirectory string, isPluginInstalled utils.PluginLookup) ([]*utils.PlanItem, error) {
+	cr := &configReader{log: log.New("provisioning.datasources")}
+	configs, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var errStrings []string
+	for _, cfg := range configs {
+		for _, ds := range cfg.Datasources {
+			if !isPluginInstalled("datasource", ds.Type) {
+				errStrings = append(errStrings, fmt.Sprintf("data source %q has unknown type %q", ds.Name, ds.Type))
+			}
+		}
+	}
+	if len(errStrings) != 0 {
+		return nil, errors.New(strings.Join(errStrings, "\n"))
+	}
+
+	var plan []*utils.PlanItem
+	for _, cfg := range configs {
+		deleted := make(map[string]bool)
+		for _, ds := range cfg.DeleteDatasources {
+			exists, err := datasourceExists(ds.OrgID, ds.Name)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				deleted[ds.Name] = true
+				plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "datasource", Name: ds.Name, OrgID: ds.OrgID})
+			}
+		}
+
+		for _, ds := range cfg.Datasources {
+			exists, err := datasourceExists(ds.OrgID, ds.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			action := utils.PlanCreate
+			if exists && !deleted[ds.Name] {
+				action = utils.PlanUpdate
+			}
+			plan = append(plan, &utils.PlanItem{Action: action, Kind: "datasource", Name: ds.Name, OrgID: ds.OrgID})
+		}
+	}
+
+	return plan, nil
+}
+
+func datasourceExists(orgID int64, name string) (bool, error) {
+	query := &models.GetDataSourceQuery{OrgId: orgID, Name: name}
+	err := bus.Dispatch(query)
+	if errors.Is(err, models.ErrDataSourceNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
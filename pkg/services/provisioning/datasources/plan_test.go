@@ -0,0 +1,49 @@
+package datasources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+func TestPlan(t *testing.T) {
+	fakeRepo = &fakeRepository{
+		loadAll: []*models.DataSource{
+			{Name: "Graphite", OrgId: 1, Id: 1},
+			{Name: "old-graphite", OrgId: 1, Id: 2},
+		},
+	}
+	bus.ClearBusHandlers()
+	bus.AddHandler("test", mockGet)
+	bus.AddHandler("test", mockGetOrg)
+
+	installed := func(pluginType, pluginID string) bool {
+		return pluginType == "datasource" && (pluginID == "prometheus" || pluginID == "graphite")
+	}
+
+	t.Run("Plans changes without applying them", func(t *testing.T) {
+		plan, err := Plan(twoDatasourcesConfigPurgeOthers, installed)
+		require.NoError(t, err)
+
+		assert.Equal(t, []*utils.PlanItem{
+			{Action: utils.PlanDelete, Kind: "datasource", Name: "old-graphite", OrgID: 1},
+			{Action: utils.PlanCreate, Kind: "datasource", Name: "Prometheus", OrgID: 1},
+			{Action: utils.PlanUpdate, Kind: "datasource", Name: "Graphite", OrgID: 1},
+		}, plan)
+		assert.Empty(t, fakeRepo.inserted)
+		assert.Empty(t, fakeRepo.updated)
+		assert.Empty(t, fakeRepo.deleted)
+	})
+
+	t.Run("Fails for unknown data source types", func(t *testing.T) {
+		_, err := Plan(twoDatasourcesConfigPurgeOthers, func(string, string) bool { return false })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `data source "Prometheus" has unknown type "prometheus"`)
+		assert.Contains(t, err.Error(), `data source "Graphite" has unknown type "graphite"`)
+	})
+}
@@ -0,0 +1,182 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/util"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*config, error) {
+	var providers []*config
+	cr.log.Debug("Looking for library panel provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read library panel provisioning files from directory", "path", path, "error", err)
+		return providers, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing library panel provisioning file", "path", path, "file.Name", file.Name())
+			parsed, err := cr.parseLibraryPanelConfig(path, file)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse provisioning config file: %s error: %w", file.Name(), err)
+			}
+
+			providers = append(providers, parsed...)
+		}
+	}
+
+	cr.log.Debug("Validating library panel providers")
+	if err := validateProviders(providers); err != nil {
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+func (cr *configReader) parseLibraryPanelConfig(path string, file os.FileInfo) ([]*config, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersion := &configVersion{}
+	if err := yaml.Unmarshal(yamlFile, &apiVersion); err != nil {
+		return nil, err
+	}
+	if apiVersion == nil || apiVersion.APIVersion < 1 {
+		return nil, errors.New("apiVersion 1 is required")
+	}
+
+	v1 := &configV1{}
+	if err := yaml.Unmarshal(yamlFile, &v1); err != nil {
+		return nil, err
+	}
+	if v1 == nil {
+		return nil, nil
+	}
+
+	return v1.mapToLibraryPanelsAsConfig(), nil
+}
+
+func validateProviders(providers []*config) error {
+	var errStrings []string
+	seen := make(map[string]bool)
+	for index, provider := range providers {
+		if provider.Name == "" {
+			errStrings = append(
+				errStrings,
+				fmt.Sprintf("Library panel provider %d in configuration doesn't contain required field name", index+1),
+			)
+		} else if seen[provider.Name] {
+			errStrings = append(errStrings, fmt.Sprintf("Library panel provider name %q is not unique", provider.Name))
+		}
+		seen[provider.Name] = true
+
+		if provider.Path == "" {
+			errStrings = append(
+				errStrings,
+				fmt.Sprintf("Library panel provider %d in configuration doesn't contain required option path", index+1),
+			)
+		}
+	}
+	if len(errStrings) != 0 {
+		return errors.New(strings.Join(errStrings, "\n"))
+	}
+
+	for _, provider := range providers {
+		if provider.OrgID < 1 {
+			provider.OrgID = 1
+		}
+
+		if err := utils.CheckOrgExists(provider.OrgID); err != nil {
+			return fmt.Errorf("failed to provision library panels with %q provider: %w", provider.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// readLibraryPanels reads the library panel JSON files in the directory of a provider
+// and its subdirectories.
+func readLibraryPanels(provider *config) ([]*libraryPanelFromFile, error) {
+	path, err := filepath.Abs(provider.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var panels []*libraryPanelFromFile
+	err = filepath.Walk(path, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			return nil
+		}
+
+		panel, err := readLibraryPanel(filename)
+		if err != nil {
+			return err
+		}
+		panels = append(panels, panel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read library panels of %q provider: %w", provider.Name, err)
+	}
+
+	return panels, nil
+}
+
+func readLibraryPanel(filename string) (*libraryPanelFromFile, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from the provisioning configuration
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	panel := &libraryPanelFromFile{Path: filename}
+	if err := json.Unmarshal(data, panel); err != nil {
+		return nil, fmt.Errorf("invalid library panel %q: %w", filename, err)
+	}
+
+	var model map[string]interface{}
+	if err := json.Unmarshal(panel.Model, &model); err != nil || model == nil {
+		return nil, fmt.Errorf("library panel %q doesn't contain a valid model", filename)
+	}
+	if panel.UID == "" {
+		return nil, fmt.Errorf("library panel %q doesn't contain required field uid", filename)
+	}
+	if !util.IsValidShortUID(panel.UID) || len(panel.UID) > 40 {
+		return nil, fmt.Errorf("library panel %q has invalid uid %q", filename, panel.UID)
+	}
+	if panel.Name == "" {
+		// panels exported from dashboards carry their name as title
+		panel.Name, _ = model["title"].(string)
+	}
+	if panel.Name == "" {
+		return nil, fmt.Errorf("library panel %q doesn't contain required field name", filename)
+	}
+
+	return panel, nil
+}
@@ -0,0 +1,88 @@
+package librarypanels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	noRequiredFields  = "./testdata/test-configs/no-required-fields"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+	invalidPanels     = "./testdata/test-configs/invalid-panels"
+	duplicateUIDs     = "./testdata/test-configs/duplicate-uids"
+)
+
+func TestLibraryPanelsAsConfig(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	require.NoError(t, sqlstore.CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+
+	cfgProvider := &configReader{log: log.New("test logger")}
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		providers, err := cfgProvider.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, providers, 1)
+
+		provider := providers[0]
+		assert.Equal(t, "shared", provider.Name)
+		assert.Equal(t, int64(1), provider.OrgID)
+		assert.Equal(t, "Shared panels", provider.Folder)
+		assert.Equal(t, "./testdata/panels/shared", provider.Path)
+
+		panels, err := readLibraryPanels(provider)
+		require.NoError(t, err)
+		require.Len(t, panels, 2)
+		assert.Equal(t, "cpu-usage", panels[0].UID)
+		assert.Equal(t, "CPU usage", panels[0].Name)
+		assert.Contains(t, string(panels[0].Model), "node_cpu_usage")
+		assert.Equal(t, "memory-usage", panels[1].UID)
+		assert.Equal(t, "Memory usage", panels[1].Name)
+	})
+
+	t.Run("Checksum changes with the folder and the panel", func(t *testing.T) {
+		panel := &libraryPanelFromFile{UID: "cpu-usage", Name: "CPU usage", Model: []byte(`{"type":"graph"}`)}
+		checkSum := panel.checkSum(1)
+		assert.Equal(t, checkSum, panel.checkSum(1))
+		assert.NotEqual(t, checkSum, panel.checkSum(2))
+
+		panel.Model = []byte(`{"type":"stat"}`)
+		assert.NotEqual(t, checkSum, panel.checkSum(1))
+	})
+
+	t.Run("Missing required fields should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(noRequiredFields)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Library panel provider 1 in configuration doesn't contain required field name")
+		assert.Contains(t, err.Error(), "Library panel provider 1 in configuration doesn't contain required option path")
+		assert.Contains(t, err.Error(), "Library panel provider 2 in configuration doesn't contain required option path")
+	})
+
+	t.Run("Invalid library panel files should return error", func(t *testing.T) {
+		providers, err := cfgProvider.readConfig(invalidPanels)
+		require.NoError(t, err)
+		require.Len(t, providers, 1)
+
+		_, err = readLibraryPanels(providers[0])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't contain required field uid")
+	})
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		_, err := cfgProvider.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Empty folder should return empty config", func(t *testing.T) {
+		providers, err := cfgProvider.readConfig(emptyFolder)
+		require.NoError(t, err)
+		assert.Empty(t, providers)
+	})
+}
@@ -0,0 +1,180 @@
+package librarypanels
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+)
+
+// Store is the storage of library panels used by provisioning.
+type Store interface {
+	GetProvisionedLibraryPanels() ([]*librarypanels.LibraryPanelProvisioning, error)
+	SaveProvisionedLibraryPanel(cmd *librarypanels.SaveProvisionedLibraryPanelCommand) error
+	DeleteProvisionedLibraryPanel(orgID int64, uid string) error
+}
+
+type libraryPanelKey struct {
+	orgID int64
+	uid   string
+}
+
+// Provision library panels
+func Provision(configDirectory string, store Store) error {
+	lp := newLibraryPanelProvisioner(log.New("provisioning.librarypanels"), store)
+	return lp.applyChanges(configDirectory)
+}
+
+// LibraryPanelProvisioner is responsible for provisioning library panels
+type LibraryPanelProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+	store       Store
+}
+
+func newLibraryPanelProvisioner(log log.Logger, store Store) LibraryPanelProvisioner {
+	return LibraryPanelProvisioner{
+		log:         log,
+		cfgProvider: &configReader{log: log},
+		store:       store,
+	}
+}
+
+func (lp *LibraryPanelProvisioner) applyChanges(configPath string) error {
+	providers, err := lp.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	// all files are read before changing anything, so a broken file doesn't cause
+	// the library panels of other files to be deleted
+	panels := make(map[*config][]*libraryPanelFromFile, len(providers))
+	seen := make(map[libraryPanelKey]string)
+	for _, provider := range providers {
+		providerPanels, err := readLibraryPanels(provider)
+		if err != nil {
+			return err
+		}
+
+		for _, panel := range providerPanels {
+			key := libraryPanelKey{orgID: provider.OrgID, uid: panel.UID}
+			if path, exists := seen[key]; exists {
+				return fmt.Errorf("library panel %q is provisioned by both %q and %q", panel.UID, path, panel.Path)
+			}
+			seen[key] = panel.Path
+		}
+		panels[provider] = providerPanels
+	}
+
+	provisioned, err := lp.store.GetProvisionedLibraryPanels()
+	if err != nil {
+		return err
+	}
+	checkSums := make(map[libraryPanelKey]string, len(provisioned))
+	for _, p := range provisioned {
+		checkSums[libraryPanelKey{orgID: p.OrgID, uid: p.UID}] = p.CheckSum
+	}
+
+	for _, provider := range providers {
+		if err := lp.provisionLibraryPanels(provider, panels[provider], checkSums); err != nil {
+			return err
+		}
+	}
+
+	return lp.handleMissingLibraryPanels(provisioned, seen)
+}
+
+func (lp *LibraryPanelProvisioner) provisionLibraryPanels(provider *config, panels []*libraryPanelFromFile, checkSums map[libraryPanelKey]string) error {
+	if len(panels) == 0 {
+		return nil
+	}
+
+	folderID, err := getOrCreateFolderID(provider)
+	if err != nil {
+		return fmt.Errorf("failed to provision library panels with %q provider: %w", provider.Name, err)
+	}
+
+	for _, panel := range panels {
+		checkSum := panel.checkSum(folderID)
+		if checkSums[libraryPanelKey{orgID: provider.OrgID, uid: panel.UID}] == checkSum {
+			lp.log.Debug("library panel is up to date", "uid", panel.UID)
+			continue
+		}
+
+		lp.log.Debug("saving library panel from configuration", "uid", panel.UID, "name", panel.Name)
+		cmd := &librarypanels.SaveProvisionedLibraryPanelCommand{
+			OrgID:      provider.OrgID,
+			FolderID:   folderID,
+			UID:        panel.UID,
+			Name:       panel.Name,
+			Model:      panel.Model,
+			ExternalID: panel.Path,
+			CheckSum:   checkSum,
+		}
+		if err := lp.store.SaveProvisionedLibraryPanel(cmd); err != nil {
+			return fmt.Errorf("failed to provision library panel %q: %w", panel.UID, err)
+		}
+	}
+
+	return nil
+}
+
+// handleMissingLibraryPanels deletes the library panels that were provisioned before
+// but are no longer part of any provider.
+func (lp *LibraryPanelProvisioner) handleMissingLibraryPanels(provisioned []*librarypanels.LibraryPanelProvisioning, seen map[libraryPanelKey]string) error {
+	for _, p := range provisioned {
+		if _, exists := seen[libraryPanelKey{orgID: p.OrgID, uid: p.UID}]; exists {
+			continue
+		}
+
+		lp.log.Debug("deleting provisioned library panel, missing in configuration", "uid", p.UID, "path", p.ExternalID)
+		if err := lp.store.DeleteProvisionedLibraryPanel(p.OrgID, p.UID); err != nil {
+			lp.log.Error("failed to delete library panel", "uid", p.UID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// getOrCreateFolderID returns the id of the folder of a provider, creating the folder
+// if it doesn't exist. Library panels of providers without folder go to the General folder.
+func getOrCreateFolderID(provider *config) (int64, error) {
+	if provider.Folder == "" {
+		return 0, nil
+	}
+
+	query := &models.GetDashboardQuery{Slug: models.SlugifyTitle(provider.Folder), OrgId: provider.OrgID}
+	if provider.FolderUID != "" {
+		query = &models.GetDashboardQuery{Uid: provider.FolderUID, OrgId: provider.OrgID}
+	}
+
+	err := bus.Dispatch(query)
+	if err != nil && !errors.Is(err, models.ErrDashboardNotFound) {
+		return 0, err
+	}
+
+	if errors.Is(err, models.ErrDashboardNotFound) {
+		dash := &dashboards.SaveDashboardDTO{}
+		dash.Dashboard = models.NewDashboardFolder(provider.Folder)
+		dash.Dashboard.IsFolder = true
+		dash.Overwrite = true
+		dash.OrgId = provider.OrgID
+		dash.Dashboard.SetUid(provider.FolderUID)
+		dbDash, err := dashboards.NewProvisioningService().SaveFolderForProvisionedDashboards(dash)
+		if err != nil {
+			return 0, err
+		}
+
+		return dbDash.Id, nil
+	}
+
+	if !query.Result.IsFolder {
+		return 0, fmt.Errorf("got invalid response. expected folder, found dashboard")
+	}
+
+	return query.Result.Id, nil
+}
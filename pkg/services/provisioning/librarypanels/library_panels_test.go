@@ -0,0 +1,116 @@
+package librarypanels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type fakeLibraryPanelStore struct {
+	panels      map[libraryPanelKey]*librarypanels.SaveProvisionedLibraryPanelCommand
+	provisioned map[libraryPanelKey]*librarypanels.LibraryPanelProvisioning
+	saved       int
+	deleted     []string
+}
+
+func newFakeLibraryPanelStore() *fakeLibraryPanelStore {
+	return &fakeLibraryPanelStore{
+		panels:      map[libraryPanelKey]*librarypanels.SaveProvisionedLibraryPanelCommand{},
+		provisioned: map[libraryPanelKey]*librarypanels.LibraryPanelProvisioning{},
+	}
+}
+
+func (f *fakeLibraryPanelStore) GetProvisionedLibraryPanels() ([]*librarypanels.LibraryPanelProvisioning, error) {
+	var result []*librarypanels.LibraryPanelProvisioning
+	for _, p := range f.provisioned {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (f *fakeLibraryPanelStore) SaveProvisionedLibraryPanel(cmd *librarypanels.SaveProvisionedLibraryPanelCommand) error {
+	f.saved++
+	key := libraryPanelKey{orgID: cmd.OrgID, uid: cmd.UID}
+	f.panels[key] = cmd
+	f.provisioned[key] = &librarypanels.LibraryPanelProvisioning{
+		OrgID: cmd.OrgID, UID: cmd.UID, ExternalID: cmd.ExternalID, CheckSum: cmd.CheckSum,
+	}
+	return nil
+}
+
+func (f *fakeLibraryPanelStore) DeleteProvisionedLibraryPanel(orgID int64, uid string) error {
+	key := libraryPanelKey{orgID: orgID, uid: uid}
+	f.deleted = append(f.deleted, uid)
+	delete(f.panels, key)
+	delete(f.provisioned, key)
+	return nil
+}
+
+func TestLibraryPanelProvisioner(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	require.NoError(t, sqlstore.CreateOrg(&models.CreateOrgCommand{Name: "Main Org."}))
+	bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.UpdateDashboardAlertsCommand) error {
+		return nil
+	})
+
+	store := newFakeLibraryPanelStore()
+	provisioner := newLibraryPanelProvisioner(log.New("test logger"), store)
+
+	t.Run("Creates the folder and the library panels", func(t *testing.T) {
+		require.NoError(t, provisioner.applyChanges(correctProperties))
+
+		query := &models.GetDashboardQuery{Slug: models.SlugifyTitle("Shared panels"), OrgId: 1}
+		require.NoError(t, bus.Dispatch(query))
+		assert.True(t, query.Result.IsFolder)
+
+		assert.Equal(t, 2, store.saved)
+		cpu := store.panels[libraryPanelKey{orgID: 1, uid: "cpu-usage"}]
+		require.NotNil(t, cpu)
+		assert.Equal(t, "CPU usage", cpu.Name)
+		assert.Equal(t, query.Result.Id, cpu.FolderID)
+		assert.Contains(t, cpu.ExternalID, "cpu.json")
+	})
+
+	t.Run("Skips library panels that didn't change", func(t *testing.T) {
+		require.NoError(t, provisioner.applyChanges(correctProperties))
+		assert.Equal(t, 2, store.saved)
+	})
+
+	t.Run("Updates library panels that changed", func(t *testing.T) {
+		store.provisioned[libraryPanelKey{orgID: 1, uid: "cpu-usage"}].CheckSum = "outdated"
+
+		require.NoError(t, provisioner.applyChanges(correctProperties))
+		assert.Equal(t, 3, store.saved)
+	})
+
+	t.Run("Deletes library panels missing from the configuration", func(t *testing.T) {
+		require.NoError(t, provisioner.applyChanges(emptyFolder))
+		assert.ElementsMatch(t, []string{"cpu-usage", "memory-usage"}, store.deleted)
+		assert.Empty(t, store.panels)
+	})
+
+	t.Run("Keeps library panels if a file is invalid", func(t *testing.T) {
+		require.NoError(t, provisioner.applyChanges(correctProperties))
+		store.deleted = nil
+
+		require.Error(t, provisioner.applyChanges(invalidPanels))
+		assert.Empty(t, store.deleted)
+		assert.Len(t, store.panels, 2)
+	})
+
+	t.Run("Duplicate uids should return error", func(t *testing.T) {
+		err := provisioner.applyChanges(duplicateUIDs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `library panel "memory-usage" is provisioned by both`)
+	})
+}
@@ -0,0 +1,92 @@
+package librarypanels
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and the library panel files
+// of their providers, and returns the changes provisioning them would make, without making them.
+func Plan(configDirectory string, store Store) ([]*utils.PlanItem, error) {
+	cr := &configReader{log: log.New("provisioning.librarypanels")}
+	providers, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	provisioned, err := store.GetProvisionedLibraryPanels()
+	if err != nil {
+		return nil, err
+	}
+	checkSums := make(map[libraryPanelKey]string, len(provisioned))
+	for _, p := range provisioned {
+		checkSums[libraryPanelKey{orgID: p.OrgID, uid: p.UID}] = p.CheckSum
+	}
+
+	var plan []*utils.PlanItem
+	seen := make(map[libraryPanelKey]string)
+	for _, provider := range providers {
+		panels, err := readLibraryPanels(provider)
+		if err != nil {
+			return nil, err
+		}
+
+		// folders that don't exist yet are created by provisioning
+		folderID, folderExists, err := planFolderID(provider)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, panel := range panels {
+			key := libraryPanelKey{orgID: provider.OrgID, uid: panel.UID}
+			if path, exists := seen[key]; exists {
+				return nil, fmt.Errorf("library panel %q is provisioned by both %q and %q", panel.UID, path, panel.Path)
+			}
+			seen[key] = panel.Path
+
+			action := utils.PlanUpdate
+			checkSum, wasProvisioned := checkSums[key]
+			switch {
+			case !wasProvisioned:
+				action = utils.PlanCreate
+			case folderExists && checkSum == panel.checkSum(folderID):
+				action = utils.PlanUnchanged
+			}
+			plan = append(plan, &utils.PlanItem{Action: action, Kind: "library panel", Name: panel.Name, OrgID: provider.OrgID})
+		}
+	}
+
+	for _, p := range provisioned {
+		if _, exists := seen[libraryPanelKey{orgID: p.OrgID, uid: p.UID}]; !exists {
+			plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "library panel", Name: p.UID, OrgID: p.OrgID})
+		}
+	}
+
+	return plan, nil
+}
+
+func planFolderID(provider *config) (int64, bool, error) {
+	if provider.Folder == "" {
+		return 0, true, nil
+	}
+
+	query := &models.GetDashboardQuery{Slug: models.SlugifyTitle(provider.Folder), OrgId: provider.OrgID}
+	if provider.FolderUID != "" {
+		query = &models.GetDashboardQuery{Uid: provider.FolderUID, OrgId: provider.OrgID}
+	}
+
+	err := bus.Dispatch(query)
+	if errors.Is(err, models.ErrDashboardNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return query.Result.Id, true, nil
+}
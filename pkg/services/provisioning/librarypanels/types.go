@@ -0,0 +1,71 @@
+package librarypanels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// config is a provider of library panels, a directory of library panel JSON files
+// that are provisioned into a folder.
+type config struct {
+	Name      string
+	OrgID     int64
+	Folder    string
+	FolderUID string
+	Path      string
+}
+
+type configVersion struct {
+	APIVersion int64 `json:"apiVersion" yaml:"apiVersion"`
+}
+
+type configV1 struct {
+	Providers []*configs `json:"providers" yaml:"providers"`
+}
+
+type configs struct {
+	Name      values.StringValue `json:"name" yaml:"name"`
+	OrgID     values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Folder    values.StringValue `json:"folder" yaml:"folder"`
+	FolderUID values.StringValue `json:"folderUid" yaml:"folderUid"`
+	Options   values.JSONValue   `json:"options" yaml:"options"`
+}
+
+func (lc *configV1) mapToLibraryPanelsAsConfig() []*config {
+	var r []*config
+	for _, v := range lc.Providers {
+		path, _ := v.Options.Value()["path"].(string)
+		r = append(r, &config{
+			Name:      v.Name.Value(),
+			OrgID:     v.OrgID.Value(),
+			Folder:    v.Folder.Value(),
+			FolderUID: v.FolderUID.Value(),
+			Path:      path,
+		})
+	}
+
+	return r
+}
+
+// libraryPanelFromFile is a library panel read from a JSON file. The format is the same
+// as the one returned by the library panel API, so exported panels can be provisioned.
+type libraryPanelFromFile struct {
+	UID   string          `json:"uid"`
+	Name  string          `json:"name"`
+	Model json.RawMessage `json:"model"`
+
+	// Path is the file the library panel is read from.
+	Path string `json:"-"`
+}
+
+// checkSum returns a hash of the library panel and the folder it's provisioned to.
+func (lp *libraryPanelFromFile) checkSum(folderID int64) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d/%s/", folderID, lp.Name)
+	_, _ = hash.Write(lp.Model)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package notifiers
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and returns the changes
+// provisioning them would make, without making them.
+func Plan(configDirectory string) ([]*utils.PlanItem, error) {
+	cr := &configReader{log: log.New("provisioning.notifiers")}
+	configs, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*utils.PlanItem
+	for _, cfg := range configs {
+		deleted := make(map[string]bool)
+		for _, notification := range cfg.DeleteNotifications {
+			orgID, err := planOrgID(notification.OrgID, notification.OrgName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete %q alert notification: %w", notification.Name, err)
+			}
+
+			existing, err := getNotificationByUID(orgID, notification.UID)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				deleted[existing.Uid] = true
+				plan = append(plan, &utils.PlanItem{Action: utils.PlanDelete, Kind: "alert notification", Name: existing.Name, OrgID: orgID})
+			}
+		}
+
+		for _, notification := range cfg.Notifications {
+			orgID, err := planOrgID(notification.OrgID, notification.OrgName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to provision %q alert notification: %w", notification.Name, err)
+			}
+
+			existing, err := getNotificationByUID(orgID, notification.UID)
+			if err != nil {
+				return nil, err
+			}
+
+			action := utils.PlanCreate
+			if existing != nil && !deleted[existing.Uid] {
+				action = utils.PlanUpdate
+			}
+			plan = append(plan, &utils.PlanItem{Action: action, Kind: "alert notification", Name: notification.Name, OrgID: orgID})
+		}
+	}
+
+	return plan, nil
+}
+
+func planOrgID(orgID int64, orgName string) (int64, error) {
+	if orgID == 0 && orgName != "" {
+		getOrg := &models.GetOrgByNameQuery{Name: orgName}
+		if err := bus.Dispatch(getOrg); err != nil {
+			return 0, fmt.Errorf("organization %q: %w", orgName, err)
+		}
+		return getOrg.Result.Id, nil
+	}
+	if orgID < 0 {
+		return 1, nil
+	}
+	return orgID, nil
+}
+
+func getNotificationByUID(orgID int64, uid string) (*models.AlertNotification, error) {
+	if uid == "" {
+		return nil, nil
+	}
+
+	query := &models.GetAlertNotificationsWithUidQuery{OrgId: orgID, Uid: uid}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
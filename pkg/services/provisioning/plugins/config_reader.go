@@ -17,11 +17,12 @@ type configReader interface {
 }
 
 type configReaderImpl struct {
-	log log.Logger
+	log            log.Logger
+	isAppInstalled func(pluginID string) bool
 }
 
 func newConfigReader(logger log.Logger) configReader {
-	return &configReaderImpl{log: logger}
+	return &configReaderImpl{log: logger, isAppInstalled: manager.IsAppInstalled}
 }
 
 func (cr *configReaderImpl) readConfig(path string) ([]*pluginsAsConfig, error) {
@@ -55,7 +56,7 @@ func (cr *configReaderImpl) readConfig(path string) ([]*pluginsAsConfig, error)
 
 	checkOrgIDAndOrgName(apps)
 
-	err = validatePluginsConfig(apps)
+	err = validatePluginsConfig(apps, cr.isAppInstalled)
 	if err != nil {
 		return nil, err
 	}
@@ -105,14 +106,14 @@ func validateRequiredField(apps []*pluginsAsConfig) error {
 	return nil
 }
 
-func validatePluginsConfig(apps []*pluginsAsConfig) error {
+func validatePluginsConfig(apps []*pluginsAsConfig, isAppInstalled func(pluginID string) bool) error {
 	for i := range apps {
 		if apps[i].Apps == nil {
 			continue
 		}
 
 		for _, app := range apps[i].Apps {
-			if !manager.IsAppInstalled(app.PluginID) {
+			if !isAppInstalled(app.PluginID) {
 				return fmt.Errorf("app plugin not installed: %s", app.PluginID)
 			}
 		}
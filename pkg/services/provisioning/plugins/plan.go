@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Plan validates the provisioning config files in a directory and returns the changes
+// provisioning them would make, without making them.
+func Plan(configDirectory string, isPluginInstalled utils.PluginLookup) ([]*utils.PlanItem, error) {
+	cr := &configReaderImpl{
+		log: log.New("provisioning.plugins"),
+		isAppInstalled: func(pluginID string) bool {
+			return isPluginInstalled("app", pluginID)
+		},
+	}
+	configs, err := cr.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*utils.PlanItem
+	for _, cfg := range configs {
+		for _, app := range cfg.Apps {
+			orgID := app.OrgID
+			if orgID == 0 && app.OrgName != "" {
+				getOrgQuery := &models.GetOrgByNameQuery{Name: app.OrgName}
+				if err := bus.Dispatch(getOrgQuery); err != nil {
+					return nil, fmt.Errorf("failed to provision %q app: organization %q: %w", app.PluginID, app.OrgName, err)
+				}
+				orgID = getOrgQuery.Result.Id
+			} else if err := utils.CheckOrgExists(orgID); err != nil {
+				return nil, fmt.Errorf("failed to provision %q app: %w", app.PluginID, err)
+			}
+
+			action := utils.PlanUpdate
+			query := &models.GetPluginSettingByIdQuery{OrgId: orgID, PluginId: app.PluginID}
+			if err := bus.Dispatch(query); err != nil {
+				if !errors.Is(err, models.ErrPluginSettingNotFound) {
+					return nil, err
+				}
+				action = utils.PlanCreate
+			}
+
+			plan = append(plan, &utils.PlanItem{Action: action, Kind: "app", Name: app.PluginID, OrgID: orgID})
+		}
+	}
+
+	return plan, nil
+}
@@ -7,8 +7,14 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/registry"
+	librarypanelservice "github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/provisioning/access"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/librarypanels"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
 	"github.com/grafana/grafana/pkg/setting"
@@ -16,9 +22,12 @@ import (
 )
 
 type ProvisioningService interface {
+	ProvisionAccess() error
 	ProvisionDatasources() error
 	ProvisionPlugins() error
 	ProvisionNotifications() error
+	ProvisionAlerting() error
+	ProvisionLibraryPanels() error
 	ProvisionDashboards() error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
@@ -28,12 +37,15 @@ func init() {
 	registry.Register(&registry.Descriptor{
 		Name: "ProvisioningService",
 		Instance: NewProvisioningServiceImpl(
-			func(path string) (dashboards.DashboardProvisioner, error) {
-				return dashboards.New(path)
+			func(configDirectory, dataPath string) (dashboards.DashboardProvisioner, error) {
+				return dashboards.New(configDirectory, dataPath)
 			},
 			notifiers.Provision,
 			datasources.Provision,
 			plugins.Provision,
+			alerting.Provision,
+			access.Provision,
+			librarypanels.Provision,
 		),
 		InitPriority: registry.Low,
 	})
@@ -44,6 +56,9 @@ func NewProvisioningServiceImpl(
 	provisionNotifiers func(string) error,
 	provisionDatasources func(string) error,
 	provisionPlugins func(string) error,
+	provisionAlerting func(string, store.Store) error,
+	provisionAccess func(string) error,
+	provisionLibraryPanels func(string, librarypanels.Store) error,
 ) *provisioningServiceImpl {
 	return &provisioningServiceImpl{
 		log:                     log.New("provisioning"),
@@ -51,11 +66,16 @@ func NewProvisioningServiceImpl(
 		provisionNotifiers:      provisionNotifiers,
 		provisionDatasources:    provisionDatasources,
 		provisionPlugins:        provisionPlugins,
+		provisionAlerting:       provisionAlerting,
+		provisionAccess:         provisionAccess,
+		provisionLibraryPanels:  provisionLibraryPanels,
 	}
 }
 
 type provisioningServiceImpl struct {
-	Cfg                     *setting.Cfg `inject:""`
+	Cfg                     *setting.Cfg                             `inject:""`
+	AlertNG                 *ngalert.AlertNG                         `inject:""`
+	LibraryPanelService     *librarypanelservice.LibraryPanelService `inject:""`
 	log                     log.Logger
 	pollingCtxCancel        context.CancelFunc
 	newDashboardProvisioner dashboards.DashboardProvisionerFactory
@@ -63,11 +83,20 @@ type provisioningServiceImpl struct {
 	provisionNotifiers      func(string) error
 	provisionDatasources    func(string) error
 	provisionPlugins        func(string) error
+	provisionAlerting       func(string, store.Store) error
+	provisionAccess         func(string) error
+	provisionLibraryPanels  func(string, librarypanels.Store) error
 	mutex                   sync.Mutex
 }
 
 func (ps *provisioningServiceImpl) Init() error {
-	err := ps.ProvisionDatasources()
+	// orgs and users are provisioned first as the other provisioners refer to them
+	err := ps.ProvisionAccess()
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionDatasources()
 	if err != nil {
 		return err
 	}
@@ -82,6 +111,17 @@ func (ps *provisioningServiceImpl) Init() error {
 		return err
 	}
 
+	err = ps.ProvisionAlerting()
+	if err != nil {
+		return err
+	}
+
+	// library panels are provisioned before dashboards, which can refer to them
+	err = ps.ProvisionLibraryPanels()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -92,6 +132,11 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 		return err
 	}
 
+	// permissions of provisioned dashboards can only be applied once they exist
+	if err := ps.ProvisionAccess(); err != nil {
+		ps.log.Error("Failed to provision dashboard permissions", "error", err)
+	}
+
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
 		ps.mutex.Lock()
@@ -114,6 +159,12 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 	}
 }
 
+func (ps *provisioningServiceImpl) ProvisionAccess() error {
+	accessPath := filepath.Join(ps.Cfg.ProvisioningPath, "access")
+	err := ps.provisionAccess(accessPath)
+	return errutil.Wrap("Access provisioning error", err)
+}
+
 func (ps *provisioningServiceImpl) ProvisionDatasources() error {
 	datasourcePath := filepath.Join(ps.Cfg.ProvisioningPath, "datasources")
 	err := ps.provisionDatasources(datasourcePath)
@@ -132,9 +183,29 @@ func (ps *provisioningServiceImpl) ProvisionNotifications() error {
 	return errutil.Wrap("Alert notification provisioning error", err)
 }
 
+func (ps *provisioningServiceImpl) ProvisionAlerting() error {
+	if ps.AlertNG == nil || ps.AlertNG.IsDisabled() {
+		return nil
+	}
+
+	alertingPath := filepath.Join(ps.Cfg.ProvisioningPath, "alerting")
+	err := ps.provisionAlerting(alertingPath, ps.AlertNG.Store())
+	return errutil.Wrap("Alerting provisioning error", err)
+}
+
+func (ps *provisioningServiceImpl) ProvisionLibraryPanels() error {
+	if ps.LibraryPanelService == nil || !ps.LibraryPanelService.IsEnabled() {
+		return nil
+	}
+
+	libraryPanelsPath := filepath.Join(ps.Cfg.ProvisioningPath, "librarypanels")
+	err := ps.provisionLibraryPanels(libraryPanelsPath, ps.LibraryPanelService)
+	return errutil.Wrap("Library panel provisioning error", err)
+}
+
 func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath)
+	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath, ps.Cfg.DataPath)
 	if err != nil {
 		return errutil.Wrap("Failed to create provisioner", err)
 	}
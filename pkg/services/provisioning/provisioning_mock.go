@@ -1,9 +1,12 @@
 package provisioning
 
 type Calls struct {
+	ProvisionAccess                     []interface{}
 	ProvisionDatasources                []interface{}
 	ProvisionPlugins                    []interface{}
 	ProvisionNotifications              []interface{}
+	ProvisionAlerting                   []interface{}
+	ProvisionLibraryPanels              []interface{}
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
@@ -11,9 +14,12 @@ type Calls struct {
 
 type ProvisioningServiceMock struct {
 	Calls                                   *Calls
+	ProvisionAccessFunc                     func() error
 	ProvisionDatasourcesFunc                func() error
 	ProvisionPluginsFunc                    func() error
 	ProvisionNotificationsFunc              func() error
+	ProvisionAlertingFunc                   func() error
+	ProvisionLibraryPanelsFunc              func() error
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
@@ -25,6 +31,14 @@ func NewProvisioningServiceMock() *ProvisioningServiceMock {
 	}
 }
 
+func (mock *ProvisioningServiceMock) ProvisionAccess() error {
+	mock.Calls.ProvisionAccess = append(mock.Calls.ProvisionAccess, nil)
+	if mock.ProvisionAccessFunc != nil {
+		return mock.ProvisionAccessFunc()
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionDatasources() error {
 	mock.Calls.ProvisionDatasources = append(mock.Calls.ProvisionDatasources, nil)
 	if mock.ProvisionDatasourcesFunc != nil {
@@ -49,6 +63,22 @@ func (mock *ProvisioningServiceMock) ProvisionNotifications() error {
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionAlerting() error {
+	mock.Calls.ProvisionAlerting = append(mock.Calls.ProvisionAlerting, nil)
+	if mock.ProvisionAlertingFunc != nil {
+		return mock.ProvisionAlertingFunc()
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) ProvisionLibraryPanels() error {
+	mock.Calls.ProvisionLibraryPanels = append(mock.Calls.ProvisionLibraryPanels, nil)
+	if mock.ProvisionLibraryPanelsFunc != nil {
+		return mock.ProvisionLibraryPanelsFunc()
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionDashboards() error {
 	mock.Calls.ProvisionDashboards = append(mock.Calls.ProvisionDashboards, nil)
 	if mock.ProvisionDashboardsFunc != nil {
@@ -91,12 +91,15 @@ func setup() *serviceTestStruct {
 	}
 
 	serviceTest.service = NewProvisioningServiceImpl(
-		func(path string) (dashboards.DashboardProvisioner, error) {
+		func(configDirectory, dataPath string) (dashboards.DashboardProvisioner, error) {
 			return serviceTest.mock, nil
 		},
 		nil,
 		nil,
 		nil,
+		nil,
+		func(string) error { return nil },
+		nil,
 	)
 	serviceTest.service.Cfg = setting.NewCfg()
 
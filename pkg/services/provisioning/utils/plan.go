@@ -0,0 +1,29 @@
+package utils
+
+import "fmt"
+
+// PlanAction is the change provisioning would make to an entity.
+type PlanAction string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanUpdate    PlanAction = "update"
+	PlanDelete    PlanAction = "delete"
+	PlanUnchanged PlanAction = "unchanged"
+)
+
+// PlanItem describes a change provisioning would make to an entity, without making it.
+type PlanItem struct {
+	Action PlanAction
+	// Kind is the kind of the entity, e.g. datasource or dashboard.
+	Kind  string
+	Name  string
+	OrgID int64
+}
+
+func (p *PlanItem) String() string {
+	return fmt.Sprintf("%s %s %q (org %d)", p.Action, p.Kind, p.Name, p.OrgID)
+}
+
+// PluginLookup reports whether a plugin of the given type, e.g. datasource or app, is installed.
+type PluginLookup func(pluginType, pluginID string) bool
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/grafana/grafana/pkg/infra/secrets"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -156,7 +157,9 @@ func (val *JSONValue) Value() map[string]interface{} {
 }
 
 // StringMapValue represents a string value in a YAML
-// config that can be overridden by environment variables
+// config that can be overridden by environment variables.
+// It's used for secure settings, so references to secrets of secrets providers,
+// like $__vault{path:key}, are kept as they are to be resolved when used.
 type StringMapValue struct {
 	value map[string]string
 	Raw   map[string]string
@@ -172,7 +175,7 @@ func (val *StringMapValue) UnmarshalYAML(unmarshal func(interface{}) error) erro
 	interpolated := make(map[string]string)
 	raw := make(map[string]string)
 	for key, val := range unmarshaled {
-		interpolated[key], raw[key], err = interpolateValue(val)
+		interpolated[key], raw[key], err = interpolateSecureValue(val)
 		if err != nil {
 			return err
 		}
@@ -248,16 +251,45 @@ func interpolateValue(val string) (string, string, error) {
 	parts := strings.Split(val, "$$")
 	interpolated := make([]string, len(parts))
 	for i, v := range parts {
-		expanded, err := setting.ExpandVar(v)
+		expanded, err := expandVar(v)
 		if err != nil {
 			return val, val, fmt.Errorf("failed to interpolate value '%s': %w", val, err)
 		}
-		v = expanded
-		interpolated[i] = os.ExpandEnv(v)
+		interpolated[i] = expanded
 	}
 	return strings.Join(interpolated, "$"), val, nil
 }
 
+// interpolateSecureValue works like interpolateValue, but keeps references to secrets
+// of secrets providers, like $__vault{path:key}, as they are.
+func interpolateSecureValue(val string) (string, string, error) {
+	parts := strings.Split(val, "$$")
+	interpolated := make([]string, len(parts))
+	for i, v := range parts {
+		var sb strings.Builder
+		last := 0
+		for _, loc := range append(secrets.ReferenceIndexes(v), []int{len(v), len(v)}) {
+			expanded, err := expandVar(v[last:loc[0]])
+			if err != nil {
+				return val, val, fmt.Errorf("failed to interpolate value '%s': %w", val, err)
+			}
+			sb.WriteString(expanded)
+			sb.WriteString(v[loc[0]:loc[1]])
+			last = loc[1]
+		}
+		interpolated[i] = sb.String()
+	}
+	return strings.Join(interpolated, "$"), val, nil
+}
+
+func expandVar(v string) (string, error) {
+	expanded, err := setting.ExpandVar(v)
+	if err != nil {
+		return "", err
+	}
+	return os.ExpandEnv(expanded), nil
+}
+
 type interpolated struct {
 	value string
 	raw   string
@@ -241,6 +241,19 @@ func TestValues(t *testing.T) {
 					"four":  "true",
 				})
 			})
+
+			Convey("Should keep secret references", func() {
+				doc := `
+                 val:
+                   one: $__vault{grafana/postgres:password}
+                   two: $STRING-$__secretfile{postgres:password}
+               `
+				unmarshalingTest(doc, d)
+				So(d.Val.Value(), ShouldResemble, map[string]string{
+					"one": "$__vault{grafana/postgres:password}",
+					"two": "test-$__secretfile{postgres:password}",
+				})
+			})
 		})
 
 		Reset(func() {
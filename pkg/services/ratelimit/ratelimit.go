@@ -0,0 +1,71 @@
+// Package ratelimit limits the rate of the requests to the route groups of the HTTP API, with token buckets
+// of every user, API key or organization.
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	registry.RegisterService(&RateLimitService{})
+}
+
+// RateLimitService tracks the buckets of the rate limits, locally or in the remote cache.
+type RateLimitService struct {
+	Cfg         *setting.Cfg             `inject:""`
+	RemoteCache *remotecache.RemoteCache `inject:""`
+
+	store store
+	now   func() time.Time
+}
+
+func (s *RateLimitService) Init() error {
+	s.now = time.Now
+	if s.Cfg.RateLimits.Storage == "remote_cache" {
+		s.store = &remoteStore{cache: s.RemoteCache}
+	} else {
+		s.store = newLocalStore()
+	}
+	return nil
+}
+
+// Allow takes a request from the bucket of the key for the route group, and returns how long to wait before
+// retrying when the bucket is empty.
+func (s *RateLimitService) Allow(group, key string) (bool, time.Duration, error) {
+	if !s.Cfg.RateLimits.Enabled {
+		return true, 0, nil
+	}
+
+	limit, ok := s.Cfg.RateLimits.Limits[group]
+	if !ok {
+		return true, 0, nil
+	}
+
+	retryAfter, err := s.store.take(fmt.Sprintf("rate-limit:%s:%s", group, key), limit, s.now())
+	if err != nil {
+		return false, 0, err
+	}
+	return retryAfter == 0, retryAfter, nil
+}
+
+// gcra implements the token buckets with the generic cell rate algorithm: the bucket of a key is the theoretical
+// arrival time of its next request, which every request pushes back by the emission interval of the limit.
+// It returns the new theoretical arrival time when the request is allowed, or how long to wait otherwise.
+func gcra(tat time.Time, limit setting.RateLimit, now time.Time) (time.Time, time.Duration) {
+	interval := time.Duration(float64(time.Second) / limit.RPS)
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-time.Duration(limit.Burst) * interval)
+	if now.Before(allowAt) {
+		return tat, allowAt.Sub(now)
+	}
+	return newTAT, 0
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, storage string) (*RateLimitService, *time.Time) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.RateLimits = setting.RateLimitSettings{
+		Enabled: true,
+		KeyBy:   "user",
+		Storage: storage,
+		Limits: map[string]setting.RateLimit{
+			setting.RateLimitGroupQuery: {RPS: 10, Burst: 5},
+		},
+	}
+
+	s := &RateLimitService{Cfg: cfg, RemoteCache: remotecache.NewFakeStore(t)}
+	require.NoError(t, s.Init())
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestRateLimitService(t *testing.T) {
+	for _, storage := range []string{"local", "remote_cache"} {
+		t.Run("With "+storage+" storage", func(t *testing.T) {
+			t.Run("Bursts are allowed, then requests at the average rate", func(t *testing.T) {
+				s, now := newTestService(t, storage)
+
+				for i := 0; i < 5; i++ {
+					allowed, _, err := s.Allow(setting.RateLimitGroupQuery, "user:1")
+					require.NoError(t, err)
+					assert.True(t, allowed)
+				}
+
+				allowed, retryAfter, err := s.Allow(setting.RateLimitGroupQuery, "user:1")
+				require.NoError(t, err)
+				assert.False(t, allowed)
+				assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+				*now = now.Add(retryAfter)
+				allowed, _, err = s.Allow(setting.RateLimitGroupQuery, "user:1")
+				require.NoError(t, err)
+				assert.True(t, allowed)
+
+				allowed, _, err = s.Allow(setting.RateLimitGroupQuery, "user:1")
+				require.NoError(t, err)
+				assert.False(t, allowed)
+			})
+
+			t.Run("Keys have buckets of their own", func(t *testing.T) {
+				s, _ := newTestService(t, storage)
+
+				for i := 0; i < 5; i++ {
+					_, _, err := s.Allow(setting.RateLimitGroupQuery, "api_key:1")
+					require.NoError(t, err)
+				}
+
+				allowed, _, err := s.Allow(setting.RateLimitGroupQuery, "user:1")
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			})
+
+			t.Run("Concurrent requests don't exceed the burst", func(t *testing.T) {
+				s, _ := newTestService(t, storage)
+
+				var mu sync.Mutex
+				var wg sync.WaitGroup
+				allowedCount := 0
+				for i := 0; i < 20; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						allowed, _, err := s.Allow(setting.RateLimitGroupQuery, "user:1")
+						assert.NoError(t, err)
+						if allowed {
+							mu.Lock()
+							allowedCount++
+							mu.Unlock()
+						}
+					}()
+				}
+				wg.Wait()
+
+				assert.LessOrEqual(t, allowedCount, 5)
+				assert.Greater(t, allowedCount, 0)
+			})
+
+			t.Run("Groups without limit are not limited", func(t *testing.T) {
+				s, _ := newTestService(t, storage)
+
+				for i := 0; i < 10; i++ {
+					allowed, _, err := s.Allow(setting.RateLimitGroupSearch, "user:1")
+					require.NoError(t, err)
+					assert.True(t, allowed)
+				}
+			})
+		})
+	}
+
+	t.Run("Nothing is limited when rate limits are disabled", func(t *testing.T) {
+		s, _ := newTestService(t, "local")
+		s.Cfg.RateLimits.Enabled = false
+
+		for i := 0; i < 10; i++ {
+			allowed, _, err := s.Allow(setting.RateLimitGroupQuery, "user:1")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+	})
+}
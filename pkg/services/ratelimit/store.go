@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// localStoreCleanupInterval is the number of requests between the removals of the full buckets of the local store
+const localStoreCleanupInterval = 1000
+
+type store interface {
+	// take takes a request from the bucket of the key, and returns how long to wait when the bucket is empty
+	take(key string, limit setting.RateLimit, now time.Time) (time.Duration, error)
+}
+
+// localStore keeps the buckets in memory, for the limits of this instance only.
+type localStore struct {
+	mu       sync.Mutex
+	tats     map[string]time.Time
+	requests int
+}
+
+func newLocalStore() *localStore {
+	return &localStore{tats: map[string]time.Time{}}
+}
+
+func (s *localStore) take(key string, limit setting.RateLimit, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if s.requests%localStoreCleanupInterval == 0 {
+		for k, tat := range s.tats {
+			if tat.Before(now) {
+				delete(s.tats, k)
+			}
+		}
+	}
+
+	tat, retryAfter := gcra(s.tats[key], limit, now)
+	if retryAfter == 0 {
+		s.tats[key] = tat
+	}
+	return retryAfter, nil
+}
+
+// remoteStoreMaxAttempts is the number of times a request of the remote store retries taking its token when
+// the bucket is updated concurrently, before it is refused
+const remoteStoreMaxAttempts = 5
+
+// remoteStore keeps the buckets in the remote cache, shared by all instances. The buckets are updated with a
+// compare and swap, so that concurrent requests of a key can't exceed the limit.
+type remoteStore struct {
+	cache *remotecache.RemoteCache
+}
+
+func (s *remoteStore) take(key string, limit setting.RateLimit, now time.Time) (time.Duration, error) {
+	var retryAfter time.Duration
+	for attempt := 0; attempt < remoteStoreMaxAttempts; attempt++ {
+		value, err := s.cache.Get(key)
+		if err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return 0, err
+		}
+		nanos, found := value.(int64)
+
+		var stored time.Time
+		if found {
+			stored = time.Unix(0, nanos)
+		}
+
+		var tat time.Time
+		tat, retryAfter = gcra(stored, limit, now)
+		if retryAfter > 0 {
+			return retryAfter, nil
+		}
+
+		// The bucket is full again at the theoretical arrival time, the remote cache expires in whole seconds
+		ttl := tat.Sub(now).Truncate(time.Second) + time.Second
+		var taken bool
+		if found {
+			taken, err = s.cache.CompareAndSwap(key, nanos, tat.UnixNano(), ttl)
+		} else {
+			taken, err = s.cache.SetIfNotExists(key, tat.UnixNano(), ttl)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if taken {
+			return 0, nil
+		}
+		retryAfter = time.Duration(float64(time.Second) / limit.RPS)
+	}
+
+	// The bucket is too contended, the request is refused until the next token
+	return retryAfter, nil
+}
@@ -0,0 +1,486 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Filter is a parsed filter expression, as in the filter parameter of the list requests.
+type Filter interface {
+	// match checks whether the JSON decoded value of a resource, or of a complex attribute, matches the filter
+	match(value interface{}) bool
+}
+
+// Path is a parsed attribute path of a PATCH operation, like `emails[type eq "work"].value`.
+type Path struct {
+	// Attr is the name of the attribute, lowercased and without schema, like "emails" or "name.givenname"
+	Attr string
+	// Filter selects the values of a multi-valued attribute, it is nil when the path has no filter
+	Filter Filter
+	// SubAttr is the lowercased sub-attribute of the values selected by the filter
+	SubAttr string
+}
+
+// ParseFilter parses a filter expression, it returns a bad request Error for invalid filters.
+func ParseFilter(filter string) (Filter, error) {
+	p, err := newParser(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, invalidFilter("unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+// ParsePath parses an attribute path, it returns a bad request Error for invalid paths.
+func ParsePath(path string) (*Path, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, NewError(http.StatusBadRequest, ScimTypeInvalidPath, "invalid path %q", path)
+	}
+	result := &Path{Attr: strings.Join(attrPath(tok.text), ".")}
+
+	if p.peek().kind == tokenOpenBracket {
+		p.next()
+		if result.Filter, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, NewError(http.StatusBadRequest, ScimTypeInvalidPath, "invalid path %q", path)
+		}
+		if tok := p.peek(); tok.kind == tokenWord && strings.HasPrefix(tok.text, ".") {
+			p.next()
+			result.SubAttr = strings.ToLower(strings.TrimPrefix(tok.text, "."))
+		}
+	}
+
+	if !p.done() {
+		return nil, NewError(http.StatusBadRequest, ScimTypeInvalidPath, "invalid path %q", path)
+	}
+	return result, nil
+}
+
+// Matches checks whether a resource, or the value of a complex attribute, matches a filter.
+func Matches(f Filter, value interface{}) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return false, err
+	}
+	return f.match(decoded), nil
+}
+
+func invalidFilter(format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, ScimTypeInvalidFilter, "invalid filter: "+format, args...)
+}
+
+// attrPath returns the lowercased components of an attribute path, without its schema.
+func attrPath(path string) []string {
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.Split(strings.ToLower(path), ".")
+}
+
+// resolve returns the values of an attribute path, with the values of the multi-valued attributes flattened.
+func resolve(value interface{}, path []string) []interface{} {
+	current := []interface{}{value}
+	for _, name := range path {
+		var next []interface{}
+		for _, v := range current {
+			object, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key, attr := range object {
+				if !strings.EqualFold(key, name) {
+					continue
+				}
+				if values, ok := attr.([]interface{}); ok {
+					next = append(next, values...)
+				} else {
+					next = append(next, attr)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// simpleValues returns the values of an attribute path, where complex values stand for their value
+// sub-attribute, as in `emails eq "user@example.com"`.
+func simpleValues(value interface{}, path []string) []interface{} {
+	values := resolve(value, path)
+	for i, v := range values {
+		if object, ok := v.(map[string]interface{}); ok {
+			values[i] = nil
+			if sub := resolve(object, []string{"value"}); len(sub) > 0 {
+				values[i] = sub[0]
+			}
+		}
+	}
+	return values
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f *logicalFilter) match(value interface{}) bool {
+	if f.and {
+		return f.left.match(value) && f.right.match(value)
+	}
+	return f.left.match(value) || f.right.match(value)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f *notFilter) match(value interface{}) bool {
+	return !f.filter.match(value)
+}
+
+// valuePathFilter matches the resources with a value of a multi-valued attribute matching its filter.
+type valuePathFilter struct {
+	path   []string
+	filter Filter
+}
+
+func (f *valuePathFilter) match(value interface{}) bool {
+	for _, v := range resolve(value, f.path) {
+		if f.filter.match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+type presentFilter struct {
+	path []string
+}
+
+func (f *presentFilter) match(value interface{}) bool {
+	for _, v := range simpleValues(value, f.path) {
+		if s, ok := v.(string); v != nil && (!ok || s != "") {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	path     []string
+	operator string
+	value    interface{}
+}
+
+func (f *compareFilter) match(value interface{}) bool {
+	values := simpleValues(value, f.path)
+	if f.operator == "ne" {
+		for _, v := range values {
+			if compare("eq", v, f.value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if f.operator == "eq" && f.value == nil {
+		return len(values) == 0 || compare("eq", values[0], nil)
+	}
+	for _, v := range values {
+		if compare(f.operator, v, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares an attribute value with the value of a filter, strings are compared case-insensitively.
+func compare(operator string, actual, expected interface{}) bool {
+	switch e := expected.(type) {
+	case nil:
+		return operator == "eq" && actual == nil
+	case bool:
+		a, ok := actual.(bool)
+		return ok && operator == "eq" && a == e
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		return compareOrdered(operator, a < e, a == e)
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch operator {
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		}
+		return compareOrdered(operator, a < e, a == e)
+	}
+	return false
+}
+
+func compareOrdered(operator string, less, equal bool) bool {
+	switch operator {
+	case "eq":
+		return equal
+	case "gt":
+		return !less && !equal
+	case "ge":
+		return !less
+	case "lt":
+		return less
+	case "le":
+		return less || equal
+	}
+	return false
+}
+
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true,
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(input string) (*parser, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, invalidFilter("unterminated string")
+			}
+			var s string
+			if err := json.Unmarshal([]byte(input[i:end+1]), &s); err != nil {
+				return nil, invalidFilter("invalid string %s", input[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s})
+			i = end + 1
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t\n\r()[]\"", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[i:end]})
+			i = end
+		}
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, keyword)
+}
+
+// parseOr parses the expressions joined with or, which have the lowest precedence.
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseExpression() (Filter, error) {
+	negate := false
+	if p.isKeyword("not") {
+		p.next()
+		negate = true
+		if p.peek().kind != tokenOpenParen {
+			return nil, invalidFilter("expected ( after not")
+		}
+	}
+
+	var f Filter
+	var err error
+	if p.peek().kind == tokenOpenParen {
+		p.next()
+		if f, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseParen {
+			return nil, invalidFilter("expected )")
+		}
+	} else if f, err = p.parseAttrExpression(); err != nil {
+		return nil, err
+	}
+
+	if negate {
+		return &notFilter{filter: f}, nil
+	}
+	return f, nil
+}
+
+func (p *parser) parseAttrExpression() (Filter, error) {
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, invalidFilter("expected an attribute, got %q", tok.text)
+	}
+	path := attrPath(tok.text)
+
+	if p.peek().kind == tokenOpenBracket {
+		p.next()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, invalidFilter("expected ]")
+		}
+		return &valuePathFilter{path: path, filter: filter}, nil
+	}
+
+	op := p.next()
+	operator := strings.ToLower(op.text)
+	if op.kind != tokenWord {
+		return nil, invalidFilter("expected an operator after %q", tok.text)
+	}
+	if operator == "pr" {
+		return &presentFilter{path: path}, nil
+	}
+	if !compareOperators[operator] {
+		return nil, invalidFilter("unknown operator %q", op.text)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &compareFilter{path: path, operator: operator, value: value}, nil
+}
+
+func (p *parser) parseValue() (interface{}, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return tok.text, nil
+	case tokenWord:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if n, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return n, nil
+		}
+	}
+	return nil, invalidFilter("invalid value %q", tok.text)
+}
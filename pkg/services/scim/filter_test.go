@@ -0,0 +1,130 @@
+package scim
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = map[string]interface{}{
+	"id":          "42",
+	"userName":    "bjensen",
+	"displayName": "Barbara Jensen",
+	"active":      true,
+	"name":        map[string]interface{}{"givenName": "Barbara", "familyName": "Jensen"},
+	"emails": []interface{}{
+		map[string]interface{}{"value": "bjensen@example.com", "type": "work", "primary": true},
+		map[string]interface{}{"value": "babs@example.org", "type": "home"},
+	},
+	"meta": map[string]interface{}{"lastModified": "2021-05-13T04:42:34Z"},
+}
+
+func TestParseFilter(t *testing.T) {
+	for filter, expected := range map[string]bool{
+		`userName eq "bjensen"`: true,
+		`USERNAME EQ "BJensen"`: true,
+		`userName eq "other"`:   false,
+		`userName ne "other"`:   true,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`: true,
+		`name.familyName co "ens"`:      true,
+		`userName sw "bj"`:              true,
+		`userName ew "sen"`:             true,
+		`displayName pr`:                true,
+		`title pr`:                      false,
+		`active eq true`:                true,
+		`active eq false`:               false,
+		`title eq null`:                 true,
+		`emails eq "babs@example.org"`:  true,
+		`emails.value co "example.org"`: true,
+		`emails[type eq "work" and value co "@example.com"]`:                             true,
+		`emails[type eq "home" and value co "@example.com"]`:                             false,
+		`meta.lastModified gt "2021-01-01T00:00:00Z"`:                                    true,
+		`meta.lastModified lt "2021-01-01T00:00:00Z"`:                                    false,
+		`userName eq "other" or active eq true`:                                          true,
+		`userName eq "bjensen" and not (active eq true)`:                                 false,
+		`(userName eq "other" or userName eq "bjensen") and name.givenName eq "Barbara"`: true,
+		`userName eq "other" or userName eq "bjensen" and active eq false`:               false,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			f, err := ParseFilter(filter)
+			require.NoError(t, err)
+
+			matches, err := Matches(f, testUser)
+			require.NoError(t, err)
+			assert.Equal(t, expected, matches)
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName like "bjensen"`,
+		`userName eq bjensen`,
+		`userName eq "bjensen`,
+		`(userName eq "bjensen"`,
+		`not userName eq "bjensen"`,
+		`emails[type eq "work"`,
+		`userName eq "bjensen" active eq true`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := ParseFilter(filter)
+			require.Error(t, err)
+
+			var scimErr *Error
+			require.True(t, errors.As(err, &scimErr))
+			assert.Equal(t, 400, scimErr.StatusCode())
+			assert.Equal(t, ScimTypeInvalidFilter, scimErr.ScimType)
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	t.Run("Simple paths", func(t *testing.T) {
+		path, err := ParsePath("name.givenName")
+		require.NoError(t, err)
+		assert.Equal(t, &Path{Attr: "name.givenname"}, path)
+
+		path, err = ParsePath("urn:ietf:params:scim:schemas:core:2.0:User:active")
+		require.NoError(t, err)
+		assert.Equal(t, &Path{Attr: "active"}, path)
+	})
+
+	t.Run("Paths with a value filter", func(t *testing.T) {
+		path, err := ParsePath(`emails[type eq "work"].value`)
+		require.NoError(t, err)
+		assert.Equal(t, "emails", path.Attr)
+		assert.Equal(t, "value", path.SubAttr)
+
+		matches, err := Matches(path.Filter, map[string]interface{}{"type": "work"})
+		require.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("Invalid paths", func(t *testing.T) {
+		for _, p := range []string{``, `members[value eq "1"`, `members[value eq "1"] extra`} {
+			_, err := ParsePath(p)
+			require.Error(t, err, p)
+		}
+	})
+}
+
+func TestPage(t *testing.T) {
+	for _, tc := range []struct {
+		total, startIndex, count int
+		from, to                 int
+	}{
+		{total: 10, startIndex: 1, count: 5, from: 0, to: 5},
+		{total: 10, startIndex: 8, count: 5, from: 7, to: 10},
+		{total: 10, startIndex: 0, count: 3, from: 0, to: 3},
+		{total: 10, startIndex: 20, count: 3, from: 10, to: 10},
+		{total: 500, startIndex: 1, count: 1000, from: 0, to: MaxResults},
+		{total: 10, startIndex: 1, count: -1, from: 0, to: 0},
+	} {
+		from, to := Page(tc.total, tc.startIndex, tc.count)
+		assert.Equal(t, []int{tc.from, tc.to}, []int{from, to}, "%+v", tc)
+	}
+}
@@ -0,0 +1,100 @@
+// Package scim implements the parts of the SCIM 2.0 protocol (RFC 7643 and RFC 7644) which don't depend on
+// the resources: the schemas, the errors, the list responses, and the filters and paths of the requests.
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of the requests and responses
+const ContentType = "application/scim+json"
+
+// Schemas of the resources and messages
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Types of the bad requests
+const (
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidPath   = "invalidPath"
+	ScimTypeInvalidValue  = "invalidValue"
+	ScimTypeNoTarget      = "noTarget"
+	ScimTypeUniqueness    = "uniqueness"
+	ScimTypeMutability    = "mutability"
+	ScimTypeInvalidSyntax = "invalidSyntax"
+)
+
+// MaxResults is the maximum number of resources returned in a list response
+const MaxResults = 200
+
+// Error is the body of the error responses.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// StatusCode returns the HTTP status of the error.
+func (e *Error) StatusCode() int {
+	status, err := strconv.Atoi(e.Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// NewError returns an error of the status, with a SCIM type for the bad requests.
+func NewError(status int, scimType string, format string, args ...interface{}) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   fmt.Sprintf(format, args...),
+	}
+}
+
+// ListResponse is the body of the responses listing resources.
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// Page returns the bounds of the slice of the page of the results starting at the 1-based start index, and
+// holding count results at most, as in the startIndex and count parameters of the list requests.
+func Page(total, startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > MaxResults {
+		count = MaxResults
+	}
+
+	from := startIndex - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+	return from, to
+}
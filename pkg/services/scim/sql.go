@@ -0,0 +1,159 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrFilterNotSupported is returned by FilterSQL for the filters it can't translate, whose resources must be
+// matched with Matches instead.
+var ErrFilterNotSupported = errors.New("filter not supported in SQL")
+
+// SQLKind is the type of the value of an attribute in the database.
+type SQLKind int
+
+const (
+	// SQLString values are compared case-insensitively, with all the operators
+	SQLString SQLKind = iota
+	// SQLBool values are only compared with eq
+	SQLBool
+	// SQLID values are the integer IDs which are strings in the resources, only compared with eq and ne
+	SQLID
+)
+
+// SQLAttribute is the SQL expression of the value of an attribute.
+type SQLAttribute struct {
+	// Expr is the SQL expression of the value, it mustn't be NULL
+	Expr string
+	Kind SQLKind
+	// Negated is set for the boolean attributes which are the negation of Expr
+	Negated bool
+	// Exists is set for the multi-valued attributes, it is a SQL condition with a %s verb replaced by the
+	// condition on Expr, like "EXISTS (SELECT 1 FROM ... WHERE ... AND %s)"
+	Exists string
+}
+
+// SQLAttributes maps the lowercased attribute paths, like "username" or "emails.value", to their SQL expressions.
+type SQLAttributes map[string]SQLAttribute
+
+// likeEscape escapes the wildcards of the LIKE patterns, it isn't a backslash whose escaping differs
+// between the databases.
+const likeEscape = "!"
+
+var likeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// FilterSQL translates a filter to a SQL condition and its arguments. It returns ErrFilterNotSupported for
+// the filters on attributes which aren't in attrs, the filters of multi-valued attributes like
+// `emails[type eq "work"]`, and the comparisons which aren't supported by the kind of the attribute.
+func FilterSQL(f Filter, attrs SQLAttributes) (string, []interface{}, error) {
+	switch f := f.(type) {
+	case *logicalFilter:
+		left, leftArgs, err := FilterSQL(f.left, attrs)
+		if err != nil {
+			return "", nil, err
+		}
+		right, rightArgs, err := FilterSQL(f.right, attrs)
+		if err != nil {
+			return "", nil, err
+		}
+		operator := "OR"
+		if f.and {
+			operator = "AND"
+		}
+		return fmt.Sprintf("(%s %s %s)", left, operator, right), append(leftArgs, rightArgs...), nil
+	case *notFilter:
+		cond, args, err := FilterSQL(f.filter, attrs)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + cond, args, nil
+	case *presentFilter:
+		attr, ok := attrs[strings.Join(f.path, ".")]
+		if !ok {
+			return "", nil, ErrFilterNotSupported
+		}
+		cond := "1 = 1"
+		if attr.Kind == SQLString {
+			cond = attr.Expr + " <> ''"
+		}
+		return attr.exists("(" + cond + ")"), nil, nil
+	case *compareFilter:
+		attr, ok := attrs[strings.Join(f.path, ".")]
+		if !ok {
+			return "", nil, ErrFilterNotSupported
+		}
+		if f.operator == "ne" {
+			cond, args, err := attr.compare("eq", f.value)
+			if err != nil {
+				return "", nil, err
+			}
+			return "NOT " + attr.exists(cond), args, nil
+		}
+		if f.operator == "eq" && f.value == nil {
+			present, _, err := FilterSQL(&presentFilter{path: f.path}, attrs)
+			return "NOT " + present, nil, err
+		}
+		cond, args, err := attr.compare(f.operator, f.value)
+		if err != nil {
+			return "", nil, err
+		}
+		return attr.exists(cond), args, nil
+	}
+	return "", nil, ErrFilterNotSupported
+}
+
+func (attr SQLAttribute) exists(cond string) string {
+	if attr.Exists == "" {
+		return cond
+	}
+	return "(" + fmt.Sprintf(attr.Exists, cond) + ")"
+}
+
+// compare returns the condition comparing the attribute with a value, with the semantics of compare.
+func (attr SQLAttribute) compare(operator string, value interface{}) (string, []interface{}, error) {
+	never := "(1 = 0)"
+
+	switch attr.Kind {
+	case SQLBool:
+		if operator != "eq" {
+			return never, nil, nil
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return never, nil, nil
+		}
+		return "(" + attr.Expr + " = ?)", []interface{}{b != attr.Negated}, nil
+	case SQLID:
+		if operator != "eq" {
+			return "", nil, ErrFilterNotSupported
+		}
+		s, ok := value.(string)
+		if !ok {
+			return never, nil, nil
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return never, nil, nil
+		}
+		return "(" + attr.Expr + " = ?)", []interface{}{id}, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return never, nil, nil
+	}
+	s = strings.ToLower(s)
+	expr := "LOWER(" + attr.Expr + ")"
+	switch operator {
+	case "co":
+		return "(" + expr + " LIKE ? ESCAPE '" + likeEscape + "')", []interface{}{"%" + likeReplacer.Replace(s) + "%"}, nil
+	case "sw":
+		return "(" + expr + " LIKE ? ESCAPE '" + likeEscape + "')", []interface{}{likeReplacer.Replace(s) + "%"}, nil
+	case "ew":
+		return "(" + expr + " LIKE ? ESCAPE '" + likeEscape + "')", []interface{}{"%" + likeReplacer.Replace(s)}, nil
+	}
+	sqlOperators := map[string]string{"eq": "=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+	return "(" + expr + " " + sqlOperators[operator] + " ?)", []interface{}{s}, nil
+}
@@ -0,0 +1,64 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAttributes = SQLAttributes{
+	"id":           {Expr: "u.id", Kind: SQLID},
+	"username":     {Expr: "u.login"},
+	"active":       {Expr: "u.is_disabled", Kind: SQLBool, Negated: true},
+	"groups.value": {Expr: "tm.team_id", Kind: SQLID, Exists: "EXISTS (SELECT 1 FROM team_member AS tm WHERE %s)"},
+}
+
+func TestFilterSQL(t *testing.T) {
+	for filter, expected := range map[string]struct {
+		cond string
+		args []interface{}
+	}{
+		`userName eq "BJensen"`: {"(LOWER(u.login) = ?)", []interface{}{"bjensen"}},
+		`userName co "50%_"`:    {"(LOWER(u.login) LIKE ? ESCAPE '!')", []interface{}{"%50!%!_%"}},
+		`userName sw "a!"`:      {"(LOWER(u.login) LIKE ? ESCAPE '!')", []interface{}{"a!!%"}},
+		`userName ne "bjensen"`: {"NOT (LOWER(u.login) = ?)", []interface{}{"bjensen"}},
+		`userName pr`:           {"(u.login <> '')", nil},
+		`userName eq null`:      {"NOT (u.login <> '')", nil},
+		`userName eq 42`:        {"(1 = 0)", nil},
+		`active eq true`:        {"(u.is_disabled = ?)", []interface{}{false}},
+		`id eq "42"`:            {"(u.id = ?)", []interface{}{int64(42)}},
+		`id eq "bjensen"`:       {"(1 = 0)", nil},
+		`groups.value eq "7"`:   {"(EXISTS (SELECT 1 FROM team_member AS tm WHERE (tm.team_id = ?)))", []interface{}{int64(7)}},
+		`userName eq "a" or not (active eq false) and id eq "1"`: {
+			"((LOWER(u.login) = ?) OR (NOT (u.is_disabled = ?) AND (u.id = ?)))", []interface{}{"a", true, int64(1)},
+		},
+	} {
+		t.Run(filter, func(t *testing.T) {
+			f, err := ParseFilter(filter)
+			require.NoError(t, err)
+
+			cond, args, err := FilterSQL(f, testAttributes)
+			require.NoError(t, err)
+			assert.Equal(t, expected.cond, cond)
+			assert.Equal(t, expected.args, args)
+		})
+	}
+}
+
+func TestFilterSQLNotSupported(t *testing.T) {
+	for _, filter := range []string{
+		`title eq "Tour Guide"`,
+		`emails[type eq "work"]`,
+		`id gt "42"`,
+		`userName eq "bjensen" and title pr`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			f, err := ParseFilter(filter)
+			require.NoError(t, err)
+
+			_, _, err = FilterSQL(f, testAttributes)
+			assert.ErrorIs(t, err, ErrFilterNotSupported)
+		})
+	}
+}
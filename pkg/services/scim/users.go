@@ -0,0 +1,16 @@
+package scim
+
+import "github.com/grafana/grafana/pkg/models"
+
+// SearchUsersQuery returns a page of the users of an organization matching a filter, ordered by ID. Grafana
+// Admins aren't SCIM users. It fails with ErrFilterNotSupported for the filters FilterSQL can't translate.
+type SearchUsersQuery struct {
+	OrgId  int64
+	Filter Filter
+	Offset int
+	// Limit is the maximum number of users returned, all of them when negative
+	Limit int
+
+	Result     []*models.User
+	TotalCount int64
+}
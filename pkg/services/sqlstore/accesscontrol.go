@@ -0,0 +1,353 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const maxRoleUidLength = 40
+
+func init() {
+	bus.AddHandler("sql", CreateRole)
+	bus.AddHandler("sql", UpdateRole)
+	bus.AddHandler("sql", DeleteRole)
+	bus.AddHandler("sql", GetRoles)
+	bus.AddHandler("sql", GetRoleByUid)
+
+	bus.AddHandler("sql", AddRoleAssignment)
+	bus.AddHandler("sql", RemoveRoleAssignment)
+	bus.AddHandler("sql", GetRoleAssignments)
+	bus.AddHandler("sql", GetUserPermissions)
+}
+
+func CreateRole(cmd *models.CreateRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		uid := cmd.Uid
+		if uid == "" {
+			uid = util.GenerateShortUID()
+		} else if !util.IsValidShortUID(uid) || len(uid) > maxRoleUidLength {
+			return models.ErrRoleInvalidUid
+		}
+
+		if exists, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, uid).Exist(&models.Role{}); err != nil {
+			return err
+		} else if exists {
+			return models.ErrRoleUidTaken
+		}
+
+		if err := validateRoleName(sess, cmd.OrgId, cmd.Name, 0); err != nil {
+			return err
+		}
+
+		role := models.Role{
+			OrgId:       cmd.OrgId,
+			Uid:         uid,
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+		}
+
+		if _, err := sess.Insert(&role); err != nil {
+			return err
+		}
+
+		permissions, err := insertRolePermissions(sess, role.Id, cmd.Permissions)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = roleDTO(&role, permissions)
+		return nil
+	})
+}
+
+func UpdateRole(cmd *models.UpdateRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		if err := validateRoleName(sess, cmd.OrgId, cmd.Name, role.Id); err != nil {
+			return err
+		}
+
+		role.Name = cmd.Name
+		role.Description = cmd.Description
+		role.Updated = time.Now()
+
+		if _, err := sess.ID(role.Id).Cols("name", "description", "updated").Update(role); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("DELETE FROM permission WHERE role_id = ?", role.Id); err != nil {
+			return err
+		}
+
+		permissions, err := insertRolePermissions(sess, role.Id, cmd.Permissions)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = roleDTO(role, permissions)
+		return nil
+	})
+}
+
+// DeleteRole deletes a role, its permissions and its assignments
+func DeleteRole(cmd *models.DeleteRoleCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM role_assignment WHERE role_id = ?",
+			"DELETE FROM permission WHERE role_id = ?",
+			"DELETE FROM role WHERE id = ?",
+		}
+
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, role.Id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func GetRoles(query *models.GetRolesQuery) error {
+	query.Result = make([]*models.Role, 0)
+	return x.Where("org_id=?", query.OrgId).Asc("name").Find(&query.Result)
+}
+
+func GetRoleByUid(query *models.GetRoleByUidQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, query.OrgId, query.Uid)
+		if err != nil {
+			return err
+		}
+
+		var permissions []models.RolePermission
+		if err := sess.Where("role_id=?", role.Id).Asc("action", "scope").Find(&permissions); err != nil {
+			return err
+		}
+
+		query.Result = roleDTO(role, permissions)
+		return nil
+	})
+}
+
+func AddRoleAssignment(cmd *models.AddRoleAssignmentCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, cmd.OrgId, cmd.RoleUid)
+		if err != nil {
+			return err
+		}
+
+		assignment := models.RoleAssignment{
+			OrgId:       cmd.OrgId,
+			RoleId:      role.Id,
+			UserId:      cmd.UserId,
+			TeamId:      cmd.TeamId,
+			ApiKeyId:    cmd.ApiKeyId,
+			BuiltinRole: cmd.BuiltinRole,
+			Created:     time.Now(),
+		}
+
+		if !assignment.Valid() {
+			return models.ErrInvalidRoleAssignment
+		}
+
+		if err := validateRoleAssignee(sess, assignment); err != nil {
+			return err
+		}
+
+		exists, err := sess.Where("role_id=? AND user_id=? AND team_id=? AND api_key_id=? AND builtin_role=?",
+			assignment.RoleId, assignment.UserId, assignment.TeamId, assignment.ApiKeyId, assignment.BuiltinRole).
+			Exist(&models.RoleAssignment{})
+		if err != nil {
+			return err
+		} else if exists {
+			return models.ErrRoleAssignmentExists
+		}
+
+		if _, err := sess.Insert(&assignment); err != nil {
+			return err
+		}
+
+		cmd.Result = &assignment
+		return nil
+	})
+}
+
+func RemoveRoleAssignment(cmd *models.RemoveRoleAssignmentCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, cmd.OrgId, cmd.RoleUid)
+		if err != nil {
+			return err
+		}
+
+		res, err := sess.Exec("DELETE FROM role_assignment WHERE id = ? AND role_id = ?", cmd.Id, role.Id)
+		if err != nil {
+			return err
+		}
+
+		if rows, err := res.RowsAffected(); err != nil {
+			return err
+		} else if rows == 0 {
+			return models.ErrRoleAssignmentNotFound
+		}
+
+		return nil
+	})
+}
+
+func GetRoleAssignments(query *models.GetRoleAssignmentsQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		role, err := getRoleByUid(sess, query.OrgId, query.RoleUid)
+		if err != nil {
+			return err
+		}
+
+		query.Result = make([]*models.RoleAssignment, 0)
+		return sess.Where("role_id=?", role.Id).Asc("id").Find(&query.Result)
+	})
+}
+
+// GetUserPermissions returns the permissions of the custom roles assigned to the user,
+// the teams of the user, the API key or the built-in roles in the organization
+func GetUserPermissions(query *models.GetUserPermissionsQuery) error {
+	filters := []string{"(role_assignment.user_id > 0 AND role_assignment.user_id = ?)"}
+	params := []interface{}{query.OrgId, query.UserId}
+
+	if query.UserId > 0 {
+		filters = append(filters, `role_assignment.team_id IN (SELECT team_id FROM team_member WHERE team_member.org_id = ? AND team_member.user_id = ?)`)
+		params = append(params, query.OrgId, query.UserId)
+	}
+
+	if query.ApiKeyId > 0 {
+		filters = append(filters, "role_assignment.api_key_id = ?")
+		params = append(params, query.ApiKeyId)
+	}
+
+	if len(query.BuiltinRoles) > 0 {
+		filters = append(filters, "role_assignment.builtin_role IN (?"+strings.Repeat(",?", len(query.BuiltinRoles)-1)+")")
+		for _, role := range query.BuiltinRoles {
+			params = append(params, role)
+		}
+	}
+
+	rawSQL := `SELECT DISTINCT permission.action, permission.scope
+		FROM permission
+		INNER JOIN role_assignment ON role_assignment.role_id = permission.role_id
+		WHERE role_assignment.org_id = ? AND (` + strings.Join(filters, " OR ") + `)`
+
+	query.Result = make([]models.Permission, 0)
+	return x.SQL(rawSQL, params...).Find(&query.Result)
+}
+
+func getRoleByUid(sess *DBSession, orgId int64, uid string) (*models.Role, error) {
+	var role models.Role
+	if exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&role); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, models.ErrRoleNotFound
+	}
+
+	return &role, nil
+}
+
+func validateRoleName(sess *DBSession, orgId int64, name string, existingId int64) error {
+	var role models.Role
+	exists, err := sess.Where("org_id=? AND name=?", orgId, name).Get(&role)
+	if err != nil {
+		return err
+	}
+
+	if exists && role.Id != existingId {
+		return models.ErrRoleNameTaken
+	}
+
+	return nil
+}
+
+func validateRoleAssignee(sess *DBSession, assignment models.RoleAssignment) error {
+	var sqlOrArgs []interface{}
+
+	switch {
+	case assignment.UserId > 0:
+		sqlOrArgs = []interface{}{"SELECT 1 FROM org_user WHERE org_id = ? AND user_id = ?", assignment.OrgId, assignment.UserId}
+	case assignment.TeamId > 0:
+		sqlOrArgs = []interface{}{"SELECT 1 FROM team WHERE org_id = ? AND id = ?", assignment.OrgId, assignment.TeamId}
+	case assignment.ApiKeyId > 0:
+		sqlOrArgs = []interface{}{"SELECT 1 FROM api_key WHERE org_id = ? AND id = ?", assignment.OrgId, assignment.ApiKeyId}
+	default:
+		if !models.IsValidBuiltinRole(assignment.BuiltinRole) {
+			return models.ErrInvalidBuiltinRole
+		}
+		return nil
+	}
+
+	res, err := sess.Query(sqlOrArgs...)
+	if err != nil {
+		return err
+	}
+
+	if len(res) == 0 {
+		return models.ErrRoleAssigneeNotFound
+	}
+
+	return nil
+}
+
+func insertRolePermissions(sess *DBSession, roleId int64, permissions []models.Permission) ([]models.RolePermission, error) {
+	inserted := make([]models.RolePermission, 0, len(permissions))
+	seen := make(map[models.Permission]bool, len(permissions))
+	for _, p := range permissions {
+		if p.Action == "" {
+			return nil, models.ErrInvalidPermissionAction
+		}
+
+		// duplicate permissions would break the unique index
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		permission := models.RolePermission{RoleId: roleId, Action: p.Action, Scope: p.Scope}
+		if _, err := sess.Insert(&permission); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, permission)
+	}
+
+	return inserted, nil
+}
+
+func roleDTO(role *models.Role, permissions []models.RolePermission) *models.RoleDTO {
+	dto := &models.RoleDTO{
+		Id:          role.Id,
+		OrgId:       role.OrgId,
+		Uid:         role.Uid,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: make([]models.Permission, 0, len(permissions)),
+		Created:     role.Created,
+		Updated:     role.Updated,
+	}
+
+	for _, p := range permissions {
+		dto.Permissions = append(dto.Permissions, models.Permission{Action: p.Action, Scope: p.Scope})
+	}
+
+	return dto
+}
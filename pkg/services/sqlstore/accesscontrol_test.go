@@ -0,0 +1,169 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestAccessControlRoles(t *testing.T) {
+	InitTestDB(t)
+
+	adminCmd := models.CreateUserCommand{Login: "rbac-admin"}
+	require.NoError(t, CreateUser(context.Background(), &adminCmd))
+	orgID := adminCmd.Result.OrgId
+
+	createRole := func(t *testing.T, name string, permissions ...models.Permission) *models.RoleDTO {
+		t.Helper()
+
+		cmd := models.CreateRoleCommand{OrgId: orgID, Name: name, Permissions: permissions}
+		require.NoError(t, CreateRole(&cmd))
+		return cmd.Result
+	}
+
+	t.Run("Roles can be created, updated and deleted", func(t *testing.T) {
+		role := createRole(t, "datasource reader",
+			models.Permission{Action: "datasources:read", Scope: "datasources:id:1"},
+			models.Permission{Action: "datasources:read", Scope: "datasources:id:1"},
+		)
+		assert.NotEmpty(t, role.Uid)
+		assert.Equal(t, []models.Permission{{Action: "datasources:read", Scope: "datasources:id:1"}}, role.Permissions)
+
+		err := CreateRole(&models.CreateRoleCommand{OrgId: orgID, Name: "datasource reader"})
+		assert.Equal(t, models.ErrRoleNameTaken, err)
+
+		err = CreateRole(&models.CreateRoleCommand{OrgId: orgID, Uid: role.Uid, Name: "other"})
+		assert.Equal(t, models.ErrRoleUidTaken, err)
+
+		update := models.UpdateRoleCommand{
+			OrgId:       orgID,
+			Uid:         role.Uid,
+			Name:        "datasource writer",
+			Description: "Writes data sources",
+			Permissions: []models.Permission{{Action: "datasources:write", Scope: "datasources:*"}},
+		}
+		require.NoError(t, UpdateRole(&update))
+
+		query := models.GetRoleByUidQuery{OrgId: orgID, Uid: role.Uid}
+		require.NoError(t, GetRoleByUid(&query))
+		assert.Equal(t, "datasource writer", query.Result.Name)
+		assert.Equal(t, "Writes data sources", query.Result.Description)
+		assert.Equal(t, []models.Permission{{Action: "datasources:write", Scope: "datasources:*"}}, query.Result.Permissions)
+
+		rolesQuery := models.GetRolesQuery{OrgId: orgID}
+		require.NoError(t, GetRoles(&rolesQuery))
+		require.Len(t, rolesQuery.Result, 1)
+
+		require.NoError(t, DeleteRole(&models.DeleteRoleCommand{OrgId: orgID, Uid: role.Uid}))
+
+		err = GetRoleByUid(&models.GetRoleByUidQuery{OrgId: orgID, Uid: role.Uid})
+		assert.Equal(t, models.ErrRoleNotFound, err)
+
+		err = GetRoleByUid(&models.GetRoleByUidQuery{OrgId: orgID + 1, Uid: role.Uid})
+		assert.Equal(t, models.ErrRoleNotFound, err)
+	})
+
+	t.Run("Permissions come from the roles of the user, the teams, the API key and the built-in roles", func(t *testing.T) {
+		createOrgUser := func(t *testing.T, login string) int64 {
+			t.Helper()
+
+			cmd := models.CreateUserCommand{Login: login, SkipOrgSetup: true}
+			require.NoError(t, CreateUser(context.Background(), &cmd))
+			require.NoError(t, AddOrgUser(&models.AddOrgUserCommand{OrgId: orgID, UserId: cmd.Result.Id, Role: models.ROLE_VIEWER}))
+			return cmd.Result.Id
+		}
+
+		userID := createOrgUser(t, "rbac-user")
+		otherID := createOrgUser(t, "rbac-other")
+
+		teamCmd := models.CreateTeamCommand{Name: "rbac-team", OrgId: orgID}
+		require.NoError(t, CreateTeam(&teamCmd))
+		require.NoError(t, AddTeamMember(&models.AddTeamMemberCommand{OrgId: orgID, TeamId: teamCmd.Result.Id, UserId: userID}))
+
+		keyCmd := models.AddApiKeyCommand{OrgId: orgID, Name: "rbac-key", Key: "rbac-key", Role: models.ROLE_VIEWER}
+		require.NoError(t, AddApiKey(&keyCmd))
+
+		userRole := createRole(t, "user role", models.Permission{Action: "users:read", Scope: "users:*"})
+		teamRole := createRole(t, "team role", models.Permission{Action: "dashboards:create", Scope: "folders:id:1"})
+		keyRole := createRole(t, "key role", models.Permission{Action: "alerting:manage"})
+		builtinRole := createRole(t, "builtin role", models.Permission{Action: "datasources:read", Scope: "datasources:*"})
+
+		assign := func(t *testing.T, cmd models.AddRoleAssignmentCommand) *models.RoleAssignment {
+			t.Helper()
+			cmd.OrgId = orgID
+			require.NoError(t, AddRoleAssignment(&cmd))
+			return cmd.Result
+		}
+
+		assign(t, models.AddRoleAssignmentCommand{RoleUid: userRole.Uid, UserId: userID})
+		teamAssignment := assign(t, models.AddRoleAssignmentCommand{RoleUid: teamRole.Uid, TeamId: teamCmd.Result.Id})
+		assign(t, models.AddRoleAssignmentCommand{RoleUid: keyRole.Uid, ApiKeyId: keyCmd.Result.Id})
+		assign(t, models.AddRoleAssignmentCommand{RoleUid: builtinRole.Uid, BuiltinRole: "Editor"})
+
+		err := AddRoleAssignment(&models.AddRoleAssignmentCommand{OrgId: orgID, RoleUid: userRole.Uid, UserId: userID})
+		assert.Equal(t, models.ErrRoleAssignmentExists, err)
+
+		err = AddRoleAssignment(&models.AddRoleAssignmentCommand{OrgId: orgID, RoleUid: userRole.Uid, UserId: userID, TeamId: teamCmd.Result.Id})
+		assert.Equal(t, models.ErrInvalidRoleAssignment, err)
+
+		err = AddRoleAssignment(&models.AddRoleAssignmentCommand{OrgId: orgID, RoleUid: userRole.Uid, BuiltinRole: "Owner"})
+		assert.Equal(t, models.ErrInvalidBuiltinRole, err)
+
+		err = AddRoleAssignment(&models.AddRoleAssignmentCommand{OrgId: orgID, RoleUid: userRole.Uid, TeamId: 1000})
+		assert.Equal(t, models.ErrRoleAssigneeNotFound, err)
+
+		getPermissions := func(t *testing.T, query models.GetUserPermissionsQuery) []models.Permission {
+			t.Helper()
+			query.OrgId = orgID
+			require.NoError(t, GetUserPermissions(&query))
+			return query.Result
+		}
+
+		assert.ElementsMatch(t, []models.Permission{
+			{Action: "users:read", Scope: "users:*"},
+			{Action: "dashboards:create", Scope: "folders:id:1"},
+		}, getPermissions(t, models.GetUserPermissionsQuery{UserId: userID, BuiltinRoles: []string{"Viewer"}}))
+
+		assert.ElementsMatch(t, []models.Permission{
+			{Action: "datasources:read", Scope: "datasources:*"},
+		}, getPermissions(t, models.GetUserPermissionsQuery{UserId: otherID, BuiltinRoles: []string{"Viewer", "Editor"}}))
+
+		assert.ElementsMatch(t, []models.Permission{
+			{Action: "alerting:manage", Scope: ""},
+		}, getPermissions(t, models.GetUserPermissionsQuery{ApiKeyId: keyCmd.Result.Id, BuiltinRoles: []string{"Viewer"}}))
+
+		assignmentsQuery := models.GetRoleAssignmentsQuery{OrgId: orgID, RoleUid: teamRole.Uid}
+		require.NoError(t, GetRoleAssignments(&assignmentsQuery))
+		require.Len(t, assignmentsQuery.Result, 1)
+		assert.Equal(t, teamCmd.Result.Id, assignmentsQuery.Result[0].TeamId)
+
+		t.Run("Removing an assignment removes its permissions", func(t *testing.T) {
+			require.NoError(t, RemoveRoleAssignment(&models.RemoveRoleAssignmentCommand{OrgId: orgID, RoleUid: teamRole.Uid, Id: teamAssignment.Id}))
+
+			err := RemoveRoleAssignment(&models.RemoveRoleAssignmentCommand{OrgId: orgID, RoleUid: teamRole.Uid, Id: teamAssignment.Id})
+			assert.Equal(t, models.ErrRoleAssignmentNotFound, err)
+
+			assert.ElementsMatch(t, []models.Permission{
+				{Action: "users:read", Scope: "users:*"},
+			}, getPermissions(t, models.GetUserPermissionsQuery{UserId: userID}))
+		})
+
+		t.Run("Deleting an API key removes its assignments", func(t *testing.T) {
+			require.NoError(t, DeleteApiKeyCtx(context.Background(), &models.DeleteApiKeyCommand{OrgId: orgID, Id: keyCmd.Result.Id}))
+
+			assert.Empty(t, getPermissions(t, models.GetUserPermissionsQuery{ApiKeyId: keyCmd.Result.Id}))
+		})
+
+		t.Run("Removing a user from the organization removes its assignments", func(t *testing.T) {
+			require.NoError(t, RemoveOrgUser(&models.RemoveOrgUserCommand{OrgId: orgID, UserId: userID}))
+
+			assert.Empty(t, getPermissions(t, models.GetUserPermissionsQuery{UserId: userID}))
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -165,6 +166,29 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 		params = append(params, query.DashboardId)
 	}
 
+	if len(query.DashboardIds) > 0 {
+		// the IDs are written in the query, there can be more of them than bind parameters
+		ids := make([]string, len(query.DashboardIds))
+		for i, id := range query.DashboardIds {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		sql.WriteString(` AND a.dashboard_id IN (` + strings.Join(ids, ",") + `)`)
+	}
+
+	if len(query.PrevStates) > 0 {
+		sql.WriteString(` AND a.prev_state IN (?` + strings.Repeat(",?", len(query.PrevStates)-1) + `)`)
+		for _, state := range query.PrevStates {
+			params = append(params, state)
+		}
+	}
+
+	if len(query.NewStates) > 0 {
+		sql.WriteString(` AND a.new_state IN (?` + strings.Repeat(",?", len(query.NewStates)-1) + `)`)
+		for _, state := range query.NewStates {
+			params = append(params, state)
+		}
+	}
+
 	if query.PanelId != 0 {
 		sql.WriteString(` AND a.panel_id = ?`)
 		params = append(params, query.PanelId)
@@ -222,8 +246,14 @@ func (r *SQLAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 		query.Limit = 100
 	}
 
-	// order of ORDER BY arguments match the order of a sql index for performance
-	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + dialect.Limit(query.Limit) + " ) dt on dt.id = annotation.id")
+	limit := dialect.Limit(query.Limit)
+	if query.Offset > 0 {
+		limit = dialect.LimitOffset(query.Limit, query.Offset)
+	}
+
+	// order of ORDER BY arguments match the order of a sql index for performance,
+	// the id makes the order stable across pages
+	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC, a.id DESC" + limit + " ) dt on dt.id = annotation.id")
 
 	items := make([]*annotations.ItemDTO, 0)
 
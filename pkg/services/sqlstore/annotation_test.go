@@ -97,6 +97,24 @@ func TestAnnotations(t *testing.T) {
 			assert.Equal(t, items[0].Updated, items[0].Created)
 		})
 
+		t.Run("Can query for annotations of several dashboards", func(t *testing.T) {
+			items, err := repo.Find(&annotations.ItemQuery{
+				OrgId:        1,
+				DashboardIds: []int64{2, 3},
+			})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, annotation2.Id, items[0].Id)
+
+			items, err = repo.Find(&annotations.ItemQuery{
+				OrgId:        1,
+				DashboardIds: []int64{1, 2},
+				NewStates:    []string{"alerting"},
+			})
+			require.NoError(t, err)
+			assert.Empty(t, items)
+		})
+
 		t.Run("Can query for annotation by id", func(t *testing.T) {
 			items, err := repo.Find(&annotations.ItemQuery{
 				OrgId:        1,
@@ -14,13 +14,15 @@ func init() {
 	bus.AddHandler("sql", GetApiKeyByName)
 	bus.AddHandlerCtx("sql", DeleteApiKeyCtx)
 	bus.AddHandler("sql", AddApiKey)
+	bus.AddHandler("sql", UpdateApiKeyLastUsedAt)
 }
 
 func GetApiKeys(query *models.GetApiKeysQuery) error {
-	sess := x.Limit(100, 0).Where("org_id=? and ( expires IS NULL or expires >= ?)",
+	// the tokens of service accounts are listed with the service accounts
+	sess := x.Limit(100, 0).Where("org_id=? and service_account_id IS NULL and ( expires IS NULL or expires >= ?)",
 		query.OrgId, timeNow().Unix()).Asc("name")
 	if query.IncludeExpired {
-		sess = x.Limit(100, 0).Where("org_id=?", query.OrgId).Asc("name")
+		sess = x.Limit(100, 0).Where("org_id=? and service_account_id IS NULL", query.OrgId).Asc("name")
 	}
 
 	query.Result = make([]*models.ApiKey, 0)
@@ -30,7 +32,11 @@ func GetApiKeys(query *models.GetApiKeysQuery) error {
 func DeleteApiKeyCtx(ctx context.Context, cmd *models.DeleteApiKeyCommand) error {
 	return withDbSession(ctx, func(sess *DBSession) error {
 		var rawSQL = "DELETE FROM api_key WHERE id=? and org_id=?"
-		_, err := sess.Exec(rawSQL, cmd.Id, cmd.OrgId)
+		if _, err := sess.Exec(rawSQL, cmd.Id, cmd.OrgId); err != nil {
+			return err
+		}
+
+		_, err := sess.Exec("DELETE FROM role_assignment WHERE api_key_id=? and org_id=?", cmd.Id, cmd.OrgId)
 		return err
 	})
 }
@@ -59,6 +65,8 @@ func AddApiKey(cmd *models.AddApiKeyCommand) error {
 			Created: updated,
 			Updated: updated,
 			Expires: expires,
+
+			ServiceAccountId: cmd.ServiceAccountId,
 		}
 
 		if _, err := sess.Insert(&t); err != nil {
@@ -69,6 +77,13 @@ func AddApiKey(cmd *models.AddApiKeyCommand) error {
 	})
 }
 
+func UpdateApiKeyLastUsedAt(cmd *models.UpdateApiKeyLastUsedAtCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("UPDATE api_key SET last_used_at=? WHERE id=?", timeNow(), cmd.Id)
+		return err
+	})
+}
+
 func GetApiKeyById(query *models.GetApiKeyByIdQuery) error {
 	var apikey models.ApiKey
 	has, err := x.Id(query.ApiKeyId).Get(&apikey)
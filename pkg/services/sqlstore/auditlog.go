@@ -0,0 +1,103 @@
+package sqlstore
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", AddAuditLogEntry)
+	bus.AddHandler("sql", SearchAuditLog)
+	bus.AddHandler("sql", DeleteOldAuditLogEntries)
+}
+
+func AddAuditLogEntry(cmd *models.AddAuditLogEntryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Table("audit_log").Insert(cmd.Entry)
+		return err
+	})
+}
+
+func SearchAuditLog(query *models.SearchAuditLogQuery) error {
+	if query.PerPage <= 0 {
+		query.PerPage = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	var conditions []string
+	var params []interface{}
+
+	filters := []struct {
+		column string
+		value  interface{}
+		isSet  bool
+	}{
+		{"org_id", query.OrgId, query.OrgId > 0},
+		{"user_id", query.UserId, query.UserId > 0},
+		{"action", query.Action, query.Action != ""},
+		{"resource_type", query.ResourceType, query.ResourceType != ""},
+		{"resource_uid", query.ResourceUid, query.ResourceUid != ""},
+		{"outcome", query.Outcome, query.Outcome != ""},
+	}
+	for _, f := range filters {
+		if f.isSet {
+			conditions = append(conditions, f.column+" = ?")
+			params = append(params, f.value)
+		}
+	}
+
+	if !query.From.IsZero() {
+		conditions = append(conditions, "created >= ?")
+		params = append(params, query.From)
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "created <= ?")
+		params = append(params, query.To)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	sess := x.Table("audit_log")
+	if where != "" {
+		sess.Where(where, params...)
+	}
+
+	entries := make([]*models.AuditLogEntry, 0)
+	offset := query.PerPage * (query.Page - 1)
+	if err := sess.Desc("created", "id").Limit(query.PerPage, offset).Find(&entries); err != nil {
+		return err
+	}
+
+	countSess := x.Table("audit_log")
+	if where != "" {
+		countSess.Where(where, params...)
+	}
+	count, err := countSess.Count(&models.AuditLogEntry{})
+	if err != nil {
+		return err
+	}
+
+	query.Result = models.SearchAuditLogQueryResult{
+		TotalCount: count,
+		Entries:    entries,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+	}
+	return nil
+}
+
+func DeleteOldAuditLogEntries(cmd *models.DeleteOldAuditLogEntriesCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM audit_log WHERE created < ?", cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows, err = res.RowsAffected()
+		return err
+	})
+}
@@ -0,0 +1,64 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	InitTestDB(t)
+
+	now := time.Now()
+	for _, entry := range []*models.AuditLogEntry{
+		{OrgId: 1, UserId: 1, Action: "create", ResourceType: "dashboards", ResourceUid: "abc", Outcome: models.AuditLogOutcomeSuccess, Created: now.Add(-2 * time.Hour)},
+		{OrgId: 1, UserId: 2, Action: "update", ResourceType: "dashboards", ResourceUid: "abc", Outcome: models.AuditLogOutcomeFailure, Created: now.Add(-time.Hour)},
+		{OrgId: 2, UserId: 2, Action: "delete", ResourceType: "datasources", ResourceUid: "prom", Outcome: models.AuditLogOutcomeSuccess, Created: now},
+	} {
+		require.NoError(t, AddAuditLogEntry(&models.AddAuditLogEntryCommand{Entry: entry}))
+	}
+
+	t.Run("Search returns the newest entries first", func(t *testing.T) {
+		query := models.SearchAuditLogQuery{}
+		require.NoError(t, SearchAuditLog(&query))
+		assert.Equal(t, int64(3), query.Result.TotalCount)
+		require.Len(t, query.Result.Entries, 3)
+		assert.Equal(t, "delete", query.Result.Entries[0].Action)
+		assert.Equal(t, "create", query.Result.Entries[2].Action)
+	})
+
+	t.Run("Search filters and pages the entries", func(t *testing.T) {
+		query := models.SearchAuditLogQuery{OrgId: 1, ResourceType: "dashboards", ResourceUid: "abc", PerPage: 1, Page: 2}
+		require.NoError(t, SearchAuditLog(&query))
+		assert.Equal(t, int64(2), query.Result.TotalCount)
+		require.Len(t, query.Result.Entries, 1)
+		assert.Equal(t, "create", query.Result.Entries[0].Action)
+
+		query = models.SearchAuditLogQuery{UserId: 2, Outcome: models.AuditLogOutcomeFailure}
+		require.NoError(t, SearchAuditLog(&query))
+		require.Len(t, query.Result.Entries, 1)
+		assert.Equal(t, "update", query.Result.Entries[0].Action)
+
+		query = models.SearchAuditLogQuery{From: now.Add(-90 * time.Minute), To: now.Add(-30 * time.Minute)}
+		require.NoError(t, SearchAuditLog(&query))
+		require.Len(t, query.Result.Entries, 1)
+		assert.Equal(t, "update", query.Result.Entries[0].Action)
+	})
+
+	t.Run("Old entries are deleted", func(t *testing.T) {
+		cmd := models.DeleteOldAuditLogEntriesCommand{OlderThan: now.Add(-30 * time.Minute)}
+		require.NoError(t, DeleteOldAuditLogEntries(&cmd))
+		assert.Equal(t, int64(2), cmd.DeletedRows)
+
+		query := models.SearchAuditLogQuery{}
+		require.NoError(t, SearchAuditLog(&query))
+		require.Len(t, query.Result.Entries, 1)
+		assert.Equal(t, "delete", query.Result.Entries[0].Action)
+	})
+}
@@ -35,6 +35,11 @@ func TestIntegratedDashboardService(t *testing.T) {
 				return nil
 			})
 
+			bus.AddHandler("test", func(query *models.EvaluatePermissionQuery) error {
+				query.Result = false
+				return nil
+			})
+
 			savedFolder := saveTestFolder(t, "Saved folder", testOrgId)
 			savedDashInFolder := saveTestDashboard(t, "Saved dash in folder", testOrgId, savedFolder.Id)
 			saveTestDashboard(t, "Other saved dash in folder", testOrgId, savedFolder.Id)
@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/encryption"
+)
+
+// GetDataKey returns the data key with the name.
+func (ss *SQLStore) GetDataKey(name string) (*encryption.DataKey, error) {
+	dataKey := &encryption.DataKey{}
+	err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+		exists, err := sess.Where("name = ?", name).Get(dataKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return encryption.ErrDataKeyNotFound
+		}
+		return nil
+	})
+
+	return dataKey, err
+}
+
+// GetActiveDataKey returns the latest active data key.
+func (ss *SQLStore) GetActiveDataKey() (*encryption.DataKey, error) {
+	dataKey := &encryption.DataKey{}
+	err := ss.WithDbSession(context.Background(), func(sess *DBSession) error {
+		exists, err := sess.Where("active = ?", dialect.BooleanStr(true)).Desc("id").Get(dataKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return encryption.ErrDataKeyNotFound
+		}
+		return nil
+	})
+
+	return dataKey, err
+}
+
+// CreateDataKey stores a new active data key and deactivates the other data keys.
+func (ss *SQLStore) CreateDataKey(dataKey *encryption.DataKey) error {
+	return ss.WithTransactionalDbSession(context.Background(), func(sess *DBSession) error {
+		return CreateDataKeyInSession(sess, dataKey)
+	})
+}
+
+// CreateDataKeyInSession stores a new active data key and deactivates the other data keys,
+// using the session of a transaction.
+func CreateDataKeyInSession(sess *DBSession, dataKey *encryption.DataKey) error {
+	if _, err := sess.Exec("UPDATE data_key SET active = ?, updated = ? WHERE active = ?",
+		dialect.BooleanStr(false), dataKey.Created, dialect.BooleanStr(true)); err != nil {
+		return err
+	}
+
+	dataKey.Active = true
+	_, err := sess.Insert(dataKey)
+	return err
+}
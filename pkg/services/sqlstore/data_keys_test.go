@@ -0,0 +1,44 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/encryption"
+)
+
+func TestDataKeys(t *testing.T) {
+	ss := InitTestDB(t)
+
+	first, _, err := encryption.NewDataKey("secret_key")
+	require.NoError(t, err)
+	require.NoError(t, ss.CreateDataKey(first))
+
+	second, _, err := encryption.NewDataKey("secret_key")
+	require.NoError(t, err)
+	require.NoError(t, ss.CreateDataKey(second))
+
+	t.Run("Latest data key is active", func(t *testing.T) {
+		active, err := ss.GetActiveDataKey()
+		require.NoError(t, err)
+		assert.Equal(t, second.Name, active.Name)
+		assert.Equal(t, second.EncryptedKey, active.EncryptedKey)
+		assert.Greater(t, active.Id, first.Id)
+	})
+
+	t.Run("Previous data keys are kept but deactivated", func(t *testing.T) {
+		key, err := ss.GetDataKey(first.Name)
+		require.NoError(t, err)
+		assert.False(t, key.Active)
+		assert.Equal(t, first.EncryptedKey, key.EncryptedKey)
+	})
+
+	t.Run("Missing data keys should return not found", func(t *testing.T) {
+		_, err := ss.GetDataKey("missing")
+		require.ErrorIs(t, err, encryption.ErrDataKeyNotFound)
+	})
+}
@@ -1,6 +1,8 @@
 package sqlstore
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -14,6 +16,9 @@ func init() {
 	bus.AddHandler("sql", CreateLoginAttempt)
 	bus.AddHandler("sql", DeleteOldLoginAttempts)
 	bus.AddHandler("sql", GetUserLoginAttemptCount)
+	bus.AddHandler("sql", GetIPLoginAttemptCount)
+	bus.AddHandler("sql", GetLoginLockouts)
+	bus.AddHandler("sql", DeleteLoginAttempts)
 }
 
 func CreateLoginAttempt(cmd *models.CreateLoginAttemptCommand) error {
@@ -79,6 +84,96 @@ func GetUserLoginAttemptCount(query *models.GetUserLoginAttemptCountQuery) error
 	return nil
 }
 
+func GetIPLoginAttemptCount(query *models.GetIPLoginAttemptCountQuery) error {
+	loginAttempt := new(models.LoginAttempt)
+	total, err := x.
+		Where("ip_address = ?", query.IpAddress).
+		And("created >= ?", query.Since.Unix()).
+		Count(loginAttempt)
+
+	if err != nil {
+		return err
+	}
+
+	query.Result = total
+	return nil
+}
+
+type loginAttemptCount struct {
+	Subject      string
+	Attempts     int64
+	FirstAttempt int64
+	LastAttempt  int64
+}
+
+func GetLoginLockouts(query *models.GetLoginLockoutsQuery) error {
+	query.Result = []*models.LoginLockout{}
+
+	for _, column := range []string{"username", "ip_address"} {
+		maxAttempts := query.MaxUserAttempts
+		if column == "ip_address" {
+			maxAttempts = query.MaxIPAttempts
+		}
+		if maxAttempts <= 0 {
+			continue
+		}
+
+		rawSQL := fmt.Sprintf(`SELECT %[1]s AS subject, COUNT(*) AS attempts,
+			MIN(created) AS first_attempt, MAX(created) AS last_attempt
+			FROM login_attempt WHERE created >= ? GROUP BY %[1]s HAVING COUNT(*) >= ?`, column)
+
+		var counts []*loginAttemptCount
+		if err := x.SQL(rawSQL, query.Since.Unix(), maxAttempts).Find(&counts); err != nil {
+			return err
+		}
+
+		for _, count := range counts {
+			lockout := &models.LoginLockout{
+				Attempts:     count.Attempts,
+				FirstAttempt: time.Unix(count.FirstAttempt, 0),
+				LastAttempt:  time.Unix(count.LastAttempt, 0),
+			}
+			if column == "username" {
+				lockout.Username = count.Subject
+			} else {
+				lockout.IpAddress = count.Subject
+			}
+			query.Result = append(query.Result, lockout)
+		}
+	}
+
+	sort.SliceStable(query.Result, func(i, j int) bool {
+		return query.Result[i].LastAttempt.After(query.Result[j].LastAttempt)
+	})
+	return nil
+}
+
+func DeleteLoginAttempts(cmd *models.DeleteLoginAttemptsCommand) error {
+	if cmd.Id == 0 && cmd.Username == "" && cmd.IpAddress == "" {
+		return nil
+	}
+
+	return inTransaction(func(sess *DBSession) error {
+		if cmd.Id != 0 {
+			sess.And("id = ?", cmd.Id)
+		}
+		if cmd.Username != "" {
+			sess.And("username = ?", cmd.Username)
+		}
+		if cmd.IpAddress != "" {
+			sess.And("ip_address = ?", cmd.IpAddress)
+		}
+
+		deleted, err := sess.Delete(&models.LoginAttempt{})
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows = deleted
+		return nil
+	})
+}
+
 func toInt64(i interface{}) int64 {
 	switch i := i.(type) {
 	case []byte:
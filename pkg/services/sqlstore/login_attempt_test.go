@@ -123,5 +123,89 @@ func TestLoginAttempts(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(cmd.DeletedRows, ShouldEqual, 3)
 		})
+
+		Convey("Should return the total count of login attempts from an IP address since beginning of time + 1min", func() {
+			query := models.GetIPLoginAttemptCountQuery{
+				IpAddress: "192.168.0.1",
+				Since:     timePlusOneMinute,
+			}
+			err := GetIPLoginAttemptCount(&query)
+			So(err, ShouldBeNil)
+			So(query.Result, ShouldEqual, 2)
+		})
+
+		Convey("Given a login attempt with another username from another IP address", func() {
+			mockTime(beginningOfTime.Add(time.Minute * 3))
+			err := CreateLoginAttempt(&models.CreateLoginAttemptCommand{
+				Username:  "other",
+				IpAddress: "2001:db8:85a3::8a2e:370:7334",
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Should return the usernames and IP addresses with too many login attempts", func() {
+				query := models.GetLoginLockoutsQuery{
+					Since:           beginningOfTime,
+					MaxUserAttempts: 3,
+					MaxIPAttempts:   1,
+				}
+				err := GetLoginLockouts(&query)
+				So(err, ShouldBeNil)
+				So(query.Result, ShouldHaveLength, 3)
+
+				So(query.Result[0].IpAddress, ShouldEqual, "2001:db8:85a3::8a2e:370:7334")
+				So(query.Result[0].Attempts, ShouldEqual, 1)
+				So(query.Result[1].Username, ShouldEqual, user)
+				So(query.Result[1].Attempts, ShouldEqual, 3)
+				So(query.Result[1].FirstAttempt.Unix(), ShouldEqual, beginningOfTime.Unix())
+				So(query.Result[1].LastAttempt.Unix(), ShouldEqual, timePlusTwoMinutes.Unix())
+				So(query.Result[2].IpAddress, ShouldEqual, "192.168.0.1")
+			})
+
+			Convey("Should not return the IP addresses without a max", func() {
+				query := models.GetLoginLockoutsQuery{
+					Since:           timePlusOneMinute,
+					MaxUserAttempts: 2,
+				}
+				err := GetLoginLockouts(&query)
+				So(err, ShouldBeNil)
+				So(query.Result, ShouldHaveLength, 1)
+				So(query.Result[0].Username, ShouldEqual, user)
+			})
+
+			Convey("Should delete the login attempts with a username", func() {
+				cmd := models.DeleteLoginAttemptsCommand{Username: user}
+				err := DeleteLoginAttempts(&cmd)
+				So(err, ShouldBeNil)
+				So(cmd.DeletedRows, ShouldEqual, 3)
+			})
+
+			Convey("Should delete the login attempts from an IP address", func() {
+				cmd := models.DeleteLoginAttemptsCommand{IpAddress: "2001:db8:85a3::8a2e:370:7334"}
+				err := DeleteLoginAttempts(&cmd)
+				So(err, ShouldBeNil)
+				So(cmd.DeletedRows, ShouldEqual, 1)
+
+				query := models.GetUserLoginAttemptCountQuery{Username: user, Since: beginningOfTime}
+				err = GetUserLoginAttemptCount(&query)
+				So(err, ShouldBeNil)
+				So(query.Result, ShouldEqual, 3)
+			})
+
+			Convey("Should delete a single login attempt", func() {
+				createCmd := models.CreateLoginAttemptCommand{Username: user, IpAddress: "192.168.0.1"}
+				err := CreateLoginAttempt(&createCmd)
+				So(err, ShouldBeNil)
+
+				cmd := models.DeleteLoginAttemptsCommand{Id: createCmd.Result.Id}
+				err = DeleteLoginAttempts(&cmd)
+				So(err, ShouldBeNil)
+				So(cmd.DeletedRows, ShouldEqual, 1)
+
+				query := models.GetUserLoginAttemptCountQuery{Username: user, Since: beginningOfTime}
+				err = GetUserLoginAttemptCount(&query)
+				So(err, ShouldBeNil)
+				So(query.Result, ShouldEqual, 3)
+			})
+		})
 	})
 }
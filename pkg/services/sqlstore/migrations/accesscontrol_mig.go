@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAccessControlMigrations(mg *Migrator) {
+	roleV1 := Table{
+		Name: "role",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create role table", NewAddTableMigration(roleV1))
+
+	mg.AddMigration("add index role.org_id", NewAddIndexMigration(roleV1, roleV1.Indices[0]))
+	mg.AddMigration("add unique index role_org_id_uid", NewAddIndexMigration(roleV1, roleV1.Indices[1]))
+	mg.AddMigration("add unique index role_org_id_name", NewAddIndexMigration(roleV1, roleV1.Indices[2]))
+
+	permissionV1 := Table{
+		Name: "permission",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "role_id", Type: DB_BigInt, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "scope", Type: DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"role_id"}},
+			{Cols: []string{"role_id", "action", "scope"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create permission table", NewAddTableMigration(permissionV1))
+
+	mg.AddMigration("add index permission.role_id", NewAddIndexMigration(permissionV1, permissionV1.Indices[0]))
+	mg.AddMigration("add unique index permission_role_id_action_scope", NewAddIndexMigration(permissionV1, permissionV1.Indices[1]))
+
+	// a role is assigned to one of a user, a team, an API key or a built-in role
+	roleAssignmentV1 := Table{
+		Name: "role_assignment",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "role_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "team_id", Type: DB_BigInt, Nullable: false},
+			{Name: "api_key_id", Type: DB_BigInt, Nullable: false},
+			{Name: "builtin_role", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"role_id"}},
+			{Cols: []string{"org_id", "user_id"}},
+			{Cols: []string{"org_id", "team_id"}},
+			{Cols: []string{"org_id", "api_key_id"}},
+			{Cols: []string{"org_id", "builtin_role"}},
+			{Cols: []string{"role_id", "user_id", "team_id", "api_key_id", "builtin_role"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create role_assignment table", NewAddTableMigration(roleAssignmentV1))
+
+	mg.AddMigration("add index role_assignment.role_id", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[0]))
+	mg.AddMigration("add index role_assignment.org_id_user_id", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[1]))
+	mg.AddMigration("add index role_assignment.org_id_team_id", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[2]))
+	mg.AddMigration("add index role_assignment.org_id_api_key_id", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[3]))
+	mg.AddMigration("add index role_assignment.org_id_builtin_role", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[4]))
+	mg.AddMigration("add unique index role_assignment_assignee", NewAddIndexMigration(roleAssignmentV1, roleAssignmentV1.Indices[5]))
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAuditLogMigrations(mg *Migrator) {
+	auditLogV1 := Table{
+		Name: "audit_log",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "api_key_id", Type: DB_BigInt, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "method", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "route", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "resource_type", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "resource_uid", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "before_summary", Type: DB_Text, Nullable: true},
+			{Name: "after_summary", Type: DB_Text, Nullable: true},
+			{Name: "client_ip", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "status", Type: DB_Int, Nullable: false},
+			{Name: "outcome", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"created"}},
+			{Cols: []string{"org_id", "created"}},
+			{Cols: []string{"user_id"}},
+			{Cols: []string{"resource_type", "resource_uid"}},
+		},
+	}
+
+	mg.AddMigration("create audit_log table", NewAddTableMigration(auditLogV1))
+	addTableIndicesMigrations(mg, "v1", auditLogV1)
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDataKeyMigrations(mg *Migrator) {
+	dataKeyV1 := Table{
+		Name: "data_key",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "active", Type: DB_Bool, Nullable: false},
+			{Name: "encrypted_key", Type: DB_Blob, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create data_key table v1", NewAddTableMigration(dataKeyV1))
+
+	mg.AddMigration("add unique index data_key.name", NewAddIndexMigration(dataKeyV1, dataKeyV1.Indices[0]))
+}
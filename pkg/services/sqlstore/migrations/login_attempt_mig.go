@@ -39,4 +39,12 @@ func addLoginAttemptMigrations(mg *Migrator) {
 		"username":   "username",
 		"ip_address": "ip_address",
 	})
+
+	mg.AddMigration("alter login_attempt.ip_address to length 50", NewRawSQLMigration("").
+		Postgres("ALTER TABLE login_attempt ALTER COLUMN ip_address TYPE VARCHAR(50);").
+		Mysql("ALTER TABLE login_attempt MODIFY ip_address VARCHAR(50) NOT NULL;"))
+
+	mg.AddMigration("add index login_attempt.ip_address", NewAddIndexMigration(loginAttemptV2, &Index{
+		Cols: []string{"ip_address"},
+	}))
 }
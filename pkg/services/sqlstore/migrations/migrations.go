@@ -35,6 +35,12 @@ func AddMigrations(mg *Migrator) {
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
 	addShortURLMigrations(mg)
+	addDataKeyMigrations(mg)
+	addAccessControlMigrations(mg)
+	addAuditLogMigrations(mg)
+	addUserTotpMigrations(mg)
+	addTeamGroupMigrations(mg)
+	addUserPasswordHistoryMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addUserPasswordHistoryMigrations(mg *Migrator) {
+	userPasswordHistoryV1 := Table{
+		Name: "user_password_history",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "password", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "salt", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create user_password_history table", NewAddTableMigration(userPasswordHistoryV1))
+	addTableIndicesMigrations(mg, "v1", userPasswordHistoryV1)
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addUserTotpMigrations(mg *Migrator) {
+	userTotpV1 := Table{
+		Name: "user_totp",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "secret", Type: DB_Blob, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "recovery_codes", Type: DB_Text, Nullable: true},
+			{Name: "last_used_step", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_totp table", NewAddTableMigration(userTotpV1))
+	addTableIndicesMigrations(mg, "v1", userTotpV1)
+
+	// require_totp requires the members of the organization to use two-factor authentication
+	mg.AddMigration("Add require_totp column to org", NewAddColumnMigration(Table{Name: "org"}, &Column{
+		Name: "require_totp", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+}
@@ -20,6 +20,7 @@ func init() {
 	bus.AddHandler("sql", CreateOrg)
 	bus.AddHandler("sql", UpdateOrg)
 	bus.AddHandler("sql", UpdateOrgAddress)
+	bus.AddHandler("sql", UpdateOrgRequireTotp)
 	bus.AddHandler("sql", GetOrgByName)
 	bus.AddHandler("sql", SearchOrgs)
 	bus.AddHandler("sql", DeleteOrg)
@@ -125,15 +126,20 @@ func createOrg(name string, userID int64, engine *xorm.Engine) (models.Org, erro
 			return err
 		}
 
-		user := models.OrgUser{
-			OrgId:   org.Id,
-			UserId:  userID,
-			Role:    models.ROLE_ADMIN,
-			Created: time.Now(),
-			Updated: time.Now(),
-		}
+		// orgs created without an initial admin user, e.g. by provisioning, start without members
+		if userID != 0 {
+			user := models.OrgUser{
+				OrgId:   org.Id,
+				UserId:  userID,
+				Role:    models.ROLE_ADMIN,
+				Created: time.Now(),
+				Updated: time.Now(),
+			}
 
-		_, err := sess.Insert(&user)
+			if _, err := sess.Insert(&user); err != nil {
+				return err
+			}
+		}
 
 		sess.publishAfterCommit(&events.OrgCreated{
 			Timestamp: org.Created,
@@ -141,7 +147,7 @@ func createOrg(name string, userID int64, engine *xorm.Engine) (models.Org, erro
 			Name:      org.Name,
 		})
 
-		return err
+		return nil
 	}, 0); err != nil {
 		return org, err
 	}
@@ -224,6 +230,26 @@ func UpdateOrgAddress(cmd *models.UpdateOrgAddressCommand) error {
 	})
 }
 
+func UpdateOrgRequireTotp(cmd *models.UpdateOrgRequireTotpCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		org := models.Org{
+			RequireTotp: cmd.Required,
+			Updated:     time.Now(),
+		}
+
+		affectedRows, err := sess.ID(cmd.OrgId).Cols("require_totp", "updated").Update(&org)
+		if err != nil {
+			return err
+		}
+
+		if affectedRows == 0 {
+			return models.ErrOrgNotFound
+		}
+
+		return nil
+	})
+}
+
 func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 	return inTransaction(func(sess *DBSession) error {
 		if res, err := sess.Query("SELECT 1 from org WHERE id=?", cmd.Id); err != nil {
@@ -241,6 +267,10 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 			"DELETE FROM org_user WHERE org_id = ?",
 			"DELETE FROM org WHERE id = ?",
 			"DELETE FROM temp_user WHERE org_id = ?",
+			"DELETE FROM permission WHERE EXISTS (SELECT 1 FROM role WHERE org_id = ? AND permission.role_id = role.id)",
+			"DELETE FROM role_assignment WHERE org_id = ?",
+			"DELETE FROM role WHERE org_id = ?",
+			"DELETE FROM team_group WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -250,6 +280,11 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 			}
 		}
 
+		sess.publishAfterCommit(&events.OrgDeleted{
+			Timestamp: time.Now(),
+			Id:        cmd.Id,
+		})
+
 		return nil
 	})
 }
@@ -83,6 +83,7 @@ func UpdateOrgUser(cmd *models.UpdateOrgUserCommand) error {
 			return models.ErrOrgUserNotFound
 		}
 
+		wasAdmin := orgUser.Role == models.ROLE_ADMIN
 		orgUser.Role = cmd.Role
 		orgUser.Updated = time.Now()
 		_, err = sess.ID(orgUser.Id).Update(&orgUser)
@@ -90,6 +91,11 @@ func UpdateOrgUser(cmd *models.UpdateOrgUserCommand) error {
 			return err
 		}
 
+		// only demoting an admin can leave the org without one, orgs might be
+		// provisioned without admins
+		if !wasAdmin {
+			return nil
+		}
 		return validateOneAdminLeftInOrg(cmd.OrgId, sess)
 	})
 }
@@ -106,6 +112,9 @@ func GetOrgUsers(query *models.GetOrgUsersQuery) error {
 	whereConditions = append(whereConditions, "org_user.org_id = ?")
 	whereParams = append(whereParams, query.OrgId)
 
+	whereConditions = append(whereConditions, dialect.Quote("user")+".is_service_account = ?")
+	whereParams = append(whereParams, false)
+
 	if query.Query != "" {
 		queryWithWildcards := "%" + query.Query + "%"
 		whereConditions = append(whereConditions, "(email "+dialect.LikeStr()+" ? OR name "+dialect.LikeStr()+" ? OR login "+dialect.LikeStr()+" ?)")
@@ -156,6 +165,7 @@ func RemoveOrgUser(cmd *models.RemoveOrgUserCommand) error {
 			"DELETE FROM org_user WHERE org_id=? and user_id=?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and user_id = ?",
 			"DELETE FROM team_member WHERE org_id=? and user_id = ?",
+			"DELETE FROM role_assignment WHERE org_id=? and user_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -0,0 +1,59 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+func init() {
+	bus.AddHandler("sql", SearchSCIMUsers)
+}
+
+// scimUserAttributes are the attributes of the SCIM users which can be filtered in SQL, on the user u of the
+// org_user ou.
+var scimUserAttributes = scim.SQLAttributes{
+	"id":             {Expr: "u.id", Kind: scim.SQLID},
+	"username":       {Expr: "COALESCE(u.login, '')"},
+	"displayname":    {Expr: "COALESCE(u.name, '')"},
+	"name.formatted": {Expr: "COALESCE(u.name, '')"},
+	"emails":         {Expr: "COALESCE(u.email, '')"},
+	"emails.value":   {Expr: "COALESCE(u.email, '')"},
+	"active":         {Expr: "u.is_disabled", Kind: scim.SQLBool, Negated: true},
+	"groups":         {Expr: "tm.team_id", Kind: scim.SQLID, Exists: scimUserGroupsExists},
+	"groups.value":   {Expr: "tm.team_id", Kind: scim.SQLID, Exists: scimUserGroupsExists},
+}
+
+const scimUserGroupsExists = "EXISTS (SELECT 1 FROM team_member AS tm WHERE tm.user_id = u.id AND tm.org_id = ou.org_id AND %s)"
+
+func SearchSCIMUsers(query *scim.SearchUsersQuery) error {
+	builder := &SQLBuilder{}
+	builder.Write(`FROM org_user AS ou
+		INNER JOIN `+dialect.Quote("user")+` AS u ON u.id = ou.user_id
+		WHERE ou.org_id = ? AND u.is_admin = ?`, query.OrgId, dialect.BooleanStr(false))
+	if query.Filter != nil {
+		cond, params, err := scim.FilterSQL(query.Filter, scimUserAttributes)
+		if err != nil {
+			return err
+		}
+		builder.Write(" AND "+cond, params...)
+	}
+
+	var count struct {
+		Count int64
+	}
+	if _, err := x.SQL("SELECT COUNT(*) AS count "+builder.GetSQLString(), builder.GetParams()...).Get(&count); err != nil {
+		return err
+	}
+	query.TotalCount = count.Count
+
+	query.Result = make([]*models.User, 0)
+	if query.Limit == 0 {
+		return nil
+	}
+	builder.Write(" ORDER BY u.id")
+	if query.Limit > 0 {
+		builder.Write(dialect.LimitOffset(int64(query.Limit), int64(query.Offset)))
+	}
+	return x.SQL("SELECT u.* "+builder.GetSQLString(), builder.GetParams()...).Find(&query.Result)
+}
@@ -0,0 +1,278 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateServiceAccount)
+	bus.AddHandler("sql", UpdateServiceAccount)
+	bus.AddHandler("sql", DeleteServiceAccount)
+	bus.AddHandler("sql", GetServiceAccounts)
+	bus.AddHandler("sql", GetServiceAccountById)
+	bus.AddHandler("sql", GetServiceAccountTokens)
+	bus.AddHandler("sql", DeleteServiceAccountToken)
+	bus.AddHandler("sql", ConvertApiKeysToServiceAccounts)
+}
+
+const serviceAccountSelect = `SELECT
+	u.id          as id,
+	org_user.org_id as org_id,
+	u.name        as name,
+	u.login       as login,
+	org_user.role as role,
+	u.is_disabled as is_disabled,
+	(SELECT COUNT(*) FROM api_key WHERE api_key.service_account_id = u.id) as tokens
+	FROM org_user
+	INNER JOIN `
+
+func CreateServiceAccount(cmd *models.CreateServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		serviceAccount, err := createServiceAccount(sess, cmd.OrgId, cmd.Name, cmd.Role)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = serviceAccount
+		return nil
+	})
+}
+
+func UpdateServiceAccount(cmd *models.UpdateServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := getServiceAccount(sess, cmd.OrgId, cmd.Id); err != nil {
+			return err
+		}
+
+		user := models.User{Updated: time.Now()}
+		cols := []string{"updated"}
+
+		if cmd.Name != "" {
+			user.Name = cmd.Name
+			cols = append(cols, "name")
+		}
+
+		if cmd.IsDisabled != nil {
+			user.IsDisabled = *cmd.IsDisabled
+			cols = append(cols, "is_disabled")
+		}
+
+		if _, err := sess.ID(cmd.Id).Cols(cols...).Update(&user); err != nil {
+			return err
+		}
+
+		if cmd.Role != nil {
+			orgUser := models.OrgUser{Role: *cmd.Role, Updated: time.Now()}
+			if _, err := sess.Where("org_id=? AND user_id=?", cmd.OrgId, cmd.Id).Cols("role", "updated").Update(&orgUser); err != nil {
+				return err
+			}
+		}
+
+		serviceAccount, err := getServiceAccount(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = serviceAccount
+		return nil
+	})
+}
+
+func DeleteServiceAccount(cmd *models.DeleteServiceAccountCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := getServiceAccount(sess, cmd.OrgId, cmd.Id); err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM role_assignment WHERE api_key_id IN (SELECT id FROM api_key WHERE service_account_id = ?)",
+			"DELETE FROM api_key WHERE service_account_id = ?",
+		}
+
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, cmd.Id); err != nil {
+				return err
+			}
+		}
+
+		return deleteUserInTransaction(sess, &models.DeleteUserCommand{UserId: cmd.Id})
+	})
+}
+
+func GetServiceAccounts(query *models.GetServiceAccountsQuery) error {
+	query.Result = make([]*models.ServiceAccountDTO, 0)
+
+	rawSQL := serviceAccountSelect + dialect.Quote("user") + ` as u ON u.id = org_user.user_id
+		WHERE org_user.org_id = ? AND u.is_service_account = ?
+		ORDER BY u.name`
+
+	return x.SQL(rawSQL, query.OrgId, true).Find(&query.Result)
+}
+
+func GetServiceAccountById(query *models.GetServiceAccountByIdQuery) error {
+	return withDbSession(context.Background(), func(sess *DBSession) error {
+		serviceAccount, err := getServiceAccount(sess, query.OrgId, query.Id)
+		if err != nil {
+			return err
+		}
+
+		query.Result = serviceAccount
+		return nil
+	})
+}
+
+func GetServiceAccountTokens(query *models.GetServiceAccountTokensQuery) error {
+	query.Result = make([]*models.ApiKey, 0)
+	return x.Where("org_id=? AND service_account_id=?", query.OrgId, query.ServiceAccountId).Asc("name").Find(&query.Result)
+}
+
+func DeleteServiceAccountToken(cmd *models.DeleteServiceAccountTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM api_key WHERE id=? AND org_id=? AND service_account_id=?", cmd.Id, cmd.OrgId, cmd.ServiceAccountId)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrServiceAccountTokenNotFound
+		}
+
+		_, err = sess.Exec("DELETE FROM role_assignment WHERE api_key_id=? AND org_id=?", cmd.Id, cmd.OrgId)
+		return err
+	})
+}
+
+// ConvertApiKeysToServiceAccounts creates a service account for each API key, with the name and the
+// role of the key, and makes the key a token of the service account. The keys keep working.
+func ConvertApiKeysToServiceAccounts(cmd *models.ConvertApiKeysToServiceAccountsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		keys := make([]*models.ApiKey, 0)
+		sess.Where("org_id=? AND service_account_id IS NULL", cmd.OrgId)
+		if cmd.ApiKeyId > 0 {
+			sess.And("id=?", cmd.ApiKeyId)
+		}
+		if err := sess.Asc("name").Find(&keys); err != nil {
+			return err
+		}
+
+		if cmd.ApiKeyId > 0 && len(keys) == 0 {
+			return models.ErrInvalidApiKey
+		}
+
+		for _, key := range keys {
+			if !cmd.MaxRole.Includes(key.Role) {
+				return models.ErrServiceAccountRoleTooHigh
+			}
+		}
+
+		cmd.Result = make([]*models.ServiceAccountDTO, 0, len(keys))
+		for _, key := range keys {
+			serviceAccount, err := createServiceAccount(sess, key.OrgId, key.Name, key.Role)
+			if err != nil {
+				return fmt.Errorf("failed to convert API key %q: %w", key.Name, err)
+			}
+
+			if _, err := sess.Exec("UPDATE api_key SET service_account_id=? WHERE id=?", serviceAccount.Id, key.Id); err != nil {
+				return err
+			}
+
+			serviceAccount.Tokens = 1
+			cmd.Result = append(cmd.Result, serviceAccount)
+		}
+
+		return nil
+	})
+}
+
+func createServiceAccount(sess *DBSession, orgID int64, name string, role models.RoleType) (*models.ServiceAccountDTO, error) {
+	if err := verifyExistingOrg(sess, orgID); err != nil {
+		return nil, err
+	}
+
+	login := serviceAccountLogin(orgID, name)
+	exists, err := sess.Where("login=? OR email=?", login, login).Get(&models.User{})
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, models.ErrServiceAccountAlreadyExists
+	}
+
+	rands, err := util.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := util.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Email:            login,
+		Name:             name,
+		Login:            login,
+		Rands:            rands,
+		Salt:             salt,
+		IsServiceAccount: true,
+		OrgId:            orgID,
+		Created:          time.Now(),
+		Updated:          time.Now(),
+		LastSeenAt:       time.Now().AddDate(-10, 0, 0),
+	}
+	if _, err := sess.Insert(&user); err != nil {
+		return nil, err
+	}
+
+	orgUser := models.OrgUser{
+		OrgId:   orgID,
+		UserId:  user.Id,
+		Role:    role,
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+	if _, err := sess.Insert(&orgUser); err != nil {
+		return nil, err
+	}
+
+	return &models.ServiceAccountDTO{
+		Id:    user.Id,
+		OrgId: orgID,
+		Name:  user.Name,
+		Login: user.Login,
+		Role:  role,
+	}, nil
+}
+
+func getServiceAccount(sess *DBSession, orgID int64, id int64) (*models.ServiceAccountDTO, error) {
+	rawSQL := serviceAccountSelect + dialect.Quote("user") + ` as u ON u.id = org_user.user_id
+		WHERE org_user.org_id = ? AND u.id = ? AND u.is_service_account = ?`
+
+	var serviceAccount models.ServiceAccountDTO
+	has, err := sess.SQL(rawSQL, orgID, id, true).Get(&serviceAccount)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, models.ErrServiceAccountNotFound
+	}
+
+	return &serviceAccount, nil
+}
+
+// serviceAccountLogin returns the login of a service account, which is unique in the organization.
+func serviceAccountLogin(orgID int64, name string) string {
+	slug := models.SlugifyTitle(name)
+	if slug == "" {
+		slug = util.GenerateShortUID()
+	}
+
+	return models.ServiceAccountLoginPrefix + strconv.FormatInt(orgID, 10) + "-" + slug
+}
@@ -0,0 +1,138 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	InitTestDB(t)
+
+	adminCmd := models.CreateUserCommand{Login: "sa-admin"}
+	require.NoError(t, CreateUser(context.Background(), &adminCmd))
+	orgID := adminCmd.Result.OrgId
+
+	t.Run("Service accounts are users of the organization that can't log in", func(t *testing.T) {
+		cmd := models.CreateServiceAccountCommand{OrgId: orgID, Name: "CI pipeline", Role: models.ROLE_EDITOR}
+		require.NoError(t, CreateServiceAccount(&cmd))
+		assert.Equal(t, "CI pipeline", cmd.Result.Name)
+		assert.Equal(t, models.ROLE_EDITOR, cmd.Result.Role)
+
+		err := CreateServiceAccount(&models.CreateServiceAccountCommand{OrgId: orgID, Name: "CI pipeline", Role: models.ROLE_VIEWER})
+		assert.Equal(t, models.ErrServiceAccountAlreadyExists, err)
+
+		signedInQuery := models.GetSignedInUserQuery{UserId: cmd.Result.Id, OrgId: orgID}
+		require.NoError(t, GetSignedInUser(&signedInQuery))
+		assert.True(t, signedInQuery.Result.IsServiceAccount)
+		assert.Equal(t, models.ROLE_EDITOR, signedInQuery.Result.OrgRole)
+
+		orgUsersQuery := models.GetOrgUsersQuery{OrgId: orgID}
+		require.NoError(t, GetOrgUsers(&orgUsersQuery))
+		require.Len(t, orgUsersQuery.Result, 1)
+		assert.Equal(t, "sa-admin", orgUsersQuery.Result[0].Login)
+
+		searchQuery := models.SearchUsersQuery{Query: "CI pipeline"}
+		require.NoError(t, SearchUsers(&searchQuery))
+		assert.Empty(t, searchQuery.Result.Users)
+
+		disabled := true
+		role := models.ROLE_VIEWER
+		update := models.UpdateServiceAccountCommand{OrgId: orgID, Id: cmd.Result.Id, Name: "Deployments", Role: &role, IsDisabled: &disabled}
+		require.NoError(t, UpdateServiceAccount(&update))
+		assert.Equal(t, "Deployments", update.Result.Name)
+		assert.Equal(t, models.ROLE_VIEWER, update.Result.Role)
+		assert.True(t, update.Result.IsDisabled)
+
+		query := models.GetServiceAccountsQuery{OrgId: orgID}
+		require.NoError(t, GetServiceAccounts(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, cmd.Result.Id, query.Result[0].Id)
+
+		err = GetServiceAccountById(&models.GetServiceAccountByIdQuery{OrgId: orgID, Id: adminCmd.Result.Id})
+		assert.Equal(t, models.ErrServiceAccountNotFound, err)
+
+		err = GetServiceAccountById(&models.GetServiceAccountByIdQuery{OrgId: orgID + 1, Id: cmd.Result.Id})
+		assert.Equal(t, models.ErrServiceAccountNotFound, err)
+	})
+
+	t.Run("Service accounts have multiple tokens", func(t *testing.T) {
+		cmd := models.CreateServiceAccountCommand{OrgId: orgID, Name: "Exporter", Role: models.ROLE_VIEWER}
+		require.NoError(t, CreateServiceAccount(&cmd))
+		serviceAccountID := cmd.Result.Id
+
+		for _, name := range []string{"exporter-1", "exporter-2"} {
+			require.NoError(t, AddApiKey(&models.AddApiKeyCommand{
+				OrgId: orgID, Name: name, Key: name, Role: models.ROLE_VIEWER, ServiceAccountId: &serviceAccountID,
+			}))
+		}
+
+		tokensQuery := models.GetServiceAccountTokensQuery{OrgId: orgID, ServiceAccountId: serviceAccountID}
+		require.NoError(t, GetServiceAccountTokens(&tokensQuery))
+		require.Len(t, tokensQuery.Result, 2)
+		assert.Nil(t, tokensQuery.Result[0].LastUsedAt)
+
+		keysQuery := models.GetApiKeysQuery{OrgId: orgID, IncludeExpired: true}
+		require.NoError(t, GetApiKeys(&keysQuery))
+		assert.Empty(t, keysQuery.Result)
+
+		require.NoError(t, UpdateApiKeyLastUsedAt(&models.UpdateApiKeyLastUsedAtCommand{Id: tokensQuery.Result[0].Id}))
+		keyQuery := models.GetApiKeyByIdQuery{ApiKeyId: tokensQuery.Result[0].Id}
+		require.NoError(t, GetApiKeyById(&keyQuery))
+		assert.NotNil(t, keyQuery.Result.LastUsedAt)
+
+		err := DeleteServiceAccountToken(&models.DeleteServiceAccountTokenCommand{OrgId: orgID, ServiceAccountId: serviceAccountID + 1, Id: tokensQuery.Result[0].Id})
+		assert.Equal(t, models.ErrServiceAccountTokenNotFound, err)
+
+		require.NoError(t, DeleteServiceAccountToken(&models.DeleteServiceAccountTokenCommand{OrgId: orgID, ServiceAccountId: serviceAccountID, Id: tokensQuery.Result[0].Id}))
+
+		saQuery := models.GetServiceAccountByIdQuery{OrgId: orgID, Id: serviceAccountID}
+		require.NoError(t, GetServiceAccountById(&saQuery))
+		assert.Equal(t, int64(1), saQuery.Result.Tokens)
+
+		require.NoError(t, DeleteServiceAccount(&models.DeleteServiceAccountCommand{OrgId: orgID, Id: serviceAccountID}))
+
+		err = GetServiceAccountById(&models.GetServiceAccountByIdQuery{OrgId: orgID, Id: serviceAccountID})
+		assert.Equal(t, models.ErrServiceAccountNotFound, err)
+
+		err = GetApiKeyByName(&models.GetApiKeyByNameQuery{OrgId: orgID, KeyName: "exporter-2"})
+		assert.Equal(t, models.ErrInvalidApiKey, err)
+	})
+
+	t.Run("API keys are converted to service accounts", func(t *testing.T) {
+		for _, key := range []models.AddApiKeyCommand{
+			{OrgId: orgID, Name: "grafana-backup", Key: "grafana-backup", Role: models.ROLE_ADMIN},
+			{OrgId: orgID, Name: "alloy", Key: "alloy", Role: models.ROLE_VIEWER},
+		} {
+			key := key
+			require.NoError(t, AddApiKey(&key))
+		}
+
+		err := ConvertApiKeysToServiceAccounts(&models.ConvertApiKeysToServiceAccountsCommand{OrgId: orgID, MaxRole: models.ROLE_EDITOR})
+		require.Equal(t, models.ErrServiceAccountRoleTooHigh, err)
+
+		cmd := models.ConvertApiKeysToServiceAccountsCommand{OrgId: orgID, MaxRole: models.ROLE_ADMIN}
+		require.NoError(t, ConvertApiKeysToServiceAccounts(&cmd))
+		require.Len(t, cmd.Result, 2)
+		assert.Equal(t, "alloy", cmd.Result[0].Name)
+		assert.Equal(t, models.ROLE_VIEWER, cmd.Result[0].Role)
+		assert.Equal(t, "grafana-backup", cmd.Result[1].Name)
+		assert.Equal(t, models.ROLE_ADMIN, cmd.Result[1].Role)
+
+		keyQuery := models.GetApiKeyByNameQuery{OrgId: orgID, KeyName: "grafana-backup"}
+		require.NoError(t, GetApiKeyByName(&keyQuery))
+		require.NotNil(t, keyQuery.Result.ServiceAccountId)
+		assert.Equal(t, cmd.Result[1].Id, *keyQuery.Result.ServiceAccountId)
+
+		err = ConvertApiKeysToServiceAccounts(&models.ConvertApiKeysToServiceAccountsCommand{
+			OrgId: orgID, ApiKeyId: keyQuery.Result.Id, MaxRole: models.ROLE_ADMIN,
+		})
+		assert.Equal(t, models.ErrInvalidApiKey, err)
+	})
+}
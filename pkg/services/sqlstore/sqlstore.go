@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/encryption"
 	"github.com/grafana/grafana/pkg/infra/fs"
 	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -73,6 +74,12 @@ func (ss *SQLStore) Register() {
 	})
 }
 
+// SkipEnsureDefaultOrgAndUser makes Init leave the database without the main organization and admin user,
+// for commands which must not change the database.
+func (ss *SQLStore) SkipEnsureDefaultOrgAndUser() {
+	ss.skipEnsureDefaultOrgAndUser = true
+}
+
 func (ss *SQLStore) Init() error {
 	ss.log = log.New("sqlstore")
 	ss.readConfig()
@@ -107,12 +114,21 @@ func (ss *SQLStore) Init() error {
 	annotations.SetRepository(&SQLAnnotationRepo{})
 	annotations.SetAnnotationCleaner(&AnnotationCleanupService{batchSize: ss.Cfg.AnnotationCleanupJobBatchSize, log: log.New("annotationcleaner")})
 	ss.Bus.SetTransactionManager(ss)
+	encryption.SetKeyStore(ss)
 
 	// Register handlers
 	ss.addUserQueryAndCommandHandlers()
 	ss.addAlertNotificationUidByIdHandler()
 	ss.addPreferencesQueryAndCommandHandlers()
 
+	// The active data key is created at startup rather than when the first secret is encrypted,
+	// which might happen in a transaction that locks the database
+	if !ss.dbCfg.SkipMigrations {
+		if err := encryption.EnsureActiveDataKey(); err != nil {
+			return errutil.Wrap("failed to create data key", err)
+		}
+	}
+
 	if err := ss.Reset(); err != nil {
 		return err
 	}
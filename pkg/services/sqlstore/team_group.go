@@ -0,0 +1,92 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", AddTeamGroup)
+	bus.AddHandler("sql", RemoveTeamGroup)
+	bus.AddHandler("sql", GetTeamGroups)
+	bus.AddHandler("sql", GetTeamGroupsByGroupIds)
+}
+
+// AddTeamGroup links a team to an external group
+func AddTeamGroup(cmd *models.AddTeamGroupCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := teamExists(cmd.OrgId, cmd.TeamId, sess); err != nil {
+			return err
+		}
+
+		if res, err := sess.Query("SELECT 1 FROM team_group WHERE org_id=? and team_id=? and LOWER(group_id)=?",
+			cmd.OrgId, cmd.TeamId, strings.ToLower(cmd.GroupId)); err != nil {
+			return err
+		} else if len(res) > 0 {
+			return models.ErrTeamGroupAlreadyAdded
+		}
+
+		entity := models.TeamGroup{
+			OrgId:   cmd.OrgId,
+			TeamId:  cmd.TeamId,
+			GroupId: cmd.GroupId,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}
+
+		_, err := sess.Insert(&entity)
+		return err
+	})
+}
+
+// RemoveTeamGroup removes the link between a team and an external group
+func RemoveTeamGroup(cmd *models.RemoveTeamGroupCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM team_group WHERE org_id=? and team_id=? and LOWER(group_id)=?",
+			cmd.OrgId, cmd.TeamId, strings.ToLower(cmd.GroupId))
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return models.ErrTeamGroupNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetTeamGroups returns the external groups linked to a team
+func GetTeamGroups(query *models.GetTeamGroupsQuery) error {
+	query.Result = make([]*models.TeamGroupDTO, 0)
+	return x.Table("team_group").
+		Where("org_id=? and team_id=?", query.OrgId, query.TeamId).
+		Cols("org_id", "team_id", "group_id").
+		Asc("group_id").
+		Find(&query.Result)
+}
+
+// GetTeamGroupsByGroupIds returns the teams of all organizations linked to one of the external groups
+func GetTeamGroupsByGroupIds(query *models.GetTeamGroupsByGroupIdsQuery) error {
+	query.Result = make([]*models.TeamGroupDTO, 0)
+	if len(query.GroupIds) == 0 {
+		return nil
+	}
+
+	params := make([]interface{}, 0, len(query.GroupIds))
+	for _, groupId := range query.GroupIds {
+		params = append(params, strings.ToLower(groupId))
+	}
+
+	return x.Table("team_group").
+		Where("LOWER(group_id) IN (?"+strings.Repeat(",?", len(params)-1)+")", params...).
+		Cols("org_id", "team_id", "group_id").
+		Find(&query.Result)
+}
@@ -0,0 +1,68 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamGroups(t *testing.T) {
+	InitTestDB(t)
+
+	team1 := models.CreateTeamCommand{OrgId: 1, Name: "team1"}
+	require.NoError(t, CreateTeam(&team1))
+	team2 := models.CreateTeamCommand{OrgId: 2, Name: "team2"}
+	require.NoError(t, CreateTeam(&team2))
+
+	const ldapGroup = "cn=admins,ou=groups,dc=grafana,dc=org"
+
+	t.Run("Groups can be added to teams", func(t *testing.T) {
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team1.Result.Id, GroupId: ldapGroup}))
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team1.Result.Id, GroupId: "editors"}))
+		require.NoError(t, AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 2, TeamId: team2.Result.Id, GroupId: "Editors"}))
+
+		query := models.GetTeamGroupsQuery{OrgId: 1, TeamId: team1.Result.Id}
+		require.NoError(t, GetTeamGroups(&query))
+		require.Len(t, query.Result, 2)
+		assert.Equal(t, ldapGroup, query.Result[0].GroupId)
+		assert.Equal(t, "editors", query.Result[1].GroupId)
+	})
+
+	t.Run("Groups are added once per team", func(t *testing.T) {
+		err := AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team1.Result.Id, GroupId: "EDITORS"})
+		assert.Equal(t, models.ErrTeamGroupAlreadyAdded, err)
+	})
+
+	t.Run("Groups can't be added to teams of other organizations", func(t *testing.T) {
+		err := AddTeamGroup(&models.AddTeamGroupCommand{OrgId: 1, TeamId: team2.Result.Id, GroupId: "viewers"})
+		assert.Equal(t, models.ErrTeamNotFound, err)
+	})
+
+	t.Run("Teams are found by groups of any organization and case", func(t *testing.T) {
+		query := models.GetTeamGroupsByGroupIdsQuery{GroupIds: []string{"EDITORS", "unknown"}}
+		require.NoError(t, GetTeamGroupsByGroupIds(&query))
+		require.Len(t, query.Result, 2)
+
+		teamIds := []int64{query.Result[0].TeamId, query.Result[1].TeamId}
+		assert.ElementsMatch(t, []int64{team1.Result.Id, team2.Result.Id}, teamIds)
+	})
+
+	t.Run("Groups can be removed from teams", func(t *testing.T) {
+		require.NoError(t, RemoveTeamGroup(&models.RemoveTeamGroupCommand{OrgId: 1, TeamId: team1.Result.Id, GroupId: "Editors"}))
+
+		err := RemoveTeamGroup(&models.RemoveTeamGroupCommand{OrgId: 1, TeamId: team1.Result.Id, GroupId: "editors"})
+		assert.Equal(t, models.ErrTeamGroupNotFound, err)
+	})
+
+	t.Run("Deleting a team deletes its groups", func(t *testing.T) {
+		require.NoError(t, DeleteTeam(&models.DeleteTeamCommand{OrgId: 1, Id: team1.Result.Id}))
+
+		query := models.GetTeamGroupsByGroupIdsQuery{GroupIds: []string{ldapGroup}}
+		require.NoError(t, GetTeamGroupsByGroupIds(&query))
+		assert.Empty(t, query.Result)
+	})
+}
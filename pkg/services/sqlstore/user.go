@@ -152,6 +152,12 @@ func (ss *SQLStore) createUser(ctx context.Context, args userCreationArgs, skipO
 			return err
 		}
 
+		if user.Password != "" {
+			if err := addUserPasswordHistory(sess, user.Id, user.Password, user.Salt); err != nil {
+				return err
+			}
+		}
+
 		sess.publishAfterCommit(&events.UserCreated{
 			Timestamp: user.Created,
 			Id:        user.Id,
@@ -247,6 +253,12 @@ func CreateUser(ctx context.Context, cmd *models.CreateUserCommand) error {
 			return err
 		}
 
+		if user.Password != "" {
+			if err := addUserPasswordHistory(sess, user.Id, user.Password, user.Salt); err != nil {
+				return err
+			}
+		}
+
 		sess.publishAfterCommit(&events.UserCreated{
 			Timestamp: user.Created,
 			Id:        user.Id,
@@ -383,8 +395,15 @@ func ChangeUserPassword(cmd *models.ChangeUserPasswordCommand) error {
 			Updated:  time.Now(),
 		}
 
-		_, err := sess.ID(cmd.UserId).Update(&user)
-		return err
+		if _, err := sess.ID(cmd.UserId).Update(&user); err != nil {
+			return err
+		}
+
+		var current models.User
+		if _, err := sess.ID(cmd.UserId).Cols("salt").Get(&current); err != nil {
+			return err
+		}
+		return addUserPasswordHistory(sess, cmd.UserId, cmd.NewPassword, current.Salt)
 	})
 }
 
@@ -525,6 +544,8 @@ func GetSignedInUser(query *models.GetSignedInUserQuery) error {
 		u.name           as name,
 		u.help_flags1    as help_flags1,
 		u.last_seen_at   as last_seen_at,
+		u.is_service_account as is_service_account,
+		u.is_disabled    as is_disabled,
 		(SELECT COUNT(*) FROM org_user where org_user.user_id = u.id) as org_count,
 		org.name         as org_name,
 		org_user.role    as org_role,
@@ -590,6 +611,10 @@ func SearchUsers(query *models.SearchUsersQuery) error {
 	joinCondition = "user_auth.id=" + joinCondition + dialect.Limit(1) + ")"
 	sess.Join("LEFT", "user_auth", joinCondition)
 
+	// service accounts are listed by organization
+	whereConditions = append(whereConditions, "is_service_account = ?")
+	whereParams = append(whereParams, false)
+
 	if query.OrgId > 0 {
 		whereConditions = append(whereConditions, "org_id = ?")
 		whereParams = append(whereParams, query.OrgId)
@@ -605,6 +630,11 @@ func SearchUsers(query *models.SearchUsersQuery) error {
 		whereParams = append(whereParams, query.IsDisabled)
 	}
 
+	if query.IsGrafanaAdmin != nil {
+		whereConditions = append(whereConditions, "is_admin = ?")
+		whereParams = append(whereParams, query.IsGrafanaAdmin)
+	}
+
 	if query.AuthModule != "" {
 		whereConditions = append(whereConditions, `auth_module=?`)
 		whereParams = append(whereParams, query.AuthModule)
@@ -716,7 +746,10 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 		"DELETE FROM team_member WHERE user_id = ?",
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
+		"DELETE FROM user_totp WHERE user_id = ?",
+		"DELETE FROM user_password_history WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM role_assignment WHERE user_id = ?",
 	}
 
 	for _, sql := range deletes {
@@ -0,0 +1,92 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// seedUserPasswordHistoryBatchSize is the number of rows of the password history inserted by statement when
+// seeding it, which keeps the statements under the bind parameter limits of the databases
+var seedUserPasswordHistoryBatchSize = 100
+
+func init() {
+	bus.AddHandler("sql", GetUserPasswordHistory)
+	bus.AddHandler("sql", SeedUserPasswordHistory)
+}
+
+func GetUserPasswordHistory(query *models.GetUserPasswordHistoryQuery) error {
+	query.Result = make([]*models.UserPasswordHistory, 0)
+	sess := x.Where("user_id = ?", query.UserId).Desc("created").Desc("id")
+	if query.Limit > 0 {
+		sess.Limit(query.Limit)
+	}
+	return sess.Find(&query.Result)
+}
+
+func SeedUserPasswordHistory(cmd *models.SeedUserPasswordHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		var users []*models.User
+		rawSQL := `SELECT u.id, u.password, u.salt FROM ` + dialect.Quote("user") + ` AS u
+			WHERE u.password IS NOT NULL AND u.password <> ''
+			AND NOT EXISTS (SELECT 1 FROM user_password_history AS h WHERE h.user_id = u.id)`
+		if err := sess.SQL(rawSQL).Find(&users); err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		for start := 0; start < len(users); start += seedUserPasswordHistoryBatchSize {
+			end := start + seedUserPasswordHistoryBatchSize
+			if end > len(users) {
+				end = len(users)
+			}
+
+			entries := make([]*models.UserPasswordHistory, 0, end-start)
+			for _, user := range users[start:end] {
+				entries = append(entries, &models.UserPasswordHistory{
+					UserId:   user.Id,
+					Password: user.Password,
+					Salt:     user.Salt,
+					Created:  cmd.Created,
+				})
+			}
+
+			seeded, err := sess.Insert(&entries)
+			if err != nil {
+				return err
+			}
+			cmd.SeededRows += seeded
+		}
+		return nil
+	})
+}
+
+// addUserPasswordHistory records the new password of a user, and forgets the passwords older than
+// the ones which can be kept.
+func addUserPasswordHistory(sess *DBSession, userID int64, password, salt string) error {
+	entry := models.UserPasswordHistory{
+		UserId:   userID,
+		Password: password,
+		Salt:     salt,
+		Created:  time.Now(),
+	}
+	if _, err := sess.Insert(&entry); err != nil {
+		return err
+	}
+
+	var ids []int64
+	if err := sess.Table("user_password_history").Cols("id").Where("user_id = ?", userID).
+		Desc("created").Desc("id").Limit(1000, setting.MaxPasswordHistoryCount).Find(&ids); err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		if _, err := sess.In("id", ids).Delete(&models.UserPasswordHistory{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
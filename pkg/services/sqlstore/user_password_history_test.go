@@ -0,0 +1,105 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestUserPasswordHistory(t *testing.T) {
+	InitTestDB(t)
+
+	cmd := &models.CreateUserCommand{Login: "history", Email: "history@example.com", Password: "initial-password"}
+	require.NoError(t, CreateUser(context.Background(), cmd))
+	user := cmd.Result
+
+	t.Run("The password of a new user is recorded", func(t *testing.T) {
+		query := models.GetUserPasswordHistoryQuery{UserId: user.Id}
+		require.NoError(t, GetUserPasswordHistory(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, user.Password, query.Result[0].Password)
+		assert.Equal(t, user.Salt, query.Result[0].Salt)
+	})
+
+	t.Run("Password changes are recorded, the most recent first", func(t *testing.T) {
+		require.NoError(t, ChangeUserPassword(&models.ChangeUserPasswordCommand{UserId: user.Id, NewPassword: "encoded-1"}))
+
+		query := models.GetUserPasswordHistoryQuery{UserId: user.Id, Limit: 1}
+		require.NoError(t, GetUserPasswordHistory(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, "encoded-1", query.Result[0].Password)
+		assert.Equal(t, user.Salt, query.Result[0].Salt)
+	})
+
+	t.Run("Only the last passwords are kept", func(t *testing.T) {
+		for i := 0; i < setting.MaxPasswordHistoryCount+5; i++ {
+			require.NoError(t, ChangeUserPassword(&models.ChangeUserPasswordCommand{
+				UserId: user.Id, NewPassword: fmt.Sprintf("encoded-%d", i+2),
+			}))
+		}
+
+		query := models.GetUserPasswordHistoryQuery{UserId: user.Id}
+		require.NoError(t, GetUserPasswordHistory(&query))
+		assert.Len(t, query.Result, setting.MaxPasswordHistoryCount)
+	})
+
+	t.Run("The current passwords of the users without history are seeded", func(t *testing.T) {
+		seeded := &models.CreateUserCommand{Login: "seeded", Email: "seeded@example.com", Password: "seeded-password"}
+		require.NoError(t, CreateUser(context.Background(), seeded))
+		_, err := x.Exec("DELETE FROM user_password_history WHERE user_id = ?", seeded.Result.Id)
+		require.NoError(t, err)
+		noPassword := &models.CreateUserCommand{Login: "no-password", Email: "no-password@example.com"}
+		require.NoError(t, CreateUser(context.Background(), noPassword))
+
+		created := time.Now().Add(-time.Hour).Truncate(time.Second)
+		cmd := models.SeedUserPasswordHistoryCommand{Created: created}
+		require.NoError(t, SeedUserPasswordHistory(&cmd))
+		assert.Equal(t, int64(1), cmd.SeededRows)
+
+		query := models.GetUserPasswordHistoryQuery{UserId: seeded.Result.Id}
+		require.NoError(t, GetUserPasswordHistory(&query))
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, seeded.Result.Password, query.Result[0].Password)
+		assert.Equal(t, seeded.Result.Salt, query.Result[0].Salt)
+		assert.True(t, created.Equal(query.Result[0].Created))
+
+		cmd = models.SeedUserPasswordHistoryCommand{Created: time.Now()}
+		require.NoError(t, SeedUserPasswordHistory(&cmd))
+		assert.Equal(t, int64(0), cmd.SeededRows)
+	})
+
+	t.Run("The password history is seeded in batches", func(t *testing.T) {
+		seedUserPasswordHistoryBatchSize = 2
+		t.Cleanup(func() { seedUserPasswordHistoryBatchSize = 100 })
+
+		for i := 0; i < 3; i++ {
+			batched := &models.CreateUserCommand{
+				Login: fmt.Sprintf("batched-%d", i), Email: fmt.Sprintf("batched-%d@example.com", i), Password: "batched-password",
+			}
+			require.NoError(t, CreateUser(context.Background(), batched))
+			_, err := x.Exec("DELETE FROM user_password_history WHERE user_id = ?", batched.Result.Id)
+			require.NoError(t, err)
+		}
+
+		cmd := models.SeedUserPasswordHistoryCommand{Created: time.Now()}
+		require.NoError(t, SeedUserPasswordHistory(&cmd))
+		assert.Equal(t, int64(3), cmd.SeededRows)
+	})
+
+	t.Run("The history is deleted with the user", func(t *testing.T) {
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: user.Id}))
+
+		query := models.GetUserPasswordHistoryQuery{UserId: user.Id}
+		require.NoError(t, GetUserPasswordHistory(&query))
+		assert.Empty(t, query.Result)
+	})
+}
@@ -236,6 +236,26 @@ func TestUserDataAccess(t *testing.T) {
 				So(fourth, ShouldBeTrue)
 			})
 
+			Convey("Can return list users based on their is_admin flag", func() {
+				ss = InitTestDB(t)
+				createFiveTestUsers(func(i int) *models.CreateUserCommand {
+					return &models.CreateUserCommand{
+						Email:   fmt.Sprint("user", i, "@test.com"),
+						Name:    fmt.Sprint("user", i),
+						Login:   fmt.Sprint("loginuser", i),
+						IsAdmin: i == 3,
+					}
+				})
+
+				isGrafanaAdmin := true
+				query := models.SearchUsersQuery{IsGrafanaAdmin: &isGrafanaAdmin}
+				err := SearchUsers(&query)
+				So(err, ShouldBeNil)
+
+				So(query.Result.Users, ShouldHaveLength, 1)
+				So(query.Result.Users[0].Name, ShouldEqual, "user3")
+			})
+
 			Convey("Can return list users based on their is_disabled flag", func() {
 				ss = InitTestDB(t)
 				createFiveTestUsers(func(i int) *models.CreateUserCommand {
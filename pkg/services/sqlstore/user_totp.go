@@ -0,0 +1,123 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", GetUserTotp)
+	bus.AddHandler("sql", SaveUserTotpSecret)
+	bus.AddHandler("sql", EnableUserTotp)
+	bus.AddHandler("sql", UseUserTotpStep)
+	bus.AddHandler("sql", UpdateUserTotpRecoveryCodes)
+	bus.AddHandler("sql", DeleteUserTotp)
+	bus.AddHandler("sql", IsTotpRequiredForUser)
+}
+
+func GetUserTotp(query *models.GetUserTotpQuery) error {
+	var userTotp models.UserTotp
+	exists, err := x.Where("user_id = ?", query.UserId).Get(&userTotp)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrUserTotpNotFound
+	}
+
+	query.Result = &userTotp
+	return nil
+}
+
+func SaveUserTotpSecret(cmd *models.SaveUserTotpSecretCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM user_totp WHERE user_id = ?", cmd.UserId); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		userTotp := models.UserTotp{
+			UserId:  cmd.UserId,
+			Secret:  cmd.Secret,
+			Created: now,
+			Updated: now,
+		}
+		_, err := sess.Insert(&userTotp)
+		return err
+	})
+}
+
+func EnableUserTotp(cmd *models.EnableUserTotpCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("UPDATE user_totp SET enabled = ?, recovery_codes = ?, last_used_step = ?, updated = ? WHERE user_id = ?",
+			true, cmd.RecoveryCodes, cmd.Step, time.Now(), cmd.UserId)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrUserTotpNotFound
+		}
+		return nil
+	})
+}
+
+func UseUserTotpStep(cmd *models.UseUserTotpStepCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		// the condition on the step makes concurrent logins with the same code fail
+		res, err := sess.Exec("UPDATE user_totp SET last_used_step = ?, updated = ? WHERE user_id = ? AND last_used_step < ?",
+			cmd.Step, time.Now(), cmd.UserId, cmd.Step)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrUserTotpCodeUsed
+		}
+		return nil
+	})
+}
+
+func UpdateUserTotpRecoveryCodes(cmd *models.UpdateUserTotpRecoveryCodesCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		// the condition on the previous codes makes concurrent logins with the same recovery code fail
+		res, err := sess.Exec("UPDATE user_totp SET recovery_codes = ?, updated = ? WHERE user_id = ? AND recovery_codes = ?",
+			cmd.RecoveryCodes, time.Now(), cmd.UserId, cmd.PreviousRecoveryCodes)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return models.ErrUserTotpCodeUsed
+		}
+		return nil
+	})
+}
+
+func DeleteUserTotp(cmd *models.DeleteUserTotpCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM user_totp WHERE user_id = ?", cmd.UserId)
+		return err
+	})
+}
+
+func IsTotpRequiredForUser(query *models.IsTotpRequiredForUserQuery) error {
+	count, err := x.Table("org_user").
+		Join("INNER", "org", "org.id = org_user.org_id").
+		Where("org_user.user_id = ? AND org.require_totp = ?", query.UserId, true).
+		Count()
+	if err != nil {
+		return err
+	}
+
+	query.Result = count > 0
+	return nil
+}
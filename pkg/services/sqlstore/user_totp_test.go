@@ -0,0 +1,83 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestUserTotp(t *testing.T) {
+	InitTestDB(t)
+
+	cmd := &models.CreateUserCommand{Login: "totp", Email: "totp@example.com"}
+	require.NoError(t, CreateUser(context.Background(), cmd))
+	userID := cmd.Result.Id
+
+	t.Run("Get returns not found before enrollment", func(t *testing.T) {
+		err := GetUserTotp(&models.GetUserTotpQuery{UserId: userID})
+		require.Equal(t, models.ErrUserTotpNotFound, err)
+	})
+
+	t.Run("Enrollment replaces the pending secret", func(t *testing.T) {
+		require.NoError(t, SaveUserTotpSecret(&models.SaveUserTotpSecretCommand{UserId: userID, Secret: []byte("first")}))
+		require.NoError(t, SaveUserTotpSecret(&models.SaveUserTotpSecretCommand{UserId: userID, Secret: []byte("second")}))
+
+		query := models.GetUserTotpQuery{UserId: userID}
+		require.NoError(t, GetUserTotp(&query))
+		assert.Equal(t, []byte("second"), query.Result.Secret)
+		assert.False(t, query.Result.Enabled)
+	})
+
+	t.Run("Enable stores the recovery codes and the step", func(t *testing.T) {
+		require.NoError(t, EnableUserTotp(&models.EnableUserTotpCommand{UserId: userID, RecoveryCodes: `["a","b"]`, Step: 100}))
+
+		query := models.GetUserTotpQuery{UserId: userID}
+		require.NoError(t, GetUserTotp(&query))
+		assert.True(t, query.Result.Enabled)
+		assert.Equal(t, `["a","b"]`, query.Result.RecoveryCodes)
+		assert.Equal(t, int64(100), query.Result.LastUsedStep)
+	})
+
+	t.Run("Steps can be used only once", func(t *testing.T) {
+		require.Equal(t, models.ErrUserTotpCodeUsed, UseUserTotpStep(&models.UseUserTotpStepCommand{UserId: userID, Step: 100}))
+		require.NoError(t, UseUserTotpStep(&models.UseUserTotpStepCommand{UserId: userID, Step: 101}))
+		require.Equal(t, models.ErrUserTotpCodeUsed, UseUserTotpStep(&models.UseUserTotpStepCommand{UserId: userID, Step: 99}))
+	})
+
+	t.Run("Recovery codes can be updated once", func(t *testing.T) {
+		cmd := models.UpdateUserTotpRecoveryCodesCommand{UserId: userID, RecoveryCodes: `["b"]`, PreviousRecoveryCodes: `["a","b"]`}
+		require.NoError(t, UpdateUserTotpRecoveryCodes(&cmd))
+		require.Equal(t, models.ErrUserTotpCodeUsed, UpdateUserTotpRecoveryCodes(&cmd))
+
+		query := models.GetUserTotpQuery{UserId: userID}
+		require.NoError(t, GetUserTotp(&query))
+		assert.Equal(t, `["b"]`, query.Result.RecoveryCodes)
+	})
+
+	t.Run("Organizations can require two-factor authentication", func(t *testing.T) {
+		query := models.IsTotpRequiredForUserQuery{UserId: userID}
+		require.NoError(t, IsTotpRequiredForUser(&query))
+		assert.False(t, query.Result)
+
+		require.NoError(t, UpdateOrgRequireTotp(&models.UpdateOrgRequireTotpCommand{OrgId: cmd.Result.OrgId, Required: true}))
+		require.NoError(t, IsTotpRequiredForUser(&query))
+		assert.True(t, query.Result)
+
+		orgQuery := models.GetOrgByIdQuery{Id: cmd.Result.OrgId}
+		require.NoError(t, GetOrgById(&orgQuery))
+		assert.True(t, orgQuery.Result.RequireTotp)
+	})
+
+	t.Run("Deleting the user deletes its authenticator", func(t *testing.T) {
+		require.NoError(t, DeleteUser(&models.DeleteUserCommand{UserId: userID}))
+
+		err := GetUserTotp(&models.GetUserTotpQuery{UserId: userID})
+		require.Equal(t, models.ErrUserTotpNotFound, err)
+	})
+}
@@ -0,0 +1,93 @@
+// Package teamsync synchronizes the members of teams with the groups of the users in their auth provider.
+package teamsync
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/login"
+)
+
+var logger = log.New("teamsync")
+
+func init() {
+	registry.RegisterService(&TeamSyncService{})
+}
+
+// TeamSyncService keeps the external members of teams in sync with the groups linked to the teams
+// whenever external users are upserted, when they log in or when LDAP users are synchronized.
+type TeamSyncService struct {
+	LoginService *login.LoginService `inject:""`
+}
+
+func (s *TeamSyncService) Init() error {
+	s.LoginService.TeamSync = SyncTeams
+	return nil
+}
+
+type teamKey struct {
+	orgId  int64
+	teamId int64
+}
+
+// SyncTeams makes the user an external member of the teams linked to its external groups, in the
+// organizations the user belongs to, and removes it from the teams it is no longer synchronized to.
+// Members added manually are never removed.
+func SyncTeams(user *models.User, extUser *models.ExternalUserInfo) error {
+	orgsQuery := &models.GetUserOrgListQuery{UserId: user.Id}
+	if err := bus.Dispatch(orgsQuery); err != nil {
+		return err
+	}
+	orgIds := make(map[int64]bool, len(orgsQuery.Result))
+	for _, org := range orgsQuery.Result {
+		orgIds[org.OrgId] = true
+	}
+
+	groupsQuery := &models.GetTeamGroupsByGroupIdsQuery{GroupIds: extUser.Groups}
+	if err := bus.Dispatch(groupsQuery); err != nil {
+		return err
+	}
+	teams := map[teamKey]bool{}
+	for _, group := range groupsQuery.Result {
+		if orgIds[group.OrgId] {
+			teams[teamKey{orgId: group.OrgId, teamId: group.TeamId}] = true
+		}
+	}
+
+	membersQuery := &models.GetTeamMembersQuery{UserId: user.Id, External: true}
+	if err := bus.Dispatch(membersQuery); err != nil {
+		return err
+	}
+	synced := map[teamKey]bool{}
+	for _, member := range membersQuery.Result {
+		key := teamKey{orgId: member.OrgId, teamId: member.TeamId}
+		synced[key] = true
+		if teams[key] {
+			continue
+		}
+
+		logger.Debug("Removing user from team", "user", user.Id, "org", key.orgId, "team", key.teamId)
+		cmd := &models.RemoveTeamMemberCommand{OrgId: key.orgId, TeamId: key.teamId, UserId: user.Id}
+		if err := bus.Dispatch(cmd); err != nil && !errors.Is(err, models.ErrTeamMemberNotFound) {
+			return err
+		}
+	}
+
+	for key := range teams {
+		if synced[key] {
+			continue
+		}
+
+		logger.Debug("Adding user to team", "user", user.Id, "org", key.orgId, "team", key.teamId)
+		cmd := &models.AddTeamMemberCommand{OrgId: key.orgId, TeamId: key.teamId, UserId: user.Id, External: true}
+		// members added manually stay manual members
+		if err := bus.Dispatch(cmd); err != nil && !errors.Is(err, models.ErrTeamMemberAlreadyAdded) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package teamsync
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type teamSyncScenario struct {
+	groups  []*models.TeamGroupDTO
+	members []*models.TeamMemberDTO
+
+	added   []teamKey
+	removed []teamKey
+}
+
+func setupTeamSyncScenario(t *testing.T) *teamSyncScenario {
+	t.Helper()
+
+	sc := &teamSyncScenario{}
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	bus.AddHandler("test", func(query *models.GetUserOrgListQuery) error {
+		query.Result = []*models.UserOrgDTO{{OrgId: 1}, {OrgId: 2}}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetTeamGroupsByGroupIdsQuery) error {
+		for _, group := range sc.groups {
+			for _, groupId := range query.GroupIds {
+				if group.GroupId == groupId {
+					query.Result = append(query.Result, group)
+				}
+			}
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetTeamMembersQuery) error {
+		assert.True(t, query.External)
+		for _, member := range sc.members {
+			if member.UserId == query.UserId {
+				query.Result = append(query.Result, member)
+			}
+		}
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.AddTeamMemberCommand) error {
+		assert.True(t, cmd.External)
+		if cmd.TeamId == 40 {
+			return models.ErrTeamMemberAlreadyAdded
+		}
+		sc.added = append(sc.added, teamKey{orgId: cmd.OrgId, teamId: cmd.TeamId})
+		return nil
+	})
+	bus.AddHandler("test", func(cmd *models.RemoveTeamMemberCommand) error {
+		sc.removed = append(sc.removed, teamKey{orgId: cmd.OrgId, teamId: cmd.TeamId})
+		return nil
+	})
+
+	return sc
+}
+
+func sortedKeys(keys []teamKey) []teamKey {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].teamId < keys[j].teamId })
+	return keys
+}
+
+func TestSyncTeams(t *testing.T) {
+	sc := setupTeamSyncScenario(t)
+	sc.groups = []*models.TeamGroupDTO{
+		{OrgId: 1, TeamId: 10, GroupId: "admins"},
+		{OrgId: 2, TeamId: 11, GroupId: "admins"},
+		{OrgId: 3, TeamId: 30, GroupId: "admins"},
+		{OrgId: 1, TeamId: 20, GroupId: "editors"},
+		{OrgId: 2, TeamId: 40, GroupId: "viewers"},
+		{OrgId: 1, TeamId: 50, GroupId: "former"},
+	}
+	sc.members = []*models.TeamMemberDTO{
+		{OrgId: 1, TeamId: 20, UserId: 1, External: true},
+		{OrgId: 1, TeamId: 50, UserId: 1, External: true},
+	}
+
+	err := SyncTeams(&models.User{Id: 1}, &models.ExternalUserInfo{Groups: []string{"admins", "editors", "viewers"}})
+	require.NoError(t, err)
+
+	t.Run("User is added to the teams of its groups in its organizations", func(t *testing.T) {
+		assert.Equal(t, []teamKey{{orgId: 1, teamId: 10}, {orgId: 2, teamId: 11}}, sortedKeys(sc.added))
+	})
+
+	t.Run("User is removed from the teams of its former groups", func(t *testing.T) {
+		assert.Equal(t, []teamKey{{orgId: 1, teamId: 50}}, sc.removed)
+	})
+}
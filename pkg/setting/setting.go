@@ -219,6 +219,30 @@ type Cfg struct {
 	// SMTP email settings
 	Smtp SmtpSettings
 
+	// Secrets providers
+	Secrets SecretsSettings
+
+	// Audit log of the API calls changing resources
+	AuditLog AuditLogSettings
+
+	// Query result caching of the data sources
+	QueryCaching QueryCachingSettings
+
+	// Two-factor authentication with time-based one-time passwords
+	Totp TotpSettings
+
+	// Password policy of the built-in users
+	PasswordPolicy PasswordPolicySettings
+
+	// SCIM provisioning of the users and teams
+	SCIM SCIMSettings
+
+	// Lockout of the usernames and IP addresses after failed logins, and login notifications
+	LoginLockout LoginLockoutSettings
+
+	// JWT authentication
+	JWTAuth JWTAuthSettings
+
 	// Rendering
 	ImagesDir                      string
 	RendererUrl                    string
@@ -286,9 +310,15 @@ type Cfg struct {
 	AuthProxyWhitelist        string
 	AuthProxyHeaders          map[string]string
 	AuthProxySyncTTL          int
+	AuthProxySignatureHeader  string
+	AuthProxySignatureType    string
+	AuthProxySignatureSecret  string
+	AuthProxySignatureMaxAge  time.Duration
 
 	// OAuth
-	OAuthCookieMaxAge int
+	OAuthCookieMaxAge            int
+	OAuthValidateSession         bool
+	OAuthValidateSessionInterval time.Duration
 
 	// SAML Auth
 	SAMLEnabled             bool
@@ -347,6 +377,9 @@ type Cfg struct {
 
 	Quota QuotaSettings
 
+	// Rate limits
+	RateLimits RateLimitSettings
+
 	DefaultTheme string
 
 	AutoAssignOrg     bool
@@ -879,7 +912,30 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cfg.readAWSConfig()
 	cfg.readSessionConfig()
 	cfg.readSmtpSettings()
+	cfg.readSecretsSettings()
+	if err := cfg.readAuditLogSettings(); err != nil {
+		return err
+	}
+	if err := cfg.readQueryCachingSettings(); err != nil {
+		return err
+	}
+	cfg.readTotpSettings()
+	if err := cfg.readPasswordPolicySettings(); err != nil {
+		return err
+	}
+	if err := cfg.readSCIMSettings(); err != nil {
+		return err
+	}
+	if err := cfg.readLoginLockoutSettings(); err != nil {
+		return err
+	}
+	if err := cfg.readJWTAuthSettings(); err != nil {
+		return err
+	}
 	cfg.readQuotaSettings()
+	if err := cfg.readRateLimitSettings(); err != nil {
+		return err
+	}
 	cfg.readAnnotationSettings()
 	cfg.readExpressionsSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
@@ -908,10 +964,12 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cacheServer := iniFile.Section("remote_cache")
 	dbName := valueAsString(cacheServer, "type", "database")
 	connStr := valueAsString(cacheServer, "connstr", "")
+	prefix := valueAsString(cacheServer, "prefix", "")
 
 	cfg.RemoteCacheOptions = &RemoteCacheOptions{
 		Name:    dbName,
 		ConnStr: connStr,
+		Prefix:  prefix,
 	}
 
 	cfg.readDateFormats()
@@ -927,6 +985,7 @@ func valueAsString(section *ini.Section, keyName string, defaultValue string) st
 type RemoteCacheOptions struct {
 	Name    string
 	ConnStr string
+	Prefix  string
 }
 
 func (cfg *Cfg) readLDAPConfig() {
@@ -1132,6 +1191,11 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	DisableSignoutMenu = auth.Key("disable_signout_menu").MustBool(false)
 	OAuthAutoLogin = auth.Key("oauth_auto_login").MustBool(false)
 	cfg.OAuthCookieMaxAge = auth.Key("oauth_state_cookie_max_age").MustInt(600)
+	cfg.OAuthValidateSession = auth.Key("oauth_validate_session").MustBool(false)
+	cfg.OAuthValidateSessionInterval, err = gtime.ParseDuration(valueAsString(auth, "oauth_validate_session_interval", "5m"))
+	if err != nil {
+		return err
+	}
 	SignoutRedirectUrl = valueAsString(auth, "signout_redirect_url", "")
 
 	// SigV4
@@ -1186,6 +1250,22 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 		}
 	}
 
+	cfg.AuthProxySignatureHeader = valueAsString(authProxy, "signature_header", "")
+	cfg.AuthProxySignatureType = valueAsString(authProxy, "signature_type", "hmac")
+	cfg.AuthProxySignatureSecret = valueAsString(authProxy, "signature_secret", "")
+	cfg.AuthProxySignatureMaxAge, err = gtime.ParseDuration(valueAsString(authProxy, "signature_max_age", "30s"))
+	if err != nil {
+		return err
+	}
+	if cfg.AuthProxySignatureHeader != "" {
+		if cfg.AuthProxySignatureType != "hmac" && cfg.AuthProxySignatureType != "jwt" {
+			return fmt.Errorf("invalid auth proxy signature_type %q, must be hmac or jwt", cfg.AuthProxySignatureType)
+		}
+		if cfg.AuthProxySignatureSecret == "" {
+			return errors.New("auth proxy signature_secret is required when signature_header is set")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,31 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+type AuditLogSettings struct {
+	Enabled bool
+	// MaxAge is how long entries are kept, 0 keeps them forever
+	MaxAge           time.Duration
+	ExportToLog      bool
+	MaxSummaryLength int
+}
+
+func (cfg *Cfg) readAuditLogSettings() error {
+	sec := cfg.Raw.Section("audit_log")
+	cfg.AuditLog.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.AuditLog.ExportToLog = sec.Key("export_to_log").MustBool(false)
+	cfg.AuditLog.MaxSummaryLength = sec.Key("max_summary_length").MustInt(4096)
+
+	maxAge, err := gtime.ParseDuration(valueAsString(sec, "max_age", "90d"))
+	if err != nil {
+		return fmt.Errorf("invalid audit_log max_age: %w", err)
+	}
+	cfg.AuditLog.MaxAge = maxAge
+
+	return nil
+}
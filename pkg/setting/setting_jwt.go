@@ -0,0 +1,73 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type JWTAuthSettings struct {
+	Enabled bool
+	// HeaderName is the header with the token, "Bearer " prefixes are removed
+	HeaderName string
+
+	// Only one of the key sources is used, in this order
+	JWKSetURL  string
+	JWKSetFile string
+	KeyFile    string
+	// CacheTTL is how long the keys fetched from JWKSetURL are cached
+	CacheTTL time.Duration
+
+	// Issuer and Audiences are checked when set, the tokens must have one of the audiences
+	Issuer    string
+	Audiences []string
+	// Leeway is the clock skew allowed when checking the expiry of the tokens
+	Leeway time.Duration
+
+	LoginClaim          string
+	EmailClaim          string
+	NameClaim           string
+	RoleAttributePath   string
+	GroupsAttributePath string
+	AutoSignUp          bool
+}
+
+func (cfg *Cfg) readJWTAuthSettings() error {
+	sec := cfg.Raw.Section("auth.jwt")
+	jwt := JWTAuthSettings{
+		Enabled:             sec.Key("enabled").MustBool(false),
+		HeaderName:          valueAsString(sec, "header_name", "X-JWT-Assertion"),
+		JWKSetURL:           valueAsString(sec, "jwk_set_url", ""),
+		JWKSetFile:          valueAsString(sec, "jwk_set_file", ""),
+		KeyFile:             valueAsString(sec, "key_file", ""),
+		Issuer:              valueAsString(sec, "issuer", ""),
+		Audiences:           util.SplitString(valueAsString(sec, "audience", "")),
+		LoginClaim:          valueAsString(sec, "login_claim", "sub"),
+		EmailClaim:          valueAsString(sec, "email_claim", "email"),
+		NameClaim:           valueAsString(sec, "name_claim", "name"),
+		RoleAttributePath:   valueAsString(sec, "role_attribute_path", ""),
+		GroupsAttributePath: valueAsString(sec, "groups_attribute_path", ""),
+		AutoSignUp:          sec.Key("auto_sign_up").MustBool(false),
+	}
+
+	cacheTTL, err := gtime.ParseDuration(valueAsString(sec, "cache_ttl", "60m"))
+	if err != nil {
+		return fmt.Errorf("invalid auth.jwt cache_ttl: %w", err)
+	}
+	jwt.CacheTTL = cacheTTL
+
+	leeway, err := gtime.ParseDuration(valueAsString(sec, "leeway", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid auth.jwt leeway: %w", err)
+	}
+	jwt.Leeway = leeway
+
+	if jwt.Enabled && jwt.JWKSetURL == "" && jwt.JWKSetFile == "" && jwt.KeyFile == "" {
+		return fmt.Errorf("auth.jwt requires one of jwk_set_url, jwk_set_file or key_file")
+	}
+
+	cfg.JWTAuth = jwt
+	return nil
+}
@@ -0,0 +1,62 @@
+package setting
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/infra/network"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type LoginLockoutSettings struct {
+	// MaxUserAttempts is the number of failed logins with a username after which its logins are blocked
+	MaxUserAttempts int64
+	// MaxIPAttempts is the number of failed logins from an IP address after which its logins are blocked, 0 for no limit
+	MaxIPAttempts int64
+	// Window is the period the failed logins are counted over
+	Window time.Duration
+	// NotifyUser emails the users whose logins are blocked
+	NotifyUser bool
+	// NotifyAdmins emails the Grafana Admins when logins are blocked
+	NotifyAdmins bool
+	// NotifyNewLogin emails the users logging in from a new IP address or user agent
+	NotifyNewLogin bool
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP headers give the IP address of the client
+	TrustedProxies []*net.IPNet
+}
+
+func (cfg *Cfg) readLoginLockoutSettings() error {
+	sec := cfg.Raw.Section("auth.lockout")
+	cfg.LoginLockout.MaxUserAttempts = sec.Key("max_user_attempts").MustInt64(5)
+	if cfg.LoginLockout.MaxUserAttempts < 1 {
+		return fmt.Errorf("invalid auth.lockout max_user_attempts %d, must be at least 1", cfg.LoginLockout.MaxUserAttempts)
+	}
+
+	cfg.LoginLockout.MaxIPAttempts = sec.Key("max_ip_attempts").MustInt64(0)
+	if cfg.LoginLockout.MaxIPAttempts < 0 {
+		return fmt.Errorf("invalid auth.lockout max_ip_attempts %d, must be 0 or more", cfg.LoginLockout.MaxIPAttempts)
+	}
+
+	window, err := gtime.ParseDuration(valueAsString(sec, "window", "5m"))
+	if err != nil {
+		return fmt.Errorf("invalid auth.lockout window: %w", err)
+	}
+	if window <= 0 {
+		return fmt.Errorf("invalid auth.lockout window %s, must be positive", window)
+	}
+	cfg.LoginLockout.Window = window
+
+	cfg.LoginLockout.NotifyUser = sec.Key("notify_user").MustBool(false)
+	cfg.LoginLockout.NotifyAdmins = sec.Key("notify_admins").MustBool(false)
+	cfg.LoginLockout.NotifyNewLogin = sec.Key("notify_new_login").MustBool(false)
+
+	trustedProxies, err := network.ParseCIDRs(util.SplitString(valueAsString(sec, "trusted_proxies", "")))
+	if err != nil {
+		return fmt.Errorf("invalid auth.lockout trusted_proxies: %w", err)
+	}
+	cfg.LoginLockout.TrustedProxies = trustedProxies
+
+	return nil
+}
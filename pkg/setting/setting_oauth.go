@@ -11,6 +11,7 @@ type OAuthInfo struct {
 	AllowedDomains         []string
 	HostedDomain           string
 	ApiUrl                 string
+	IntrospectionUrl       string
 	AllowSignup            bool
 	Name                   string
 	TlsClientCert          string
@@ -0,0 +1,53 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+// MaxPasswordHistoryCount is the number of previous passwords kept for every user
+const MaxPasswordHistoryCount = 24
+
+type PasswordPolicySettings struct {
+	// Enabled enforces the policy on the passwords of the built-in users
+	Enabled   bool
+	MinLength int
+	// Character classes the passwords must contain
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// BreachedPasswordsFile lists the passwords refused, as plain text or SHA-1 hashes, one per line
+	BreachedPasswordsFile string
+	// HistoryCount is the number of previous passwords of a user which can't be used again
+	HistoryCount int
+	// MaxAge is how long passwords can be used before users must change them at login, 0 for no limit
+	MaxAge time.Duration
+}
+
+func (cfg *Cfg) readPasswordPolicySettings() error {
+	sec := cfg.Raw.Section("auth.password_policy")
+	cfg.PasswordPolicy.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.PasswordPolicy.MinLength = sec.Key("min_length").MustInt(12)
+	cfg.PasswordPolicy.RequireUppercase = sec.Key("require_uppercase").MustBool(false)
+	cfg.PasswordPolicy.RequireLowercase = sec.Key("require_lowercase").MustBool(false)
+	cfg.PasswordPolicy.RequireDigit = sec.Key("require_digit").MustBool(false)
+	cfg.PasswordPolicy.RequireSymbol = sec.Key("require_symbol").MustBool(false)
+	cfg.PasswordPolicy.BreachedPasswordsFile = valueAsString(sec, "breached_passwords_file", "")
+
+	cfg.PasswordPolicy.HistoryCount = sec.Key("history_count").MustInt(0)
+	if cfg.PasswordPolicy.HistoryCount < 0 || cfg.PasswordPolicy.HistoryCount > MaxPasswordHistoryCount {
+		return fmt.Errorf("invalid auth.password_policy history_count %d, must be between 0 and %d",
+			cfg.PasswordPolicy.HistoryCount, MaxPasswordHistoryCount)
+	}
+
+	maxAge, err := gtime.ParseDuration(valueAsString(sec, "max_age", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid auth.password_policy max_age: %w", err)
+	}
+	cfg.PasswordPolicy.MaxAge = maxAge
+
+	return nil
+}
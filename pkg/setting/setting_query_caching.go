@@ -0,0 +1,36 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+type QueryCachingSettings struct {
+	// Enabled lets the data sources enable query caching in their settings
+	Enabled bool
+	// TTL is the default time to live of the results, for the data sources not setting one
+	TTL time.Duration
+	// MaxTTL caps the time to live set by the data sources
+	MaxTTL time.Duration
+}
+
+func (cfg *Cfg) readQueryCachingSettings() error {
+	sec := cfg.Raw.Section("query_caching")
+	cfg.QueryCaching.Enabled = sec.Key("enabled").MustBool(true)
+
+	ttl, err := gtime.ParseDuration(valueAsString(sec, "ttl", "1m"))
+	if err != nil {
+		return fmt.Errorf("invalid query_caching ttl: %w", err)
+	}
+	cfg.QueryCaching.TTL = ttl
+
+	maxTTL, err := gtime.ParseDuration(valueAsString(sec, "max_ttl", "1h"))
+	if err != nil {
+		return fmt.Errorf("invalid query_caching max_ttl: %w", err)
+	}
+	cfg.QueryCaching.MaxTTL = maxTTL
+
+	return nil
+}
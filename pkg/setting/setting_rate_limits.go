@@ -0,0 +1,61 @@
+package setting
+
+import (
+	"fmt"
+	"math"
+)
+
+// Route groups of the HTTP API with rate limits of their own
+const (
+	RateLimitGroupQuery  = "query"
+	RateLimitGroupSearch = "search"
+	RateLimitGroupAdmin  = "admin"
+)
+
+type RateLimitSettings struct {
+	Enabled bool
+	// KeyBy is either "user", where every user and API key has buckets of its own, or "org"
+	KeyBy string
+	// Storage is either "local", or "remote_cache" to share the buckets between instances
+	Storage string
+	// Limits are the limits of the route groups, groups without limit are not limited
+	Limits map[string]RateLimit
+}
+
+type RateLimit struct {
+	// RPS is the average number of requests per second allowed
+	RPS float64
+	// Burst is the number of requests allowed at once
+	Burst int
+}
+
+func (cfg *Cfg) readRateLimitSettings() error {
+	sec := cfg.Raw.Section("rate_limits")
+	cfg.RateLimits.Enabled = sec.Key("enabled").MustBool(false)
+
+	cfg.RateLimits.KeyBy = valueAsString(sec, "key_by", "user")
+	if cfg.RateLimits.KeyBy != "user" && cfg.RateLimits.KeyBy != "org" {
+		return fmt.Errorf("invalid rate_limits key_by %q, must be user or org", cfg.RateLimits.KeyBy)
+	}
+
+	cfg.RateLimits.Storage = valueAsString(sec, "storage", "local")
+	if cfg.RateLimits.Storage != "local" && cfg.RateLimits.Storage != "remote_cache" {
+		return fmt.Errorf("invalid rate_limits storage %q, must be local or remote_cache", cfg.RateLimits.Storage)
+	}
+
+	cfg.RateLimits.Limits = map[string]RateLimit{}
+	for _, group := range []string{RateLimitGroupQuery, RateLimitGroupSearch, RateLimitGroupAdmin} {
+		rps := sec.Key(group + "_rps").MustFloat64(0)
+		if rps <= 0 {
+			continue
+		}
+		// Defaults to a burst of one second of requests
+		burst := sec.Key(group + "_burst").MustInt(int(math.Ceil(rps)))
+		if burst < 1 {
+			return fmt.Errorf("invalid rate_limits %s_burst %d, must be at least 1", group, burst)
+		}
+		cfg.RateLimits.Limits[group] = RateLimit{RPS: rps, Burst: burst}
+	}
+
+	return nil
+}
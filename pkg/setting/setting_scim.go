@@ -0,0 +1,42 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+)
+
+type SCIMSettings struct {
+	// Enabled serves the SCIM API of the identity providers provisioning users and groups
+	Enabled bool
+	// Token is the bearer token the identity providers authenticate with
+	Token string
+	// OrgID is the organization of the users and of the teams provisioned
+	OrgID int64
+	// OrgRole is the role of the users provisioned in the organization
+	OrgRole string
+	// AdoptExistingUsers adds the existing users of the other organizations, except the Grafana Admins, to the
+	// organization when they are provisioned, instead of refusing them
+	AdoptExistingUsers bool
+}
+
+func (cfg *Cfg) readSCIMSettings() error {
+	sec := cfg.Raw.Section("auth.scim")
+	cfg.SCIM.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.SCIM.Token = valueAsString(sec, "token", "")
+	cfg.SCIM.OrgID = sec.Key("org_id").MustInt64(1)
+	cfg.SCIM.OrgRole = valueAsString(sec, "org_role", "Viewer")
+	cfg.SCIM.AdoptExistingUsers = sec.Key("adopt_existing_users").MustBool(false)
+
+	if !cfg.SCIM.Enabled {
+		return nil
+	}
+	if cfg.SCIM.Token == "" {
+		return errors.New("auth.scim token is required when SCIM is enabled")
+	}
+	switch cfg.SCIM.OrgRole {
+	case "Viewer", "Editor", "Admin":
+	default:
+		return fmt.Errorf("invalid auth.scim org_role %q, must be Viewer, Editor or Admin", cfg.SCIM.OrgRole)
+	}
+	return nil
+}
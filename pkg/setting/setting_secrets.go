@@ -0,0 +1,38 @@
+package setting
+
+import "time"
+
+type SecretsSettings struct {
+	FileDir       string
+	OrgPathPrefix string
+	Vault         VaultSettings
+}
+
+type VaultSettings struct {
+	URL           string
+	Token         string
+	Namespace     string
+	Mount         string
+	KVVersion     int
+	TLSSkipVerify bool
+	CacheTTL      time.Duration
+}
+
+func (cfg *Cfg) readSecretsSettings() {
+	sec := cfg.Raw.Section("secrets")
+	cfg.Secrets.FileDir = sec.Key("file_dir").String()
+	// an empty prefix allows every org to reference any secret, so it isn't replaced with the default
+	cfg.Secrets.OrgPathPrefix = "orgs/{org_id}"
+	if sec.HasKey("org_path_prefix") {
+		cfg.Secrets.OrgPathPrefix = sec.Key("org_path_prefix").String()
+	}
+
+	vault := cfg.Raw.Section("secrets.vault")
+	cfg.Secrets.Vault.URL = vault.Key("url").String()
+	cfg.Secrets.Vault.Token = vault.Key("token").String()
+	cfg.Secrets.Vault.Namespace = vault.Key("namespace").String()
+	cfg.Secrets.Vault.Mount = vault.Key("mount").MustString("secret")
+	cfg.Secrets.Vault.KVVersion = vault.Key("kv_version").MustInt(2)
+	cfg.Secrets.Vault.TLSSkipVerify = vault.Key("tls_skip_verify").MustBool(false)
+	cfg.Secrets.Vault.CacheTTL = vault.Key("cache_ttl").MustDuration(5 * time.Minute)
+}
@@ -0,0 +1,14 @@
+package setting
+
+type TotpSettings struct {
+	// Enabled lets the users of the built-in login enroll TOTP authenticators
+	Enabled bool
+	// Issuer is shown by the authenticator apps next to the accounts
+	Issuer string
+}
+
+func (cfg *Cfg) readTotpSettings() {
+	sec := cfg.Raw.Section("auth.totp")
+	cfg.Totp.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.Totp.Issuer = valueAsString(sec, "issuer", "Grafana")
+}
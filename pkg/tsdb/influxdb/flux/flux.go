@@ -78,7 +78,7 @@ func runnerFromDataSource(dsInfo *models.DataSource) (*runner, error) {
 	if url == "" {
 		return nil, fmt.Errorf("missing URL from datasource configuration")
 	}
-	token, found := dsInfo.SecureJsonData.DecryptedValue("token")
+	token, found := dsInfo.DecryptedValue("token")
 	if !found {
 		return nil, fmt.Errorf("token is missing from datasource configuration and is needed to use Flux")
 	}
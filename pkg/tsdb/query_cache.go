@@ -0,0 +1,298 @@
+package tsdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// QueryCacheKeyPrefix starts the keys of the cached query results. It's followed by the IDs of the
+// organization and of the data source, so that their results can be deleted with DeletePrefix,
+// for example "query-cache:1:" for the organization 1.
+const QueryCacheKeyPrefix = "query-cache"
+
+// volatileQueryFields change between the requests of identical queries, so they aren't part of the keys.
+var volatileQueryFields = []string{"requestId", "key"}
+
+var queryCacheLogger = log.New("tsdb.querycache")
+
+func init() {
+	remotecache.Register(cachedDataResponse{})
+}
+
+// cachedDataResponse is the form of plugins.DataResponse stored in the remote cache,
+// which encodes the fields holding interfaces.
+type cachedDataResponse struct {
+	Results  map[string]cachedDataQueryResult
+	Message  string
+	CachedAt time.Time
+}
+
+type cachedDataQueryResult struct {
+	RefID       string
+	ErrorString string
+	// Meta and Tables are encoded in JSON, Dataframes in Arrow
+	Meta       []byte
+	Series     plugins.DataTimeSeriesSlice
+	Tables     []byte
+	Dataframes [][]byte
+}
+
+// queryCacheTTL returns how long the results of the query are cached, 0 if they aren't.
+func (s *Service) queryCacheTTL(ds *models.DataSource, query plugins.DataQuery) time.Duration {
+	if s.Cfg == nil || s.RemoteCache == nil || !s.Cfg.QueryCaching.Enabled || ds.JsonData == nil {
+		return 0
+	}
+
+	// Only the queries of users are cached, alerting always gets fresh results
+	if query.User == nil || query.Debug || query.TimeRange == nil {
+		return 0
+	}
+
+	if !ds.JsonData.Get("queryCachingEnabled").MustBool(false) {
+		return 0
+	}
+
+	// The results depend on the user when their OAuth token is forwarded to the data source
+	if ds.JsonData.Get("oauthPassThru").MustBool(false) {
+		return 0
+	}
+
+	ttl := s.Cfg.QueryCaching.TTL
+	if value := ds.JsonData.Get("queryCachingTTL").MustString(""); value != "" {
+		dsTTL, err := gtime.ParseDuration(value)
+		if err != nil {
+			queryCacheLogger.Warn("Invalid query caching TTL of data source", "datasource", ds.Name, "ttl", value, "error", err)
+		} else {
+			ttl = dsTTL
+		}
+	}
+
+	if s.Cfg.QueryCaching.MaxTTL > 0 && ttl > s.Cfg.QueryCaching.MaxTTL {
+		ttl = s.Cfg.QueryCaching.MaxTTL
+	}
+
+	// The caches expire items by the second, 0 meaning never
+	if ttl < time.Second {
+		return 0
+	}
+
+	return ttl
+}
+
+// handleOrgDeleted deletes the cached query results of the deleted organization.
+func (s *Service) handleOrgDeleted(event *events.OrgDeleted) error {
+	if s.RemoteCache == nil {
+		return nil
+	}
+
+	err := s.RemoteCache.DeletePrefix(fmt.Sprintf("%s:%d:", QueryCacheKeyPrefix, event.Id))
+	switch {
+	case errors.Is(err, remotecache.ErrDeletePrefixNotSupported):
+		queryCacheLogger.Debug("Cached query results of deleted organization expire after their TTL", "orgId", event.Id)
+	case err != nil:
+		queryCacheLogger.Warn("Failed to delete cached query results of deleted organization", "orgId", event.Id, "error", err)
+	}
+
+	return nil
+}
+
+// cachedDataQuery returns the cached results of the query, or queries the data source and caches its results.
+func (s *Service) cachedDataQuery(ctx context.Context, plugin plugins.DataPlugin, ds *models.DataSource,
+	query plugins.DataQuery, ttl time.Duration) (plugins.DataResponse, error) {
+	key, err := queryCacheKey(ds, query, ttl)
+	if err != nil {
+		queryCacheLogger.Warn("Failed to compute query cache key", "datasource", ds.Name, "error", err)
+		return plugin.DataQuery(ctx, ds, query)
+	}
+
+	cached, err := s.RemoteCache.Get(key)
+	switch {
+	case err == nil:
+		if c, ok := cached.(cachedDataResponse); ok {
+			resp, err := c.toDataResponse()
+			if err == nil {
+				metrics.MDataSourceQueryCacheTotal.WithLabelValues("hit").Inc()
+				return resp, nil
+			}
+			queryCacheLogger.Warn("Failed to decode cached query results", "datasource", ds.Name, "error", err)
+		}
+	case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+		queryCacheLogger.Warn("Failed to get query results from cache", "datasource", ds.Name, "error", err)
+	}
+
+	metrics.MDataSourceQueryCacheTotal.WithLabelValues("miss").Inc()
+
+	resp, err := plugin.DataQuery(ctx, ds, query)
+	if err != nil {
+		return resp, err
+	}
+	resp.CacheStatus = plugins.DataCacheMiss
+
+	for _, result := range resp.Results {
+		// Errors may be temporary, like timeouts, so they aren't cached
+		if result.Error != nil || result.ErrorString != "" {
+			return resp, nil
+		}
+	}
+
+	c, err := newCachedDataResponse(resp)
+	if err == nil {
+		err = s.RemoteCache.Set(key, c, ttl)
+	}
+	if err != nil {
+		queryCacheLogger.Warn("Failed to cache query results", "datasource", ds.Name, "error", err)
+	}
+
+	return resp, nil
+}
+
+// queryCacheKey returns the cache key of the query results: a hash of the data source version,
+// of the queries without their volatile fields, and of the time range. The bounds relative to now are
+// aligned on the TTL, so that the same relative time range, like the last hour, has the same key during
+// the TTL, while absolute bounds are keyed exactly.
+func queryCacheKey(ds *models.DataSource, query plugins.DataQuery, ttl time.Duration) (string, error) {
+	from, err := query.TimeRange.ParseFrom()
+	if err != nil {
+		return "", err
+	}
+	to, err := query.TimeRange.ParseTo()
+	if err != nil {
+		return "", err
+	}
+
+	queries := make([]map[string]interface{}, 0, len(query.Queries))
+	for _, q := range query.Queries {
+		model := map[string]interface{}{}
+		if q.Model != nil {
+			m, err := q.Model.Map()
+			if err != nil {
+				return "", err
+			}
+			// copy the model, which is still used to query the data source
+			for field, value := range m {
+				model[field] = value
+			}
+		}
+		for _, field := range volatileQueryFields {
+			delete(model, field)
+		}
+
+		queries = append(queries, map[string]interface{}{
+			"refId":         q.RefID,
+			"queryType":     q.QueryType,
+			"maxDataPoints": q.MaxDataPoints,
+			"intervalMs":    q.IntervalMS,
+			"model":         model,
+		})
+	}
+
+	// encoding/json sorts the keys of the maps, so equal queries have the same encoding
+	data, err := json.Marshal(map[string]interface{}{
+		"datasourceVersion": ds.Version,
+		"from":              queryCacheKeyTime(query.TimeRange.From, from, ttl),
+		"to":                queryCacheKeyTime(query.TimeRange.To, to, ttl),
+		"queries":           queries,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%d:%d:%x", QueryCacheKeyPrefix, ds.OrgId, ds.Id, sha256.Sum256(data)), nil
+}
+
+// queryCacheKeyTime returns the time of a bound of the time range in the cache key: aligned on the TTL
+// when the bound is relative to now, like "now-1h", and exact otherwise.
+func queryCacheKeyTime(bound string, t time.Time, ttl time.Duration) int64 {
+	if strings.HasPrefix(bound, "now") {
+		return t.Truncate(ttl).UnixNano()
+	}
+	return t.UnixNano()
+}
+
+func newCachedDataResponse(resp plugins.DataResponse) (cachedDataResponse, error) {
+	c := cachedDataResponse{
+		Results:  make(map[string]cachedDataQueryResult, len(resp.Results)),
+		Message:  resp.Message,
+		CachedAt: time.Now(),
+	}
+
+	for refID, result := range resp.Results {
+		cr := cachedDataQueryResult{
+			RefID:       result.RefID,
+			ErrorString: result.ErrorString,
+			Series:      result.Series,
+		}
+
+		var err error
+		if result.Meta != nil {
+			if cr.Meta, err = result.Meta.MarshalJSON(); err != nil {
+				return c, err
+			}
+		}
+		if result.Tables != nil {
+			if cr.Tables, err = json.Marshal(result.Tables); err != nil {
+				return c, err
+			}
+		}
+		if result.Dataframes != nil {
+			if cr.Dataframes, err = result.Dataframes.Encoded(); err != nil {
+				return c, err
+			}
+		}
+
+		c.Results[refID] = cr
+	}
+
+	return c, nil
+}
+
+func (c cachedDataResponse) toDataResponse() (plugins.DataResponse, error) {
+	resp := plugins.DataResponse{
+		Results:     make(map[string]plugins.DataQueryResult, len(c.Results)),
+		Message:     c.Message,
+		CacheStatus: plugins.DataCacheHit,
+		CachedAt:    c.CachedAt,
+	}
+
+	for refID, cr := range c.Results {
+		result := plugins.DataQueryResult{
+			RefID:       cr.RefID,
+			ErrorString: cr.ErrorString,
+			Series:      cr.Series,
+		}
+
+		if cr.Meta != nil {
+			meta, err := simplejson.NewJson(cr.Meta)
+			if err != nil {
+				return resp, err
+			}
+			result.Meta = meta
+		}
+		if cr.Tables != nil {
+			if err := json.Unmarshal(cr.Tables, &result.Tables); err != nil {
+				return resp, err
+			}
+		}
+		if cr.Dataframes != nil {
+			result.Dataframes = plugins.NewEncodedDataFrames(cr.Dataframes)
+		}
+
+		resp.Results[refID] = result
+	}
+
+	return resp, nil
+}
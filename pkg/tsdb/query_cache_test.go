@@ -0,0 +1,220 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	newRequest := func(requestID string) plugins.DataQuery {
+		timeRange := plugins.NewDataTimeRange("now-1h", "now")
+		return plugins.DataQuery{
+			TimeRange: &timeRange,
+			User:      &models.SignedInUser{OrgId: 1},
+			Queries: []plugins.DataSubQuery{{
+				RefID: "A",
+				Model: simplejson.NewFromAny(map[string]interface{}{"expr": "up", "requestId": requestID}),
+			}},
+		}
+	}
+
+	newDataSource := func(jsonData map[string]interface{}) *models.DataSource {
+		return &models.DataSource{Id: 1, OrgId: 1, Type: "test", JsonData: simplejson.NewFromAny(jsonData)}
+	}
+
+	createCachingService := func(t *testing.T) (Service, *fakeExecutor, *int) {
+		t.Helper()
+
+		svc, exe := createService()
+		svc.Cfg = setting.NewCfg()
+		svc.Cfg.QueryCaching = setting.QueryCachingSettings{Enabled: true, TTL: time.Minute, MaxTTL: time.Hour}
+		svc.RemoteCache = &remotecache.RemoteCache{
+			Cfg: &setting.Cfg{RemoteCacheOptions: &setting.RemoteCacheOptions{Name: "memory"}},
+		}
+		require.NoError(t, svc.RemoteCache.Init())
+
+		calls := 0
+		exe.HandleQuery("A", func(query plugins.DataQuery) plugins.DataQueryResult {
+			calls++
+			return plugins.DataQueryResult{
+				RefID:      "A",
+				Meta:       simplejson.NewFromAny(map[string]interface{}{"executedQueryString": "up"}),
+				Series:     plugins.DataTimeSeriesSlice{{Name: "up"}},
+				Dataframes: plugins.NewDecodedDataFrames(data.Frames{data.NewFrame("up", data.NewField("value", nil, []float64{1}))}),
+			}
+		})
+
+		return svc, exe, &calls
+	}
+
+	t.Run("Results of data sources with query caching enabled are cached", func(t *testing.T) {
+		svc, _, calls := createCachingService(t)
+		ds := newDataSource(map[string]interface{}{"queryCachingEnabled": true})
+
+		res, err := svc.HandleRequest(context.Background(), ds, newRequest("Q100"))
+		require.NoError(t, err)
+		assert.Equal(t, plugins.DataCacheMiss, res.CacheStatus)
+
+		res, err = svc.HandleRequest(context.Background(), ds, newRequest("Q101"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, plugins.DataCacheHit, res.CacheStatus)
+		assert.False(t, res.CachedAt.IsZero())
+
+		result := res.Results["A"]
+		assert.Equal(t, "up", result.Series[0].Name)
+		assert.Equal(t, "up", result.Meta.Get("executedQueryString").MustString())
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, "up", frames[0].Name)
+	})
+
+	t.Run("Results aren't cached", func(t *testing.T) {
+		cases := map[string]struct {
+			jsonData map[string]interface{}
+			request  func(plugins.DataQuery) plugins.DataQuery
+		}{
+			"when the data source doesn't enable query caching": {
+				jsonData: map[string]interface{}{},
+			},
+			"when the data source forwards the OAuth token of the user": {
+				jsonData: map[string]interface{}{"queryCachingEnabled": true, "oauthPassThru": true},
+			},
+			"when the query doesn't come from a user": {
+				jsonData: map[string]interface{}{"queryCachingEnabled": true},
+				request: func(q plugins.DataQuery) plugins.DataQuery {
+					q.User = nil
+					return q
+				},
+			},
+		}
+
+		for desc, tc := range cases {
+			t.Run(desc, func(t *testing.T) {
+				svc, _, calls := createCachingService(t)
+				ds := newDataSource(tc.jsonData)
+
+				for i := 0; i < 2; i++ {
+					req := newRequest("Q100")
+					if tc.request != nil {
+						req = tc.request(req)
+					}
+					res, err := svc.HandleRequest(context.Background(), ds, req)
+					require.NoError(t, err)
+					assert.Empty(t, res.CacheStatus)
+				}
+				assert.Equal(t, 2, *calls)
+			})
+		}
+	})
+
+	t.Run("Errors aren't cached", func(t *testing.T) {
+		svc, exe, _ := createCachingService(t)
+		calls := 0
+		exe.HandleQuery("A", func(query plugins.DataQuery) plugins.DataQueryResult {
+			calls++
+			return plugins.DataQueryResult{RefID: "A", Error: errors.New("timeout")}
+		})
+		ds := newDataSource(map[string]interface{}{"queryCachingEnabled": true})
+
+		for i := 0; i < 2; i++ {
+			_, err := svc.HandleRequest(context.Background(), ds, newRequest("Q100"))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Results are deleted with the organization", func(t *testing.T) {
+		svc, _, calls := createCachingService(t)
+		ds := newDataSource(map[string]interface{}{"queryCachingEnabled": true})
+
+		_, err := svc.HandleRequest(context.Background(), ds, newRequest("Q100"))
+		require.NoError(t, err)
+
+		require.NoError(t, svc.handleOrgDeleted(&events.OrgDeleted{Id: 2}))
+		res, err := svc.HandleRequest(context.Background(), ds, newRequest("Q101"))
+		require.NoError(t, err)
+		assert.Equal(t, plugins.DataCacheHit, res.CacheStatus)
+
+		require.NoError(t, svc.handleOrgDeleted(&events.OrgDeleted{Id: 1}))
+		res, err = svc.HandleRequest(context.Background(), ds, newRequest("Q102"))
+		require.NoError(t, err)
+		assert.Equal(t, plugins.DataCacheMiss, res.CacheStatus)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("The TTL of the data source is capped", func(t *testing.T) {
+		svc, _, _ := createCachingService(t)
+		req := newRequest("Q100")
+
+		assert.Equal(t, 5*time.Minute, svc.queryCacheTTL(newDataSource(map[string]interface{}{"queryCachingEnabled": true, "queryCachingTTL": "5m"}), req))
+		assert.Equal(t, time.Hour, svc.queryCacheTTL(newDataSource(map[string]interface{}{"queryCachingEnabled": true, "queryCachingTTL": "1d"}), req))
+		assert.Equal(t, time.Minute, svc.queryCacheTTL(newDataSource(map[string]interface{}{"queryCachingEnabled": true, "queryCachingTTL": "soon"}), req))
+	})
+}
+
+func TestQueryCacheKey(t *testing.T) {
+	ds := &models.DataSource{Id: 2, OrgId: 3, Version: 1}
+	now := time.Date(2021, 6, 1, 10, 0, 30, 0, time.UTC)
+
+	newQuery := func(now time.Time, model map[string]interface{}) plugins.DataQuery {
+		return plugins.DataQuery{
+			TimeRange: &plugins.DataTimeRange{From: "now-1h", To: "now", Now: now},
+			Queries:   []plugins.DataSubQuery{{RefID: "A", Model: simplejson.NewFromAny(model)}},
+		}
+	}
+
+	key, err := queryCacheKey(ds, newQuery(now, map[string]interface{}{"expr": "up", "requestId": "Q1"}), time.Minute)
+	require.NoError(t, err)
+	assert.Regexp(t, "^query-cache:3:2:[0-9a-f]{64}$", key)
+
+	sameKey, err := queryCacheKey(ds, newQuery(now.Add(20*time.Second), map[string]interface{}{"expr": "up", "requestId": "Q2"}), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey, "volatile fields and times in the same TTL interval shouldn't change the key")
+
+	for _, otherQuery := range []plugins.DataQuery{
+		newQuery(now.Add(time.Minute), map[string]interface{}{"expr": "up"}),
+		newQuery(now, map[string]interface{}{"expr": "down"}),
+	} {
+		otherKey, err := queryCacheKey(ds, otherQuery, time.Minute)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherKey)
+	}
+
+	t.Run("Absolute time ranges are keyed on their exact bounds", func(t *testing.T) {
+		absoluteQuery := func(from, to time.Time) plugins.DataQuery {
+			query := newQuery(now, map[string]interface{}{"expr": "up"})
+			query.TimeRange = &plugins.DataTimeRange{
+				From: strconv.FormatInt(from.UnixNano()/int64(time.Millisecond), 10),
+				To:   strconv.FormatInt(to.UnixNano()/int64(time.Millisecond), 10),
+				Now:  now,
+			}
+			return query
+		}
+
+		key, err := queryCacheKey(ds, absoluteQuery(now.Add(-time.Hour), now), time.Hour)
+		require.NoError(t, err)
+		otherKey, err := queryCacheKey(ds, absoluteQuery(now.Add(-time.Hour+time.Second), now.Add(time.Second)), time.Hour)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherKey, "absolute ranges in the same TTL interval shouldn't share results")
+	})
+
+	updatedDs := &models.DataSource{Id: 2, OrgId: 3, Version: 2}
+	otherKey, err := queryCacheKey(updatedDs, newQuery(now, map[string]interface{}{"expr": "up"}), time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey, "updating the data source should change the key")
+}
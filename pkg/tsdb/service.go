@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/manager"
@@ -47,6 +49,7 @@ type Service struct {
 	CloudMonitoringService *cloudmonitoring.Service      `inject:""`
 	AzureMonitorService    *azuremonitor.Service         `inject:""`
 	PluginManager          *manager.PluginManager        `inject:""`
+	RemoteCache            *remotecache.RemoteCache      `inject:""`
 
 	registry map[string]func(*models.DataSource) (plugins.DataPlugin, error)
 }
@@ -66,6 +69,8 @@ func (s *Service) Init() error {
 	s.registry["grafana-azure-monitor-datasource"] = s.AzureMonitorService.NewExecutor
 	s.registry["loki"] = loki.NewExecutor
 	s.registry["tempo"] = tempo.NewExecutor
+
+	bus.AddEventListener(s.handleOrgDeleted)
 	return nil
 }
 
@@ -87,6 +92,10 @@ func (s *Service) HandleRequest(ctx context.Context, ds *models.DataSource, quer
 		}
 	}
 
+	if ttl := s.queryCacheTTL(ds, query); ttl > 0 {
+		return s.cachedDataQuery(ctx, plugin, ds, query, ttl)
+	}
+
 	return plugin.DataQuery(ctx, ds, query)
 }
 
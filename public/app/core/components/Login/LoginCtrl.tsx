@@ -3,6 +3,7 @@ import config from 'app/core/config';
 import { getBackendSrv } from '@grafana/runtime';
 import appEvents from 'app/core/app_events';
 import { AppEvents } from '@grafana/data';
+import { TotpEnrollment, TotpFormModel } from './TotpForm';
 
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
@@ -30,16 +31,33 @@ interface Props {
     isOauthEnabled: boolean;
     loginHint: string;
     passwordHint: string;
+    isPasswordExpired: boolean;
+    changeExpiredPassword: (pw: string) => void;
+    isTotpRequired: boolean;
+    totpEnrollment?: TotpEnrollment;
+    submitTotp: (data: TotpFormModel) => void;
+    cancelTotp: () => void;
+    recoveryCodes?: string[];
+    continueLogin: () => void;
   }) => JSX.Element;
 }
 
 interface State {
   isLoggingIn: boolean;
   isChangingPassword: boolean;
+  // passwordChangeToken is set between the expired password and the new password of the login
+  passwordChangeToken?: string;
+  // totpToken is set between the password and the two-factor authentication code of the login
+  totpToken?: string;
+  totpEnrollment?: TotpEnrollment;
+  recoveryCodes?: string[];
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
   result: any = {};
+  isDefaultPassword = false;
+  // pendingRecoveryCodes are shown once the expired password of a user who enrolled during the login is changed
+  pendingRecoveryCodes?: string[];
 
   constructor(props: Props) {
     super(props);
@@ -87,16 +105,20 @@ export class LoginCtrl extends PureComponent<Props, State> {
       isLoggingIn: true,
     });
 
+    this.isDefaultPassword = formModel.password === 'admin' && !config.ldapEnabled && !config.authProxyEnabled;
+
     getBackendSrv()
       .post('/login', formModel)
       .then((result: any) => {
-        this.result = result;
-        if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
-          this.toGrafana();
+        if (result.totpToken) {
+          this.startTotp(result);
+          return;
+        }
+        if (result.passwordExpired) {
+          this.startPasswordChange(result);
           return;
-        } else {
-          this.changeView();
         }
+        this.loggedIn(result);
       })
       .catch(() => {
         this.setState({
@@ -105,6 +127,113 @@ export class LoginCtrl extends PureComponent<Props, State> {
       });
   };
 
+  startPasswordChange = (result: any) => {
+    this.pendingRecoveryCodes = result.recoveryCodes;
+    this.setState({
+      isLoggingIn: false,
+      passwordChangeToken: result.passwordChangeToken,
+      totpToken: undefined,
+      totpEnrollment: undefined,
+    });
+  };
+
+  changeExpiredPassword = (password: string) => {
+    this.setState({
+      isLoggingIn: true,
+    });
+
+    getBackendSrv()
+      .post('/login/password', {
+        token: this.state.passwordChangeToken,
+        newPassword: password,
+        confirmPassword: password,
+      })
+      .then((result: any) => {
+        this.isDefaultPassword = false;
+        this.setState({ isLoggingIn: false, passwordChangeToken: undefined });
+        this.loggedIn({ ...result, recoveryCodes: this.pendingRecoveryCodes });
+      })
+      .catch((err: any) => {
+        this.setState({
+          isLoggingIn: false,
+        });
+        // the password change expired, the expired password is asked again
+        if (err?.status === 401) {
+          this.pendingRecoveryCodes = undefined;
+          this.setState({ passwordChangeToken: undefined });
+        }
+      });
+  };
+
+  loggedIn = (result: any) => {
+    this.result = result;
+    if (result.recoveryCodes?.length) {
+      // the user enrolled an authenticator during the login
+      this.setState({ totpToken: undefined, totpEnrollment: undefined, recoveryCodes: result.recoveryCodes });
+      return;
+    }
+    this.continueLogin();
+  };
+
+  continueLogin = () => {
+    if (this.isDefaultPassword) {
+      this.setState({ totpToken: undefined, totpEnrollment: undefined, recoveryCodes: undefined });
+      this.changeView();
+      return;
+    }
+    this.toGrafana();
+  };
+
+  startTotp = (result: any) => {
+    this.setState({
+      isLoggingIn: false,
+      totpToken: result.totpToken,
+    });
+
+    if (result.totpEnrollmentRequired) {
+      getBackendSrv()
+        .post('/login/totp/enroll', { token: result.totpToken })
+        .then((enrollment: TotpEnrollment) => {
+          this.setState({ totpEnrollment: enrollment });
+        })
+        .catch(() => this.cancelTotp());
+    }
+  };
+
+  submitTotp = (formModel: TotpFormModel) => {
+    this.setState({
+      isLoggingIn: true,
+    });
+
+    getBackendSrv()
+      .post('/login/totp', { ...formModel, token: this.state.totpToken })
+      .then((result: any) => {
+        if (result.passwordExpired) {
+          this.startPasswordChange(result);
+          return;
+        }
+        this.setState({ isLoggingIn: false });
+        this.loggedIn(result);
+      })
+      .catch((err: any) => {
+        this.setState({
+          isLoggingIn: false,
+        });
+        // the login expired or had too many invalid codes, the password is asked again
+        if (err?.status === 401 && err?.data?.message !== 'invalid two-factor authentication code') {
+          this.cancelTotp();
+        }
+      });
+  };
+
+  cancelTotp = () => {
+    this.setState({
+      isLoggingIn: false,
+      totpToken: undefined,
+      totpEnrollment: undefined,
+    });
+  };
+
   changeView = () => {
     this.setState({
       isChangingPassword: true,
@@ -126,8 +255,15 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword } = this.state;
-    const { login, toGrafana, changePassword } = this;
+    const {
+      isLoggingIn,
+      isChangingPassword,
+      passwordChangeToken,
+      totpToken,
+      totpEnrollment,
+      recoveryCodes,
+    } = this.state;
+    const { login, toGrafana, changePassword, changeExpiredPassword, submitTotp, cancelTotp, continueLogin } = this;
     const { loginHint, passwordHint, disableLoginForm, ldapEnabled, authProxyEnabled, disableUserSignUp } = config;
 
     return (
@@ -145,6 +281,14 @@ export class LoginCtrl extends PureComponent<Props, State> {
           changePassword,
           skipPasswordChange: toGrafana,
           isChangingPassword,
+          isPasswordExpired: !!passwordChangeToken,
+          changeExpiredPassword,
+          isTotpRequired: !!totpToken,
+          totpEnrollment,
+          submitTotp,
+          cancelTotp,
+          recoveryCodes,
+          continueLogin,
         })}
       </>
     );
@@ -8,8 +8,9 @@ import { LoginServiceButtons } from './LoginServiceButtons';
 import LoginCtrl from './LoginCtrl';
 import { LoginForm } from './LoginForm';
 import { ChangePassword } from '../ForgottenPassword/ChangePassword';
+import { TotpForm, TotpRecoveryCodes } from './TotpForm';
 import { Branding } from 'app/core/components/Branding/Branding';
-import { HorizontalGroup, LinkButton } from '@grafana/ui';
+import { Alert, HorizontalGroup, LinkButton } from '@grafana/ui';
 import { LoginLayout, InnerBox } from './LoginLayout';
 import config from 'app/core/config';
 
@@ -35,9 +36,17 @@ export const LoginPage: FC = () => {
           changePassword,
           skipPasswordChange,
           isChangingPassword,
+          isPasswordExpired,
+          changeExpiredPassword,
+          isTotpRequired,
+          totpEnrollment,
+          submitTotp,
+          cancelTotp,
+          recoveryCodes,
+          continueLogin,
         }) => (
           <>
-            {!isChangingPassword && (
+            {!isChangingPassword && !isPasswordExpired && !isTotpRequired && !recoveryCodes && (
               <InnerBox>
                 {!disableLoginForm && (
                   <>
@@ -67,6 +76,29 @@ export const LoginPage: FC = () => {
                 {!disableUserSignUp && <UserSignup />}
               </InnerBox>
             )}
+            {isPasswordExpired && (
+              <InnerBox>
+                <Alert severity="warning" title="Your password expired">
+                  Choose a new password to log in.
+                </Alert>
+                <ChangePassword onSubmit={changeExpiredPassword} />
+              </InnerBox>
+            )}
+            {isTotpRequired && (
+              <InnerBox>
+                <TotpForm
+                  onSubmit={submitTotp}
+                  onCancel={cancelTotp}
+                  isLoggingIn={isLoggingIn}
+                  enrollment={totpEnrollment}
+                />
+              </InnerBox>
+            )}
+            {recoveryCodes && (
+              <InnerBox>
+                <TotpRecoveryCodes codes={recoveryCodes} onContinue={continueLogin} />
+              </InnerBox>
+            )}
             {isChangingPassword && (
               <InnerBox>
                 <ChangePassword onSubmit={changePassword} onSkip={() => skipPasswordChange()} />
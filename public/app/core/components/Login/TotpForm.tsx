@@ -0,0 +1,114 @@
+import React, { FC, useState } from 'react';
+import { css } from 'emotion';
+import { selectors } from '@grafana/e2e-selectors';
+import { Alert, Button, ClipboardButton, Field, Form, Input, VerticalGroup } from '@grafana/ui';
+
+import { submitButton } from './LoginForm';
+
+export interface TotpEnrollment {
+  secret: string;
+  uri: string;
+}
+
+export interface TotpFormModel {
+  code?: string;
+  recoveryCode?: string;
+}
+
+interface Props {
+  onSubmit: (data: TotpFormModel) => void;
+  onCancel: () => void;
+  isLoggingIn: boolean;
+  // enrollment is set when the user must set up an authenticator before logging in
+  enrollment?: TotpEnrollment;
+}
+
+const secretStyles = css`
+  font-family: monospace;
+  word-break: break-all;
+`;
+
+export const TotpForm: FC<Props> = ({ onSubmit, onCancel, isLoggingIn, enrollment }) => {
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+
+  return (
+    <Form onSubmit={onSubmit}>
+      {({ register, errors }) => (
+        <>
+          {enrollment && (
+            <Field
+              label="Set up your authenticator"
+              description="Two-factor authentication is required. Add this key to your authenticator app, then enter the code it shows."
+            >
+              <VerticalGroup>
+                <span className={secretStyles}>{enrollment.secret}</span>
+                <ClipboardButton variant="secondary" size="sm" getText={() => enrollment.uri}>
+                  Copy setup URI
+                </ClipboardButton>
+              </VerticalGroup>
+            </Field>
+          )}
+          {useRecoveryCode ? (
+            <Field label="Recovery code" invalid={!!errors.recoveryCode} error={errors.recoveryCode?.message}>
+              <Input
+                autoFocus
+                name="recoveryCode"
+                autoComplete="off"
+                ref={register({ required: 'Recovery code is required' })}
+              />
+            </Field>
+          ) : (
+            <Field label="Authentication code" invalid={!!errors.code} error={errors.code?.message}>
+              <Input
+                autoFocus
+                name="code"
+                autoComplete="one-time-code"
+                inputMode="numeric"
+                ref={register({ required: 'Authentication code is required' })}
+              />
+            </Field>
+          )}
+          <VerticalGroup>
+            <Button aria-label={selectors.pages.Login.submit} className={submitButton} disabled={isLoggingIn}>
+              {isLoggingIn ? 'Verifying...' : 'Verify'}
+            </Button>
+            {!enrollment && (
+              <Button variant="link" type="button" onClick={() => setUseRecoveryCode(!useRecoveryCode)}>
+                {useRecoveryCode ? 'Use an authentication code' : 'Use a recovery code'}
+              </Button>
+            )}
+            <Button variant="link" type="button" onClick={onCancel}>
+              Back to login
+            </Button>
+          </VerticalGroup>
+        </>
+      )}
+    </Form>
+  );
+};
+
+interface RecoveryCodesProps {
+  codes: string[];
+  onContinue: () => void;
+}
+
+export const TotpRecoveryCodes: FC<RecoveryCodesProps> = ({ codes, onContinue }) => {
+  return (
+    <VerticalGroup>
+      <Alert severity="info" title="Save your recovery codes">
+        Each code logs you in once if you lose access to your authenticator. They won&apos;t be shown again.
+      </Alert>
+      <div className={secretStyles}>
+        {codes.map((code) => (
+          <div key={code}>{code}</div>
+        ))}
+      </div>
+      <ClipboardButton variant="secondary" getText={() => codes.join('\n')}>
+        Copy recovery codes
+      </ClipboardButton>
+      <Button className={submitButton} onClick={onContinue}>
+        Continue
+      </Button>
+    </VerticalGroup>
+  );
+};
@@ -57,7 +57,12 @@ export type NotifierType =
   | 'victorops'
   | 'pushover'
   | 'LINE'
-  | 'kafka';
+  | 'kafka'
+  | 'mattermost'
+  | 'rocketchat'
+  | 'zulip'
+  | 'matrix'
+  | 'sns';
 
 export interface NotifierDTO {
   name: string;